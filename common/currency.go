@@ -0,0 +1,11 @@
+package common
+
+// Currency is an ISO 4217 currency code, like EUR or USD.
+type Currency string
+
+const (
+	EUR Currency = "EUR"
+	USD Currency = "USD"
+	GBP Currency = "GBP"
+	CHF Currency = "CHF"
+)
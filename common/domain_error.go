@@ -0,0 +1,43 @@
+package common
+
+// ErrorKind classifies the errors of the domain by what went wrong, independent of how an error is reported.
+type ErrorKind int
+
+const (
+	// Internal errors are failures the client cannot do anything about, like an unreachable storage.
+	Internal ErrorKind = iota
+	// NotFound errors are returned for something that does not exist.
+	NotFound
+	// Unauthenticated errors are returned for requests without valid credentials.
+	Unauthenticated
+	// Forbidden errors are returned for something that exists but belongs to another user.
+	Forbidden
+	// Validation errors are returned for input that is malformed or misses values.
+	Validation
+	// Unprocessable errors are returned for well-formed input that breaks a rule of the domain, like an overpayment.
+	Unprocessable
+	// Conflict errors are returned for operations the current state does not allow.
+	Conflict
+	// Declined errors are returned when a payment is declined.
+	Declined
+	// Unavailable errors are returned when a dependency is unavailable for now, so the operation may be retried.
+	Unavailable
+)
+
+// DomainError is an error of a kind with a stable, machine-readable code like "order_not_found".
+// The errors of the domain are declared as DomainErrors and wrapped with their details,
+// like fmt.Errorf("%w: %d", ErrUnknownProduct, productId); errors.As finds them in the chain.
+type DomainError struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+}
+
+// NewDomainError creates an error of the kind with the code and message.
+func NewDomainError(kind ErrorKind, code string, message string) *DomainError {
+	return &DomainError{Kind: kind, Code: code, Message: message}
+}
+
+func (e *DomainError) Error() string {
+	return e.Message
+}
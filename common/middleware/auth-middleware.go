@@ -14,9 +14,7 @@ func AuthMiddleware(authService services.AuthService, userService services.Users
 		// Get the token from the Authorization header
 		authHeader := ctx.Get("Authorization")
 		if authHeader == "" {
-			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Authorization token is missing",
-			})
+			return services.ErrAuthTokenMissing
 		}
 
 		// Extract the token
@@ -26,17 +24,13 @@ func AuthMiddleware(authService services.AuthService, userService services.Users
 		userId, err := authService.GetUserIDByToken(context, token)
 		if err != nil {
 			logger.Error().Err(err).Msg("Error getting user ID from token")
-			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid or expired token",
-			})
+			return services.ErrInvalidAuthToken
 		}
 
 		user, err := userService.GetUserByID(context, userId)
 		if err != nil {
 			logger.Error().Err(err).Msg("Error getting user from token")
-			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid or expired token",
-			})
+			return services.ErrInvalidAuthToken
 		}
 		logger = logger.With().Int("userId", user.ID).Logger()
 		log.SetFiberLogger(ctx, &logger)
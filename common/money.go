@@ -0,0 +1,230 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMoney is returned for an amount of money that is not a decimal number or has more decimal places
+// than a Money keeps.
+var ErrInvalidMoney = NewDomainError(Validation, "invalid_money", "invalid amount of money")
+
+// RoundingMode is the rule an amount of money is rounded to cents with.
+type RoundingMode int
+
+const (
+	// HalfUp rounds to the nearest cent, half a cent away from zero.
+	HalfUp RoundingMode = iota
+	// HalfEven rounds to the nearest cent, half a cent to the even cent.
+	HalfEven
+	// Down rounds towards zero.
+	Down
+	// Up rounds away from zero.
+	Up
+)
+
+const (
+	// moneyPlaces are the decimal places a Money keeps.
+	moneyPlaces = 6
+	// microsPerCent and microsPerUnit are the millionths of a cent and of a unit of a currency.
+	microsPerCent = 10_000
+	microsPerUnit = 1_000_000
+)
+
+// Money is an exact decimal amount of money of six decimal places. Amounts that are paid, and the totals
+// of orders, are whole cents, rounded with an explicit RoundingMode; the further places keep prices per
+// unit of weight exact. The zero value is no money.
+//
+// Money is encoded in JSON as a decimal string, like "12.50", and decoded from such a string or a plain number.
+type Money struct {
+	micros int64
+}
+
+// NewMoney creates an amount of money from a number, it keeps the decimal the number is written as,
+// rounded half to even beyond six places. It is meant for literals and plain numbers of clients,
+// amounts that are computed are kept as Money.
+func NewMoney(amount float64) Money {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return Money{}
+	}
+	micros := new(big.Rat).Mul(decimal(amount), big.NewRat(microsPerUnit, 1))
+	return roundMicros(micros, 1, HalfEven)
+}
+
+// Cents creates an amount of money of whole cents.
+func Cents(cents int64) Money {
+	return Money{micros: cents * microsPerCent}
+}
+
+// ParseMoney parses a decimal amount of money like "12.50" or "-0.6", it fails for more than six decimal places.
+func ParseMoney(value string) (Money, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" || strings.ContainsAny(trimmed, "/eE") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, value)
+	}
+	amount, ok := new(big.Rat).SetString(trimmed)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, value)
+	}
+	micros := amount.Mul(amount, big.NewRat(microsPerUnit, 1))
+	if !micros.IsInt() {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidMoney, value, moneyPlaces)
+	}
+	if !micros.Num().IsInt64() {
+		return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, value)
+	}
+	return Money{micros: micros.Num().Int64()}, nil
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{micros: m.micros + other.micros}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{micros: m.micros - other.micros}
+}
+
+func (m Money) Neg() Money {
+	return Money{micros: -m.micros}
+}
+
+// Mul multiplies the amount by a factor, like a quantity, a weight or an exchange rate, rounded to cents.
+func (m Money) Mul(factor float64, mode RoundingMode) Money {
+	product := new(big.Rat).Mul(big.NewRat(m.micros, 1), decimal(factor))
+	return roundMicros(product, microsPerCent, mode)
+}
+
+// Div divides the amount into equal parts, rounded to cents.
+func (m Money) Div(divisor int, mode RoundingMode) Money {
+	if divisor == 0 {
+		return Money{}
+	}
+	return roundMicros(big.NewRat(m.micros, int64(divisor)), microsPerCent, mode)
+}
+
+// Scale multiplies the amount by a factor keeping six decimal places, rounded half to even.
+// It converts prices per unit of weight, which are not rounded to cents.
+func (m Money) Scale(factor float64) Money {
+	product := new(big.Rat).Mul(big.NewRat(m.micros, 1), decimal(factor))
+	return roundMicros(product, 1, HalfEven)
+}
+
+// Round rounds the amount to cents.
+func (m Money) Round(mode RoundingMode) Money {
+	return roundMicros(big.NewRat(m.micros, 1), microsPerCent, mode)
+}
+
+// Compare compares the amount to another one, it is -1, 0 or +1 when it is less, equal or more.
+func (m Money) Compare(other Money) int {
+	switch {
+	case m.micros < other.micros:
+		return -1
+	case m.micros > other.micros:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) IsZero() bool {
+	return m.micros == 0
+}
+
+// Sign is -1, 0 or +1 for a negative amount, no money or a positive amount.
+func (m Money) Sign() int {
+	return m.Compare(Money{})
+}
+
+// Float64 is the amount as a number, for comparisons with numbers that are not amounts of money.
+func (m Money) Float64() float64 {
+	value, _ := strconv.ParseFloat(m.String(), 64)
+	return value
+}
+
+// String formats the amount with at least two decimal places, like "12.50" or "0.012990" trimmed to "0.01299".
+func (m Money) String() string {
+	micros := m.micros
+	sign := ""
+	if micros < 0 {
+		sign = "-"
+	}
+	units := micros / microsPerUnit
+	fraction := micros % microsPerUnit
+	if units < 0 {
+		units = -units
+	}
+	if fraction < 0 {
+		fraction = -fraction
+	}
+	decimals := strings.TrimRight(fmt.Sprintf("%06d", fraction), "0")
+	for len(decimals) < 2 {
+		decimals += "0"
+	}
+	return fmt.Sprintf("%s%d.%s", sign, units, decimals)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON decodes a decimal string or, as sent by clients of the first version of the API, a plain number.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if value == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	} else if strings.HasPrefix(value, `"`) {
+		return fmt.Errorf("%w: %s", ErrInvalidMoney, value)
+	}
+	if strings.ContainsAny(value, "eE") {
+		// numbers in exponent notation are plain numbers all the same
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidMoney, value)
+		}
+		value = strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	parsed, err := ParseMoney(value)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// decimal is the exact decimal a number is written as.
+func decimal(number float64) *big.Rat {
+	value, ok := new(big.Rat).SetString(strconv.FormatFloat(number, 'f', -1, 64))
+	if !ok {
+		return new(big.Rat)
+	}
+	return value
+}
+
+// roundMicros rounds an amount of millionths to a multiple of the step.
+func roundMicros(micros *big.Rat, step int64, mode RoundingMode) Money {
+	steps := new(big.Rat).Quo(micros, big.NewRat(step, 1))
+	quotient, remainder := new(big.Int).QuoRem(steps.Num(), steps.Denom(), new(big.Int))
+
+	if remainder.Sign() != 0 {
+		// twice the remainder against the denominator tells whether the amount is below, at or above half a step
+		half := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(steps.Denom())
+		awayFromZero := false
+		switch mode {
+		case HalfUp:
+			awayFromZero = half >= 0
+		case HalfEven:
+			awayFromZero = half > 0 || (half == 0 && quotient.Bit(0) == 1)
+		case Up:
+			awayFromZero = true
+		}
+		if awayFromZero {
+			quotient.Add(quotient, big.NewInt(int64(steps.Sign())))
+		}
+	}
+	return Money{micros: quotient.Int64() * step}
+}
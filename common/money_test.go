@@ -0,0 +1,140 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMoney(t *testing.T) {
+	assert.Equal(t, "0.30", NewMoney(0.1).Add(NewMoney(0.2)).String(), "expected decimal addition to be exact")
+	assert.Equal(t, "10.23", NewMoney(10.23).String())
+	assert.Equal(t, "0.00", NewMoney(0).String())
+	assert.Equal(t, "-5.50", NewMoney(-5.5).String())
+	assert.Equal(t, "0.00299", NewMoney(0.00299).String(), "expected places beyond cents to be kept")
+	assert.Equal(t, Cents(1999), NewMoney(19.99))
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected Money
+		err      bool
+	}{
+		{name: "whole units", value: "12", expected: Cents(1200)},
+		{name: "cents", value: "12.50", expected: Cents(1250)},
+		{name: "negative", value: "-0.6", expected: Cents(-60)},
+		{name: "surrounding spaces", value: " 3.20 ", expected: Cents(320)},
+		{name: "six decimal places", value: "0.000001", expected: Money{micros: 1}},
+		{name: "seven decimal places", value: "0.0000001", err: true},
+		{name: "empty", value: "", err: true},
+		{name: "not a number", value: "ten", err: true},
+		{name: "fraction", value: "1/3", err: true},
+		{name: "exponent", value: "1e3", err: true},
+		{name: "out of range", value: "99999999999999999999", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money, err := ParseMoney(tt.value)
+
+			if tt.err {
+				assert.True(t, errors.Is(err, ErrInvalidMoney), "expected an invalid money error, got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, money)
+		})
+	}
+}
+
+func TestMoney_Rounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		mode     RoundingMode
+		expected string
+	}{
+		{name: "half up", amount: "2.345", mode: HalfUp, expected: "2.35"},
+		{name: "half up negative", amount: "-2.345", mode: HalfUp, expected: "-2.35"},
+		{name: "half up below half", amount: "2.3449", mode: HalfUp, expected: "2.34"},
+		{name: "half even to even", amount: "2.345", mode: HalfEven, expected: "2.34"},
+		{name: "half even from odd", amount: "2.355", mode: HalfEven, expected: "2.36"},
+		{name: "half even above half", amount: "2.3451", mode: HalfEven, expected: "2.35"},
+		{name: "down", amount: "2.349", mode: Down, expected: "2.34"},
+		{name: "down negative", amount: "-2.349", mode: Down, expected: "-2.34"},
+		{name: "up", amount: "2.341", mode: Up, expected: "2.35"},
+		{name: "up negative", amount: "-2.341", mode: Up, expected: "-2.35"},
+		{name: "whole cents", amount: "2.34", mode: Up, expected: "2.34"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, err := ParseMoney(tt.amount)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, amount.Round(tt.mode).String())
+		})
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	price := NewMoney(2.99)
+
+	assert.Equal(t, "4.49", price.Mul(1.5, HalfUp).String(), "expected 4.485 to round half up")
+	assert.Equal(t, "4.48", price.Mul(1.5, HalfEven).String(), "expected 4.485 to round half to even")
+	assert.Equal(t, "0.00299", price.Scale(0.001).String(), "expected a price per gram to keep its places")
+	assert.Equal(t, "3.41", NewMoney(10.23).Div(3, HalfUp).String())
+	assert.Equal(t, Money{}, NewMoney(10).Div(0, HalfUp), "expected no money for a division by zero")
+	assert.Equal(t, "-2.99", price.Neg().String())
+	assert.Equal(t, "0.01", NewMoney(3).Sub(price).String())
+
+	assert.Equal(t, -1, NewMoney(1).Compare(NewMoney(2)))
+	assert.Equal(t, 0, NewMoney(2).Compare(Cents(200)))
+	assert.Equal(t, 1, NewMoney(2).Compare(NewMoney(1.99)))
+	assert.Equal(t, -1, NewMoney(-1).Sign())
+	assert.True(t, Money{}.IsZero())
+	assert.Equal(t, 2.99, price.Float64())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	type body struct {
+		Amount Money `json:"amount"`
+	}
+
+	encoded, err := json.Marshal(body{Amount: NewMoney(10.5)})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"10.50"}`, string(encoded), "expected the amount as a decimal string")
+
+	tests := []struct {
+		name     string
+		json     string
+		expected Money
+		err      bool
+	}{
+		{name: "decimal string", json: `{"amount":"10.50"}`, expected: Cents(1050)},
+		{name: "plain number", json: `{"amount":10.5}`, expected: Cents(1050)},
+		{name: "exact plain number", json: `{"amount":0.1}`, expected: Cents(10)},
+		{name: "exponent number", json: `{"amount":1.5e2}`, expected: Cents(15000)},
+		{name: "null", json: `{"amount":null}`, expected: Money{}},
+		{name: "not a number", json: `{"amount":"ten"}`, err: true},
+		{name: "too many places", json: `{"amount":0.0000001}`, err: true},
+		{name: "boolean", json: `{"amount":true}`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var decoded body
+			err := json.Unmarshal([]byte(tt.json), &decoded)
+
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, decoded.Amount)
+		})
+	}
+}
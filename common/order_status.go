@@ -0,0 +1,12 @@
+package common
+
+type OrderStatus string
+
+const (
+	Pending   OrderStatus = "Pending"
+	Paid      OrderStatus = "Paid"
+	Fulfilled OrderStatus = "Fulfilled"
+	Delivered OrderStatus = "Delivered"
+	Cancelled OrderStatus = "Cancelled"
+	Refunded  OrderStatus = "Refunded"
+)
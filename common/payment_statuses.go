@@ -0,0 +1,11 @@
+package common
+
+// PaymentStatus is the state of a payment with the payment gateway.
+type PaymentStatus string
+
+const (
+	PaymentAuthorized PaymentStatus = "Authorized"
+	PaymentCaptured   PaymentStatus = "Captured"
+	PaymentVoided     PaymentStatus = "Voided"
+	PaymentRefunded   PaymentStatus = "Refunded"
+)
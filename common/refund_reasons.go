@@ -0,0 +1,16 @@
+package common
+
+type RefundReason string
+
+const (
+	CustomerRequest  RefundReason = "CustomerRequest"
+	DamagedGoods     RefundReason = "DamagedGoods"
+	NotDelivered     RefundReason = "NotDelivered"
+	DuplicatePayment RefundReason = "DuplicatePayment"
+	Goodwill         RefundReason = "Goodwill"
+	// WeightAdjustment refunds what was paid beyond the price of an order after weighing, it is not given by clients.
+	WeightAdjustment RefundReason = "WeightAdjustment"
+	// OrderCancelled and OrderRefunded give back the payments of an order moved to that status, they are not given by clients.
+	OrderCancelled RefundReason = "OrderCancelled"
+	OrderRefunded  RefundReason = "OrderRefunded"
+)
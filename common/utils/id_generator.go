@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IDStrategy selects how an IDGenerator creates ids.
+type IDStrategy string
+
+const (
+	// SequenceIDs counts up from 1.
+	SequenceIDs IDStrategy = "sequence"
+	// TimeOrderedIDs are snowflake-style ids: milliseconds since idEpoch followed by a per-millisecond sequence.
+	TimeOrderedIDs IDStrategy = "time"
+	// UUIDIDs are taken from random (version 4) UUIDs.
+	UUIDIDs IDStrategy = "uuid"
+	// SeededIDs are pseudo-random ids, the same seed always yields the same ids.
+	SeededIDs IDStrategy = "seeded"
+)
+
+const (
+	// maxID keeps ids within the integers a JSON number (a float64) represents exactly.
+	maxID = 1<<53 - 1
+	// sequenceBits is the number of bits of a time-ordered id counting the ids of one millisecond.
+	sequenceBits = 12
+)
+
+// idEpoch is the start of the timestamps of time-ordered ids.
+var idEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// IDGenerator creates the ids of stored entities. Ids are positive and never repeat for a generator.
+type IDGenerator interface {
+	NewID() int
+}
+
+// IDGeneratorConfig configures the ids of stored entities.
+type IDGeneratorConfig struct {
+	Strategy IDStrategy
+	// Seed seeds the ids of the SeededIDs strategy.
+	Seed int64
+}
+
+// NewIDGeneratorConfig reads the id strategy from FP_KATA_ID_STRATEGY, one of sequence, time, uuid or seeded,
+// and the seed of seeded ids from FP_KATA_ID_SEED. Ids are time-ordered by default.
+func NewIDGeneratorConfig() IDGeneratorConfig {
+	config := IDGeneratorConfig{Strategy: TimeOrderedIDs}
+	switch strategy := IDStrategy(os.Getenv("FP_KATA_ID_STRATEGY")); strategy {
+	case SequenceIDs, TimeOrderedIDs, UUIDIDs, SeededIDs:
+		config.Strategy = strategy
+	}
+	if seed, err := strconv.ParseInt(os.Getenv("FP_KATA_ID_SEED"), 10, 64); err == nil {
+		config.Seed = seed
+	}
+	return config
+}
+
+// NewIDGenerator creates the generator of the configured strategy.
+func NewIDGenerator(config IDGeneratorConfig) IDGenerator {
+	switch config.Strategy {
+	case SequenceIDs:
+		return NewSequenceIDGenerator()
+	case UUIDIDs:
+		return NewUUIDGenerator()
+	case SeededIDs:
+		return NewSeededIDGenerator(config.Seed)
+	default:
+		return NewTimeOrderedIDGenerator()
+	}
+}
+
+type sequenceIDGenerator struct {
+	mu     sync.Mutex
+	lastID int
+}
+
+// NewSequenceIDGenerator creates ids 1, 2, 3, ...
+func NewSequenceIDGenerator() IDGenerator {
+	return &sequenceIDGenerator{}
+}
+
+func (g *sequenceIDGenerator) NewID() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.lastID++
+	return g.lastID
+}
+
+type timeOrderedIDGenerator struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	lastTick int64
+	sequence int64
+}
+
+// NewTimeOrderedIDGenerator creates ids that increase with the time they are created at.
+func NewTimeOrderedIDGenerator() IDGenerator {
+	return &timeOrderedIDGenerator{now: time.Now}
+}
+
+func (g *timeOrderedIDGenerator) NewID() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tick := g.now().Sub(idEpoch).Milliseconds()
+	// a clock going backwards keeps counting on the last millisecond, so ids never repeat
+	if tick <= g.lastTick {
+		tick = g.lastTick
+		g.sequence++
+		if g.sequence == 1<<sequenceBits {
+			// the ids of this millisecond are used up, borrow them from the next one
+			tick++
+			g.sequence = 0
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastTick = tick
+	return int(tick<<sequenceBits|g.sequence) & maxID
+}
+
+type uuidGenerator struct{}
+
+// NewUUIDGenerator creates ids from the random bits of version 4 UUIDs, they need no coordination between processes.
+func NewUUIDGenerator() IDGenerator {
+	return uuidGenerator{}
+}
+
+func (uuidGenerator) NewID() int {
+	for {
+		id := uuid.New()
+		// the first 8 bytes of a version 4 UUID are random apart from its 4 version bits
+		if value := int(binary.BigEndian.Uint64(id[:8]) & maxID); value != 0 {
+			return value
+		}
+	}
+}
+
+type seededIDGenerator struct {
+	mu     sync.Mutex
+	random *rand.Rand
+	issued map[int]bool
+}
+
+// NewSeededIDGenerator creates random looking ids that are the same on every run with the same seed.
+func NewSeededIDGenerator(seed int64) IDGenerator {
+	return &seededIDGenerator{
+		random: rand.New(rand.NewSource(seed)),
+		issued: make(map[int]bool),
+	}
+}
+
+func (g *seededIDGenerator) NewID() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for {
+		id := int(g.random.Int63n(maxID)) + 1
+		if !g.issued[id] {
+			g.issued[id] = true
+			return id
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDGenerators(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator IDGenerator
+	}{
+		{name: "sequence", generator: NewSequenceIDGenerator()},
+		{name: "time ordered", generator: NewTimeOrderedIDGenerator()},
+		{name: "uuid", generator: NewUUIDGenerator()},
+		{name: "seeded", generator: NewSeededIDGenerator(42)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			ids := make([][]int, 8)
+			for i := range ids {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					for j := 0; j < 1000; j++ {
+						ids[i] = append(ids[i], test.generator.NewID())
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			seen := make(map[int]bool)
+			for _, generated := range ids {
+				for _, id := range generated {
+					assert.False(t, seen[id], "expected id %d to be generated once", id)
+					assert.True(t, id > 0 && id <= maxID, "expected id %d to be positive and exact as a JSON number", id)
+					seen[id] = true
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceIDGenerator(t *testing.T) {
+	generator := NewSequenceIDGenerator()
+	assert.Equal(t, []int{1, 2, 3}, []int{generator.NewID(), generator.NewID(), generator.NewID()}, "expected ids to count up from 1")
+}
+
+func TestTimeOrderedIDGenerator(t *testing.T) {
+	now := idEpoch.Add(time.Second)
+	generator := &timeOrderedIDGenerator{now: func() time.Time { return now }}
+
+	first := generator.NewID()
+	second := generator.NewID()
+	assert.Equal(t, 1000<<sequenceBits, first, "expected the milliseconds since the epoch")
+	assert.Equal(t, first+1, second, "expected ids of the same millisecond to be counted")
+
+	now = now.Add(-time.Minute)
+	assert.Greater(t, generator.NewID(), second, "expected ids to keep increasing when the clock goes back")
+
+	now = now.Add(time.Hour)
+	later := generator.NewID()
+	assert.Equal(t, 3541000<<sequenceBits, later, "expected the sequence to restart in a later millisecond")
+
+	for i := 1; i < 1<<sequenceBits; i++ {
+		generator.NewID()
+	}
+	assert.Equal(t, 3541001<<sequenceBits, generator.NewID(), "expected a full millisecond to continue on the next one")
+}
+
+func TestSeededIDGenerator(t *testing.T) {
+	first, second, other := NewSeededIDGenerator(7), NewSeededIDGenerator(7), NewSeededIDGenerator(8)
+	for i := 0; i < 10; i++ {
+		id := first.NewID()
+		assert.Equal(t, id, second.NewID(), "expected the same seed to yield the same ids")
+		assert.NotEqual(t, id, other.NewID(), "expected another seed to yield other ids")
+	}
+}
+
+func TestNewIDGenerator(t *testing.T) {
+	tests := []struct {
+		strategy string
+		seed     string
+		expected IDGenerator
+	}{
+		{strategy: "sequence", expected: &sequenceIDGenerator{}},
+		{strategy: "uuid", expected: uuidGenerator{}},
+		{strategy: "seeded", seed: "7", expected: NewSeededIDGenerator(7)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.strategy, func(t *testing.T) {
+			t.Setenv("FP_KATA_ID_STRATEGY", test.strategy)
+			t.Setenv("FP_KATA_ID_SEED", test.seed)
+			assert.IsType(t, test.expected, NewIDGenerator(NewIDGeneratorConfig()), "unexpected generator")
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		t.Setenv("FP_KATA_ID_STRATEGY", "random")
+		assert.Equal(t, IDGeneratorConfig{Strategy: TimeOrderedIDs}, NewIDGeneratorConfig(), "expected time-ordered ids by default")
+		assert.IsType(t, &timeOrderedIDGenerator{}, NewIDGenerator(NewIDGeneratorConfig()), "unexpected generator")
+	})
+}
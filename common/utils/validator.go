@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"fp_kata/common"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+)
+
+// NewValidator creates a validator of requests, amounts of money are validated like numbers.
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		return field.Interface().(common.Money).Float64()
+	}, common.Money{})
+	return validate
+}
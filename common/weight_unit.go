@@ -0,0 +1,23 @@
+package common
+
+type WeightUnit string
+
+const (
+	Kilogram WeightUnit = "kg"
+	Gram     WeightUnit = "g"
+	Pound    WeightUnit = "lb"
+)
+
+var gramsPerUnit = map[WeightUnit]float64{
+	Kilogram: 1000,
+	Gram:     1,
+	Pound:    453.59237,
+}
+
+// Convert converts a weight given in this unit to the target unit.
+func (u WeightUnit) Convert(weight float64, target WeightUnit) float64 {
+	if u == target {
+		return weight
+	}
+	return weight * gramsPerUnit[u] / gramsPerUnit[target]
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"fp_kata/common/middleware"
+	"fp_kata/internal/controllers"
 	fpLog "fp_kata/pkg/log"
 	"github.com/bytedance/sonic"
 	"github.com/gofiber/fiber/v3"
@@ -15,12 +16,20 @@ func InitApp() *fiber.App {
 		AppName:     "fp_kata",
 		JSONEncoder: sonic.Marshal,
 		JSONDecoder: sonic.Unmarshal,
+		// errors returned by handlers are responded as problem details
+		ErrorHandler: controllers.ErrorHandler,
 	})
 
 	app.Use(middleware.LoggingMiddleware(&log.Logger))
 
-	appModules := InitializeAppModules()
+	appModules, err := InitializeAppModules()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to wire the application")
+	}
 	appModules.OrdersController.RegisterOrderRoutes(app, appModules.AuthMiddleware)
 	appModules.UsersController.RegisterUserRoutes(app, appModules.AuthMiddleware)
+	appModules.ProductsController.RegisterProductRoutes(app, appModules.AuthMiddleware)
+	appModules.InventoryController.RegisterInventoryRoutes(app, appModules.AuthMiddleware)
+	appModules.PaymentsController.RegisterPaymentRoutes(app, appModules.AuthMiddleware)
 	return app
 }
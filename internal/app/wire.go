@@ -5,37 +5,67 @@ package app
 
 import (
 	"fp_kata/common/middleware"
+	"fp_kata/common/utils"
 	"fp_kata/internal/controllers"
 	"fp_kata/internal/datasources/file"
 	"fp_kata/internal/datasources/yugabyte"
+	"fp_kata/internal/gateways"
+	"fp_kata/internal/gateways/fake"
 	"fp_kata/internal/services"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/wire"
 )
 
 type AppModules struct {
-	AuthMiddleware   fiber.Handler
-	UsersController  controllers.UsersController
-	OrdersController controllers.OrdersController
+	AuthMiddleware      fiber.Handler
+	UsersController     controllers.UsersController
+	OrdersController    controllers.OrdersController
+	ProductsController  controllers.ProductsController
+	InventoryController controllers.InventoryController
+	PaymentsController  controllers.PaymentsController
 }
 
 // Define a ProviderSet that provides AuthService once.
 var AppModulesSet = wire.NewSet(
 	// Dependencies used across multiple parts of the app.
+	utils.NewIDGeneratorConfig,
+	utils.NewIDGenerator,
 	file.NewOrdersStorage,
 	file.NewUsersStorage,
 	yugabyte.NewPaymentsStorage,
+	file.NewProductsFile,
+	file.NewProductsStorage,
+	file.NewInventoryStorage,
+	file.NewIdempotencyStorage,
+	file.NewExchangeRatesFile,
+	file.NewExchangeRatesStorage,
+	fake.NewPaymentGateway,
+	wire.Bind(new(gateways.PaymentGateway), new(*fake.PaymentGateway)),
 
 	// Services
 	services.NewAuthService,
 	services.NewUsersService,
 	services.NewPaymentsService,
+	services.NewPaymentMethodsConfig,
 	services.NewOrdersService,
+	services.NewProductsService,
+	services.NewInventoryService,
 	services.NewAuthorizationService,
+	services.NewWeighingConfig,
+	services.NewWeighingService,
+	services.NewIdempotencyConfig,
+	services.NewIdempotencyService,
+	services.NewOrderNumberConfig,
+	services.NewOrderNumberGenerator,
+	services.NewExchangeRatesConfig,
+	services.NewExchangeRatesService,
 
 	// Controllers
 	controllers.NewUsersController,
 	controllers.NewOrdersController,
+	controllers.NewProductsController,
+	controllers.NewInventoryController,
+	controllers.NewPaymentsController,
 
 	// Middleware
 	middleware.AuthMiddleware,
@@ -49,16 +79,22 @@ func newAppModules(
 	authMW fiber.Handler,
 	usersCtrl controllers.UsersController,
 	ordersCtrl controllers.OrdersController,
+	productsCtrl controllers.ProductsController,
+	inventoryCtrl controllers.InventoryController,
+	paymentsCtrl controllers.PaymentsController,
 ) *AppModules {
 	return &AppModules{
-		AuthMiddleware:   authMW,
-		UsersController:  usersCtrl,
-		OrdersController: ordersCtrl,
+		AuthMiddleware:      authMW,
+		UsersController:     usersCtrl,
+		OrdersController:    ordersCtrl,
+		ProductsController:  productsCtrl,
+		InventoryController: inventoryCtrl,
+		PaymentsController:  paymentsCtrl,
 	}
 }
 
-// InitializeAppModules wires up the entire application in one go.
-func InitializeAppModules() *AppModules {
+// InitializeAppModules wires up the entire application in one go, it fails when a provider cannot load its configuration.
+func InitializeAppModules() (*AppModules, error) {
 	wire.Build(AppModulesSet)
-	return &AppModules{} // This return is never reached; Wire will generate the code.
+	return &AppModules{}, nil // This return is never reached; Wire will generate the code.
 }
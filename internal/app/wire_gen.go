@@ -8,9 +8,12 @@ package app
 
 import (
 	"fp_kata/common/middleware"
+	"fp_kata/common/utils"
 	"fp_kata/internal/controllers"
 	"fp_kata/internal/datasources/file"
 	"fp_kata/internal/datasources/yugabyte"
+	"fp_kata/internal/gateways"
+	"fp_kata/internal/gateways/fake"
 	"fp_kata/internal/services"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/wire"
@@ -18,43 +21,84 @@ import (
 
 // Injectors from wire.go:
 
-// InitializeAppModules wires up the entire application in one go.
-func InitializeAppModules() *AppModules {
+// InitializeAppModules wires up the entire application in one go, it fails when a provider cannot load its configuration.
+func InitializeAppModules() (*AppModules, error) {
 	authService := services.NewAuthService()
 	usersDatasource := file.NewUsersStorage()
 	usersService := services.NewUsersService(usersDatasource, authService)
 	v := middleware.AuthMiddleware(authService, usersService)
 	usersController := controllers.NewUsersController(usersService)
 	ordersDatasource := file.NewOrdersStorage()
-	paymentsDatasource := yugabyte.NewPaymentsStorage()
-	paymentsService := services.NewPaymentsService(paymentsDatasource)
+	idGeneratorConfig := utils.NewIDGeneratorConfig()
+	idGenerator := utils.NewIDGenerator(idGeneratorConfig)
+	paymentsDatasource := yugabyte.NewPaymentsStorage(idGenerator)
+	paymentMethodsConfig, err := services.NewPaymentMethodsConfig()
+	if err != nil {
+		return nil, err
+	}
+	paymentGateway := fake.NewPaymentGateway()
+	paymentsService := services.NewPaymentsService(paymentsDatasource, paymentMethodsConfig, paymentGateway)
 	authorizationService := services.NewAuthorizationService()
-	ordersService := services.NewOrdersService(ordersDatasource, paymentsService, authorizationService)
-	ordersController := controllers.NewOrdersController(ordersService)
-	appModules := newAppModules(v, usersController, ordersController)
-	return appModules
+	productsFile := file.NewProductsFile()
+	productsDatasource, err := file.NewProductsStorage(productsFile)
+	if err != nil {
+		return nil, err
+	}
+	productsService := services.NewProductsService(productsDatasource)
+	inventoryDatasource := file.NewInventoryStorage()
+	inventoryService := services.NewInventoryService(inventoryDatasource, productsService)
+	orderNumberConfig := services.NewOrderNumberConfig()
+	orderNumberGenerator := services.NewOrderNumberGenerator(orderNumberConfig)
+	exchangeRatesConfig := services.NewExchangeRatesConfig()
+	exchangeRatesFile := file.NewExchangeRatesFile()
+	exchangeRatesDatasource, err := file.NewExchangeRatesStorage(exchangeRatesFile)
+	if err != nil {
+		return nil, err
+	}
+	exchangeRatesService := services.NewExchangeRatesService(exchangeRatesConfig, exchangeRatesDatasource)
+	ordersService := services.NewOrdersService(ordersDatasource, paymentsService, authorizationService, productsService, inventoryService, idGenerator, orderNumberGenerator, exchangeRatesService)
+	weighingConfig := services.NewWeighingConfig()
+	weighingService := services.NewWeighingService(weighingConfig, ordersService)
+	idempotencyConfig := services.NewIdempotencyConfig()
+	idempotencyDatasource := file.NewIdempotencyStorage()
+	idempotencyService := services.NewIdempotencyService(idempotencyConfig, idempotencyDatasource)
+	ordersController := controllers.NewOrdersController(ordersService, weighingService, idempotencyService)
+	productsController := controllers.NewProductsController(productsService)
+	inventoryController := controllers.NewInventoryController(inventoryService)
+	paymentsController := controllers.NewPaymentsController(paymentsService, ordersService)
+	appModules := newAppModules(v, usersController, ordersController, productsController, inventoryController, paymentsController)
+	return appModules, nil
 }
 
 // wire.go:
 
 type AppModules struct {
-	AuthMiddleware   fiber.Handler
-	UsersController  controllers.UsersController
-	OrdersController controllers.OrdersController
+	AuthMiddleware      fiber.Handler
+	UsersController     controllers.UsersController
+	OrdersController    controllers.OrdersController
+	ProductsController  controllers.ProductsController
+	InventoryController controllers.InventoryController
+	PaymentsController  controllers.PaymentsController
 }
 
 // Define a ProviderSet that provides AuthService once.
-var AppModulesSet = wire.NewSet(file.NewOrdersStorage, file.NewUsersStorage, yugabyte.NewPaymentsStorage, services.NewAuthService, services.NewUsersService, services.NewPaymentsService, services.NewOrdersService, services.NewAuthorizationService, controllers.NewUsersController, controllers.NewOrdersController, middleware.AuthMiddleware, newAppModules)
+var AppModulesSet = wire.NewSet(utils.NewIDGeneratorConfig, utils.NewIDGenerator, file.NewOrdersStorage, file.NewUsersStorage, yugabyte.NewPaymentsStorage, file.NewProductsFile, file.NewProductsStorage, file.NewInventoryStorage, file.NewIdempotencyStorage, file.NewExchangeRatesFile, file.NewExchangeRatesStorage, fake.NewPaymentGateway, wire.Bind(new(gateways.PaymentGateway), new(*fake.PaymentGateway)), services.NewAuthService, services.NewUsersService, services.NewPaymentsService, services.NewPaymentMethodsConfig, services.NewOrdersService, services.NewProductsService, services.NewInventoryService, services.NewAuthorizationService, services.NewWeighingConfig, services.NewWeighingService, services.NewIdempotencyConfig, services.NewIdempotencyService, services.NewOrderNumberConfig, services.NewOrderNumberGenerator, services.NewExchangeRatesConfig, services.NewExchangeRatesService, controllers.NewUsersController, controllers.NewOrdersController, controllers.NewProductsController, controllers.NewInventoryController, controllers.NewPaymentsController, middleware.AuthMiddleware, newAppModules)
 
 // newAppModules ties together all the pieces into a single struct.
 func newAppModules(
 	authMW fiber.Handler,
 	usersCtrl controllers.UsersController,
 	ordersCtrl controllers.OrdersController,
+	productsCtrl controllers.ProductsController,
+	inventoryCtrl controllers.InventoryController,
+	paymentsCtrl controllers.PaymentsController,
 ) *AppModules {
 	return &AppModules{
-		AuthMiddleware:   authMW,
-		UsersController:  usersCtrl,
-		OrdersController: ordersCtrl,
+		AuthMiddleware:      authMW,
+		UsersController:     usersCtrl,
+		OrdersController:    ordersCtrl,
+		ProductsController:  productsCtrl,
+		InventoryController: inventoryCtrl,
+		PaymentsController:  paymentsCtrl,
 	}
 }
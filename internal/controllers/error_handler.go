@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/internal/filters"
+	"fp_kata/pkg/log"
+	"fp_kata/pkg/transports"
+	"github.com/gofiber/fiber/v3"
+	"github.com/rs/zerolog"
+	"net/http"
+	"strings"
+)
+
+// The errors of requests that are rejected before they reach a service.
+var (
+	errInvalidPayload   = common.NewDomainError(common.Validation, "invalid_payload", "invalid request payload")
+	errValidationFailed = common.NewDomainError(common.Validation, "validation_failed", "validation failed")
+	errInvalidParameter = common.NewDomainError(common.Validation, "invalid_parameter", "invalid parameter")
+)
+
+// kindStatuses are the statuses the kinds of domain errors are responded with.
+var kindStatuses = map[common.ErrorKind]int{
+	common.NotFound:        fiber.StatusNotFound,
+	common.Unauthenticated: fiber.StatusUnauthorized,
+	common.Forbidden:       fiber.StatusForbidden,
+	common.Validation:      fiber.StatusBadRequest,
+	common.Unprocessable:   fiber.StatusUnprocessableEntity,
+	common.Conflict:        fiber.StatusConflict,
+	common.Declined:        fiber.StatusPaymentRequired,
+	common.Unavailable:     fiber.StatusServiceUnavailable,
+}
+
+// ErrorHandler responds to the errors returned by handlers with problem details (RFC 7807). Domain errors are
+// responded with the status of their kind and their code, errors of fiber with their status. All other errors
+// are logged and responded as internal server errors, without details. Errors of filter expressions carry
+// the position and token of the error.
+func ErrorHandler(requestCtx fiber.Ctx, err error) error {
+	problem := transports.ProblemResponse{
+		Type:     "about:blank",
+		Status:   fiber.StatusInternalServerError,
+		Instance: requestCtx.Path(),
+		Code:     "internal_error",
+	}
+
+	var domainErr *common.DomainError
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &domainErr) && domainErr.Kind != common.Internal:
+		problem.Status = kindStatuses[domainErr.Kind]
+		problem.Code = domainErr.Code
+		problem.Detail = err.Error()
+		var parseErr *filters.ParseError
+		if errors.As(err, &parseErr) {
+			problem.Position = parseErr.Position
+			problem.Token = parseErr.Token
+		}
+	case errors.As(err, &fiberErr):
+		problem.Status = fiberErr.Code
+		problem.Code = strings.ReplaceAll(strings.ToLower(http.StatusText(fiberErr.Code)), " ", "_")
+		problem.Detail = fiberErr.Message
+	default:
+		// the logger is missing for requests that fail before the logging middleware
+		if logger, ok := requestCtx.Locals(log.LogFiberContextKey).(*zerolog.Logger); ok {
+			logger.Error().Err(err).Msg("Internal server error")
+		}
+	}
+	problem.Title = http.StatusText(problem.Status)
+
+	return requestCtx.Status(problem.Status).JSON(problem, transports.ProblemContentType)
+}
+
+// invalidRequest is the error of a request payload that fails validation.
+func invalidRequest(err error) error {
+	return fmt.Errorf("%w: %w", errValidationFailed, err)
+}
+
+// invalidParameter is the error of a path or query parameter that cannot be parsed.
+func invalidParameter(name string, err error) error {
+	return fmt.Errorf("%w %s: %w", errInvalidParameter, name, err)
+}
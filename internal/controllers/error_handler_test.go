@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/filters"
+	"fp_kata/internal/services"
+	"fp_kata/pkg/transports"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// assertProblem asserts that the response body is a problem with the code and detail.
+func assertProblem(t *testing.T, responseBody string, code string, detail string) {
+	t.Helper()
+
+	var problem transports.ProblemResponse
+	if assert.NoError(t, json.Unmarshal([]byte(responseBody), &problem), "Expected a problem response") {
+		assert.Equal(t, code, problem.Code, "Unexpected problem code")
+		assert.Equal(t, detail, problem.Detail, "Unexpected problem detail")
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	errThing := common.NewDomainError(common.NotFound, "thing_not_found", "thing not found")
+
+	tests := []struct {
+		name            string
+		err             error
+		expectedStatus  int
+		expectedProblem transports.ProblemResponse
+	}{
+		{
+			name:           "wrapped domain error",
+			err:            fmt.Errorf("%w: %d", errThing, 7),
+			expectedStatus: fiber.StatusNotFound,
+			expectedProblem: transports.ProblemResponse{
+				Type: "about:blank", Title: "Not Found", Status: fiber.StatusNotFound, Detail: "thing not found: 7", Instance: "/things/7", Code: "thing_not_found",
+			},
+		},
+		{
+			name:           "forbidden",
+			err:            common.NewDomainError(common.Forbidden, "not_yours", "thing belongs to another user"),
+			expectedStatus: fiber.StatusForbidden,
+			expectedProblem: transports.ProblemResponse{
+				Type: "about:blank", Title: "Forbidden", Status: fiber.StatusForbidden, Detail: "thing belongs to another user", Instance: "/things/7", Code: "not_yours",
+			},
+		},
+		{
+			name:           "unavailable",
+			err:            common.NewDomainError(common.Unavailable, "thing_busy", "thing is busy"),
+			expectedStatus: fiber.StatusServiceUnavailable,
+			expectedProblem: transports.ProblemResponse{
+				Type: "about:blank", Title: "Service Unavailable", Status: fiber.StatusServiceUnavailable, Detail: "thing is busy", Instance: "/things/7", Code: "thing_busy",
+			},
+		},
+		{
+			name:           "payment not found in storage",
+			err:            fmt.Errorf("%w: %d", datasources.ErrPaymentNotFound, 7),
+			expectedStatus: fiber.StatusNotFound,
+			expectedProblem: transports.ProblemResponse{
+				Type: "about:blank", Title: "Not Found", Status: fiber.StatusNotFound, Detail: "payment not found: 7", Instance: "/things/7", Code: "payment_not_found",
+			},
+		},
+		{
+			name:           "order without owner",
+			err:            services.ErrOrderOwnerMissing,
+			expectedStatus: fiber.StatusForbidden,
+			expectedProblem: transports.ProblemResponse{
+				Type: "about:blank", Title: "Forbidden", Status: fiber.StatusForbidden, Detail: "missing user on order", Instance: "/things/7", Code: "order_owner_missing",
+			},
+		},
+		{
+			name:           "filter error locates the error",
+			err:            fmt.Errorf("%w: %w", errInvalidFilter, &filters.ParseError{Position: 9, Token: "colour", Message: "unknown field"}),
+			expectedStatus: fiber.StatusBadRequest,
+			expectedProblem: transports.ProblemResponse{
+				Type: "about:blank", Title: "Bad Request", Status: fiber.StatusBadRequest, Detail: `invalid filter expression: unknown field at position 9 near "colour"`, Instance: "/things/7", Code: "invalid_filter", Position: 9, Token: "colour",
+			},
+		},
+		{
+			name:           "fiber error",
+			err:            fiber.NewError(fiber.StatusUnsupportedMediaType, "things are JSON"),
+			expectedStatus: fiber.StatusUnsupportedMediaType,
+			expectedProblem: transports.ProblemResponse{
+				Type: "about:blank", Title: "Unsupported Media Type", Status: fiber.StatusUnsupportedMediaType, Detail: "things are JSON", Instance: "/things/7", Code: "unsupported_media_type",
+			},
+		},
+		{
+			name:           "internal domain error has no details",
+			err:            common.NewDomainError(common.Internal, "thing_broken", "thing storage is broken"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedProblem: transports.ProblemResponse{
+				Type: "about:blank", Title: "Internal Server Error", Status: fiber.StatusInternalServerError, Instance: "/things/7", Code: "internal_error",
+			},
+		},
+		{
+			name:           "other error has no details",
+			err:            errors.New("connection refused"),
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedProblem: transports.ProblemResponse{
+				Type: "about:blank", Title: "Internal Server Error", Status: fiber.StatusInternalServerError, Instance: "/things/7", Code: "internal_error",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			app.Get("/things/:id", func(ctx fiber.Ctx) error {
+				return tc.err
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/things/7", nil))
+			assert.Nil(t, err, "Handler should not return an error")
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode, "Unexpected status code")
+			assert.Equal(t, transports.ProblemContentType, resp.Header.Get(fiber.HeaderContentType), "Unexpected content type")
+			body, _ := io.ReadAll(resp.Body)
+			var problem transports.ProblemResponse
+			assert.NoError(t, json.Unmarshal(body, &problem), "Expected a problem response")
+			assert.Equal(t, tc.expectedProblem, problem, "Unexpected problem")
+		})
+	}
+}
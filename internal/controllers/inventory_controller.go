@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"fmt"
+	"fp_kata/common/utils"
+	"fp_kata/internal/services"
+	"fp_kata/pkg/log"
+	"fp_kata/pkg/transports"
+	"github.com/gofiber/fiber/v3"
+	"strconv"
+)
+
+const compInventoryController = "InventoryController"
+
+type InventoryController struct {
+	inventoryService services.InventoryService
+}
+
+func NewInventoryController(inventoryService services.InventoryService) InventoryController {
+	return InventoryController{inventoryService: inventoryService}
+}
+
+func (c *InventoryController) RegisterInventoryRoutes(app *fiber.App, authMiddleware fiber.Handler) {
+	app.Get("/inventory", c.GetInventory, authMiddleware)
+	app.Get("/inventory/low-stock", c.GetLowStock, authMiddleware)
+	app.Get("/inventory/:productId", c.GetStock, authMiddleware)
+	app.Put("/inventory/:productId", c.SetStock, authMiddleware)
+	app.Post("/inventory/:productId/adjustments", c.AdjustStock, authMiddleware)
+}
+
+// GetInventory handles "/inventory" with method "GET"
+func (c *InventoryController) GetInventory(requestCtx fiber.Ctx) error {
+	logger := log.GetFiberLogger(requestCtx)
+	backgroundCtx := log.NewBackgroundContext(logger)
+	utils.LogAction(backgroundCtx, compInventoryController, "GetInventory")
+
+	stocks, err := c.inventoryService.GetAllStock(backgroundCtx)
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.ConvertStocks(stocks))
+}
+
+// GetLowStock handles "/inventory/low-stock" with method "GET". The optional query "threshold" replaces
+// the reorder levels of the products.
+func (c *InventoryController) GetLowStock(requestCtx fiber.Ctx) error {
+	logger := log.GetFiberLogger(requestCtx)
+	backgroundCtx := log.NewBackgroundContext(logger)
+	utils.LogAction(backgroundCtx, compInventoryController, "GetLowStock")
+
+	var threshold *int
+	if value := requestCtx.Query("threshold"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("%w threshold: must be a number of at least 0", errInvalidParameter)
+		}
+		threshold = &parsed
+	}
+
+	stocks, err := c.inventoryService.GetLowStock(backgroundCtx, threshold)
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.ConvertStocks(stocks))
+}
+
+// GetStock handles "/inventory/{productId}" with method "GET"
+func (c *InventoryController) GetStock(requestCtx fiber.Ctx) error {
+
+	productId := requestCtx.Params("productId")
+	logger := log.GetFiberLogger(requestCtx).With().Str("productId", productId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compInventoryController, "GetStock")
+
+	pid, err := strconv.Atoi(productId)
+	if err != nil {
+		return invalidParameter("productId", err)
+	}
+
+	stock, err := c.inventoryService.GetStock(backgroundCtx, pid)
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToStockResponse(*stock))
+}
+
+// SetStock handles "/inventory/{productId}" with method "PUT"
+func (c *InventoryController) SetStock(requestCtx fiber.Ctx) error {
+
+	productId := requestCtx.Params("productId")
+	logger := log.GetFiberLogger(requestCtx).With().Str("productId", productId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compInventoryController, "SetStock")
+
+	pid, err := strconv.Atoi(productId)
+	if err != nil {
+		return invalidParameter("productId", err)
+	}
+
+	stockRequest := new(transports.StockRequest)
+	if err := requestCtx.Bind().Body(stockRequest); err != nil {
+		return errInvalidPayload
+	}
+
+	validate := utils.NewValidator()
+	if err := validate.Struct(stockRequest); err != nil {
+		return invalidRequest(err)
+	}
+
+	stock, err := c.inventoryService.SetStock(backgroundCtx, *stockRequest.ToStock(pid))
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToStockResponse(*stock))
+}
+
+// AdjustStock handles "/inventory/{productId}/adjustments" with method "POST"
+func (c *InventoryController) AdjustStock(requestCtx fiber.Ctx) error {
+
+	productId := requestCtx.Params("productId")
+	logger := log.GetFiberLogger(requestCtx).With().Str("productId", productId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compInventoryController, "AdjustStock")
+
+	pid, err := strconv.Atoi(productId)
+	if err != nil {
+		return invalidParameter("productId", err)
+	}
+
+	adjustmentRequest := new(transports.StockAdjustmentRequest)
+	if err := requestCtx.Bind().Body(adjustmentRequest); err != nil {
+		return errInvalidPayload
+	}
+
+	validate := utils.NewValidator()
+	if err := validate.Struct(adjustmentRequest); err != nil {
+		return invalidRequest(err)
+	}
+
+	stock, err := c.inventoryService.AdjustStock(backgroundCtx, pid, adjustmentRequest.Delta)
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToStockResponse(*stock))
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/models"
+	"fp_kata/mocks"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func createTestInventoryController(mockInventoryService *mocks.InventoryService, contextData *map[any]any) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	mockData := make(map[any]any)
+	if contextData != nil {
+		mockData = *contextData
+	}
+
+	ctx := &mocks.CustomCtx{
+		DefaultCtx: *fiber.NewDefaultCtx(app),
+		MockLocals: mockData,
+	}
+	app.NewCtxFunc(func(app *fiber.App) fiber.CustomCtx {
+		return ctx
+	})
+
+	controller := NewInventoryController(mockInventoryService)
+	app.Get("/inventory", controller.GetInventory)
+	app.Get("/inventory/low-stock", controller.GetLowStock)
+	app.Get("/inventory/:productId", controller.GetStock)
+	app.Put("/inventory/:productId", controller.SetStock)
+	app.Post("/inventory/:productId/adjustments", controller.AdjustStock)
+
+	return app
+}
+
+func TestInventoryController(t *testing.T) {
+	threshold := 5
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		body             string
+		setupServiceMock func(mockInventoryService *mocks.InventoryService)
+		assertFunc       func(t *testing.T, responseBody string, responseCode int)
+	}{
+		{
+			name:   "list inventory",
+			method: http.MethodGet,
+			path:   "/inventory",
+			setupServiceMock: func(mockInventoryService *mocks.InventoryService) {
+				mockInventoryService.On("GetAllStock", mock.Anything).Return([]*models.Stock{{ProductID: 1, OnHand: 5, Reserved: 2, ReorderLevel: 1}}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `[{"product_id":1,"on_hand":5,"reserved":2,"available":3,"reorder_level":1}]`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:   "low stock by reorder level",
+			method: http.MethodGet,
+			path:   "/inventory/low-stock",
+			setupServiceMock: func(mockInventoryService *mocks.InventoryService) {
+				mockInventoryService.On("GetLowStock", mock.Anything, (*int)(nil)).Return([]*models.Stock{}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `[]`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:   "low stock by threshold",
+			method: http.MethodGet,
+			path:   "/inventory/low-stock?threshold=5",
+			setupServiceMock: func(mockInventoryService *mocks.InventoryService) {
+				mockInventoryService.On("GetLowStock", mock.Anything, &threshold).Return([]*models.Stock{{ProductID: 2, OnHand: 4}}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `[{"product_id":2,"on_hand":4,"reserved":0,"available":4,"reorder_level":0}]`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:   "low stock - invalid threshold",
+			method: http.MethodGet,
+			path:   "/inventory/low-stock?threshold=-1",
+			setupServiceMock: func(mockInventoryService *mocks.InventoryService) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_parameter", "invalid parameter threshold: must be a number of at least 0")
+			},
+		},
+		{
+			name:   "get stock - unknown product",
+			method: http.MethodGet,
+			path:   "/inventory/9",
+			setupServiceMock: func(mockInventoryService *mocks.InventoryService) {
+				mockInventoryService.On("GetStock", mock.Anything, 9).Return(nil, fmt.Errorf("%w: %d", datasources.ErrProductNotFound, 9))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusNotFound, responseCode, "Unexpected status code")
+			},
+		},
+		{
+			name:   "set stock",
+			method: http.MethodPut,
+			path:   "/inventory/1",
+			body:   `{"on_hand":12,"reorder_level":3}`,
+			setupServiceMock: func(mockInventoryService *mocks.InventoryService) {
+				mockInventoryService.On("SetStock", mock.Anything, models.Stock{ProductID: 1, OnHand: 12, ReorderLevel: 3}).
+					Return(&models.Stock{ProductID: 1, OnHand: 12, Reserved: 2, ReorderLevel: 3}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `{"product_id":1,"on_hand":12,"reserved":2,"available":10,"reorder_level":3}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:   "set stock - validation failed",
+			method: http.MethodPut,
+			path:   "/inventory/1",
+			body:   `{"on_hand":-1}`,
+			setupServiceMock: func(mockInventoryService *mocks.InventoryService) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assert.Contains(t, responseBody, `"code":"validation_failed"`, "Unexpected response JSON")
+			},
+		},
+		{
+			name:   "adjust stock",
+			method: http.MethodPost,
+			path:   "/inventory/1/adjustments",
+			body:   `{"delta":-2}`,
+			setupServiceMock: func(mockInventoryService *mocks.InventoryService) {
+				mockInventoryService.On("AdjustStock", mock.Anything, 1, -2).Return(&models.Stock{ProductID: 1, OnHand: 3}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `{"product_id":1,"on_hand":3,"reserved":0,"available":3,"reorder_level":0}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:   "adjust stock - reserved stock",
+			method: http.MethodPost,
+			path:   "/inventory/1/adjustments",
+			body:   `{"delta":-9}`,
+			setupServiceMock: func(mockInventoryService *mocks.InventoryService) {
+				mockInventoryService.On("AdjustStock", mock.Anything, 1, -9).
+					Return(nil, fmt.Errorf("%w: product 1 has 3 available, 9 requested", datasources.ErrInsufficientStock))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusConflict, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "insufficient_stock", "insufficient stock: product 1 has 3 available, 9 requested")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockInventoryService := new(mocks.InventoryService)
+			tc.setupServiceMock(mockInventoryService)
+
+			user := models.User{ID: 1, Username: "Jane Doe"}
+			app := createTestInventoryController(mockInventoryService, mocks.ProvideBaseMockContextData(&user))
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+
+			tc.assertFunc(t, buf.String(), resp.StatusCode)
+
+			mockInventoryService.AssertExpectations(t)
+		})
+	}
+}
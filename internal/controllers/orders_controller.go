@@ -1,35 +1,110 @@
 package controllers
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"fp_kata/common"
 	"fp_kata/common/constants"
 	"fp_kata/common/utils"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/filters"
 	"fp_kata/internal/models"
 	"fp_kata/internal/services"
 	"fp_kata/pkg/log"
 	"fp_kata/pkg/transports"
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v3"
 
 	"strconv"
+	"strings"
 )
 
 const compOrdersController = "OrdersController"
 
+const (
+	// maxOrdersPageLimit is the largest page size of the order listing.
+	maxOrdersPageLimit = 100
+	headerTotalCount   = "X-Total-Count"
+	headerNextCursor   = "X-Next-Cursor"
+
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength is the longest accepted idempotency key.
+	maxIdempotencyKeyLength = 255
+)
+
+// immutableOrderMembers are the order members a merge patch must not touch.
+var immutableOrderMembers = []string{"id", "user", "user_id", "status"}
+
 type OrdersController struct {
-	orderService services.OrdersService
+	orderService       services.OrdersService
+	weighingService    services.WeighingService
+	idempotencyService services.IdempotencyService
 }
 
-func NewOrdersController(orderService services.OrdersService) OrdersController {
+func NewOrdersController(orderService services.OrdersService, weighingService services.WeighingService,
+	idempotencyService services.IdempotencyService) OrdersController {
 	return OrdersController{
-		orderService: orderService,
+		orderService:       orderService,
+		weighingService:    weighingService,
+		idempotencyService: idempotencyService,
 	}
 }
 
+// errInvalidPrice is returned for a price query parameter that is not a number.
+var errInvalidPrice = common.NewDomainError(common.Validation, "invalid_price", "invalid price value")
+
+// errInvalidFilter is returned for a filter query parameter that is not a filter expression.
+var errInvalidFilter = common.NewDomainError(common.Validation, "invalid_filter", "invalid filter expression")
+
+// parseOrdersFilter builds the predicate of the filter and price query parameters, nil when neither is given.
+func parseOrdersFilter(requestCtx fiber.Ctx) (filters.Predicate, error) {
+	var predicate filters.Predicate
+	if expression := requestCtx.Query("filter"); expression != "" {
+		var err error
+		predicate, err = filters.Parse(expression)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidFilter, err)
+		}
+	}
+
+	if price := requestCtx.Query("price"); price != "" {
+		minPrice, err := common.ParseMoney(price)
+		if err != nil {
+			return nil, errInvalidPrice
+		}
+		pricePredicate := filters.Match("price > "+price, func(order *models.Order) bool {
+			return order.Price.Compare(minPrice) > 0
+		})
+		if predicate != nil {
+			predicate = filters.And(pricePredicate, predicate)
+		} else {
+			predicate = pricePredicate
+		}
+	}
+	return predicate, nil
+}
+
 func (c *OrdersController) RegisterOrderRoutes(app *fiber.App, authMiddleware fiber.Handler) {
 	app.Post("/orders", c.CreateOrder, authMiddleware)
+	app.Post("/orders/import", c.ImportOrders, authMiddleware)
 	app.Get("/orders", c.GetOrders, authMiddleware)
+	app.Get("/orders/export", c.ExportOrders, authMiddleware)
+	app.Get("/orders/summary", c.GetOrdersSummary, authMiddleware)
 	app.Get("/orders/:id", c.GetOrder, authMiddleware)
+	app.Put("/orders/:id", c.ReplaceOrder, authMiddleware)
+	app.Patch("/orders/:id", c.PatchOrder, authMiddleware)
+	app.Delete("/orders/:id", c.DeleteOrder, authMiddleware)
+	app.Post("/orders/:id/transitions", c.TransitionOrder, authMiddleware)
+	app.Post("/orders/:id/weighing", c.WeighOrder, authMiddleware)
+	app.Post("/orders/:id/payments", c.AddPayment, authMiddleware)
+	app.Post("/orders/:id/refunds", c.RefundOrder, authMiddleware)
+	app.Get("/orders/:id/refunds", c.GetRefunds, authMiddleware)
+	app.Get("/orders/:id/history", c.GetOrderHistory, authMiddleware)
+	app.Get("/orders/:id/versions/:n", c.GetOrderVersion, authMiddleware)
+	app.Get("/orders/:id/diff", c.DiffOrderVersions, authMiddleware)
 }
 
 func (c *OrdersController) CreateOrder(ctx fiber.Ctx) error {
@@ -43,26 +118,70 @@ func (c *OrdersController) CreateOrder(ctx fiber.Ctx) error {
 	var orderRequest = new(transports.OrderCreateRequest)
 
 	if err := ctx.Bind().Body(orderRequest); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request payload",
-		})
+		return errInvalidPayload
 	}
 
-	validate := validator.New()
+	validate := utils.NewValidator()
 	if err := validate.Struct(orderRequest); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   "Validation failed",
-			"details": err.Error(),
-		})
+		return invalidRequest(err)
+	}
+
+	order, err := orderRequest.ToOrder(user)
+	if err != nil {
+		return invalidRequest(err)
 	}
 
-	order := orderRequest.ToOrder(user)
+	key := ctx.Get(headerIdempotencyKey)
+	if key == "" {
+		return c.storeOrder(ctx, context, userID, *order)
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return fmt.Errorf("%w %s: must not be longer than %d characters", errInvalidParameter, headerIdempotencyKey, maxIdempotencyKeyLength)
+	}
 
-	newOrder, err := c.orderService.StoreOrder(context, userID, *order)
+	// retries are recognized by the bound request, so they match regardless of the formatting of the body
+	request, err := json.Marshal(orderRequest)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Unable to create the order",
-		})
+		return err
+	}
+	storedResponse, err := c.idempotencyService.Begin(context, userID, key, request)
+	if err != nil {
+		return err
+	}
+	if storedResponse != nil {
+		ctx.Set(headerIdempotentReplayed, "true")
+		ctx.Set(fiber.HeaderContentType, storedResponse.ContentType)
+		return ctx.Status(storedResponse.StatusCode).Send(storedResponse.Body)
+	}
+
+	err = c.storeOrder(ctx, context, userID, *order)
+	if err != nil {
+		// the error is responded here already, so the response to a rejected order is kept like a created order
+		err = ErrorHandler(ctx, err)
+	}
+	response := ctx.Response()
+	// server errors are not kept, the order can be placed again with the same key
+	if err != nil || response.StatusCode() >= fiber.StatusInternalServerError {
+		if abandonErr := c.idempotencyService.Abandon(context, userID, key); abandonErr != nil {
+			logger.Error().Err(abandonErr).Msg("Error abandoning idempotency key")
+		}
+		return err
+	}
+	if err := c.idempotencyService.Complete(context, userID, key, models.StoredResponse{
+		StatusCode:  response.StatusCode(),
+		ContentType: string(response.Header.ContentType()),
+		Body:        bytes.Clone(response.Body()),
+	}); err != nil {
+		logger.Error().Err(err).Msg("Error storing the response to an idempotency key")
+	}
+	return nil
+}
+
+// storeOrder places the order and writes the response.
+func (c *OrdersController) storeOrder(ctx fiber.Ctx, backgroundCtx context.Context, userID int, order models.Order) error {
+	newOrder, err := c.orderService.StoreOrder(backgroundCtx, userID, order)
+	if err != nil {
+		return err
 	}
 
 	orderResponse := transports.MapToOrderResponse(*newOrder)
@@ -80,37 +199,180 @@ func (c *OrdersController) GetOrders(requestCtx fiber.Ctx) error {
 	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
 	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
 
-	var orders []*models.Order
+	predicate, err := parseOrdersFilter(requestCtx)
+	if err != nil {
+		return err
+	}
 
-	price := requestCtx.Query("price")
-	if price != "" {
-		priceInt, err := strconv.ParseFloat(price, 64)
-		if err != nil {
-			return requestCtx.Status(fiber.StatusBadRequest).SendString("Invalid price value")
-		}
+	sorts, err := datasources.ParseOrderSort(requestCtx.Query("sort"))
+	if err != nil {
+		return err
+	}
+	query := datasources.OrdersQuery{Sort: sorts, Cursor: requestCtx.Query("cursor")}
 
-		filter := func(order *models.Order) bool {
-			return order.Price > priceInt
+	if limit := requestCtx.Query("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 || query.Limit > maxOrdersPageLimit {
+			return fmt.Errorf("%w limit: must be a number between 1 and %d", errInvalidParameter, maxOrdersPageLimit)
 		}
+	}
 
-		orders, err = c.orderService.GetOrdersWithFilter(backgroundCtx, user.ID, filter)
+	page, err := c.orderService.GetOrdersPage(backgroundCtx, user.ID, predicate, query)
+	if err != nil {
+		return err
+	}
+
+	requestCtx.Set(headerTotalCount, strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		requestCtx.Set(headerNextCursor, page.NextCursor)
+	}
+
+	orderResponses := make([]*transports.OrderResponse, len(page.Orders))
+	for i, order := range page.Orders {
+		orderResponses[i] = transports.MapToOrderResponse(*order)
+	}
+	return requestCtx.Status(fiber.StatusOK).JSON(orderResponses)
+
+}
+
+// ExportOrders handles "/orders/export" with method "GET". It exports the orders selected by the filters of
+// the order listing as csv or ndjson, writing them to the response while they are loaded.
+func (c *OrdersController) ExportOrders(requestCtx fiber.Ctx) error {
+	logger := log.GetFiberLogger(requestCtx)
+	backgroundCtx := log.NewBackgroundContext(logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "ExportOrders")
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	predicate, err := parseOrdersFilter(requestCtx)
+	if err != nil {
+		return err
+	}
+
+	sorts, err := datasources.ParseOrderSort(requestCtx.Query("sort"))
+	if err != nil {
+		return err
+	}
+	format, err := transports.ParseExportFormat(requestCtx.Query("format"))
+	if err != nil {
+		return err
+	}
+	columns, err := transports.ParseExportColumns(requestCtx.Query("columns"))
+	if err != nil {
+		return err
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == transports.ExportNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	requestCtx.Set(fiber.HeaderContentType, contentType)
+	requestCtx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="orders.%s"`, format))
+
+	return requestCtx.Status(fiber.StatusOK).SendStreamWriter(func(w *bufio.Writer) {
+		exportWriter, err := transports.NewOrderExportWriter(w, format, columns)
 		if err != nil {
-			return requestCtx.Status(fiber.StatusInternalServerError).SendString("Error filtering orders")
+			logger.Error().Err(err).Msg("Error creating the order export")
+			return
+		}
+		// the status is sent already, a failing export can only be logged and cut short
+		err = c.orderService.ExportOrders(backgroundCtx, user.ID, predicate, sorts, func(order *models.Order) error {
+			if err := exportWriter.WriteOrder(order); err != nil {
+				return err
+			}
+			if err := exportWriter.Flush(); err != nil {
+				return err
+			}
+			return w.Flush()
+		})
+		if err == nil {
+			err = exportWriter.Flush()
 		}
-	} else {
-		var err error
-		orders, err = c.orderService.GetOrders(backgroundCtx, user.ID)
 		if err != nil {
-			return requestCtx.Status(fiber.StatusInternalServerError).SendString("Error loading orders")
+			logger.Error().Err(err).Msg("Error exporting orders")
+		}
+	})
+}
+
+// GetOrdersSummary handles "/orders/summary" with method "GET". It aggregates the spend of the orders
+// selected by the filters of the order listing, grouped by the day, week or month of the period parameter.
+func (c *OrdersController) GetOrdersSummary(requestCtx fiber.Ctx) error {
+	logger := log.GetFiberLogger(requestCtx)
+	backgroundCtx := log.NewBackgroundContext(logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "GetOrdersSummary")
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	predicate, err := parseOrdersFilter(requestCtx)
+	if err != nil {
+		return err
+	}
+
+	period, err := models.ParseSummaryPeriod(requestCtx.Query("period"))
+	if err != nil {
+		return err
+	}
+
+	summary, err := c.orderService.SummarizeOrders(backgroundCtx, user.ID, predicate, period)
+	if err != nil {
+		return err
+	}
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToOrderSummaryResponse(*summary))
+}
+
+// ImportOrders handles "/orders/import" with method "POST". It imports an NDJSON or CSV upload of orders and
+// responds with a report of every row; dry_run=true only checks the rows and mode=all-or-nothing stores all or none.
+func (c *OrdersController) ImportOrders(requestCtx fiber.Ctx) error {
+	logger := log.GetFiberLogger(requestCtx)
+	backgroundCtx := log.NewBackgroundContext(logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "ImportOrders")
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	var format transports.ExportFormat
+	switch contentType, _, _ := strings.Cut(requestCtx.Get(fiber.HeaderContentType), ";"); strings.TrimSpace(contentType) {
+	case "text/csv":
+		format = transports.ExportCSV
+	case "application/x-ndjson", "application/ndjson":
+		format = transports.ExportNDJSON
+	default:
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "Content-Type must be text/csv or application/x-ndjson")
+	}
+
+	var options models.OrderImportOptions
+	if dryRun := requestCtx.Query("dry_run"); dryRun != "" {
+		var err error
+		if options.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			return fmt.Errorf("%w dry_run: must be true or false", errInvalidParameter)
 		}
 	}
+	switch requestCtx.Query("mode") {
+	case "", "row-by-row":
+	case "all-or-nothing":
+		options.AllOrNothing = true
+	default:
+		return fmt.Errorf("%w mode: must be row-by-row or all-or-nothing", errInvalidParameter)
+	}
 
-	orderResponses := make([]*transports.OrderResponse, len(orders))
-	for i, order := range orders {
-		orderResponses[i] = transports.MapToOrderResponse(*order)
+	rows, err := transports.ReadOrderImport(bytes.NewReader(requestCtx.Body()), format, user)
+	if err != nil {
+		return err
 	}
-	return requestCtx.Status(fiber.StatusOK).JSON(orderResponses)
 
+	results, err := c.orderService.ImportOrders(backgroundCtx, user.ID, rows, options)
+	if err != nil {
+		return err
+	}
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToOrderImportResponse(results, options))
 }
 
 // GetOrder handles "/orders/{id}" with method "GET"
@@ -124,7 +386,7 @@ func (c *OrdersController) GetOrder(requestCtx fiber.Ctx) error {
 
 	oid, err := strconv.Atoi(orderId)
 	if err != nil {
-		return requestCtx.Status(fiber.StatusBadRequest).SendString(err.Error())
+		return invalidParameter("id", err)
 	}
 
 	backgroundCtx = context.WithValue(backgroundCtx, "orderId", oid)
@@ -135,10 +397,395 @@ func (c *OrdersController) GetOrder(requestCtx fiber.Ctx) error {
 
 	order, err := c.orderService.GetOrder(backgroundCtx, user.ID, oid)
 	if err != nil {
-		return requestCtx.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		return err
 	}
 
 	orderResponse := transports.MapToOrderResponse(*order)
 
 	return requestCtx.Status(fiber.StatusOK).JSON(orderResponse)
 }
+
+// GetOrderHistory handles "/orders/{id}/history" with method "GET"
+func (c *OrdersController) GetOrderHistory(requestCtx fiber.Ctx) error {
+
+	orderId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("orderId", orderId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "GetOrderHistory")
+
+	oid, err := strconv.Atoi(orderId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	versions, err := c.orderService.GetOrderHistory(backgroundCtx, user.ID, oid)
+	if err != nil {
+		return err
+	}
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToOrderHistoryResponse(versions))
+}
+
+// GetOrderVersion handles "/orders/{id}/versions/{n}" with method "GET"
+func (c *OrdersController) GetOrderVersion(requestCtx fiber.Ctx) error {
+
+	orderId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("orderId", orderId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "GetOrderVersion")
+
+	oid, err := strconv.Atoi(orderId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+	version, err := strconv.Atoi(requestCtx.Params("n"))
+	if err != nil {
+		return invalidParameter("n", err)
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	orderVersion, err := c.orderService.GetOrderVersion(backgroundCtx, user.ID, oid, version)
+	if err != nil {
+		return err
+	}
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToOrderVersionResponse(*orderVersion))
+}
+
+// DiffOrderVersions handles "/orders/{id}/diff?from={n}&to={m}" with method "GET"
+func (c *OrdersController) DiffOrderVersions(requestCtx fiber.Ctx) error {
+
+	orderId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("orderId", orderId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "DiffOrderVersions")
+
+	oid, err := strconv.Atoi(orderId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+	from, fromErr := strconv.Atoi(requestCtx.Query("from"))
+	to, toErr := strconv.Atoi(requestCtx.Query("to"))
+	if fromErr != nil || toErr != nil {
+		return fmt.Errorf("%w from and to: must be version numbers", errInvalidParameter)
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	changes, err := c.orderService.DiffOrderVersions(backgroundCtx, user.ID, oid, from, to)
+	if err != nil {
+		return err
+	}
+	return requestCtx.Status(fiber.StatusOK).JSON(&transports.OrderDiffResponse{
+		From:    from,
+		To:      to,
+		Changes: transports.MapToFieldChangeResponses(changes),
+	})
+}
+
+// DeleteOrder handles "/orders/{id}" with method "DELETE"
+func (c *OrdersController) DeleteOrder(requestCtx fiber.Ctx) error {
+
+	orderId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("orderId", orderId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "DeleteOrder")
+
+	oid, err := strconv.Atoi(orderId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+
+	if err := c.orderService.CancelOrder(backgroundCtx, user.ID, oid); err != nil {
+		return err
+	}
+
+	return requestCtx.SendStatus(fiber.StatusNoContent)
+}
+
+// TransitionOrder handles "/orders/{id}/transitions" with method "POST"
+func (c *OrdersController) TransitionOrder(requestCtx fiber.Ctx) error {
+
+	orderId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("orderId", orderId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "TransitionOrder")
+
+	oid, err := strconv.Atoi(orderId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	transitionRequest := new(transports.OrderTransitionRequest)
+	if err := requestCtx.Bind().Body(transitionRequest); err != nil {
+		return errInvalidPayload
+	}
+
+	validate := utils.NewValidator()
+	if err := validate.Struct(transitionRequest); err != nil {
+		return invalidRequest(err)
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	order, err := c.orderService.TransitionOrder(backgroundCtx, user.ID, oid, transitionRequest.Status)
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToOrderResponse(*order))
+}
+
+// WeighOrder handles "/orders/{id}/weighing" with method "POST"
+func (c *OrdersController) WeighOrder(requestCtx fiber.Ctx) error {
+
+	orderId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("orderId", orderId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "WeighOrder")
+
+	oid, err := strconv.Atoi(orderId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	weighingRequest := new(transports.OrderWeighingRequest)
+	if err := requestCtx.Bind().Body(weighingRequest); err != nil {
+		return errInvalidPayload
+	}
+
+	validate := utils.NewValidator()
+	if err := validate.Struct(weighingRequest); err != nil {
+		return invalidRequest(err)
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	order, err := c.weighingService.WeighOrder(backgroundCtx, user.ID, oid, weighingRequest.ToLineWeights())
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToOrderResponse(*order))
+}
+
+// AddPayment handles "/orders/{id}/payments" with method "POST"
+func (c *OrdersController) AddPayment(requestCtx fiber.Ctx) error {
+
+	orderId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("orderId", orderId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "AddPayment")
+
+	oid, err := strconv.Atoi(orderId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	paymentRequest := new(transports.PaymentRequest)
+	if err := requestCtx.Bind().Body(paymentRequest); err != nil {
+		return errInvalidPayload
+	}
+
+	validate := utils.NewValidator()
+	if err := validate.Struct(paymentRequest); err != nil {
+		return invalidRequest(err)
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	paymentRequest.Id = 0
+	payment, err := paymentRequest.ToPayment(user)
+	if err != nil {
+		return invalidRequest(err)
+	}
+
+	order, err := c.orderService.AddPayment(backgroundCtx, user.ID, oid, *payment)
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusCreated).JSON(transports.MapToOrderResponse(*order))
+}
+
+// RefundOrder handles "/orders/{id}/refunds" with method "POST"
+func (c *OrdersController) RefundOrder(requestCtx fiber.Ctx) error {
+
+	orderId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("orderId", orderId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "RefundOrder")
+
+	oid, err := strconv.Atoi(orderId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	refundRequest := new(transports.RefundRequest)
+	if err := requestCtx.Bind().Body(refundRequest); err != nil {
+		return errInvalidPayload
+	}
+
+	validate := utils.NewValidator()
+	if err := validate.Struct(refundRequest); err != nil {
+		return invalidRequest(err)
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	refund, err := c.orderService.RefundOrder(backgroundCtx, user.ID, oid, *refundRequest.ToRefund())
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusCreated).JSON(transports.MapToRefundResponse(*refund))
+}
+
+// GetRefunds handles "/orders/{id}/refunds" with method "GET"
+func (c *OrdersController) GetRefunds(requestCtx fiber.Ctx) error {
+
+	orderId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("orderId", orderId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "GetRefunds")
+
+	oid, err := strconv.Atoi(orderId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	refunds, err := c.orderService.GetRefunds(backgroundCtx, user.ID, oid)
+	if err != nil {
+		return err
+	}
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToRefundResponses(refunds))
+}
+
+// ReplaceOrder handles "/orders/{id}" with method "PUT"
+func (c *OrdersController) ReplaceOrder(requestCtx fiber.Ctx) error {
+
+	orderId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("orderId", orderId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "ReplaceOrder")
+
+	oid, err := strconv.Atoi(orderId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	orderRequest := new(transports.OrderCreateRequest)
+	if err := requestCtx.Bind().Body(orderRequest); err != nil {
+		return errInvalidPayload
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+
+	return c.updateOrder(requestCtx, backgroundCtx, user, oid, orderRequest)
+}
+
+// PatchOrder handles "/orders/{id}" with method "PATCH", the body is a JSON Merge Patch (RFC 7396) of the order
+func (c *OrdersController) PatchOrder(requestCtx fiber.Ctx) error {
+
+	orderId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("orderId", orderId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compOrdersController, "PatchOrder")
+
+	oid, err := strconv.Atoi(orderId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	patch := requestCtx.Body()
+	patchedMembers, err := transports.MergePatchMembers(patch)
+	if err != nil {
+		return errInvalidPayload
+	}
+	for _, member := range patchedMembers {
+		for _, immutableMember := range immutableOrderMembers {
+			if member == immutableMember {
+				return fmt.Errorf("%w: %s", services.ErrImmutableField, member)
+			}
+		}
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	order, err := c.orderService.GetOrder(backgroundCtx, user.ID, oid)
+	if err != nil {
+		return err
+	}
+
+	document, err := json.Marshal(transports.MapToOrderCreateRequest(*order))
+	if err != nil {
+		return err
+	}
+	patchedDocument, err := transports.ApplyMergePatch(document, patch)
+	if err != nil {
+		return errInvalidPayload
+	}
+
+	orderRequest := new(transports.OrderCreateRequest)
+	if err := json.Unmarshal(patchedDocument, orderRequest); err != nil {
+		return errInvalidPayload
+	}
+
+	return c.updateOrder(requestCtx, backgroundCtx, user, oid, orderRequest)
+}
+
+// updateOrder validates the replacement order and stores it in place of the order with the given id
+func (c *OrdersController) updateOrder(requestCtx fiber.Ctx, backgroundCtx context.Context, user models.User, orderId int, orderRequest *transports.OrderCreateRequest) error {
+
+	validate := utils.NewValidator()
+	if err := validate.Struct(orderRequest); err != nil {
+		return invalidRequest(err)
+	}
+
+	order, err := orderRequest.ToOrder(user)
+	if err != nil {
+		return invalidRequest(err)
+	}
+	order.ID = orderId
+
+	updatedOrder, err := c.orderService.UpdateOrder(backgroundCtx, user.ID, *order)
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToOrderResponse(*updatedOrder))
+}
@@ -3,14 +3,23 @@ package controllers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"fp_kata/common"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/file"
+	"fp_kata/internal/filters"
+	"fp_kata/internal/gateways"
 	"fp_kata/internal/models"
 	"fp_kata/internal/services"
 	"fp_kata/mocks"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"fp_kata/pkg/transports"
@@ -20,7 +29,11 @@ import (
 )
 
 func createTestOrdersController(mockOrdersService services.OrdersService, contextData *map[any]any) *fiber.App {
-	app := fiber.New()
+	return createTestOrdersControllerWith(&OrdersController{orderService: mockOrdersService}, contextData)
+}
+
+func createTestOrdersControllerWith(controller *OrdersController, contextData *map[any]any) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
 	mockData := make(map[any]any)
 	if contextData != nil {
 		mockData = *contextData
@@ -34,10 +47,23 @@ func createTestOrdersController(mockOrdersService services.OrdersService, contex
 
 		return ctx
 	})
-	controller := &OrdersController{orderService: mockOrdersService}
 	app.Post("/orders", controller.CreateOrder)
+	app.Post("/orders/import", controller.ImportOrders)
 	app.Get("/orders", controller.GetOrders)
+	app.Get("/orders/export", controller.ExportOrders)
+	app.Get("/orders/summary", controller.GetOrdersSummary)
 	app.Get("/orders/:id", controller.GetOrder)
+	app.Put("/orders/:id", controller.ReplaceOrder)
+	app.Patch("/orders/:id", controller.PatchOrder)
+	app.Delete("/orders/:id", controller.DeleteOrder)
+	app.Post("/orders/:id/transitions", controller.TransitionOrder)
+	app.Post("/orders/:id/weighing", controller.WeighOrder)
+	app.Post("/orders/:id/payments", controller.AddPayment)
+	app.Post("/orders/:id/refunds", controller.RefundOrder)
+	app.Get("/orders/:id/refunds", controller.GetRefunds)
+	app.Get("/orders/:id/history", controller.GetOrderHistory)
+	app.Get("/orders/:id/versions/:n", controller.GetOrderVersion)
+	app.Get("/orders/:id/diff", controller.DiffOrderVersions)
 
 	return app
 
@@ -46,7 +72,7 @@ func createTestOrdersController(mockOrdersService services.OrdersService, contex
 func TestCreateOrder(t *testing.T) {
 
 	setupValidStoreOrderMock := func(mockOrdersService *mocks.OrdersService, body transports.OrderCreateRequest, user models.User, mockReturn *models.Order, mockError error) {
-		order := body.ToOrder(user)
+		order, _ := body.ToOrder(user)
 		mockOrdersService.
 			On("StoreOrder", mock.Anything, user.ID, *order).
 			Return(mockReturn, mockError)
@@ -69,12 +95,12 @@ func TestCreateOrder(t *testing.T) {
 			body: transports.OrderCreateRequest{
 				ProductID: 1,
 				Quantity:  2,
-				Price:     10.23,
+				Price:     common.NewMoney(10.23),
 				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
 				Payments: []*transports.PaymentRequest{
 					{
 						PaymentMethod: common.CreditCard,
-						PaymentAmount: 10.23,
+						PaymentAmount: common.NewMoney(10.23),
 					},
 				},
 			},
@@ -85,13 +111,13 @@ func TestCreateOrder(t *testing.T) {
 				User:      &models.User{ID: 1, Username: "John Doe"},
 				ProductID: 1,
 				Quantity:  2,
-				Price:     10.23,
+				Price:     common.NewMoney(10.23),
 				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
 				Payments: []*models.Payment{
 					{
 						Id:     1,
 						Method: common.CreditCard,
-						Amount: 10.23,
+						Amount: common.NewMoney(10.23),
 					},
 				}},
 			mockError:              nil,
@@ -100,13 +126,16 @@ func TestCreateOrder(t *testing.T) {
 			expectedCode: fiber.StatusCreated,
 			expectedJSON: map[string]interface{}{
 				"has_weightables": false,
+				"amount_due":      "10.23",
+				"amount_paid":     "10.23",
+				"balance":         "0.00",
 				"id":              42,
 				"order_date":      "2025-01-30T10:30:00Z",
 				"payments": []interface{}{map[string]interface{}{
-					"amount": 10.23,
+					"amount": "10.23",
 					"id":     1,
 					"method": "CreditCard"}},
-				"price":      10.23,
+				"price":      "10.23",
 				"product_id": 1,
 				"quantity":   2,
 				"user": map[string]interface{}{
@@ -125,19 +154,123 @@ func TestCreateOrder(t *testing.T) {
 			setupOrdersServiceMock: func(mockOrdersService *mocks.OrdersService, body transports.OrderCreateRequest, user models.User, mockReturn *models.Order, mockError error) {
 			},
 			expectedCode: fiber.StatusBadRequest,
-			expectedJSON: map[string]interface{}{"details": "Key: 'OrderCreateRequest.ProductID' Error:Field validation for 'ProductID' failed on the 'required' tag\nKey: 'OrderCreateRequest.Quantity' Error:Field validation for 'Quantity' failed on the 'required' tag\nKey: 'OrderCreateRequest.Price' Error:Field validation for 'Price' failed on the 'required' tag\nKey: 'OrderCreateRequest.OrderDate' Error:Field validation for 'OrderDate' failed on the 'required' tag\nKey: 'OrderCreateRequest.Payments' Error:Field validation for 'Payments' failed on the 'required' tag", "error": "Validation failed"},
+			expectedJSON: map[string]interface{}{"type": "about:blank", "title": "Bad Request", "status": fiber.StatusBadRequest, "detail": "validation failed: Key: 'OrderCreateRequest.ProductID' Error:Field validation for 'ProductID' failed on the 'required_without' tag\nKey: 'OrderCreateRequest.Quantity' Error:Field validation for 'Quantity' failed on the 'required_without' tag\nKey: 'OrderCreateRequest.OrderDate' Error:Field validation for 'OrderDate' failed on the 'required' tag\nKey: 'OrderCreateRequest.Payments' Error:Field validation for 'Payments' failed on the 'required' tag", "instance": "/orders", "code": "validation_failed"},
+		},
+		{
+			name: "success - multiple lines",
+			body: transports.OrderCreateRequest{
+				Lines: []*transports.OrderLineRequest{
+					{ProductID: 1, Quantity: 2, UnitPrice: common.NewMoney(5)},
+					{ProductID: 2, Quantity: 1, UnitPrice: common.NewMoney(0.23)},
+				},
+				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+				Payments: []*transports.PaymentRequest{
+					{
+						PaymentMethod: common.CreditCard,
+						PaymentAmount: common.NewMoney(10.23),
+					},
+				},
+			},
+
+			user: models.User{ID: 1, Username: "John Doe"},
+			mockReturn: &models.Order{
+				ID:        43,
+				Quantity:  3,
+				Price:     common.NewMoney(10.23),
+				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+				Lines: []*models.OrderLine{
+					{ProductID: 1, Quantity: 2, UnitPrice: common.NewMoney(5), LineTotal: common.NewMoney(10)},
+					{ProductID: 2, Quantity: 1, UnitPrice: common.NewMoney(0.23), LineTotal: common.NewMoney(0.23)},
+				},
+			},
+			mockError:              nil,
+			setupOrdersServiceMock: setupValidStoreOrderMock,
+
+			expectedCode: fiber.StatusCreated,
+			expectedJSON: map[string]interface{}{
+				"has_weightables": false,
+				"amount_due":      "10.23",
+				"amount_paid":     "0.00",
+				"balance":         "10.23",
+				"id":              43,
+				"order_date":      "2025-01-30T10:30:00Z",
+				"price":           "10.23",
+				"quantity":        3,
+				"lines": []interface{}{
+					map[string]interface{}{"product_id": 1, "quantity": 2, "unit_price": "5.00", "line_total": "10.00"},
+					map[string]interface{}{"product_id": 2, "quantity": 1, "unit_price": "0.23", "line_total": "0.23"},
+				}},
+		},
+		{
+			name: "bad request - lines and single product",
+			body: transports.OrderCreateRequest{
+				ProductID: 1,
+				Lines:     []*transports.OrderLineRequest{{ProductID: 1, Quantity: 2, UnitPrice: common.NewMoney(5)}},
+				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+				Payments:  []*transports.PaymentRequest{{PaymentMethod: common.CreditCard, PaymentAmount: common.NewMoney(10)}},
+			},
+
+			user:       models.User{ID: 1, Username: "John Doe"},
+			mockReturn: nil,
+			mockError:  nil,
+			setupOrdersServiceMock: func(mockOrdersService *mocks.OrdersService, body transports.OrderCreateRequest, user models.User, mockReturn *models.Order, mockError error) {
+			},
+			expectedCode: fiber.StatusBadRequest,
+			expectedJSON: map[string]interface{}{"type": "about:blank", "title": "Bad Request", "status": fiber.StatusBadRequest, "detail": "validation failed: Key: 'OrderCreateRequest.ProductID' Error:Field validation for 'ProductID' failed on the 'excluded_with' tag", "instance": "/orders", "code": "validation_failed"},
+		},
+		{
+			name: "unknown product",
+			body: transports.OrderCreateRequest{
+				ProductID: 404,
+				Quantity:  2,
+				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+				Payments: []*transports.PaymentRequest{
+					{
+						PaymentMethod: common.CreditCard,
+						PaymentAmount: common.NewMoney(10.23),
+					},
+				},
+			},
+
+			user:                   models.User{ID: 1, Username: "John Doe"},
+			mockReturn:             nil,
+			setupOrdersServiceMock: setupValidStoreOrderMock,
+			mockError:              fmt.Errorf("%w: %d", services.ErrUnknownProduct, 404),
+			expectedCode:           fiber.StatusUnprocessableEntity,
+			expectedJSON:           map[string]interface{}{"type": "about:blank", "title": "Unprocessable Entity", "status": fiber.StatusUnprocessableEntity, "detail": "unknown product: 404", "instance": "/orders", "code": "unknown_product"},
+		},
+		{
+			name: "out of stock",
+			body: transports.OrderCreateRequest{
+				ProductID: 1,
+				Quantity:  20,
+				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+				Payments: []*transports.PaymentRequest{
+					{
+						PaymentMethod: common.CreditCard,
+						PaymentAmount: common.NewMoney(10.23),
+					},
+				},
+			},
+
+			user:                   models.User{ID: 1, Username: "John Doe"},
+			mockReturn:             nil,
+			setupOrdersServiceMock: setupValidStoreOrderMock,
+			mockError:              fmt.Errorf("%w: product 1 has 2 available, 20 requested", datasources.ErrInsufficientStock),
+			expectedCode:           fiber.StatusConflict,
+			expectedJSON:           map[string]interface{}{"type": "about:blank", "title": "Conflict", "status": fiber.StatusConflict, "detail": "insufficient stock: product 1 has 2 available, 20 requested", "instance": "/orders", "code": "insufficient_stock"},
 		},
 		{
 			name: "internal server error",
 			body: transports.OrderCreateRequest{
 				ProductID: 1,
 				Quantity:  2,
-				Price:     10.23,
+				Price:     common.NewMoney(10.23),
 				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
 				Payments: []*transports.PaymentRequest{
 					{
 						PaymentMethod: common.CreditCard,
-						PaymentAmount: 10.23,
+						PaymentAmount: common.NewMoney(10.23),
 					},
 				},
 			},
@@ -147,7 +280,7 @@ func TestCreateOrder(t *testing.T) {
 			setupOrdersServiceMock: setupValidStoreOrderMock,
 			mockError:              assert.AnError,
 			expectedCode:           fiber.StatusInternalServerError,
-			expectedJSON:           map[string]interface{}{"error": "Unable to create the order"},
+			expectedJSON:           map[string]interface{}{"type": "about:blank", "title": "Internal Server Error", "status": fiber.StatusInternalServerError, "instance": "/orders", "code": "internal_error"},
 		},
 	}
 
@@ -179,6 +312,213 @@ func TestCreateOrder(t *testing.T) {
 	}
 }
 
+func TestCreateOrder_Idempotency(t *testing.T) {
+	user := models.User{ID: 1, Username: "John Doe"}
+	orderRequest := `{"product_id":1,"quantity":2,"order_date":"2025-01-30T10:30:00Z","payments":[{"payment_amount":"10.23","payment_method":"CreditCard"}]}`
+	storedOrder := &models.Order{ID: 42, ProductID: 1, Quantity: 2, Price: common.NewMoney(10.23), OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC)}
+	orderResponse := `{"id":42,"product_id":1,"quantity":2,"price":"10.23","order_date":"2025-01-30T10:30:00Z","has_weightables":false,"amount_due":"10.23","amount_paid":"0.00","balance":"10.23"}`
+	unknownProduct := `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"unknown product: 1","instance":"/orders","code":"unknown_product"}`
+
+	type request struct {
+		key  string
+		body string
+	}
+	type response struct {
+		code     int
+		body     string
+		replayed string
+	}
+
+	tests := []struct {
+		name             string
+		requests         []request
+		setupServiceMock func(mockOrdersService *mocks.OrdersService)
+		expected         []response
+	}{
+		{
+			name: "retry replays the response",
+			requests: []request{
+				{key: "key-1", body: orderRequest},
+				// retries match regardless of the formatting of the body
+				{key: "key-1", body: strings.ReplaceAll(orderRequest, ",", ", ")},
+			},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService) {
+				mockOrdersService.On("StoreOrder", mock.Anything, user.ID, mock.Anything).Return(storedOrder, nil).Once()
+			},
+			expected: []response{
+				{code: fiber.StatusCreated, body: orderResponse},
+				{code: fiber.StatusCreated, body: orderResponse, replayed: "true"},
+			},
+		},
+		{
+			name: "failed placement is replayed",
+			requests: []request{
+				{key: "key-1", body: orderRequest},
+				{key: "key-1", body: orderRequest},
+			},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService) {
+				mockOrdersService.On("StoreOrder", mock.Anything, user.ID, mock.Anything).
+					Return(nil, fmt.Errorf("%w: 1", services.ErrUnknownProduct)).Once()
+			},
+			expected: []response{
+				{code: fiber.StatusUnprocessableEntity, body: unknownProduct},
+				{code: fiber.StatusUnprocessableEntity, body: unknownProduct, replayed: "true"},
+			},
+		},
+		{
+			name: "key of a different request is rejected",
+			requests: []request{
+				{key: "key-1", body: orderRequest},
+				{key: "key-1", body: strings.Replace(orderRequest, `"quantity":2`, `"quantity":3`, 1)},
+			},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService) {
+				mockOrdersService.On("StoreOrder", mock.Anything, user.ID, mock.Anything).Return(storedOrder, nil).Once()
+			},
+			expected: []response{
+				{code: fiber.StatusCreated, body: orderResponse},
+				{code: fiber.StatusUnprocessableEntity, body: `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"idempotency key was used for a different request: \"key-1\"","instance":"/orders","code":"idempotency_key_reused"}`},
+			},
+		},
+		{
+			name: "server error can be retried",
+			requests: []request{
+				{key: "key-1", body: orderRequest},
+				{key: "key-1", body: orderRequest},
+			},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService) {
+				mockOrdersService.On("StoreOrder", mock.Anything, user.ID, mock.Anything).Return(nil, assert.AnError).Once()
+				mockOrdersService.On("StoreOrder", mock.Anything, user.ID, mock.Anything).Return(storedOrder, nil).Once()
+			},
+			expected: []response{
+				{code: fiber.StatusInternalServerError, body: `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/orders","code":"internal_error"}`},
+				{code: fiber.StatusCreated, body: orderResponse},
+			},
+		},
+		{
+			name: "requests without key are not deduplicated",
+			requests: []request{
+				{body: orderRequest},
+				{body: orderRequest},
+			},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService) {
+				mockOrdersService.On("StoreOrder", mock.Anything, user.ID, mock.Anything).Return(storedOrder, nil).Twice()
+			},
+			expected: []response{
+				{code: fiber.StatusCreated, body: orderResponse},
+				{code: fiber.StatusCreated, body: orderResponse},
+			},
+		},
+		{
+			name: "key too long",
+			requests: []request{
+				{key: strings.Repeat("k", 256), body: orderRequest},
+			},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService) {},
+			expected: []response{
+				{code: fiber.StatusBadRequest, body: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid parameter Idempotency-Key: must not be longer than 255 characters","instance":"/orders","code":"invalid_parameter"}`},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrdersService := new(mocks.OrdersService)
+			tc.setupServiceMock(mockOrdersService)
+
+			controller := &OrdersController{
+				orderService:       mockOrdersService,
+				idempotencyService: services.NewIdempotencyService(services.IdempotencyConfig{TTL: time.Hour}, file.NewIdempotencyStorage()),
+			}
+			app := createTestOrdersControllerWith(controller, mocks.ProvideBaseMockContextData(&user))
+
+			for i, request := range tc.requests {
+				req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(request.body))
+				req.Header.Set("Content-Type", "application/json")
+				if request.key != "" {
+					req.Header.Set("Idempotency-Key", request.key)
+				}
+
+				resp, err := app.Test(req)
+				assert.Nil(t, err, "Handler should not return an error")
+
+				var buf bytes.Buffer
+				buf.ReadFrom(resp.Body)
+
+				assert.Equal(t, tc.expected[i].code, resp.StatusCode, "Unexpected status code of request %d", i+1)
+				assert.JSONEq(t, tc.expected[i].body, buf.String(), "Unexpected response JSON of request %d", i+1)
+				assert.Equal(t, tc.expected[i].replayed, resp.Header.Get("Idempotent-Replayed"), "Unexpected replay header of request %d", i+1)
+				contentType := "application/json"
+				if tc.expected[i].code >= fiber.StatusBadRequest {
+					contentType = transports.ProblemContentType
+				}
+				assert.Equal(t, contentType, resp.Header.Get("Content-Type"), "Unexpected content type of request %d", i+1)
+			}
+
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateOrder_ConcurrentIdempotentRequests(t *testing.T) {
+	user := models.User{ID: 1, Username: "John Doe"}
+	orderRequest := `{"product_id":1,"quantity":2,"order_date":"2025-01-30T10:30:00Z","payments":[{"payment_amount":"10.23","payment_method":"CreditCard"}]}`
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mockOrdersService := new(mocks.OrdersService)
+	mockOrdersService.On("StoreOrder", mock.Anything, user.ID, mock.Anything).
+		Run(func(args mock.Arguments) {
+			close(started)
+			<-release
+		}).
+		Return(&models.Order{ID: 42, ProductID: 1, Quantity: 2, Price: common.NewMoney(10.23)}, nil).Once()
+
+	controller := &OrdersController{
+		orderService:       mockOrdersService,
+		idempotencyService: services.NewIdempotencyService(services.IdempotencyConfig{TTL: time.Hour}, file.NewIdempotencyStorage()),
+	}
+	// every request gets its own context, the locals of the authenticated user are set by a middleware
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/orders", controller.CreateOrder, func(ctx fiber.Ctx) error {
+		for key, value := range *mocks.ProvideBaseMockContextData(&user) {
+			ctx.Locals(key, value)
+		}
+		return ctx.Next()
+	})
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(orderRequest))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "key-1")
+		resp, err := app.Test(req, fiber.TestConfig{Timeout: 0})
+		assert.Nil(t, err, "Handler should not return an error")
+		return resp.StatusCode
+	}
+
+	first := make(chan int)
+	go func() { first <- send() }()
+	<-started
+
+	var wg sync.WaitGroup
+	duplicates := make([]int, 10)
+	for i := range duplicates {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			duplicates[i] = send()
+		}(i)
+	}
+	wg.Wait()
+	close(release)
+
+	assert.Equal(t, fiber.StatusCreated, <-first, "expected the first request to place the order")
+	for i, code := range duplicates {
+		assert.Equal(t, fiber.StatusConflict, code, "expected duplicate %d to be rejected while the order is placed", i+1)
+	}
+	assert.Equal(t, fiber.StatusCreated, send(), "expected a later retry to be replayed")
+	mockOrdersService.AssertExpectations(t)
+}
+
 func TestGetOrders(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -197,19 +537,19 @@ func TestGetOrders(t *testing.T) {
 				{
 					ID:        1,
 					ProductID: 1,
-					Price:     19.99,
+					Price:     common.NewMoney(19.99),
 					OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
 				},
 				{
 					ID:        2,
 					ProductID: 2,
-					Price:     29.99,
+					Price:     common.NewMoney(29.99),
 					OrderDate: time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC),
 				},
 			},
 			mockError: nil,
 			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, queryParams string, mockReturn []*models.Order, mockError error) {
-				mockOrdersService.On("GetOrders", mock.Anything, user.ID).Return(mockReturn, mockError)
+				mockOrdersService.On("GetOrdersPage", mock.Anything, user.ID, nil, datasources.OrdersQuery{}).Return(ordersPage(mockReturn, mockError), mockError)
 			},
 			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
 				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
@@ -217,16 +557,22 @@ func TestGetOrders(t *testing.T) {
 				expectedResponseBody, _ := json.Marshal([]interface{}{
 					map[string]interface{}{
 						"has_weightables": false,
+						"amount_due":      "19.99",
+						"amount_paid":     "0.00",
+						"balance":         "19.99",
 						"id":              1,
 						"order_date":      "2025-01-30T10:30:00Z",
-						"price":           19.99,
+						"price":           "19.99",
 						"product_id":      1,
 					},
 					map[string]interface{}{
 						"has_weightables": false,
+						"amount_due":      "29.99",
+						"amount_paid":     "0.00",
+						"balance":         "29.99",
 						"id":              2,
 						"order_date":      "2025-02-10T12:00:00Z",
-						"price":           29.99,
+						"price":           "29.99",
 						"product_id":      2},
 				})
 				assert.JSONEq(t, string(expectedResponseBody), responseBody, "Unexpected response JSON")
@@ -240,31 +586,31 @@ func TestGetOrders(t *testing.T) {
 				{
 					ID:        2,
 					ProductID: 2,
-					Price:     29.99,
+					Price:     common.NewMoney(29.99),
 					OrderDate: time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC),
 				},
 				{
 					ID:        3,
 					ProductID: 3,
-					Price:     19.99,
+					Price:     common.NewMoney(19.99),
 					OrderDate: time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC),
 				},
 			},
 			mockError: nil,
 			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, queryParams string, mockReturn []*models.Order, mockError error) {
-				call := mockOrdersService.On("GetOrdersWithFilter", mock.Anything, user.ID, mock.AnythingOfType("func(*models.Order) bool"))
+				call := mockOrdersService.On("GetOrdersPage", mock.Anything, user.ID, mock.AnythingOfType("*filters.condition"), datasources.OrdersQuery{})
 				call.Run(func(args mock.Arguments) {
-					filter := args.Get(2).(func(order *models.Order) bool)
-					assert.True(t, filter(mockReturn[0]), "Filter should match order with price 29.99")
-					assert.False(t, filter(mockReturn[1]), "Filter should not match order with price 19.99")
+					predicate := args.Get(2).(filters.Predicate)
+					assert.True(t, predicate.Matches(mockReturn[0]), "Filter should match order with price 29.99")
+					assert.False(t, predicate.Matches(mockReturn[1]), "Filter should not match order with price 19.99")
 
 					filteredOrders := make([]*models.Order, 0)
 					for _, order := range mockReturn {
-						if filter(order) {
+						if predicate.Matches(order) {
 							filteredOrders = append(filteredOrders, order)
 						}
 					}
-					call.Return(filteredOrders, mockError)
+					call.Return(ordersPage(filteredOrders, mockError), mockError)
 				})
 			},
 			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
@@ -273,9 +619,12 @@ func TestGetOrders(t *testing.T) {
 				expectedResponseBody, _ := json.Marshal([]interface{}{
 					map[string]interface{}{
 						"has_weightables": false,
+						"amount_due":      "29.99",
+						"amount_paid":     "0.00",
+						"balance":         "29.99",
 						"id":              2,
 						"order_date":      "2025-02-10T12:00:00Z",
-						"price":           29.99,
+						"price":           "29.99",
 						"product_id":      2},
 				})
 				assert.JSONEq(t, string(expectedResponseBody), responseBody, "Unexpected response JSON")
@@ -292,7 +641,89 @@ func TestGetOrders(t *testing.T) {
 			},
 			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
 				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
-				assert.Contains(t, "Invalid price value", responseBody, "Unexpected response JSON")
+				assertProblem(t, responseBody, "invalid_price", "invalid price value")
+			},
+		},
+		{
+			name:        "success - filter expression",
+			queryParams: "?filter=" + url.QueryEscape("status = Paid AND price BETWEEN 20 AND 30"),
+			user:        models.User{ID: 1, Username: "Jane Doe"},
+			mockReturn: []*models.Order{
+				{
+					ID:        2,
+					ProductID: 2,
+					Price:     common.NewMoney(29.99),
+					OrderDate: time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC),
+					Status:    common.Paid,
+				},
+			},
+			mockError: nil,
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, queryParams string, mockReturn []*models.Order, mockError error) {
+				mockOrdersService.On("GetOrdersPage", mock.Anything, user.ID, mock.MatchedBy(func(predicate filters.Predicate) bool {
+					return predicate.String() == "(status = Paid AND (price >= 20 AND price <= 30))"
+				}), datasources.OrdersQuery{}).Return(ordersPage(mockReturn, mockError), mockError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+
+				expectedResponseBody, _ := json.Marshal([]interface{}{
+					map[string]interface{}{
+						"has_weightables": false,
+						"amount_due":      "29.99",
+						"amount_paid":     "0.00",
+						"balance":         "29.99",
+						"id":              2,
+						"order_date":      "2025-02-10T12:00:00Z",
+						"price":           "29.99",
+						"product_id":      2,
+						"status":          "Paid"},
+				})
+				assert.JSONEq(t, string(expectedResponseBody), responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:        "success - filter expression combined with price",
+			queryParams: "?price=20&filter=" + url.QueryEscape("payment_method = PayPal"),
+			user:        models.User{ID: 1, Username: "Jane Doe"},
+			mockReturn:  []*models.Order{},
+			mockError:   nil,
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, queryParams string, mockReturn []*models.Order, mockError error) {
+				mockOrdersService.On("GetOrdersPage", mock.Anything, user.ID, mock.MatchedBy(func(predicate filters.Predicate) bool {
+					return predicate.String() == "(price > 20 AND payment_method = PayPal)" && predicate.RequiresPayments()
+				}), datasources.OrdersQuery{}).Return(ordersPage(mockReturn, mockError), mockError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, "[]", responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:        "failure - invalid filter expression",
+			queryParams: "?filter=" + url.QueryEscape("price > 10 AND colour = red"),
+			user:        models.User{ID: 1, Username: "Jane Doe"},
+			mockReturn:  nil,
+			mockError:   nil,
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, queryParams string, mockReturn []*models.Order, mockError error) {
+				// No service method is called for an invalid filter expression
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_filter", `invalid filter expression: unknown field at position 16 near "colour"`)
+				assert.Contains(t, responseBody, `"position":16,"token":"colour"`, "Expected the position and token of the filter error")
+			},
+		},
+		{
+			name:        "failure - error filtering orders by expression",
+			queryParams: "?filter=" + url.QueryEscape("quantity > 1"),
+			user:        models.User{ID: 1, Username: "Jane Doe"},
+			mockReturn:  nil,
+			mockError:   assert.AnError,
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, queryParams string, mockReturn []*models.Order, mockError error) {
+				mockOrdersService.On("GetOrdersPage", mock.Anything, user.ID, mock.Anything, datasources.OrdersQuery{}).Return(ordersPage(mockReturn, mockError), mockError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "internal_error", "")
 			},
 		},
 		{
@@ -302,11 +733,11 @@ func TestGetOrders(t *testing.T) {
 			mockReturn:  nil,
 			mockError:   assert.AnError,
 			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, queryParams string, mockReturn []*models.Order, mockError error) {
-				mockOrdersService.On("GetOrders", mock.Anything, user.ID).Return(mockReturn, mockError)
+				mockOrdersService.On("GetOrdersPage", mock.Anything, user.ID, nil, datasources.OrdersQuery{}).Return(ordersPage(mockReturn, mockError), mockError)
 			},
 			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
 				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
-				assert.Contains(t, "Error loading orders", responseBody, "Unexpected response JSON")
+				assertProblem(t, responseBody, "internal_error", "")
 			},
 		},
 	}
@@ -336,84 +767,103 @@ func TestGetOrders(t *testing.T) {
 	}
 }
 
-func TestGetOrder(t *testing.T) {
+// ordersPage wraps the orders returned by a mocked listing into a single page.
+func ordersPage(orders []*models.Order, err error) *models.OrdersPage {
+	if err != nil {
+		return nil
+	}
+	return &models.OrdersPage{Orders: orders, Total: len(orders)}
+}
+
+func TestGetOrders_Paging(t *testing.T) {
 	tests := []struct {
-		name             string
-		orderID          string
-		user             models.User
-		mockReturn       *models.Order
-		mockError        error
-		setupServiceMock func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockReturn *models.Order, mockError error)
-		assertFunc       func(t *testing.T, responseBody string, responseCode int)
+		name           string
+		queryParams    string
+		setServiceMock func(mockOrdersService *mocks.OrdersService, user models.User)
+		assertFunc     func(t *testing.T, resp *http.Response, responseBody string)
 	}{
 		{
-			name:    "success - valid order ID",
-			orderID: "1",
-			user:    models.User{ID: 1, Username: "Jane Doe"},
-			mockReturn: &models.Order{
-				ID:        1,
-				ProductID: 101,
-				Price:     20.5,
-				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+			name:        "success - first page",
+			queryParams: "?limit=1&sort=-order_date,price",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetOrdersPage", mock.Anything, user.ID, nil, datasources.OrdersQuery{
+					Sort: []datasources.OrderSort{
+						{Field: datasources.SortByOrderDate, Descending: true},
+						{Field: datasources.SortByPrice},
+					},
+					Limit: 1,
+				}).Return(&models.OrdersPage{
+					Orders:     []*models.Order{{ID: 2, Price: common.NewMoney(29.99), OrderDate: time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)}},
+					Total:      2,
+					NextCursor: "next",
+				}, nil)
 			},
-			mockError: nil,
-			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockReturn *models.Order, mockError error) {
-				mockOrdersService.On("GetOrder", mock.Anything, user.ID, orderID).Return(mockReturn, mockError)
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusOK, resp.StatusCode, "Unexpected status code")
+				assert.Equal(t, "2", resp.Header.Get("X-Total-Count"), "Unexpected total count")
+				assert.Equal(t, "next", resp.Header.Get("X-Next-Cursor"), "Unexpected next cursor")
+				assert.JSONEq(t, `[{"id":2,"price":"29.99","order_date":"2025-02-10T12:00:00Z","has_weightables":false,"amount_due":"29.99","amount_paid":"0.00","balance":"29.99"}]`, responseBody, "Unexpected response JSON")
 			},
-			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
-				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
-
-				expectedResponseBody, _ := json.Marshal(map[string]interface{}{
-					"id":              1,
-					"product_id":      101,
-					"price":           20.5,
-					"order_date":      "2025-01-30T10:30:00Z",
-					"has_weightables": false,
-				})
-				assert.JSONEq(t, string(expectedResponseBody), responseBody, "Unexpected response JSON")
+		},
+		{
+			name:        "success - last page",
+			queryParams: "?limit=1&cursor=next",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetOrdersPage", mock.Anything, user.ID, nil, datasources.OrdersQuery{Limit: 1, Cursor: "next"}).Return(&models.OrdersPage{
+					Orders: []*models.Order{{ID: 1, Price: common.NewMoney(19.99), OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC)}},
+					Total:  2,
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusOK, resp.StatusCode, "Unexpected status code")
+				assert.Equal(t, "2", resp.Header.Get("X-Total-Count"), "Unexpected total count")
+				assert.Empty(t, resp.Header.Get("X-Next-Cursor"), "Expected no next cursor on the last page")
 			},
 		},
 		{
-			name:       "failure - invalid order ID (non-numeric)",
-			orderID:    "abc",
-			user:       models.User{ID: 1, Username: "Jane Doe"},
-			mockReturn: nil,
-			mockError:  nil,
-			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockReturn *models.Order, mockError error) {
+			name:        "failure - invalid limit",
+			queryParams: "?limit=0",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called for an invalid limit
 			},
-			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
-				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
-				assert.Contains(t, "strconv.Atoi: parsing \"abc\": invalid syntax", responseBody, "Unexpected response JSON")
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_parameter", `invalid parameter limit: must be a number between 1 and 100`)
 			},
 		},
 		{
-			name:       "failure - order not found",
-			orderID:    "999",
-			user:       models.User{ID: 1, Username: "John Doe"},
-			mockReturn: nil,
-			mockError:  assert.AnError,
-			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockReturn *models.Order, mockError error) {
-				mockOrdersService.On("GetOrder", mock.Anything, user.ID, orderID).Return(mockReturn, mockError)
+			name:        "failure - invalid sort",
+			queryParams: "?sort=colour",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called for an invalid sort
 			},
-			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
-				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
-				assert.Contains(t, "assert.AnError general error for testing", responseBody, "Unexpected response JSON")
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_sort", `invalid sort: unknown field "colour"`)
+			},
+		},
+		{
+			name:        "failure - invalid cursor",
+			queryParams: "?cursor=garbage",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetOrdersPage", mock.Anything, user.ID, nil, datasources.OrdersQuery{Cursor: "garbage"}).Return(nil, datasources.ErrInvalidCursor)
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_cursor", `invalid cursor`)
 			},
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			user := models.User{ID: 1, Username: "Jane Doe"}
 			mockOrdersService := new(mocks.OrdersService)
+			tc.setServiceMock(mockOrdersService, user)
 
-			orderID, _ := strconv.Atoi(tc.orderID) // Handle invalid order ID
-			tc.setupServiceMock(mockOrdersService, tc.user, orderID, tc.mockReturn, tc.mockError)
-
-			mockContextData := mocks.ProvideBaseMockContextData(&tc.user)
-
+			mockContextData := mocks.ProvideBaseMockContextData(&user)
 			app := createTestOrdersController(mockOrdersService, mockContextData)
-			req := httptest.NewRequest(http.MethodGet, "/orders/"+tc.orderID, nil)
-			req.Header.Set("Content-Type", "application/json")
+			req := httptest.NewRequest(http.MethodGet, "/orders"+tc.queryParams, nil)
 
 			resp, err := app.Test(req)
 
@@ -421,11 +871,1639 @@ func TestGetOrder(t *testing.T) {
 
 			var buf bytes.Buffer
 			buf.ReadFrom(resp.Body)
-			responseBody := buf.String()
 
-			tc.assertFunc(t, responseBody, resp.StatusCode)
+			tc.assertFunc(t, resp, buf.String())
 
 			mockOrdersService.AssertExpectations(t)
 		})
 	}
 }
+
+func TestExportOrders(t *testing.T) {
+	orderDate := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+	// exporting mocks the service passing the orders to the export function.
+	exporting := func(call *mock.Call, orders ...*models.Order) {
+		call.Run(func(args mock.Arguments) {
+			export := args.Get(4).(func(order *models.Order) error)
+			for _, order := range orders {
+				if err := export(order); err != nil {
+					call.Return(err)
+					return
+				}
+			}
+			call.Return(nil)
+		})
+	}
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		setServiceMock func(mockOrdersService *mocks.OrdersService, user models.User)
+		assertFunc     func(t *testing.T, resp *http.Response, responseBody string)
+	}{
+		{
+			name:        "success - csv with selected columns",
+			queryParams: "?columns=id,price,payment_method&sort=-order_date",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				call := mockOrdersService.On("ExportOrders", mock.Anything, user.ID, nil,
+					[]datasources.OrderSort{{Field: datasources.SortByOrderDate, Descending: true}}, mock.Anything)
+				exporting(call,
+					&models.Order{ID: 2, Price: common.NewMoney(29.99), OrderDate: orderDate, Payments: []*models.Payment{{Id: 1, Amount: common.NewMoney(29.99), Method: common.PayPal}}},
+					&models.Order{ID: 1, Price: common.NewMoney(19.99), OrderDate: orderDate},
+				)
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusOK, resp.StatusCode, "Unexpected status code")
+				assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get(fiber.HeaderContentType), "Unexpected content type")
+				assert.Equal(t, `attachment; filename="orders.csv"`, resp.Header.Get(fiber.HeaderContentDisposition), "Unexpected content disposition")
+				assert.Equal(t, "id,price,payment_method\n2,29.99,PayPal\n1,19.99,\n", responseBody, "Unexpected export")
+			},
+		},
+		{
+			name:        "success - ndjson with price filter",
+			queryParams: "?format=ndjson&columns=id&price=10",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				call := mockOrdersService.On("ExportOrders", mock.Anything, user.ID, mock.AnythingOfType("*filters.condition"),
+					[]datasources.OrderSort(nil), mock.Anything)
+				exporting(call, &models.Order{ID: 2, Price: common.NewMoney(29.99)})
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusOK, resp.StatusCode, "Unexpected status code")
+				assert.Equal(t, "application/x-ndjson", resp.Header.Get(fiber.HeaderContentType), "Unexpected content type")
+				assert.Equal(t, `{"id":2}`+"\n", responseBody, "Unexpected export")
+			},
+		},
+		{
+			name:        "failure - invalid format",
+			queryParams: "?format=xml",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called for an invalid format
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_export", `invalid export: unknown format "xml"`)
+			},
+		},
+		{
+			name:        "failure - invalid column",
+			queryParams: "?columns=id,colour",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called for an invalid column
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_export", `invalid export: unknown column "colour"`)
+			},
+		},
+		{
+			name:        "failure - invalid price",
+			queryParams: "?price=cheap",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called for an invalid price
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_price", "invalid price value")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			user := models.User{ID: 1, Username: "Jane Doe"}
+			mockOrdersService := new(mocks.OrdersService)
+			tc.setServiceMock(mockOrdersService, user)
+
+			mockContextData := mocks.ProvideBaseMockContextData(&user)
+			app := createTestOrdersController(mockOrdersService, mockContextData)
+			req := httptest.NewRequest(http.MethodGet, "/orders/export"+tc.queryParams, nil)
+
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+
+			tc.assertFunc(t, resp, buf.String())
+
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetOrdersSummary(t *testing.T) {
+	tests := []struct {
+		name           string
+		queryParams    string
+		setServiceMock func(mockOrdersService *mocks.OrdersService, user models.User)
+		assertFunc     func(t *testing.T, resp *http.Response, responseBody string)
+	}{
+		{
+			name:        "success - by week with price filter",
+			queryParams: "?period=week&price=10",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("SummarizeOrders", mock.Anything, user.ID, mock.AnythingOfType("*filters.condition"), models.SummaryByWeek).Return(&models.OrderSummary{
+					Period:         models.SummaryByWeek,
+					Currency:       common.EUR,
+					OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(45), OrderCount: 2},
+					ByPeriod: []*models.PeriodAggregate{
+						{Period: "2025-W07", OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(45), OrderCount: 2}},
+					},
+					ByPaymentMethod: []*models.PaymentMethodAggregate{
+						{Method: common.PayPal, OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(20), OrderCount: 1}},
+					},
+					ByProduct: []*models.ProductAggregate{
+						{ProductID: 1, OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(45), OrderCount: 2}},
+					},
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusOK, resp.StatusCode, "Unexpected status code")
+				assert.JSONEq(t, `{
+					"period":"week","currency":"EUR","total_spend":"45.00","order_count":2,"average_basket":"22.50",
+					"by_period":[{"period":"2025-W07","total_spend":"45.00","order_count":2,"average_basket":"22.50"}],
+					"by_payment_method":[{"payment_method":"PayPal","total_spend":"20.00","order_count":1,"average_basket":"20.00"}],
+					"by_product":[{"product_id":1,"total_spend":"45.00","order_count":2,"average_basket":"22.50"}]
+				}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:        "success - no orders",
+			queryParams: "",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("SummarizeOrders", mock.Anything, user.ID, nil, models.SummaryByMonth).Return(&models.OrderSummary{Period: models.SummaryByMonth, Currency: common.EUR}, nil)
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusOK, resp.StatusCode, "Unexpected status code")
+				assert.JSONEq(t, `{"period":"month","currency":"EUR","total_spend":"0.00","order_count":0,"average_basket":"0.00","by_period":[],"by_payment_method":[],"by_product":[]}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:        "failure - invalid period",
+			queryParams: "?period=year",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called for an invalid period
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_period", `invalid period: unknown period "year"`)
+			},
+		},
+		{
+			name:        "failure - invalid filter",
+			queryParams: "?filter=" + url.QueryEscape("colour = red"),
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called for an invalid filter
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+				assert.Contains(t, responseBody, `"code":"invalid_filter"`, "Unexpected response JSON")
+			},
+		},
+		{
+			name:        "failure - service error",
+			queryParams: "?period=day",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("SummarizeOrders", mock.Anything, user.ID, nil, models.SummaryByDay).Return(nil, errors.New("storage error"))
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "internal_error", "")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			user := models.User{ID: 1, Username: "Jane Doe"}
+			mockOrdersService := new(mocks.OrdersService)
+			tc.setServiceMock(mockOrdersService, user)
+
+			mockContextData := mocks.ProvideBaseMockContextData(&user)
+			app := createTestOrdersController(mockOrdersService, mockContextData)
+			req := httptest.NewRequest(http.MethodGet, "/orders/summary"+tc.queryParams, nil)
+
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+
+			tc.assertFunc(t, resp, buf.String())
+
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestImportOrders(t *testing.T) {
+	tests := []struct {
+		name           string
+		queryParams    string
+		contentType    string
+		body           string
+		setServiceMock func(mockOrdersService *mocks.OrdersService, user models.User)
+		assertFunc     func(t *testing.T, resp *http.Response, responseBody string)
+	}{
+		{
+			name:        "success - all or nothing dry run",
+			queryParams: "?dry_run=true&mode=all-or-nothing",
+			contentType: "application/x-ndjson",
+			body: `{"product_id":1,"quantity":2,"order_date":"2025-02-10T12:00:00Z","payments":[{"payment_amount":5,"payment_method":"PayPal"}]}` + "\n" +
+				`{"product_id":1}` + "\n",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("ImportOrders", mock.Anything, user.ID, mock.MatchedBy(func(rows []*models.OrderImportRow) bool {
+					return len(rows) == 2 && rows[0].Order != nil && rows[0].Order.Quantity == 2 && rows[1].Err != nil
+				}), models.OrderImportOptions{DryRun: true, AllOrNothing: true}).Return([]*models.OrderImportResult{
+					{Row: 1, Status: models.ImportSkipped, Reason: "dry run"},
+					{Row: 2, Status: models.ImportFailed, Reason: "validation failed"},
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusOK, resp.StatusCode, "Unexpected status code")
+				assert.JSONEq(t, `{"dry_run":true,"all_or_nothing":true,"created":0,"skipped":1,"failed":1,"rows":[
+					{"row":1,"status":"skipped","reason":"dry run"},
+					{"row":2,"status":"failed","reason":"validation failed"}
+				]}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:        "success - csv",
+			contentType: "text/csv; charset=utf-8",
+			body:        "order_date,product_id,quantity,payment_amount,payment_method\n2025-02-10T12:00:00Z,1,1,1,PayPal\n",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("ImportOrders", mock.Anything, user.ID, mock.MatchedBy(func(rows []*models.OrderImportRow) bool {
+					return len(rows) == 1 && rows[0].Row == 2 && rows[0].Err == nil
+				}), models.OrderImportOptions{}).Return([]*models.OrderImportResult{
+					{Row: 2, Status: models.ImportCreated, OrderID: 7},
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusOK, resp.StatusCode, "Unexpected status code")
+				assert.JSONEq(t, `{"dry_run":false,"all_or_nothing":false,"created":1,"skipped":0,"failed":0,"rows":[{"row":2,"status":"created","order_id":7}]}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:        "failure - unsupported content type",
+			contentType: "application/json",
+			body:        "{}",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called for an unsupported upload
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusUnsupportedMediaType, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "unsupported_media_type", `Content-Type must be text/csv or application/x-ndjson`)
+			},
+		},
+		{
+			name:        "failure - invalid mode",
+			queryParams: "?mode=some",
+			contentType: "text/csv",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called for an invalid mode
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_parameter", `invalid parameter mode: must be row-by-row or all-or-nothing`)
+			},
+		},
+		{
+			name:        "failure - invalid dry run",
+			queryParams: "?dry_run=maybe",
+			contentType: "text/csv",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called for an invalid dry run flag
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_parameter", `invalid parameter dry_run: must be true or false`)
+			},
+		},
+		{
+			name:        "failure - csv without header",
+			contentType: "text/csv",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called for an unreadable upload
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_export", `invalid export: missing header row`)
+			},
+		},
+		{
+			name:        "failure - rollback error",
+			queryParams: "?mode=all-or-nothing",
+			contentType: "application/x-ndjson",
+			body:        `{"product_id":1}`,
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("ImportOrders", mock.Anything, user.ID, mock.Anything, models.OrderImportOptions{AllOrNothing: true}).
+					Return(nil, errors.New("rolling back the import: delete failed"))
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "internal_error", "")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			user := models.User{ID: 1, Username: "Jane Doe"}
+			mockOrdersService := new(mocks.OrdersService)
+			tc.setServiceMock(mockOrdersService, user)
+
+			mockContextData := mocks.ProvideBaseMockContextData(&user)
+			app := createTestOrdersController(mockOrdersService, mockContextData)
+			req := httptest.NewRequest(http.MethodPost, "/orders/import"+tc.queryParams, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+
+			tc.assertFunc(t, resp, buf.String())
+
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	tests := []struct {
+		name             string
+		orderID          string
+		user             models.User
+		mockReturn       *models.Order
+		mockError        error
+		setupServiceMock func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockReturn *models.Order, mockError error)
+		assertFunc       func(t *testing.T, responseBody string, responseCode int)
+	}{
+		{
+			name:    "success - valid order ID",
+			orderID: "1",
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			mockReturn: &models.Order{
+				ID:        1,
+				ProductID: 101,
+				Price:     common.NewMoney(20.5),
+				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+			},
+			mockError: nil,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockReturn *models.Order, mockError error) {
+				mockOrdersService.On("GetOrder", mock.Anything, user.ID, orderID).Return(mockReturn, mockError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+
+				expectedResponseBody, _ := json.Marshal(map[string]interface{}{
+					"id":              1,
+					"product_id":      101,
+					"price":           "20.50",
+					"order_date":      "2025-01-30T10:30:00Z",
+					"has_weightables": false,
+					"amount_due":      "20.50",
+					"amount_paid":     "0.00",
+					"balance":         "20.50",
+				})
+				assert.JSONEq(t, string(expectedResponseBody), responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:       "failure - invalid order ID (non-numeric)",
+			orderID:    "abc",
+			user:       models.User{ID: 1, Username: "Jane Doe"},
+			mockReturn: nil,
+			mockError:  nil,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockReturn *models.Order, mockError error) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_parameter", `invalid parameter id: strconv.Atoi: parsing "abc": invalid syntax`)
+			},
+		},
+		{
+			name:       "failure - order not found",
+			orderID:    "999",
+			user:       models.User{ID: 1, Username: "John Doe"},
+			mockReturn: nil,
+			mockError:  datasources.ErrOrderNotFound,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockReturn *models.Order, mockError error) {
+				mockOrdersService.On("GetOrder", mock.Anything, user.ID, orderID).Return(mockReturn, mockError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusNotFound, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "order_not_found", "order not found")
+			},
+		},
+		{
+			name:       "failure - order of another user",
+			orderID:    "7",
+			user:       models.User{ID: 1, Username: "John Doe"},
+			mockReturn: nil,
+			mockError:  services.ErrNotAuthorized,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockReturn *models.Order, mockError error) {
+				mockOrdersService.On("GetOrder", mock.Anything, user.ID, orderID).Return(mockReturn, mockError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusForbidden, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "not_authorized", "user is not authorized to access this order")
+			},
+		},
+		{
+			name:       "failure - service error",
+			orderID:    "999",
+			user:       models.User{ID: 1, Username: "John Doe"},
+			mockReturn: nil,
+			mockError:  assert.AnError,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockReturn *models.Order, mockError error) {
+				mockOrdersService.On("GetOrder", mock.Anything, user.ID, orderID).Return(mockReturn, mockError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "internal_error", "")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrdersService := new(mocks.OrdersService)
+
+			orderID, _ := strconv.Atoi(tc.orderID) // Handle invalid order ID
+			tc.setupServiceMock(mockOrdersService, tc.user, orderID, tc.mockReturn, tc.mockError)
+
+			mockContextData := mocks.ProvideBaseMockContextData(&tc.user)
+
+			app := createTestOrdersController(mockOrdersService, mockContextData)
+			req := httptest.NewRequest(http.MethodGet, "/orders/"+tc.orderID, nil)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+			responseBody := buf.String()
+
+			tc.assertFunc(t, responseBody, resp.StatusCode)
+
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrderVersions(t *testing.T) {
+	changedAt := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+	orderDate := time.Date(2025, 2, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		url            string
+		setServiceMock func(mockOrdersService *mocks.OrdersService, user models.User)
+		assertFunc     func(t *testing.T, resp *http.Response, responseBody string)
+	}{
+		{
+			name: "success - history",
+			url:  "/orders/5/history",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetOrderHistory", mock.Anything, user.ID, 5).Return([]*models.OrderVersion{
+					{Version: 1, ChangedAt: changedAt, ChangedBy: 1},
+					{Version: 2, ChangedAt: changedAt, ChangedBy: 1, Changes: []models.FieldChange{
+						{Field: "status", From: common.Pending, To: common.Paid},
+					}},
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusOK, resp.StatusCode, "Unexpected status code")
+				assert.JSONEq(t, `[
+					{"version":1,"changed_at":"2025-02-10T12:00:00Z","changed_by":1,"changes":[]},
+					{"version":2,"changed_at":"2025-02-10T12:00:00Z","changed_by":1,"changes":[{"field":"status","from":"Pending","to":"Paid"}]}
+				]`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name: "success - version",
+			url:  "/orders/5/versions/2",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetOrderVersion", mock.Anything, user.ID, 5, 2).Return(&models.OrderVersion{
+					Version:   2,
+					ChangedAt: changedAt,
+					ChangedBy: 1,
+					Order: &models.Order{
+						ID:        5,
+						User:      &models.User{ID: 1},
+						Status:    common.Paid,
+						OrderDate: orderDate,
+						ProductID: 1,
+						Quantity:  2,
+						Price:     common.NewMoney(20),
+						Lines:     []*models.OrderLine{models.NewOrderLine(1, 2, common.NewMoney(10))},
+						Payments:  []*models.Payment{{Id: 3}},
+					},
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusOK, resp.StatusCode, "Unexpected status code")
+				assert.JSONEq(t, `{"version":2,"changed_at":"2025-02-10T12:00:00Z","changed_by":1,"changes":[],"order":{
+					"id":5,"user_id":1,"status":"Paid","order_date":"2025-02-01T09:00:00Z","product_id":1,"quantity":2,"price":"20.00",
+					"has_weightables":false,"lines":[{"product_id":1,"quantity":2,"unit_price":"10.00","line_total":"20.00"}],"payment_ids":[3]
+				}}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name: "success - diff",
+			url:  "/orders/5/diff?from=1&to=2",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("DiffOrderVersions", mock.Anything, user.ID, 5, 1, 2).Return([]models.FieldChange{
+					{Field: "lines", From: []models.OrderLine{}, To: []models.OrderLine{*models.NewOrderLine(1, 1, common.NewMoney(5))}},
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusOK, resp.StatusCode, "Unexpected status code")
+				assert.JSONEq(t, `{"from":1,"to":2,"changes":[
+					{"field":"lines","from":[],"to":[{"product_id":1,"quantity":1,"unit_price":"5.00","line_total":"5.00"}]}
+				]}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name: "failure - unknown version",
+			url:  "/orders/5/versions/9",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetOrderVersion", mock.Anything, user.ID, 5, 9).Return(nil, fmt.Errorf("%w: 9", services.ErrOrderVersionNotFound))
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusNotFound, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "order_version_not_found", `order version not found: 9`)
+			},
+		},
+		{
+			name: "failure - invalid version",
+			url:  "/orders/5/versions/latest",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called for an invalid version
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+			},
+		},
+		{
+			name: "failure - diff without versions",
+			url:  "/orders/5/diff?from=1",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				// No service method is called without both versions
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_parameter", `invalid parameter from and to: must be version numbers`)
+			},
+		},
+		{
+			name: "failure - history of another user's order",
+			url:  "/orders/5/history",
+			setServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetOrderHistory", mock.Anything, user.ID, 5).Return(nil, services.ErrNotAuthorized)
+			},
+			assertFunc: func(t *testing.T, resp *http.Response, responseBody string) {
+				assert.Equal(t, fiber.StatusForbidden, resp.StatusCode, "Unexpected status code")
+				assertProblem(t, responseBody, "not_authorized", "user is not authorized to access this order")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			user := models.User{ID: 1, Username: "Jane Doe"}
+			mockOrdersService := new(mocks.OrdersService)
+			tc.setServiceMock(mockOrdersService, user)
+
+			mockContextData := mocks.ProvideBaseMockContextData(&user)
+			app := createTestOrdersController(mockOrdersService, mockContextData)
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+
+			tc.assertFunc(t, resp, buf.String())
+
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteOrder(t *testing.T) {
+	tests := []struct {
+		name             string
+		orderID          string
+		user             models.User
+		mockError        error
+		setupServiceMock func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockError error)
+		assertFunc       func(t *testing.T, responseBody string, responseCode int)
+	}{
+		{
+			name:      "success - order cancelled",
+			orderID:   "1",
+			user:      models.User{ID: 1, Username: "Jane Doe"},
+			mockError: nil,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockError error) {
+				mockOrdersService.On("CancelOrder", mock.Anything, user.ID, orderID).Return(mockError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusNoContent, responseCode, "Unexpected status code")
+				assert.Empty(t, responseBody, "Expected an empty response body")
+			},
+		},
+		{
+			name:      "failure - invalid order ID (non-numeric)",
+			orderID:   "abc",
+			user:      models.User{ID: 1, Username: "Jane Doe"},
+			mockError: nil,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockError error) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_parameter", `invalid parameter id: strconv.Atoi: parsing "abc": invalid syntax`)
+			},
+		},
+		{
+			name:      "failure - delivered order cannot be cancelled",
+			orderID:   "1",
+			user:      models.User{ID: 1, Username: "Jane Doe"},
+			mockError: fmt.Errorf("%w: Delivered", services.ErrOrderNotCancellable),
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockError error) {
+				mockOrdersService.On("CancelOrder", mock.Anything, user.ID, orderID).Return(mockError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusConflict, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "order_not_cancellable", "order can no longer be cancelled: Delivered")
+			},
+		},
+		{
+			name:      "failure - service error",
+			orderID:   "999",
+			user:      models.User{ID: 1, Username: "John Doe"},
+			mockError: assert.AnError,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int, mockError error) {
+				mockOrdersService.On("CancelOrder", mock.Anything, user.ID, orderID).Return(mockError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "internal_error", "")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrdersService := new(mocks.OrdersService)
+
+			orderID, _ := strconv.Atoi(tc.orderID)
+			tc.setupServiceMock(mockOrdersService, tc.user, orderID, tc.mockError)
+
+			mockContextData := mocks.ProvideBaseMockContextData(&tc.user)
+
+			app := createTestOrdersController(mockOrdersService, mockContextData)
+			req := httptest.NewRequest(http.MethodDelete, "/orders/"+tc.orderID, nil)
+
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+			responseBody := buf.String()
+
+			tc.assertFunc(t, responseBody, resp.StatusCode)
+
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTransitionOrder(t *testing.T) {
+	tests := []struct {
+		name             string
+		orderID          string
+		body             string
+		user             models.User
+		setupServiceMock func(mockOrdersService *mocks.OrdersService, user models.User, orderID int)
+		assertFunc       func(t *testing.T, responseBody string, responseCode int)
+	}{
+		{
+			name:    "success - order paid",
+			orderID: "1",
+			body:    `{"status":"Paid"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+				mockOrdersService.On("TransitionOrder", mock.Anything, user.ID, orderID, common.Paid).Return(&models.Order{
+					ID:        1,
+					ProductID: 101,
+					Price:     common.NewMoney(20.5),
+					OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+					Status:    common.Paid,
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+
+				expectedResponseBody, _ := json.Marshal(map[string]interface{}{
+					"id":              1,
+					"product_id":      101,
+					"price":           "20.50",
+					"order_date":      "2025-01-30T10:30:00Z",
+					"has_weightables": false,
+					"amount_due":      "20.50",
+					"amount_paid":     "0.00",
+					"balance":         "20.50",
+					"status":          "Paid",
+				})
+				assert.JSONEq(t, string(expectedResponseBody), responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "failure - illegal transition",
+			orderID: "1",
+			body:    `{"status":"Delivered"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+				mockOrdersService.On("TransitionOrder", mock.Anything, user.ID, orderID, common.Delivered).
+					Return(nil, fmt.Errorf("%w: Pending -> Delivered", services.ErrIllegalTransition))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusConflict, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "illegal_transition", `illegal order status transition: Pending -> Delivered`)
+			},
+		},
+		{
+			name:    "failure - unknown status",
+			orderID: "1",
+			body:    `{"status":"Lost"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assert.Contains(t, responseBody, `"code":"validation_failed"`, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "failure - invalid order ID (non-numeric)",
+			orderID: "abc",
+			body:    `{"status":"Paid"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_parameter", `invalid parameter id: strconv.Atoi: parsing "abc": invalid syntax`)
+			},
+		},
+		{
+			name:    "failure - service error",
+			orderID: "1",
+			body:    `{"status":"Paid"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+				mockOrdersService.On("TransitionOrder", mock.Anything, user.ID, orderID, common.Paid).Return(nil, assert.AnError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "internal_error", "")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrdersService := new(mocks.OrdersService)
+
+			orderID, _ := strconv.Atoi(tc.orderID)
+			tc.setupServiceMock(mockOrdersService, tc.user, orderID)
+
+			mockContextData := mocks.ProvideBaseMockContextData(&tc.user)
+
+			app := createTestOrdersController(mockOrdersService, mockContextData)
+			req := httptest.NewRequest(http.MethodPost, "/orders/"+tc.orderID+"/transitions", bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+			responseBody := buf.String()
+
+			tc.assertFunc(t, responseBody, resp.StatusCode)
+
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAddPayment(t *testing.T) {
+	tests := []struct {
+		name             string
+		orderID          string
+		body             string
+		user             models.User
+		setupServiceMock func(mockOrdersService *mocks.OrdersService, user models.User, orderID int)
+		assertFunc       func(t *testing.T, responseBody string, responseCode int)
+	}{
+		{
+			name:    "success - order settled",
+			orderID: "1",
+			body:    `{"id":7,"payment_amount":12.5,"payment_method":"PayPal"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+				mockOrdersService.On("AddPayment", mock.Anything, user.ID, orderID, mock.MatchedBy(func(payment models.Payment) bool {
+					return payment.Id == 0 && payment.Amount == common.NewMoney(12.5) && payment.Method == common.PayPal && payment.User.ID == user.ID
+				})).Return(&models.Order{
+					ID:        1,
+					ProductID: 101,
+					Price:     common.NewMoney(20.5),
+					OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+					Payments: []*models.Payment{
+						{Id: 1, Amount: common.NewMoney(8), Method: common.CreditCard},
+						{Id: 2, Amount: common.NewMoney(12.5), Method: common.PayPal},
+					},
+					Status: common.Pending,
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusCreated, responseCode, "Unexpected status code")
+
+				expectedResponseBody, _ := json.Marshal(map[string]interface{}{
+					"id":              1,
+					"product_id":      101,
+					"price":           "20.50",
+					"order_date":      "2025-01-30T10:30:00Z",
+					"has_weightables": false,
+					"status":          "Pending",
+					"payments": []interface{}{
+						map[string]interface{}{"id": 1, "amount": "8.00", "method": "CreditCard"},
+						map[string]interface{}{"id": 2, "amount": "12.50", "method": "PayPal"},
+					},
+					"amount_due":  "20.50",
+					"amount_paid": "20.50",
+					"balance":     "0.00",
+				})
+				assert.JSONEq(t, string(expectedResponseBody), responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "failure - overpayment",
+			orderID: "1",
+			body:    `{"payment_amount":30,"payment_method":"PayPal"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+				mockOrdersService.On("AddPayment", mock.Anything, user.ID, orderID, mock.Anything).
+					Return(nil, fmt.Errorf("%w: 38.00 paid, 20.50 due", services.ErrOverpayment))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusUnprocessableEntity, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "overpayment", `payments exceed the amount due: 38.00 paid, 20.50 due`)
+			},
+		},
+		{
+			name:    "failure - order closed",
+			orderID: "1",
+			body:    `{"payment_amount":5,"payment_method":"PayPal"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+				mockOrdersService.On("AddPayment", mock.Anything, user.ID, orderID, mock.Anything).
+					Return(nil, fmt.Errorf("%w: Cancelled", services.ErrOrderClosed))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusConflict, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "order_closed", `order does not accept payments: Cancelled`)
+			},
+		},
+		{
+			name:    "failure - amount not positive",
+			orderID: "1",
+			body:    `{"payment_amount":-5,"payment_method":"PayPal"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assert.Contains(t, responseBody, `"code":"validation_failed"`, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "failure - unknown payment method",
+			orderID: "1",
+			body:    `{"payment_amount":5,"payment_method":"Cash"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assert.Contains(t, responseBody, `"code":"validation_failed"`, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "success - card details",
+			orderID: "1",
+			body:    `{"payment_amount":5,"payment_method":"CreditCard","card":{"number":"4111 1111 1111 1111"}}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+				mockOrdersService.On("AddPayment", mock.Anything, user.ID, orderID, mock.MatchedBy(func(payment models.Payment) bool {
+					return payment.Details == models.Card{Brand: models.Visa, Last4: "1111"}
+				})).Return(&models.Order{
+					ID:        1,
+					ProductID: 101,
+					Price:     common.NewMoney(20.5),
+					OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+					Payments: []*models.Payment{
+						{Id: 1, Amount: common.NewMoney(5), Method: common.CreditCard, Fee: common.NewMoney(0.32), Details: models.Card{Brand: models.Visa, Last4: "1111"}},
+					},
+					Status: common.Pending,
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusCreated, responseCode, "Unexpected status code")
+				assert.Contains(t, responseBody, `{"id":1,"amount":"5.00","method":"CreditCard","fee":"0.32","card":{"brand":"Visa","last4":"1111"}}`, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "failure - invalid card number",
+			orderID: "1",
+			body:    `{"payment_amount":5,"payment_method":"CreditCard","card":{"number":"4111 1111 1111 1112"}}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "validation_failed", "validation failed: invalid payment details: card number fails the Luhn check")
+			},
+		},
+		{
+			name:    "failure - declined by the payment gateway",
+			orderID: "1",
+			body:    `{"payment_amount":5,"payment_method":"PayPal"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+				mockOrdersService.On("AddPayment", mock.Anything, user.ID, orderID, mock.Anything).
+					Return(nil, fmt.Errorf("%w: Authorize", gateways.ErrPaymentDeclined))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusPaymentRequired, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "payment_declined", `payment declined: Authorize`)
+			},
+		},
+		{
+			name:    "failure - payment gateway timed out",
+			orderID: "1",
+			body:    `{"payment_amount":5,"payment_method":"PayPal"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+				mockOrdersService.On("AddPayment", mock.Anything, user.ID, orderID, mock.Anything).
+					Return(nil, fmt.Errorf("%w: Capture", gateways.ErrGatewayTimeout))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusServiceUnavailable, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "payment_gateway_timeout", `payment gateway timed out: Capture`)
+			},
+		},
+		{
+			name:    "failure - outside the limits of the method",
+			orderID: "1",
+			body:    `{"payment_amount":0.5,"payment_method":"CreditCard"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+				mockOrdersService.On("AddPayment", mock.Anything, user.ID, orderID, mock.Anything).
+					Return(nil, fmt.Errorf("%w: 0.50 by CreditCard, at least 1.00", services.ErrPaymentLimit))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusUnprocessableEntity, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "payment_limit", `payment amount outside the limits of its method: 0.50 by CreditCard, at least 1.00`)
+			},
+		},
+		{
+			name:    "failure - no exchange rate to the currency of the order",
+			orderID: "1",
+			body:    `{"payment_amount":5,"payment_method":"PayPal","currency":"CHF"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+				mockOrdersService.On("AddPayment", mock.Anything, user.ID, orderID, mock.MatchedBy(func(payment models.Payment) bool {
+					return payment.Currency == common.CHF
+				})).Return(nil, fmt.Errorf("%w: CHF to EUR on 2025-02-01", services.ErrNoExchangeRate))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusUnprocessableEntity, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "no_exchange_rate", `no exchange rate: CHF to EUR on 2025-02-01`)
+			},
+		},
+		{
+			name:    "failure - invalid currency",
+			orderID: "1",
+			body:    `{"payment_amount":5,"payment_method":"PayPal","currency":"Euro"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+			},
+		},
+		{
+			name:    "failure - service error",
+			orderID: "1",
+			body:    `{"payment_amount":5,"payment_method":"PayPal"}`,
+			user:    models.User{ID: 1, Username: "Jane Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User, orderID int) {
+				mockOrdersService.On("AddPayment", mock.Anything, user.ID, orderID, mock.Anything).Return(nil, assert.AnError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "internal_error", "")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrdersService := new(mocks.OrdersService)
+
+			orderID, _ := strconv.Atoi(tc.orderID)
+			tc.setupServiceMock(mockOrdersService, tc.user, orderID)
+
+			mockContextData := mocks.ProvideBaseMockContextData(&tc.user)
+
+			app := createTestOrdersController(mockOrdersService, mockContextData)
+			req := httptest.NewRequest(http.MethodPost, "/orders/"+tc.orderID+"/payments", bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+			responseBody := buf.String()
+
+			tc.assertFunc(t, responseBody, resp.StatusCode)
+
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRefunds(t *testing.T) {
+	createdAt := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+	refund := &models.Refund{Id: 3, PaymentId: 2, Amount: common.NewMoney(5), Reason: common.DamagedGoods, CreatedAt: createdAt}
+
+	tests := []struct {
+		name             string
+		method           string
+		orderID          string
+		body             string
+		setupServiceMock func(mockOrdersService *mocks.OrdersService, user models.User)
+		assertFunc       func(t *testing.T, responseBody string, responseCode int)
+	}{
+		{
+			name:    "success - partial refund",
+			method:  http.MethodPost,
+			orderID: "1",
+			body:    `{"payment_id":2,"amount":5,"reason":"DamagedGoods"}`,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("RefundOrder", mock.Anything, user.ID, 1,
+					models.Refund{PaymentId: 2, Amount: common.NewMoney(5), Reason: common.DamagedGoods}).Return(refund, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusCreated, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `{"id":3,"payment_id":2,"amount":"5.00","reason":"DamagedGoods","created_at":"2025-02-10T12:00:00Z"}`,
+					responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "success - full refund without amount",
+			method:  http.MethodPost,
+			orderID: "1",
+			body:    `{"payment_id":2,"reason":"CustomerRequest"}`,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("RefundOrder", mock.Anything, user.ID, 1,
+					models.Refund{PaymentId: 2, Reason: common.CustomerRequest}).Return(refund, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusCreated, responseCode, "Unexpected status code")
+			},
+		},
+		{
+			name:    "failure - unknown reason",
+			method:  http.MethodPost,
+			orderID: "1",
+			body:    `{"payment_id":2,"reason":"Changed my mind"}`,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assert.Contains(t, responseBody, `"code":"validation_failed"`, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "failure - negative amount",
+			method:  http.MethodPost,
+			orderID: "1",
+			body:    `{"payment_id":2,"amount":-5,"reason":"Goodwill"}`,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assert.Contains(t, responseBody, `"code":"validation_failed"`, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "failure - payment of another order",
+			method:  http.MethodPost,
+			orderID: "1",
+			body:    `{"payment_id":9,"reason":"Goodwill"}`,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("RefundOrder", mock.Anything, user.ID, 1, mock.Anything).
+					Return(nil, fmt.Errorf("%w: 9", services.ErrUnknownPayment))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusUnprocessableEntity, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "unknown_payment", `payment does not belong to the order: 9`)
+			},
+		},
+		{
+			name:    "failure - refund exceeds payment",
+			method:  http.MethodPost,
+			orderID: "1",
+			body:    `{"payment_id":2,"amount":50,"reason":"Goodwill"}`,
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("RefundOrder", mock.Anything, user.ID, 1, mock.Anything).
+					Return(nil, fmt.Errorf("%w: 50.00 requested, 12.50 refundable", services.ErrRefundExceedsPayment))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusUnprocessableEntity, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "refund_exceeds_payment", `refund exceeds the refundable amount of the payment: 50.00 requested, 12.50 refundable`)
+			},
+		},
+		{
+			name:    "success - list refunds",
+			method:  http.MethodGet,
+			orderID: "1",
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetRefunds", mock.Anything, user.ID, 1).Return([]*models.Refund{refund}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `[{"id":3,"payment_id":2,"amount":"5.00","reason":"DamagedGoods","created_at":"2025-02-10T12:00:00Z"}]`,
+					responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "failure - list refunds of an invalid order id",
+			method:  http.MethodGet,
+			orderID: "abc",
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+			},
+		},
+		{
+			name:    "failure - list refunds service error",
+			method:  http.MethodGet,
+			orderID: "1",
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetRefunds", mock.Anything, user.ID, 1).Return(nil, assert.AnError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			user := models.User{ID: 1, Username: "Jane Doe"}
+			mockOrdersService := new(mocks.OrdersService)
+			tc.setupServiceMock(mockOrdersService, user)
+
+			app := createTestOrdersController(mockOrdersService, mocks.ProvideBaseMockContextData(&user))
+			req := httptest.NewRequest(tc.method, "/orders/"+tc.orderID+"/refunds", bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+
+			tc.assertFunc(t, buf.String(), resp.StatusCode)
+
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReplaceOrder(t *testing.T) {
+	validBody := transports.OrderCreateRequest{
+		ProductID: 1,
+		Quantity:  2,
+		Price:     common.NewMoney(10.23),
+		OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+		Payments: []*transports.PaymentRequest{
+			{
+				Id:            1,
+				PaymentMethod: common.CreditCard,
+				PaymentAmount: common.NewMoney(10.23),
+			},
+		},
+	}
+
+	expectedOrder := func(body transports.OrderCreateRequest, user models.User, orderID int) models.Order {
+		order, _ := body.ToOrder(user)
+		order.ID = orderID
+		return *order
+	}
+
+	tests := []struct {
+		name             string
+		orderID          string
+		body             transports.OrderCreateRequest
+		user             models.User
+		setupServiceMock func(mockOrdersService *mocks.OrdersService, body transports.OrderCreateRequest, user models.User, orderID int)
+		assertFunc       func(t *testing.T, responseBody string, responseCode int)
+	}{
+		{
+			name:    "success - order replaced",
+			orderID: "42",
+			body:    validBody,
+			user:    models.User{ID: 1, Username: "John Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, body transports.OrderCreateRequest, user models.User, orderID int) {
+				mockOrdersService.On("UpdateOrder", mock.Anything, user.ID, expectedOrder(body, user, orderID)).Return(&models.Order{
+					ID:        42,
+					ProductID: 1,
+					Quantity:  2,
+					Price:     common.NewMoney(10.23),
+					OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+					Status:    common.Pending,
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+
+				expectedResponseBody, _ := json.Marshal(map[string]interface{}{
+					"id":              42,
+					"product_id":      1,
+					"quantity":        2,
+					"price":           "10.23",
+					"order_date":      "2025-01-30T10:30:00Z",
+					"has_weightables": false,
+					"amount_due":      "10.23",
+					"amount_paid":     "0.00",
+					"balance":         "10.23",
+					"status":          "Pending",
+				})
+				assert.JSONEq(t, string(expectedResponseBody), responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "failure - validation",
+			orderID: "42",
+			body:    transports.OrderCreateRequest{},
+			user:    models.User{ID: 1, Username: "John Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, body transports.OrderCreateRequest, user models.User, orderID int) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assert.Contains(t, responseBody, `"code":"validation_failed"`, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "failure - immutable field",
+			orderID: "42",
+			body:    validBody,
+			user:    models.User{ID: 1, Username: "John Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, body transports.OrderCreateRequest, user models.User, orderID int) {
+				mockOrdersService.On("UpdateOrder", mock.Anything, user.ID, expectedOrder(body, user, orderID)).
+					Return(nil, fmt.Errorf("%w: price of a Paid order", services.ErrImmutableField))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusUnprocessableEntity, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "immutable_field", `field cannot be changed: price of a Paid order`)
+			},
+		},
+		{
+			name:    "failure - service error",
+			orderID: "42",
+			body:    validBody,
+			user:    models.User{ID: 1, Username: "John Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, body transports.OrderCreateRequest, user models.User, orderID int) {
+				mockOrdersService.On("UpdateOrder", mock.Anything, user.ID, expectedOrder(body, user, orderID)).Return(nil, assert.AnError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "internal_error", "")
+			},
+		},
+		{
+			name:    "failure - invalid order ID (non-numeric)",
+			orderID: "abc",
+			body:    validBody,
+			user:    models.User{ID: 1, Username: "John Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, body transports.OrderCreateRequest, user models.User, orderID int) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrdersService := new(mocks.OrdersService)
+
+			orderID, _ := strconv.Atoi(tc.orderID)
+			tc.setupServiceMock(mockOrdersService, tc.body, tc.user, orderID)
+
+			mockContextData := mocks.ProvideBaseMockContextData(&tc.user)
+			app := createTestOrdersController(mockOrdersService, mockContextData)
+			body, _ := json.Marshal(tc.body)
+			req := httptest.NewRequest(http.MethodPut, "/orders/"+tc.orderID, bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+			responseBody := buf.String()
+
+			tc.assertFunc(t, responseBody, resp.StatusCode)
+
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPatchOrder(t *testing.T) {
+	price := common.NewMoney(10.23)
+	storedOrder := func(user models.User) *models.Order {
+		return &models.Order{
+			ID:        42,
+			ProductID: 1,
+			Quantity:  2,
+			Price:     common.NewMoney(10.23),
+			OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+			Payments: []*models.Payment{
+				{Id: 7, Amount: common.NewMoney(10.23), Method: common.CreditCard},
+			},
+			User:   &user,
+			Status: common.Paid,
+		}
+	}
+
+	tests := []struct {
+		name             string
+		patch            string
+		user             models.User
+		setupServiceMock func(mockOrdersService *mocks.OrdersService, user models.User)
+		assertFunc       func(t *testing.T, responseBody string, responseCode int)
+	}{
+		{
+			name:  "success - quantity patched",
+			patch: `{"quantity":3,"has_weightables":true}`,
+			user:  models.User{ID: 1, Username: "John Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetOrder", mock.Anything, user.ID, 42).Return(storedOrder(user), nil)
+				mockOrdersService.On("UpdateOrder", mock.Anything, user.ID, models.Order{
+					ID:        42,
+					ProductID: 1,
+					Quantity:  3,
+					Price:     common.NewMoney(10.23),
+					OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+					Payments: []*models.Payment{
+						{Id: 7, Amount: common.NewMoney(10.23), Method: common.CreditCard, User: &user},
+					},
+					User:           &user,
+					HasWeightables: true,
+					Lines:          []*models.OrderLine{{ProductID: 1, Quantity: 3, UnitPrice: price.Div(3, common.HalfUp), LineTotal: price}},
+				}).Return(&models.Order{ID: 42, ProductID: 1, Quantity: 3, Price: common.NewMoney(10.23), HasWeightables: true, Status: common.Paid}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `{"id":42,"product_id":1,"quantity":3,"price":"10.23","order_date":"0001-01-01T00:00:00Z","has_weightables":true,"status":"Paid","amount_due":"10.23","amount_paid":"0.00","balance":"10.23"}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:  "failure - owner cannot be patched",
+			patch: `{"user_id":2}`,
+			user:  models.User{ID: 1, Username: "John Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusUnprocessableEntity, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "immutable_field", `field cannot be changed: user_id`)
+			},
+		},
+		{
+			name:  "failure - price of a paid order",
+			patch: `{"price":12.5}`,
+			user:  models.User{ID: 1, Username: "John Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetOrder", mock.Anything, user.ID, 42).Return(storedOrder(user), nil)
+				mockOrdersService.On("UpdateOrder", mock.Anything, user.ID, mock.MatchedBy(func(order models.Order) bool {
+					return order.Price == common.NewMoney(12.5)
+				})).Return(nil, fmt.Errorf("%w: price of a Paid order", services.ErrImmutableField))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusUnprocessableEntity, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "immutable_field", `field cannot be changed: price of a Paid order`)
+			},
+		},
+		{
+			name:  "failure - removing a required field",
+			patch: `{"product_id":null}`,
+			user:  models.User{ID: 1, Username: "John Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetOrder", mock.Anything, user.ID, 42).Return(storedOrder(user), nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assert.Contains(t, responseBody, `"code":"validation_failed"`, "Unexpected response JSON")
+			},
+		},
+		{
+			name:  "failure - invalid patch document",
+			patch: `[1,2,3]`,
+			user:  models.User{ID: 1, Username: "John Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_payload", "invalid request payload")
+			},
+		},
+		{
+			name:  "failure - order not found",
+			patch: `{"quantity":3}`,
+			user:  models.User{ID: 1, Username: "John Doe"},
+			setupServiceMock: func(mockOrdersService *mocks.OrdersService, user models.User) {
+				mockOrdersService.On("GetOrder", mock.Anything, user.ID, 42).Return(nil, assert.AnError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "internal_error", "")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrdersService := new(mocks.OrdersService)
+			tc.setupServiceMock(mockOrdersService, tc.user)
+
+			mockContextData := mocks.ProvideBaseMockContextData(&tc.user)
+			app := createTestOrdersController(mockOrdersService, mockContextData)
+			req := httptest.NewRequest(http.MethodPatch, "/orders/42", bytes.NewReader([]byte(tc.patch)))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+			responseBody := buf.String()
+
+			tc.assertFunc(t, responseBody, resp.StatusCode)
+
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWeighOrder(t *testing.T) {
+	tests := []struct {
+		name             string
+		orderID          string
+		body             string
+		setupServiceMock func(mockWeighingService *mocks.WeighingService, user models.User)
+		assertFunc       func(t *testing.T, responseBody string, responseCode int)
+	}{
+		{
+			name:    "success - order weighed",
+			orderID: "42",
+			body:    `{"lines":[{"index":0,"weight":1100,"unit":"g"}]}`,
+			setupServiceMock: func(mockWeighingService *mocks.WeighingService, user models.User) {
+				mockWeighingService.On("WeighOrder", mock.Anything, user.ID, 42, []models.LineWeight{
+					{Index: 0, Weight: 1100, Unit: common.Gram},
+				}).Return(&models.Order{
+					ID:             42,
+					Quantity:       1,
+					Price:          common.NewMoney(3.3),
+					HasWeightables: true,
+					Status:         common.Paid,
+					Lines: []*models.OrderLine{
+						{ProductID: 1, Quantity: 1, UnitPrice: common.NewMoney(3), LineTotal: common.NewMoney(3.3), WeightUnit: common.Kilogram, EstimatedWeight: 1, ActualWeight: 1.1},
+					},
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `{"id":42,"quantity":1,"price":"3.30","order_date":"0001-01-01T00:00:00Z","has_weightables":true,"status":"Paid","amount_due":"3.30","amount_paid":"0.00","balance":"3.30",
+					"lines":[{"product_id":1,"quantity":1,"unit_price":"3.00","line_total":"3.30","weight_unit":"kg","estimated_weight":1,"actual_weight":1.1}]}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "failure - weight out of tolerance",
+			orderID: "42",
+			body:    `{"lines":[{"index":0,"weight":2}]}`,
+			setupServiceMock: func(mockWeighingService *mocks.WeighingService, user models.User) {
+				mockWeighingService.On("WeighOrder", mock.Anything, user.ID, 42, mock.Anything).
+					Return(nil, fmt.Errorf("%w: line 0 weighs 2 kg, estimated 1 kg", services.ErrWeightOutOfTolerance))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusUnprocessableEntity, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "weight_out_of_tolerance", `weight outside of tolerance: line 0 weighs 2 kg, estimated 1 kg`)
+			},
+		},
+		{
+			name:    "failure - order no longer weighable",
+			orderID: "42",
+			body:    `{"lines":[{"index":0,"weight":1}]}`,
+			setupServiceMock: func(mockWeighingService *mocks.WeighingService, user models.User) {
+				mockWeighingService.On("WeighOrder", mock.Anything, user.ID, 42, mock.Anything).
+					Return(nil, fmt.Errorf("%w: %s", services.ErrOrderNotWeighable, common.Delivered))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusConflict, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "order_not_weighable", `order can no longer be weighed: Delivered`)
+			},
+		},
+		{
+			name:    "failure - missing weights",
+			orderID: "42",
+			body:    `{"lines":[]}`,
+			setupServiceMock: func(mockWeighingService *mocks.WeighingService, user models.User) {
+				// No service method is called for an invalid request
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assert.Contains(t, responseBody, `"code":"validation_failed"`, "Unexpected response JSON")
+			},
+		},
+		{
+			name:    "failure - invalid order id",
+			orderID: "abc",
+			body:    `{"lines":[{"index":0,"weight":1}]}`,
+			setupServiceMock: func(mockWeighingService *mocks.WeighingService, user models.User) {
+				// No service method is called for an invalid order id
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+			},
+		},
+		{
+			name:    "failure - internal server error",
+			orderID: "42",
+			body:    `{"lines":[{"index":0,"weight":1}]}`,
+			setupServiceMock: func(mockWeighingService *mocks.WeighingService, user models.User) {
+				mockWeighingService.On("WeighOrder", mock.Anything, user.ID, 42, mock.Anything).Return(nil, assert.AnError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "internal_error", "")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			user := models.User{ID: 1, Username: "John Doe"}
+			mockWeighingService := mocks.NewWeighingService(t)
+			tc.setupServiceMock(mockWeighingService, user)
+
+			mockContextData := mocks.ProvideBaseMockContextData(&user)
+			app := createTestOrdersControllerWith(&OrdersController{weighingService: mockWeighingService}, mockContextData)
+			req := httptest.NewRequest(http.MethodPost, "/orders/"+tc.orderID+"/weighing", bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+
+			tc.assertFunc(t, buf.String(), resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"context"
+	"fp_kata/common"
+	"fp_kata/common/constants"
+	"fp_kata/common/utils"
+	"fp_kata/internal/models"
+	"fp_kata/internal/services"
+	"fp_kata/pkg/log"
+	"fp_kata/pkg/transports"
+	"github.com/gofiber/fiber/v3"
+	"strconv"
+	"time"
+)
+
+const compPaymentsController = "PaymentsController"
+
+// paymentDateLayout is the layout of a day given as from or to of the payment listing.
+const paymentDateLayout = time.DateOnly
+
+// errInvalidPaymentPeriod is returned for a from or to query parameter that is neither a date nor a time.
+var errInvalidPaymentPeriod = common.NewDomainError(common.Validation, "invalid_payment_period", "from and to must be dates (2006-01-02) or RFC 3339 times")
+
+// errUnknownPaymentMethod is returned for a method query parameter that is not a payment method.
+var errUnknownPaymentMethod = common.NewDomainError(common.Validation, "unknown_payment_method", "unknown payment method")
+
+type PaymentsController struct {
+	paymentsService services.PaymentsService
+	orderService    services.OrdersService
+}
+
+func NewPaymentsController(paymentsService services.PaymentsService, orderService services.OrdersService) PaymentsController {
+	return PaymentsController{
+		paymentsService: paymentsService,
+		orderService:    orderService,
+	}
+}
+
+func (c *PaymentsController) RegisterPaymentRoutes(app *fiber.App, authMiddleware fiber.Handler) {
+	app.Get("/payments", c.GetPayments, authMiddleware)
+	app.Get("/payments/:id", c.GetPayment, authMiddleware)
+	app.Get("/orders/:id/payments", c.GetOrderPayments, authMiddleware)
+}
+
+// GetPayments handles "/payments" with method "GET". The optional query "method" selects the payments of a method,
+// "from" and "to" the payments made in a period; "to" is exclusive, so from=2025-02-01&to=2025-03-01 selects February.
+func (c *PaymentsController) GetPayments(requestCtx fiber.Ctx) error {
+	logger := log.GetFiberLogger(requestCtx)
+	backgroundCtx := log.NewBackgroundContext(logger)
+	utils.LogAction(backgroundCtx, compPaymentsController, "GetPayments")
+
+	filter, err := parsePaymentFilter(requestCtx)
+	if err != nil {
+		return err
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	payments, err := c.paymentsService.GetUserPayments(backgroundCtx, user.ID, filter)
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToPaymentResponses(payments))
+}
+
+// GetPayment handles "/payments/{id}" with method "GET"
+func (c *PaymentsController) GetPayment(requestCtx fiber.Ctx) error {
+
+	paymentId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("paymentId", paymentId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compPaymentsController, "GetPayment")
+
+	pid, err := strconv.Atoi(paymentId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	payment, err := c.paymentsService.GetUserPayment(backgroundCtx, user.ID, pid)
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToPaymentResponse(*payment))
+}
+
+// GetOrderPayments handles "/orders/{id}/payments" with method "GET". The order is read like GET "/orders/{id}",
+// so only the owner of the order sees its payments.
+func (c *PaymentsController) GetOrderPayments(requestCtx fiber.Ctx) error {
+
+	orderId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("orderId", orderId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compPaymentsController, "GetOrderPayments")
+
+	oid, err := strconv.Atoi(orderId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	user := requestCtx.Locals(constants.AuthenticatedUserKey).(models.User)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserKey, &user)
+	backgroundCtx = context.WithValue(backgroundCtx, constants.AuthenticatedUserIdKey, user.ID)
+
+	order, err := c.orderService.GetOrder(backgroundCtx, user.ID, oid)
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToPaymentResponses(order.Payments))
+}
+
+// parsePaymentFilter builds the filter of the method, from and to query parameters.
+func parsePaymentFilter(requestCtx fiber.Ctx) (models.PaymentFilter, error) {
+	filter := models.PaymentFilter{Method: common.PaymentMethod(requestCtx.Query("method"))}
+	if err := utils.NewValidator().Var(filter.Method, "omitempty,oneof=CreditCard DebitCard PayPal BankTransfer"); err != nil {
+		return filter, errUnknownPaymentMethod
+	}
+
+	var err error
+	if filter.From, err = parsePaymentTime(requestCtx.Query("from")); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parsePaymentTime(requestCtx.Query("to")); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// parsePaymentTime parses a day, which starts at midnight UTC, or a time; the zero time when no value is given.
+func parsePaymentTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if day, err := time.Parse(paymentDateLayout, value); err == nil {
+		return day, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errInvalidPaymentPeriod
+	}
+	return parsed, nil
+}
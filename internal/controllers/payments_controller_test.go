@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/internal/models"
+	"fp_kata/internal/services"
+	"fp_kata/mocks"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func createTestPaymentsController(mockPaymentsService *mocks.PaymentsService, mockOrdersService *mocks.OrdersService, contextData *map[any]any) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	mockData := make(map[any]any)
+	if contextData != nil {
+		mockData = *contextData
+	}
+
+	ctx := &mocks.CustomCtx{
+		DefaultCtx: *fiber.NewDefaultCtx(app),
+		MockLocals: mockData,
+	}
+	app.NewCtxFunc(func(app *fiber.App) fiber.CustomCtx {
+		return ctx
+	})
+
+	controller := NewPaymentsController(mockPaymentsService, mockOrdersService)
+	app.Get("/payments", controller.GetPayments)
+	app.Get("/payments/:id", controller.GetPayment)
+	app.Get("/orders/:id/payments", controller.GetOrderPayments)
+
+	return app
+}
+
+func TestPaymentsController(t *testing.T) {
+	user := models.User{ID: 1, Username: "Jane Doe"}
+	paidAt := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+	payment := &models.Payment{Id: 2, Amount: common.NewMoney(12.5), Method: common.PayPal, User: &user, Order: &models.Order{ID: 7}, PaidAt: paidAt,
+		Refunds: []*models.Refund{{Id: 3, PaymentId: 2, Amount: common.NewMoney(2.5)}}}
+	paymentJSON := `{"id":2,"amount":"12.50","method":"PayPal","order_id":7,"paid_at":"2025-02-10T12:00:00Z","amount_refunded":"2.50"}`
+
+	tests := []struct {
+		name             string
+		path             string
+		setupServiceMock func(mockPaymentsService *mocks.PaymentsService, mockOrdersService *mocks.OrdersService)
+		assertFunc       func(t *testing.T, responseBody string, responseCode int)
+	}{
+		{
+			name: "get payment",
+			path: "/payments/2",
+			setupServiceMock: func(mockPaymentsService *mocks.PaymentsService, mockOrdersService *mocks.OrdersService) {
+				mockPaymentsService.On("GetUserPayment", mock.Anything, user.ID, 2).Return(payment, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, paymentJSON, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name: "get payment of another user",
+			path: "/payments/5",
+			setupServiceMock: func(mockPaymentsService *mocks.PaymentsService, mockOrdersService *mocks.OrdersService) {
+				mockPaymentsService.On("GetUserPayment", mock.Anything, user.ID, 5).Return(nil, fmt.Errorf("%w: 5", services.ErrPaymentNotFound))
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusNotFound, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "payment_not_found", "payment not found: 5")
+			},
+		},
+		{
+			name: "get payment with invalid id",
+			path: "/payments/abc",
+			setupServiceMock: func(mockPaymentsService *mocks.PaymentsService, mockOrdersService *mocks.OrdersService) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+			},
+		},
+		{
+			name: "list payments of an order",
+			path: "/orders/7/payments",
+			setupServiceMock: func(mockPaymentsService *mocks.PaymentsService, mockOrdersService *mocks.OrdersService) {
+				mockOrdersService.On("GetOrder", mock.Anything, user.ID, 7).Return(&models.Order{ID: 7, Payments: []*models.Payment{payment}}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, "["+paymentJSON+"]", responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name: "list payments of an order of another user",
+			path: "/orders/8/payments",
+			setupServiceMock: func(mockPaymentsService *mocks.PaymentsService, mockOrdersService *mocks.OrdersService) {
+				mockOrdersService.On("GetOrder", mock.Anything, user.ID, 8).Return(nil, services.ErrNotAuthorized)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusForbidden, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "not_authorized", "user is not authorized to access this order")
+			},
+		},
+		{
+			name: "list payments by method and period",
+			path: "/payments?method=PayPal&from=2025-02-01&to=2025-03-01T00:00:00Z",
+			setupServiceMock: func(mockPaymentsService *mocks.PaymentsService, mockOrdersService *mocks.OrdersService) {
+				mockPaymentsService.On("GetUserPayments", mock.Anything, user.ID, models.PaymentFilter{
+					Method: common.PayPal,
+					From:   time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+					To:     time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+				}).Return([]*models.Payment{payment}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, "["+paymentJSON+"]", responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name: "list all payments",
+			path: "/payments",
+			setupServiceMock: func(mockPaymentsService *mocks.PaymentsService, mockOrdersService *mocks.OrdersService) {
+				mockPaymentsService.On("GetUserPayments", mock.Anything, user.ID, models.PaymentFilter{}).Return([]*models.Payment{}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `[]`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name: "list payments of an unknown method",
+			path: "/payments?method=Cash",
+			setupServiceMock: func(mockPaymentsService *mocks.PaymentsService, mockOrdersService *mocks.OrdersService) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "unknown_payment_method", "unknown payment method")
+			},
+		},
+		{
+			name: "list payments with an invalid period",
+			path: "/payments?from=yesterday",
+			setupServiceMock: func(mockPaymentsService *mocks.PaymentsService, mockOrdersService *mocks.OrdersService) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "invalid_payment_period", "from and to must be dates (2006-01-02) or RFC 3339 times")
+			},
+		},
+		{
+			name: "list payments service error",
+			path: "/payments",
+			setupServiceMock: func(mockPaymentsService *mocks.PaymentsService, mockOrdersService *mocks.OrdersService) {
+				mockPaymentsService.On("GetUserPayments", mock.Anything, user.ID, mock.Anything).Return(nil, assert.AnError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPaymentsService := new(mocks.PaymentsService)
+			mockOrdersService := new(mocks.OrdersService)
+			tc.setupServiceMock(mockPaymentsService, mockOrdersService)
+
+			app := createTestPaymentsController(mockPaymentsService, mockOrdersService, mocks.ProvideBaseMockContextData(&user))
+			resp, err := app.Test(httptest.NewRequest("GET", tc.path, nil))
+
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+
+			tc.assertFunc(t, buf.String(), resp.StatusCode)
+
+			mockPaymentsService.AssertExpectations(t)
+			mockOrdersService.AssertExpectations(t)
+		})
+	}
+}
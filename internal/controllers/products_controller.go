@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"fp_kata/common/utils"
+	"fp_kata/internal/services"
+	"fp_kata/pkg/log"
+	"fp_kata/pkg/transports"
+	"github.com/gofiber/fiber/v3"
+	"strconv"
+)
+
+const compProductsController = "ProductsController"
+
+type ProductsController struct {
+	productsService services.ProductsService
+}
+
+func NewProductsController(productsService services.ProductsService) ProductsController {
+	return ProductsController{productsService: productsService}
+}
+
+func (c *ProductsController) RegisterProductRoutes(app *fiber.App, authMiddleware fiber.Handler) {
+	app.Post("/products", c.CreateProduct, authMiddleware)
+	app.Get("/products", c.SearchProducts, authMiddleware)
+	app.Get("/products/:id", c.GetProduct, authMiddleware)
+	app.Put("/products/:id", c.ReplaceProduct, authMiddleware)
+	app.Delete("/products/:id", c.DeleteProduct, authMiddleware)
+}
+
+// CreateProduct handles "/products" with method "POST"
+func (c *ProductsController) CreateProduct(requestCtx fiber.Ctx) error {
+	logger := log.GetFiberLogger(requestCtx)
+	backgroundCtx := log.NewBackgroundContext(logger)
+	utils.LogAction(backgroundCtx, compProductsController, "CreateProduct")
+
+	productRequest := new(transports.ProductRequest)
+	if err := requestCtx.Bind().Body(productRequest); err != nil {
+		return errInvalidPayload
+	}
+
+	validate := utils.NewValidator()
+	if err := validate.Struct(productRequest); err != nil {
+		return invalidRequest(err)
+	}
+
+	product, err := c.productsService.CreateProduct(backgroundCtx, *productRequest.ToProduct())
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusCreated).JSON(transports.MapToProductResponse(*product))
+}
+
+// SearchProducts handles "/products" with method "GET", the optional query "q" searches names and descriptions
+func (c *ProductsController) SearchProducts(requestCtx fiber.Ctx) error {
+	logger := log.GetFiberLogger(requestCtx)
+	backgroundCtx := log.NewBackgroundContext(logger)
+	utils.LogAction(backgroundCtx, compProductsController, "SearchProducts")
+
+	products, err := c.productsService.SearchProducts(backgroundCtx, requestCtx.Query("q"))
+	if err != nil {
+		return err
+	}
+
+	productResponses := make([]*transports.ProductResponse, len(products))
+	for i, product := range products {
+		productResponses[i] = transports.MapToProductResponse(*product)
+	}
+	return requestCtx.Status(fiber.StatusOK).JSON(productResponses)
+}
+
+// GetProduct handles "/products/{id}" with method "GET"
+func (c *ProductsController) GetProduct(requestCtx fiber.Ctx) error {
+
+	productId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("productId", productId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compProductsController, "GetProduct")
+
+	pid, err := strconv.Atoi(productId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	product, err := c.productsService.GetProduct(backgroundCtx, pid)
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToProductResponse(*product))
+}
+
+// ReplaceProduct handles "/products/{id}" with method "PUT"
+func (c *ProductsController) ReplaceProduct(requestCtx fiber.Ctx) error {
+
+	productId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("productId", productId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compProductsController, "ReplaceProduct")
+
+	pid, err := strconv.Atoi(productId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	productRequest := new(transports.ProductRequest)
+	if err := requestCtx.Bind().Body(productRequest); err != nil {
+		return errInvalidPayload
+	}
+
+	validate := utils.NewValidator()
+	if err := validate.Struct(productRequest); err != nil {
+		return invalidRequest(err)
+	}
+
+	product := productRequest.ToProduct()
+	product.ID = pid
+	updatedProduct, err := c.productsService.UpdateProduct(backgroundCtx, *product)
+	if err != nil {
+		return err
+	}
+
+	return requestCtx.Status(fiber.StatusOK).JSON(transports.MapToProductResponse(*updatedProduct))
+}
+
+// DeleteProduct handles "/products/{id}" with method "DELETE"
+func (c *ProductsController) DeleteProduct(requestCtx fiber.Ctx) error {
+
+	productId := requestCtx.Params("id")
+	logger := log.GetFiberLogger(requestCtx).With().Str("productId", productId).Logger()
+	log.SetFiberLogger(requestCtx, &logger)
+	backgroundCtx := log.NewBackgroundContext(&logger)
+	utils.LogAction(backgroundCtx, compProductsController, "DeleteProduct")
+
+	pid, err := strconv.Atoi(productId)
+	if err != nil {
+		return invalidParameter("id", err)
+	}
+
+	if err := c.productsService.DeleteProduct(backgroundCtx, pid); err != nil {
+		return err
+	}
+
+	return requestCtx.SendStatus(fiber.StatusNoContent)
+}
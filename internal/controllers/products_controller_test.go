@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/models"
+	"fp_kata/mocks"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func createTestProductsController(mockProductsService *mocks.ProductsService, contextData *map[any]any) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	mockData := make(map[any]any)
+	if contextData != nil {
+		mockData = *contextData
+	}
+
+	ctx := &mocks.CustomCtx{
+		DefaultCtx: *fiber.NewDefaultCtx(app),
+		MockLocals: mockData,
+	}
+	app.NewCtxFunc(func(app *fiber.App) fiber.CustomCtx {
+		return ctx
+	})
+
+	controller := NewProductsController(mockProductsService)
+	app.Post("/products", controller.CreateProduct)
+	app.Get("/products", controller.SearchProducts)
+	app.Get("/products/:id", controller.GetProduct)
+	app.Put("/products/:id", controller.ReplaceProduct)
+	app.Delete("/products/:id", controller.DeleteProduct)
+
+	return app
+}
+
+func TestProductsController(t *testing.T) {
+	notFound := fmt.Errorf("%w: %d", datasources.ErrProductNotFound, 9)
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		body             string
+		setupServiceMock func(mockProductsService *mocks.ProductsService)
+		assertFunc       func(t *testing.T, responseBody string, responseCode int)
+	}{
+		{
+			name:   "create product",
+			method: http.MethodPost,
+			path:   "/products",
+			body:   `{"name":"Apples","price":2.5,"weight_unit":"kg"}`,
+			setupServiceMock: func(mockProductsService *mocks.ProductsService) {
+				mockProductsService.On("CreateProduct", mock.Anything, models.Product{Name: "Apples", Price: common.NewMoney(2.5), WeightUnit: common.Kilogram}).
+					Return(&models.Product{ID: 1, Name: "Apples", Price: common.NewMoney(2.5), WeightUnit: common.Kilogram}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusCreated, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `{"id":1,"name":"Apples","price":"2.50","weight_unit":"kg"}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:   "create product - validation failed",
+			method: http.MethodPost,
+			path:   "/products",
+			body:   `{"name":"Apples","price":0,"weight_unit":"stone"}`,
+			setupServiceMock: func(mockProductsService *mocks.ProductsService) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+				assert.Contains(t, responseBody, `"code":"validation_failed"`, "Unexpected response JSON")
+				assert.Contains(t, responseBody, "'WeightUnit' failed on the 'oneof' tag", "Unexpected response JSON")
+			},
+		},
+		{
+			name:   "search products",
+			method: http.MethodGet,
+			path:   "/products?q=bread",
+			setupServiceMock: func(mockProductsService *mocks.ProductsService) {
+				mockProductsService.On("SearchProducts", mock.Anything, "bread").
+					Return([]*models.Product{{ID: 2, Name: "Bread", Description: "Sourdough loaf", Price: common.NewMoney(3.2)}}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `[{"id":2,"name":"Bread","description":"Sourdough loaf","price":"3.20"}]`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:   "search products - no match",
+			method: http.MethodGet,
+			path:   "/products?q=cheese",
+			setupServiceMock: func(mockProductsService *mocks.ProductsService) {
+				mockProductsService.On("SearchProducts", mock.Anything, "cheese").Return([]*models.Product{}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `[]`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:   "get product",
+			method: http.MethodGet,
+			path:   "/products/2",
+			setupServiceMock: func(mockProductsService *mocks.ProductsService) {
+				mockProductsService.On("GetProduct", mock.Anything, 2).Return(&models.Product{ID: 2, Name: "Bread", Price: common.NewMoney(3.2)}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `{"id":2,"name":"Bread","price":"3.20"}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:   "get product - not found",
+			method: http.MethodGet,
+			path:   "/products/9",
+			setupServiceMock: func(mockProductsService *mocks.ProductsService) {
+				mockProductsService.On("GetProduct", mock.Anything, 9).Return(nil, notFound)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusNotFound, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "product_not_found", "product not found: 9")
+			},
+		},
+		{
+			name:   "get product - invalid id",
+			method: http.MethodGet,
+			path:   "/products/abc",
+			setupServiceMock: func(mockProductsService *mocks.ProductsService) {
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusBadRequest, responseCode, "Unexpected status code")
+			},
+		},
+		{
+			name:   "replace product",
+			method: http.MethodPut,
+			path:   "/products/2",
+			body:   `{"name":"Bread","price":3.5}`,
+			setupServiceMock: func(mockProductsService *mocks.ProductsService) {
+				mockProductsService.On("UpdateProduct", mock.Anything, models.Product{ID: 2, Name: "Bread", Price: common.NewMoney(3.5)}).
+					Return(&models.Product{ID: 2, Name: "Bread", Price: common.NewMoney(3.5)}, nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusOK, responseCode, "Unexpected status code")
+				assert.JSONEq(t, `{"id":2,"name":"Bread","price":"3.50"}`, responseBody, "Unexpected response JSON")
+			},
+		},
+		{
+			name:   "replace product - not found",
+			method: http.MethodPut,
+			path:   "/products/9",
+			body:   `{"name":"Bread","price":3.5}`,
+			setupServiceMock: func(mockProductsService *mocks.ProductsService) {
+				mockProductsService.On("UpdateProduct", mock.Anything, mock.Anything).Return(nil, notFound)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusNotFound, responseCode, "Unexpected status code")
+			},
+		},
+		{
+			name:   "delete product",
+			method: http.MethodDelete,
+			path:   "/products/2",
+			setupServiceMock: func(mockProductsService *mocks.ProductsService) {
+				mockProductsService.On("DeleteProduct", mock.Anything, 2).Return(nil)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusNoContent, responseCode, "Unexpected status code")
+			},
+		},
+		{
+			name:   "delete product - storage error",
+			method: http.MethodDelete,
+			path:   "/products/2",
+			setupServiceMock: func(mockProductsService *mocks.ProductsService) {
+				mockProductsService.On("DeleteProduct", mock.Anything, 2).Return(assert.AnError)
+			},
+			assertFunc: func(t *testing.T, responseBody string, responseCode int) {
+				assert.Equal(t, fiber.StatusInternalServerError, responseCode, "Unexpected status code")
+				assertProblem(t, responseBody, "internal_error", "")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockProductsService := new(mocks.ProductsService)
+			tc.setupServiceMock(mockProductsService)
+
+			user := models.User{ID: 1, Username: "Jane Doe"}
+			app := createTestProductsController(mockProductsService, mocks.ProvideBaseMockContextData(&user))
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			assert.Nil(t, err, "Handler should not return an error")
+
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body)
+
+			tc.assertFunc(t, buf.String(), resp.StatusCode)
+
+			mockProductsService.AssertExpectations(t)
+		})
+	}
+}
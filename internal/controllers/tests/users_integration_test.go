@@ -41,7 +41,12 @@ func TestSignUp(t *testing.T) {
 			},
 			expectedStatus: fiber.StatusBadRequest,
 			expectedResponseBody: map[string]interface{}{
-				"error": "Invalid input",
+				"type":     "about:blank",
+				"title":    "Bad Request",
+				"status":   fiber.StatusBadRequest,
+				"detail":   "invalid request payload",
+				"instance": "/users",
+				"code":     "invalid_payload",
 			},
 		},
 	}
@@ -95,7 +100,12 @@ func TestGetUser(t *testing.T) {
 			},
 			expectedStatus: fiber.StatusUnauthorized,
 			expectedResponseBody: map[string]interface{}{
-				"error": "Authorization token is missing",
+				"type":     "about:blank",
+				"title":    "Unauthorized",
+				"status":   fiber.StatusUnauthorized,
+				"detail":   "authorization token is missing",
+				"instance": "/users/me",
+				"code":     "auth_token_missing",
 			},
 		},
 	}
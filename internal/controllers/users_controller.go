@@ -37,23 +37,17 @@ func (c *UsersController) SignUp(ctx fiber.Ctx) error {
 	userRequest := new(transports.UserCreateRequest)
 
 	if err := ctx.Bind().Body(userRequest); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid input",
-		})
+		return errInvalidPayload
 	}
 
 	user := userRequest.ToUser()
 	if user == nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid input",
-		})
+		return errInvalidPayload
 	}
 
 	newUser, err := c.userService.SignUp(context, *user)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not create user",
-		})
+		return err
 	}
 	return ctx.Status(fiber.StatusCreated).JSON(transports.MapToUserResponse(*newUser))
 }
@@ -66,17 +60,13 @@ func (c *UsersController) GetUser(ctx fiber.Ctx) error {
 
 	userIdValue := ctx.Locals(constants.AuthenticatedUserIdKey)
 	if userIdValue == nil {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Authorization token is missing",
-		})
+		return services.ErrAuthTokenMissing
 	}
 	userId := userIdValue.(int)
 
 	user, err := c.userService.GetUserByID(context, userId)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not retrieve user",
-		})
+		return err
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(transports.MapToUserResponse(*user))
@@ -16,7 +16,7 @@ import (
 )
 
 func createUsersTestApp(usersService services.UsersService, contextData *map[any]any) *fiber.App {
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
 
 	mockData := make(map[any]any)
 	if contextData != nil {
@@ -82,7 +82,12 @@ func TestSignUp(t *testing.T) {
 			mockSetup:      func(service *mocks.UsersService) {},
 			expectedStatus: fiber.StatusBadRequest,
 			expectedResponseBody: map[string]interface{}{
-				"error": "Invalid input",
+				"type":     "about:blank",
+				"title":    "Bad Request",
+				"status":   fiber.StatusBadRequest,
+				"detail":   "invalid request payload",
+				"instance": "/users",
+				"code":     "invalid_payload",
 			},
 		},
 		{
@@ -96,11 +101,16 @@ func TestSignUp(t *testing.T) {
 					"SignUp",
 					mock.Anything,
 					mock.AnythingOfType("models.User"),
-				).Return(nil, assert.AnError)
+				).Return(nil, services.ErrUserStorageFull)
 			},
-			expectedStatus: fiber.StatusInternalServerError,
+			expectedStatus: fiber.StatusServiceUnavailable,
 			expectedResponseBody: map[string]interface{}{
-				"error": "Could not create user",
+				"type":     "about:blank",
+				"title":    "Service Unavailable",
+				"status":   fiber.StatusServiceUnavailable,
+				"detail":   "user storage is full",
+				"instance": "/users",
+				"code":     "user_storage_full",
 			},
 		},
 	}
@@ -179,7 +189,11 @@ func TestGetUser(t *testing.T) {
 			},
 			expectedStatus: fiber.StatusInternalServerError,
 			expectedResponseBody: map[string]interface{}{
-				"error": "Could not retrieve user",
+				"type":     "about:blank",
+				"title":    "Internal Server Error",
+				"status":   fiber.StatusInternalServerError,
+				"instance": "/users/me",
+				"code":     "internal_error",
 			},
 		},
 		{
@@ -188,7 +202,12 @@ func TestGetUser(t *testing.T) {
 			mockSetup:         func(service *mocks.UsersService) {},
 			expectedStatus:    fiber.StatusUnauthorized,
 			expectedResponseBody: map[string]interface{}{
-				"error": "Authorization token is missing",
+				"type":     "about:blank",
+				"title":    "Unauthorized",
+				"status":   fiber.StatusUnauthorized,
+				"detail":   "authorization token is missing",
+				"instance": "/users/me",
+				"code":     "auth_token_missing",
 			},
 		},
 	}
@@ -0,0 +1,15 @@
+package dsmodels
+
+import (
+	"fp_kata/common"
+	"time"
+)
+
+// ExchangeRate converts an amount of one currency to another, from its effective date until the next rate
+// of the same currencies takes effect.
+type ExchangeRate struct {
+	From          common.Currency
+	To            common.Currency
+	Rate          float64
+	EffectiveFrom time.Time
+}
@@ -0,0 +1,15 @@
+package dsmodels
+
+import "time"
+
+// IdempotencyRecord is the outcome of a request sent with an idempotency key.
+// A record without a status code belongs to a request that is still in progress.
+type IdempotencyRecord struct {
+	UserId      int
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+}
@@ -1,16 +1,44 @@
 package dsmodels
 
 import (
+	"fp_kata/common"
 	"time"
 )
 
 type Order struct {
 	ID             int
+	Number         string
 	ProductID      int
 	Quantity       int
-	Price          float64
+	Price          common.Money
+	Currency       common.Currency
 	OrderDate      time.Time
 	Payments       []int
 	UserId         int
 	HasWeightables bool
+	Status         common.OrderStatus
+	Lines          []OrderLine
+	// Conversion is the rate the price converts to the base currency with on the order date,
+	// nil when the order is priced in the base currency.
+	Conversion *ExchangeRate
+}
+
+type OrderLine struct {
+	ProductID       int
+	Quantity        int
+	UnitPrice       common.Money
+	LineTotal       common.Money
+	WeightUnit      common.WeightUnit
+	EstimatedWeight float64
+	ActualWeight    float64
+}
+
+// OrderVersion is an order as it was stored by an insert or an update.
+type OrderVersion struct {
+	// Version counts the versions of an order from 1.
+	Version   int
+	ChangedAt time.Time
+	// ChangedBy is the id of the user who stored the version, 0 when unknown.
+	ChangedBy int
+	Order     Order
 }
@@ -1,11 +1,47 @@
 package dsmodels
 
-import "fp_kata/common"
+import (
+	"fp_kata/common"
+	"time"
+)
 
 type Payment struct {
 	Id      int
-	Amount  float64
+	Amount  common.Money
 	Method  common.PaymentMethod
 	UserId  int
 	OrderId int
+	// PaidAt is the time the payment was stored first.
+	PaidAt time.Time
+	// Details are the details of the method of the payment, nil when none were given.
+	Details *PaymentDetails
+	// Fee is what the payment method charged for the payment.
+	Fee common.Money
+	// Status is the state of the payment with the payment gateway, TransactionId its transaction there.
+	Status        common.PaymentStatus
+	TransactionId string
+	// Refunds are the refunds of the payment, oldest first. They are stored with CreateRefund, not with the payment.
+	Refunds []Refund
+	// Currency is the currency the amount was paid in.
+	Currency common.Currency
+	// Conversion is the rate the amount was converted to the currency of the order with,
+	// nil when it was paid in the currency of the order.
+	Conversion *ExchangeRate
+}
+
+// PaymentDetails are the details of a payment, only the fields of its method are set.
+type PaymentDetails struct {
+	CardBrand   string
+	CardLast4   string
+	IBAN        string
+	PayPalEmail string
+}
+
+// Refund gives back the amount, or a part of it, of a payment.
+type Refund struct {
+	Id        int
+	PaymentId int
+	Amount    common.Money
+	Reason    common.RefundReason
+	CreatedAt time.Time
 }
@@ -0,0 +1,11 @@
+package dsmodels
+
+import "fp_kata/common"
+
+type Product struct {
+	ID          int
+	Name        string
+	Description string
+	Price       common.Money
+	WeightUnit  common.WeightUnit
+}
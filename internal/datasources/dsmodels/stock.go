@@ -0,0 +1,15 @@
+package dsmodels
+
+// Stock is the stock level of a product. Reserved is the quantity held by open orders.
+type Stock struct {
+	ProductID    int
+	OnHand       int
+	Reserved     int
+	ReorderLevel int
+}
+
+// StockItem is a quantity of a product reserved by an order.
+type StockItem struct {
+	ProductID int
+	Quantity  int
+}
@@ -0,0 +1,13 @@
+package datasources
+
+import (
+	"context"
+	"fp_kata/common"
+	"fp_kata/internal/datasources/dsmodels"
+)
+
+type ExchangeRatesDatasource interface {
+	// Rates returns the rates from one currency to the other, ordered by their effective date,
+	// none when the currencies are not exchanged.
+	Rates(ctx context.Context, from common.Currency, to common.Currency) ([]dsmodels.ExchangeRate, error)
+}
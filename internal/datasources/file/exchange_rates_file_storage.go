@@ -0,0 +1,81 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/common/utils"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/dsmodels"
+	"os"
+	"sort"
+)
+
+const compExchangeRatesStorage = "ExchangeRatesDatasource"
+
+// ExchangeRatesFile is the path of the JSON file the exchange rates are kept in, empty has no rates.
+type ExchangeRatesFile string
+
+// NewExchangeRatesFile reads the path of the exchange rates from FP_KATA_EXCHANGE_RATES_FILE.
+func NewExchangeRatesFile() ExchangeRatesFile {
+	return ExchangeRatesFile(os.Getenv("FP_KATA_EXCHANGE_RATES_FILE"))
+}
+
+// currencyPair is the key of the rates from one currency to another.
+type currencyPair struct {
+	from common.Currency
+	to   common.Currency
+}
+
+type fileExchangeRatesStorage struct {
+	rates map[currencyPair][]dsmodels.ExchangeRate
+}
+
+// NewExchangeRatesStorage loads the exchange rates from the file once, the rates are read only.
+// A missing file has no rates, a file that cannot be read or parsed fails.
+func NewExchangeRatesStorage(path ExchangeRatesFile) (datasources.ExchangeRatesDatasource, error) {
+	storage := &fileExchangeRatesStorage{
+		rates: make(map[currencyPair][]dsmodels.ExchangeRate),
+	}
+	if err := storage.load(path); err != nil {
+		return nil, fmt.Errorf("loading the exchange rates %s: %w", path, err)
+	}
+	return storage, nil
+}
+
+func (s *fileExchangeRatesStorage) Rates(ctx context.Context, from common.Currency, to common.Currency) ([]dsmodels.ExchangeRate, error) {
+	utils.LogAction(ctx, compExchangeRatesStorage, "Rates")
+
+	rates := s.rates[currencyPair{from: from, to: to}]
+	return append(make([]dsmodels.ExchangeRate, 0, len(rates)), rates...), nil
+}
+
+// load reads the rates file, a JSON array of exchange rates.
+func (s *fileExchangeRatesStorage) load(path ExchangeRatesFile) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(string(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var rates []dsmodels.ExchangeRate
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return err
+	}
+	for _, rate := range rates {
+		pair := currencyPair{from: rate.From, to: rate.To}
+		s.rates[pair] = append(s.rates[pair], rate)
+	}
+	for _, pairRates := range s.rates {
+		sort.SliceStable(pairRates, func(i, j int) bool {
+			return pairRates[i].EffectiveFrom.Before(pairRates[j].EffectiveFrom)
+		})
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package file
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/pkg/log"
+	zlog "github.com/rs/zerolog/log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeRatesStorage_Rates(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	path := filepath.Join(t.TempDir(), "exchange_rates.json")
+	err := os.WriteFile(path, []byte(`[
+		{"From": "EUR", "To": "USD", "Rate": 1.1, "EffectiveFrom": "2025-03-01T00:00:00Z"},
+		{"From": "EUR", "To": "GBP", "Rate": 0.85, "EffectiveFrom": "2025-01-01T00:00:00Z"},
+		{"From": "EUR", "To": "USD", "Rate": 1.05, "EffectiveFrom": "2025-01-01T00:00:00Z"}
+	]`), 0o600)
+	assert.NoError(t, err, "unexpected error writing the rates file")
+
+	storage, err := NewExchangeRatesStorage(ExchangeRatesFile(path))
+	assert.NoError(t, err, "unexpected error loading the rates file")
+
+	rates, err := storage.Rates(ctx, common.EUR, common.USD)
+	assert.NoError(t, err, "unexpected error reading rates")
+	assert.Equal(t, []dsmodels.ExchangeRate{
+		{From: common.EUR, To: common.USD, Rate: 1.05, EffectiveFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{From: common.EUR, To: common.USD, Rate: 1.1, EffectiveFrom: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}, rates, "expected the rates of the currencies ordered by their effective date")
+
+	rates, err = storage.Rates(ctx, common.USD, common.EUR)
+	assert.NoError(t, err, "unexpected error reading rates")
+	assert.Empty(t, rates, "expected no rates the other way round")
+}
+
+func TestExchangeRatesStorage_NoFile(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	for _, path := range []ExchangeRatesFile{"", ExchangeRatesFile(filepath.Join(t.TempDir(), "missing.json"))} {
+		storage, err := NewExchangeRatesStorage(path)
+		assert.NoError(t, err, "unexpected error without a rates file")
+		rates, err := storage.Rates(ctx, common.EUR, common.USD)
+		assert.NoError(t, err, "unexpected error reading rates")
+		assert.Empty(t, rates, "expected no rates without a rates file")
+	}
+}
+
+func TestExchangeRatesStorage_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exchange_rates.json")
+	err := os.WriteFile(path, []byte(`{"From": "EUR"`), 0o600)
+	assert.NoError(t, err, "unexpected error writing the rates file")
+
+	_, err = NewExchangeRatesStorage(ExchangeRatesFile(path))
+	assert.ErrorContains(t, err, "loading the exchange rates", "expected a rates file that cannot be parsed to fail")
+}
@@ -0,0 +1,92 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"fp_kata/common/utils"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/dsmodels"
+	"sync"
+	"time"
+)
+
+const compIdempotencyStorage = "IdempotencyDatasource"
+
+// idempotencyKey identifies a record, keys are scoped per user.
+type idempotencyKey struct {
+	userId int
+	key    string
+}
+
+type inMemoryIdempotencyStorage struct {
+	mu      sync.Mutex
+	records map[idempotencyKey]dsmodels.IdempotencyRecord
+}
+
+func NewIdempotencyStorage() datasources.IdempotencyDatasource {
+	return &inMemoryIdempotencyStorage{
+		records: make(map[idempotencyKey]dsmodels.IdempotencyRecord),
+	}
+}
+
+func (s *inMemoryIdempotencyStorage) Claim(ctx context.Context, record dsmodels.IdempotencyRecord, now time.Time) (dsmodels.IdempotencyRecord, bool, error) {
+	utils.LogAction(ctx, compIdempotencyStorage, "Claim")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// expired records are dropped whenever a key is claimed, so the storage does not grow with old keys
+	for id, stored := range s.records {
+		if !now.Before(stored.ExpiresAt) {
+			delete(s.records, id)
+		}
+	}
+
+	id := idempotencyKey{userId: record.UserId, key: record.Key}
+	if stored, exists := s.records[id]; exists {
+		return stored, false, nil
+	}
+	s.records[id] = record
+	return record, true, nil
+}
+
+func (s *inMemoryIdempotencyStorage) Read(ctx context.Context, userId int, key string) (dsmodels.IdempotencyRecord, error) {
+	utils.LogAction(ctx, compIdempotencyStorage, "Read")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.records[idempotencyKey{userId: userId, key: key}]
+	if !exists {
+		return dsmodels.IdempotencyRecord{}, fmt.Errorf("%w: %q", datasources.ErrIdempotencyKeyNotFound, key)
+	}
+	return record, nil
+}
+
+func (s *inMemoryIdempotencyStorage) Save(ctx context.Context, record dsmodels.IdempotencyRecord) error {
+	utils.LogAction(ctx, compIdempotencyStorage, "Save")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := idempotencyKey{userId: record.UserId, key: record.Key}
+	if _, exists := s.records[id]; !exists {
+		return fmt.Errorf("%w: %q", datasources.ErrIdempotencyKeyNotFound, record.Key)
+	}
+	s.records[id] = record
+	return nil
+}
+
+func (s *inMemoryIdempotencyStorage) Delete(ctx context.Context, userId int, key string) error {
+	utils.LogAction(ctx, compIdempotencyStorage, "Delete")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := idempotencyKey{userId: userId, key: key}
+	if _, exists := s.records[id]; !exists {
+		return fmt.Errorf("%w: %q", datasources.ErrIdempotencyKeyNotFound, key)
+	}
+	delete(s.records, id)
+	return nil
+}
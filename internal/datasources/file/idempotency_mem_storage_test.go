@@ -0,0 +1,122 @@
+package file
+
+import (
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/pkg/log"
+	zlog "github.com/rs/zerolog/log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyStorage_Claim(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	stored := dsmodels.IdempotencyRecord{UserId: 1, Key: "key-1", Fingerprint: "first", StatusCode: 201, ExpiresAt: now.Add(time.Hour)}
+
+	tests := []struct {
+		name            string
+		record          dsmodels.IdempotencyRecord
+		now             time.Time
+		expectedClaimed bool
+		expected        dsmodels.IdempotencyRecord
+	}{
+		{
+			name:            "new key is claimed",
+			record:          dsmodels.IdempotencyRecord{UserId: 1, Key: "key-2", Fingerprint: "second", ExpiresAt: now.Add(time.Hour)},
+			now:             now,
+			expectedClaimed: true,
+			expected:        dsmodels.IdempotencyRecord{UserId: 1, Key: "key-2", Fingerprint: "second", ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name:     "stored key is returned",
+			record:   dsmodels.IdempotencyRecord{UserId: 1, Key: "key-1", Fingerprint: "second", ExpiresAt: now.Add(time.Hour)},
+			now:      now.Add(59 * time.Minute),
+			expected: stored,
+		},
+		{
+			name:            "keys are scoped per user",
+			record:          dsmodels.IdempotencyRecord{UserId: 2, Key: "key-1", Fingerprint: "second", ExpiresAt: now.Add(time.Hour)},
+			now:             now,
+			expectedClaimed: true,
+			expected:        dsmodels.IdempotencyRecord{UserId: 2, Key: "key-1", Fingerprint: "second", ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name:            "expired key is claimed again",
+			record:          dsmodels.IdempotencyRecord{UserId: 1, Key: "key-1", Fingerprint: "second", ExpiresAt: now.Add(2 * time.Hour)},
+			now:             now.Add(time.Hour),
+			expectedClaimed: true,
+			expected:        dsmodels.IdempotencyRecord{UserId: 1, Key: "key-1", Fingerprint: "second", ExpiresAt: now.Add(2 * time.Hour)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := NewIdempotencyStorage()
+			_, claimed, err := storage.Claim(ctx, stored, now)
+			assert.NoError(t, err, "unexpected error storing the record")
+			assert.True(t, claimed, "expected the stored record to be claimed")
+
+			record, claimed, err := storage.Claim(ctx, test.record, test.now)
+
+			assert.NoError(t, err, "unexpected error claiming the key")
+			assert.Equal(t, test.expectedClaimed, claimed, "unexpected claim")
+			assert.Equal(t, test.expected, record, "unexpected record")
+		})
+	}
+}
+
+func TestIdempotencyStorage_ReadSaveDelete(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	storage := NewIdempotencyStorage()
+
+	_, err := storage.Read(ctx, 1, "key-1")
+	assert.ErrorIs(t, err, datasources.ErrIdempotencyKeyNotFound, "expected an unknown key")
+	assert.ErrorIs(t, storage.Save(ctx, dsmodels.IdempotencyRecord{UserId: 1, Key: "key-1"}), datasources.ErrIdempotencyKeyNotFound, "expected an unknown key")
+
+	record := dsmodels.IdempotencyRecord{UserId: 1, Key: "key-1", Fingerprint: "first", ExpiresAt: now.Add(time.Hour)}
+	_, _, err = storage.Claim(ctx, record, now)
+	assert.NoError(t, err, "unexpected error claiming the key")
+
+	record.StatusCode = 201
+	record.Body = []byte(`{"id":1}`)
+	assert.NoError(t, storage.Save(ctx, record), "unexpected error saving the record")
+	read, err := storage.Read(ctx, 1, "key-1")
+	assert.NoError(t, err, "unexpected error reading the record")
+	assert.Equal(t, record, read, "expected the saved record")
+
+	assert.NoError(t, storage.Delete(ctx, 1, "key-1"), "unexpected error deleting the record")
+	assert.ErrorIs(t, storage.Delete(ctx, 1, "key-1"), datasources.ErrIdempotencyKeyNotFound, "expected the record to be deleted")
+}
+
+func TestIdempotencyStorage_ConcurrentClaims(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	storage := NewIdempotencyStorage()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	claims := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			record := dsmodels.IdempotencyRecord{UserId: 1, Key: "key-1", ExpiresAt: now.Add(time.Hour)}
+			if _, claimed, err := storage.Claim(ctx, record, now); err == nil && claimed {
+				mu.Lock()
+				claims++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, claims, "expected the key to be claimed exactly once")
+}
@@ -0,0 +1,173 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"fp_kata/common/utils"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/dsmodels"
+	"sort"
+	"sync"
+)
+
+const compInventoryStorage = "InventoryDatasource"
+
+type inMemoryInventoryStorage struct {
+	mu           sync.Mutex
+	stock        map[int]dsmodels.Stock
+	reservations map[int][]dsmodels.StockItem
+}
+
+func NewInventoryStorage() datasources.InventoryDatasource {
+	return &inMemoryInventoryStorage{
+		stock:        make(map[int]dsmodels.Stock),
+		reservations: make(map[int][]dsmodels.StockItem),
+	}
+}
+
+func (s *inMemoryInventoryStorage) Read(ctx context.Context, productId int) (dsmodels.Stock, error) {
+	utils.LogAction(ctx, compInventoryStorage, "Read")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stockOf(productId), nil
+}
+
+func (s *inMemoryInventoryStorage) All(ctx context.Context) ([]dsmodels.Stock, error) {
+	utils.LogAction(ctx, compInventoryStorage, "All")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := make([]dsmodels.Stock, 0, len(s.stock))
+	for _, stock := range s.stock {
+		all = append(all, stock)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ProductID < all[j].ProductID
+	})
+	return all, nil
+}
+
+func (s *inMemoryInventoryStorage) Save(ctx context.Context, stock dsmodels.Stock) (dsmodels.Stock, error) {
+	utils.LogAction(ctx, compInventoryStorage, "Save")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.stockOf(stock.ProductID)
+	if stock.OnHand < stored.Reserved {
+		return dsmodels.Stock{}, fmt.Errorf("%w: product %d has %d reserved, %d on hand requested",
+			datasources.ErrInsufficientStock, stock.ProductID, stored.Reserved, stock.OnHand)
+	}
+	stored.OnHand = stock.OnHand
+	stored.ReorderLevel = stock.ReorderLevel
+	s.stock[stock.ProductID] = stored
+	return stored, nil
+}
+
+func (s *inMemoryInventoryStorage) Adjust(ctx context.Context, productId int, delta int) (dsmodels.Stock, error) {
+	utils.LogAction(ctx, compInventoryStorage, "Adjust")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.stockOf(productId)
+	if stored.OnHand+delta < stored.Reserved {
+		return dsmodels.Stock{}, fmt.Errorf("%w: product %d has %d available, %d requested",
+			datasources.ErrInsufficientStock, productId, stored.OnHand-stored.Reserved, -delta)
+	}
+	stored.OnHand += delta
+	s.stock[productId] = stored
+	return stored, nil
+}
+
+func (s *inMemoryInventoryStorage) Reserve(ctx context.Context, orderId int, items []dsmodels.StockItem) ([]dsmodels.StockItem, error) {
+	utils.LogAction(ctx, compInventoryStorage, "Reserve")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.reservations[orderId]
+	// the quantity the order already holds is available to its new reservation
+	requested := make(map[int]int)
+	for _, item := range items {
+		requested[item.ProductID] += item.Quantity
+	}
+	held := make(map[int]int)
+	for _, item := range previous {
+		held[item.ProductID] += item.Quantity
+	}
+
+	productIds := make([]int, 0, len(requested))
+	for productId := range requested {
+		productIds = append(productIds, productId)
+	}
+	sort.Ints(productIds)
+	for _, productId := range productIds {
+		stock := s.stockOf(productId)
+		available := stock.OnHand - stock.Reserved + held[productId]
+		if requested[productId] > available {
+			return nil, fmt.Errorf("%w: product %d has %d available, %d requested",
+				datasources.ErrInsufficientStock, productId, available, requested[productId])
+		}
+	}
+
+	s.release(previous)
+	for _, item := range items {
+		stock := s.stockOf(item.ProductID)
+		stock.Reserved += item.Quantity
+		s.stock[item.ProductID] = stock
+	}
+	if len(items) > 0 {
+		s.reservations[orderId] = append([]dsmodels.StockItem(nil), items...)
+	} else {
+		delete(s.reservations, orderId)
+	}
+	return previous, nil
+}
+
+func (s *inMemoryInventoryStorage) Release(ctx context.Context, orderId int) error {
+	utils.LogAction(ctx, compInventoryStorage, "Release")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.release(s.reservations[orderId])
+	delete(s.reservations, orderId)
+	return nil
+}
+
+func (s *inMemoryInventoryStorage) Commit(ctx context.Context, orderId int) error {
+	utils.LogAction(ctx, compInventoryStorage, "Commit")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range s.reservations[orderId] {
+		stock := s.stockOf(item.ProductID)
+		stock.Reserved -= item.Quantity
+		stock.OnHand -= item.Quantity
+		s.stock[item.ProductID] = stock
+	}
+	delete(s.reservations, orderId)
+	return nil
+}
+
+// release returns reserved items to the available stock.
+func (s *inMemoryInventoryStorage) release(items []dsmodels.StockItem) {
+	for _, item := range items {
+		stock := s.stockOf(item.ProductID)
+		stock.Reserved -= item.Quantity
+		s.stock[item.ProductID] = stock
+	}
+}
+
+func (s *inMemoryInventoryStorage) stockOf(productId int) dsmodels.Stock {
+	stock, exists := s.stock[productId]
+	if !exists {
+		return dsmodels.Stock{ProductID: productId}
+	}
+	return stock
+}
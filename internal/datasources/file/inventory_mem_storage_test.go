@@ -0,0 +1,163 @@
+package file
+
+import (
+	"context"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/pkg/log"
+	zlog "github.com/rs/zerolog/log"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func initTestInventoryStorage(t *testing.T, stocks ...dsmodels.Stock) (datasources.InventoryDatasource, context.Context) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	storage := NewInventoryStorage()
+	for _, stock := range stocks {
+		_, err := storage.Save(ctx, stock)
+		assert.NoError(t, err, "unexpected error saving stock")
+	}
+	return storage, ctx
+}
+
+func TestInventoryStorage_Reserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []dsmodels.StockItem
+		items    []dsmodels.StockItem
+		wantErr  string
+		expected map[int]int
+	}{
+		{
+			name:     "all items reserved",
+			items:    []dsmodels.StockItem{{ProductID: 1, Quantity: 3}, {ProductID: 2, Quantity: 1}},
+			expected: map[int]int{1: 3, 2: 1},
+		},
+		{
+			name:     "quantities of a product are added up",
+			items:    []dsmodels.StockItem{{ProductID: 1, Quantity: 3}, {ProductID: 1, Quantity: 2}},
+			expected: map[int]int{1: 5, 2: 0},
+		},
+		{
+			name:     "nothing reserved when one item oversells",
+			items:    []dsmodels.StockItem{{ProductID: 1, Quantity: 3}, {ProductID: 2, Quantity: 3}},
+			wantErr:  "insufficient stock: product 2 has 2 available, 3 requested",
+			expected: map[int]int{1: 0, 2: 0},
+		},
+		{
+			name:     "never stocked product oversells",
+			items:    []dsmodels.StockItem{{ProductID: 9, Quantity: 1}},
+			wantErr:  "insufficient stock: product 9 has 0 available, 1 requested",
+			expected: map[int]int{1: 0, 2: 0},
+		},
+		{
+			name:     "new reservation replaces the previous one",
+			previous: []dsmodels.StockItem{{ProductID: 1, Quantity: 4}, {ProductID: 2, Quantity: 2}},
+			items:    []dsmodels.StockItem{{ProductID: 1, Quantity: 5}},
+			expected: map[int]int{1: 5, 2: 0},
+		},
+		{
+			name:     "failed replacement keeps the previous reservation",
+			previous: []dsmodels.StockItem{{ProductID: 1, Quantity: 4}},
+			items:    []dsmodels.StockItem{{ProductID: 1, Quantity: 6}},
+			wantErr:  "insufficient stock: product 1 has 5 available, 6 requested",
+			expected: map[int]int{1: 4, 2: 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage, ctx := initTestInventoryStorage(t, dsmodels.Stock{ProductID: 1, OnHand: 5}, dsmodels.Stock{ProductID: 2, OnHand: 2})
+			if test.previous != nil {
+				_, err := storage.Reserve(ctx, 42, test.previous)
+				assert.NoError(t, err, "unexpected error reserving the previous items")
+			}
+
+			previous, err := storage.Reserve(ctx, 42, test.items)
+			if test.wantErr != "" {
+				assert.ErrorIs(t, err, datasources.ErrInsufficientStock, "expected an insufficient stock error")
+				assert.EqualError(t, err, test.wantErr, "unexpected error message")
+			} else {
+				assert.NoError(t, err, "unexpected error reserving stock")
+				assert.Equal(t, test.previous, previous, "expected the previous reservation")
+			}
+
+			for productId, reserved := range test.expected {
+				stock, _ := storage.Read(ctx, productId)
+				assert.Equal(t, reserved, stock.Reserved, "reserved quantity of product %d", productId)
+			}
+		})
+	}
+}
+
+func TestInventoryStorage_ReleaseAndCommit(t *testing.T) {
+	storage, ctx := initTestInventoryStorage(t, dsmodels.Stock{ProductID: 1, OnHand: 5})
+
+	_, err := storage.Reserve(ctx, 1, []dsmodels.StockItem{{ProductID: 1, Quantity: 2}})
+	assert.NoError(t, err, "unexpected error reserving stock")
+	_, err = storage.Reserve(ctx, 2, []dsmodels.StockItem{{ProductID: 1, Quantity: 3}})
+	assert.NoError(t, err, "unexpected error reserving stock")
+
+	assert.NoError(t, storage.Release(ctx, 1), "unexpected error releasing stock")
+	stock, _ := storage.Read(ctx, 1)
+	assert.Equal(t, dsmodels.Stock{ProductID: 1, OnHand: 5, Reserved: 3}, stock, "expected the released stock to be available")
+
+	assert.NoError(t, storage.Commit(ctx, 2), "unexpected error committing stock")
+	stock, _ = storage.Read(ctx, 1)
+	assert.Equal(t, dsmodels.Stock{ProductID: 1, OnHand: 2}, stock, "expected the committed stock to be off hand")
+
+	assert.NoError(t, storage.Release(ctx, 2), "releasing a committed order should do nothing")
+	stock, _ = storage.Read(ctx, 1)
+	assert.Equal(t, dsmodels.Stock{ProductID: 1, OnHand: 2}, stock, "expected the stock to be unchanged")
+}
+
+func TestInventoryStorage_SaveAndAdjust(t *testing.T) {
+	storage, ctx := initTestInventoryStorage(t, dsmodels.Stock{ProductID: 1, OnHand: 5, ReorderLevel: 2})
+	_, err := storage.Reserve(ctx, 1, []dsmodels.StockItem{{ProductID: 1, Quantity: 3}})
+	assert.NoError(t, err, "unexpected error reserving stock")
+
+	stock, err := storage.Adjust(ctx, 1, 4)
+	assert.NoError(t, err, "unexpected error adding stock")
+	assert.Equal(t, dsmodels.Stock{ProductID: 1, OnHand: 9, Reserved: 3, ReorderLevel: 2}, stock, "expected the stock to be added")
+
+	_, err = storage.Adjust(ctx, 1, -7)
+	assert.EqualError(t, err, "insufficient stock: product 1 has 6 available, 7 requested", "expected reserved stock to stay on hand")
+
+	_, err = storage.Save(ctx, dsmodels.Stock{ProductID: 1, OnHand: 2})
+	assert.ErrorIs(t, err, datasources.ErrInsufficientStock, "expected reserved stock to stay on hand")
+
+	stock, err = storage.Save(ctx, dsmodels.Stock{ProductID: 1, OnHand: 3, Reserved: 99, ReorderLevel: 1})
+	assert.NoError(t, err, "unexpected error saving stock")
+	assert.Equal(t, dsmodels.Stock{ProductID: 1, OnHand: 3, Reserved: 3, ReorderLevel: 1}, stock, "expected the reserved quantity to be kept")
+
+	all, err := storage.All(ctx)
+	assert.NoError(t, err, "unexpected error listing stock")
+	assert.Equal(t, []dsmodels.Stock{stock}, all, "expected the stocked products")
+}
+
+func TestInventoryStorage_ConcurrentReservations(t *testing.T) {
+	storage, ctx := initTestInventoryStorage(t, dsmodels.Stock{ProductID: 1, OnHand: 10})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+	for orderId := 1; orderId <= 50; orderId++ {
+		wg.Add(1)
+		go func(orderId int) {
+			defer wg.Done()
+			if _, err := storage.Reserve(ctx, orderId, []dsmodels.StockItem{{ProductID: 1, Quantity: 1}}); err == nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}(orderId)
+	}
+	wg.Wait()
+
+	stock, _ := storage.Read(ctx, 1)
+	assert.Equal(t, 10, reserved, "expected exactly the stock on hand to be reserved")
+	assert.Equal(t, 10, stock.Reserved, "expected the stock never to be oversold")
+}
@@ -1,17 +1,27 @@
 package file
 
 import (
+	"cmp"
 	"context"
-	"errors"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"fp_kata/common"
 	"fp_kata/common/utils"
 	"fp_kata/internal/datasources"
 	"fp_kata/internal/datasources/dsmodels"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
 )
 
 const compOrdersStorage = "OrdersDatasource"
 
 type inMemoryOrdersStorage struct {
-	orders map[int]dsmodels.Order
+	orders   map[int]dsmodels.Order
+	versions map[int][]dsmodels.OrderVersion
+	now      func() time.Time
 }
 
 func (s *inMemoryOrdersStorage) GetOrder(ctx context.Context, orderID int) (*dsmodels.Order, error) {
@@ -19,7 +29,7 @@ func (s *inMemoryOrdersStorage) GetOrder(ctx context.Context, orderID int) (*dsm
 
 	order, exists := s.orders[orderID]
 	if !exists {
-		return nil, errors.New("order not found")
+		return nil, datasources.ErrOrderNotFound
 	}
 	return &order, nil
 }
@@ -27,48 +37,211 @@ func (s *inMemoryOrdersStorage) GetOrder(ctx context.Context, orderID int) (*dsm
 func (s *inMemoryOrdersStorage) GetAllOrdersForUser(ctx context.Context, userID int) ([]dsmodels.Order, error) {
 	utils.LogAction(ctx, compOrdersStorage, "GetAllOrdersForUser")
 
+	return s.ordersForUser(userID, nil, nil), nil
+}
+
+func (s *inMemoryOrdersStorage) QueryOrdersForUser(ctx context.Context, userID int, query datasources.OrdersQuery) (*datasources.OrdersPage, error) {
+	utils.LogAction(ctx, compOrdersStorage, "QueryOrdersForUser")
+
+	userOrders := s.ordersForUser(userID, query.Filter, query.Sort)
+	page := &datasources.OrdersPage{Total: len(userOrders)}
+
+	start := 0
+	if query.Cursor != "" {
+		last, err := decodeCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(userOrders), func(i int) bool {
+			return compareOrders(userOrders[i], last, query.Sort) > 0
+		})
+	}
+
+	end := len(userOrders)
+	if query.Limit > 0 && start+query.Limit < end {
+		end = start + query.Limit
+		page.NextCursor = encodeCursor(userOrders[end-1], query.Sort)
+	}
+
+	page.Orders = userOrders[start:end]
+	return page, nil
+}
+
+// ordersForUser returns the orders of the user selected by the filter in the given sort order.
+func (s *inMemoryOrdersStorage) ordersForUser(userID int, filter func(order dsmodels.Order) bool, sorts []datasources.OrderSort) []dsmodels.Order {
 	userOrders := make([]dsmodels.Order, 0)
 	for _, order := range s.orders {
-		if order.UserId == userID {
+		if order.UserId == userID && (filter == nil || filter(order)) {
 			userOrders = append(userOrders, order)
 		}
 	}
-	return userOrders, nil
+	sort.Slice(userOrders, func(i, j int) bool {
+		return compareOrders(userOrders[i], userOrders[j], sorts) < 0
+	})
+	return userOrders
+}
+
+// compareOrders compares two orders by the sort fields, falling back to the order id.
+func compareOrders(a, b dsmodels.Order, sorts []datasources.OrderSort) int {
+	for _, orderSort := range sorts {
+		var result int
+		switch orderSort.Field {
+		case datasources.SortById:
+			result = cmp.Compare(a.ID, b.ID)
+		case datasources.SortByOrderDate:
+			result = a.OrderDate.Compare(b.OrderDate)
+		case datasources.SortByPrice:
+			result = priceSortKey(a).Compare(priceSortKey(b))
+		case datasources.SortByQuantity:
+			result = cmp.Compare(a.Quantity, b.Quantity)
+		case datasources.SortByProductId:
+			result = cmp.Compare(productSortKey(a), productSortKey(b))
+		case datasources.SortByStatus:
+			result = cmp.Compare(a.Status, b.Status)
+		}
+		if orderSort.Descending {
+			result = -result
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// priceSortKey is the price an order sorts by, its price converted to the base currency.
+func priceSortKey(order dsmodels.Order) common.Money {
+	if order.Conversion == nil {
+		return order.Price
+	}
+	return order.Price.Mul(order.Conversion.Rate, common.HalfUp)
+}
+
+// productSortKey is the product an order sorts by: the product of a single-line order,
+// the lowest product id of the lines of an order with several lines.
+func productSortKey(order dsmodels.Order) int {
+	if len(order.Lines) < 2 {
+		return order.ProductID
+	}
+	key := order.Lines[0].ProductID
+	for _, line := range order.Lines[1:] {
+		key = min(key, line.ProductID)
+	}
+	return key
+}
+
+// cursor holds the sort keys of the last order of a page, the listing continues after it.
+type cursor struct {
+	Sort      string             `json:"sort"`
+	ID        int                `json:"id"`
+	OrderDate time.Time          `json:"order_date"`
+	Price     common.Money       `json:"price"`
+	Quantity  int                `json:"quantity"`
+	ProductID int                `json:"product_id"`
+	Status    common.OrderStatus `json:"status"`
+}
+
+func encodeCursor(last dsmodels.Order, sorts []datasources.OrderSort) string {
+	data, _ := json.Marshal(cursor{
+		Sort:      datasources.FormatOrderSort(sorts),
+		ID:        last.ID,
+		OrderDate: last.OrderDate,
+		Price:     priceSortKey(last),
+		Quantity:  last.Quantity,
+		ProductID: productSortKey(last),
+		Status:    last.Status,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor restores the last order of the previous page; a cursor is only valid for the sort it was created with.
+func decodeCursor(encoded string, sorts []datasources.OrderSort) (dsmodels.Order, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return dsmodels.Order{}, datasources.ErrInvalidCursor
+	}
+	var last cursor
+	if err := json.Unmarshal(data, &last); err != nil {
+		return dsmodels.Order{}, datasources.ErrInvalidCursor
+	}
+	if last.Sort != datasources.FormatOrderSort(sorts) {
+		return dsmodels.Order{}, fmt.Errorf("%w: cursor was created for another sort", datasources.ErrInvalidCursor)
+	}
+	return dsmodels.Order{
+		ID:        last.ID,
+		OrderDate: last.OrderDate,
+		Price:     last.Price,
+		Quantity:  last.Quantity,
+		ProductID: last.ProductID,
+		Status:    last.Status,
+	}, nil
 }
 
 func (s *inMemoryOrdersStorage) DeleteOrder(ctx context.Context, orderID int) error {
 	utils.LogAction(ctx, compOrdersStorage, "DeleteOrder")
 
 	if _, exists := s.orders[orderID]; !exists {
-		return errors.New("order not found")
+		return datasources.ErrOrderNotFound
 	}
 	delete(s.orders, orderID)
 	return nil
 }
 
-func (s *inMemoryOrdersStorage) UpdateOrder(ctx context.Context, order dsmodels.Order) (*dsmodels.Order, error) {
+func (s *inMemoryOrdersStorage) UpdateOrder(ctx context.Context, order dsmodels.Order, changedBy int) (*dsmodels.Order, error) {
 	utils.LogAction(ctx, compOrdersStorage, "UpdateOrder")
 
 	_, exists := s.orders[order.ID]
 	if !exists {
-		return nil, errors.New("order not found")
+		return nil, datasources.ErrOrderNotFound
 	}
 	s.orders[order.ID] = order
+	s.storeVersion(order, changedBy)
 	return &order, nil
 }
 
-func (s *inMemoryOrdersStorage) InsertOrder(ctx context.Context, order dsmodels.Order) (*dsmodels.Order, error) {
+func (s *inMemoryOrdersStorage) InsertOrder(ctx context.Context, order dsmodels.Order, changedBy int) (*dsmodels.Order, error) {
 	utils.LogAction(ctx, compOrdersStorage, "InsertOrder")
 
 	if _, exists := s.orders[order.ID]; exists {
-		return nil, errors.New("order already exists")
+		return nil, datasources.ErrOrderExists
 	}
 	s.orders[order.ID] = order
+	s.storeVersion(order, changedBy)
 	return &order, nil
 }
 
+func (s *inMemoryOrdersStorage) GetOrderVersions(ctx context.Context, orderID int) ([]dsmodels.OrderVersion, error) {
+	utils.LogAction(ctx, compOrdersStorage, "GetOrderVersions")
+
+	versions, exists := s.versions[orderID]
+	if !exists {
+		return nil, datasources.ErrOrderNotFound
+	}
+	return slices.Clone(versions), nil
+}
+
+// storeVersion keeps the order as its next version, attributed to the user who changed it.
+func (s *inMemoryOrdersStorage) storeVersion(order dsmodels.Order, changedBy int) {
+	versions := s.versions[order.ID]
+	if len(versions) > 0 && reflect.DeepEqual(versions[len(versions)-1].Order, order) {
+		return
+	}
+
+	// the slices of the order are copied, so later changes by the caller do not rewrite the history
+	order.Payments = slices.Clone(order.Payments)
+	order.Lines = slices.Clone(order.Lines)
+	s.versions[order.ID] = append(versions, dsmodels.OrderVersion{
+		Version:   len(versions) + 1,
+		ChangedAt: s.now(),
+		ChangedBy: changedBy,
+		Order:     order,
+	})
+}
+
 func NewOrdersStorage() datasources.OrdersDatasource {
 	return &inMemoryOrdersStorage{
-		orders: make(map[int]dsmodels.Order),
+		orders:   make(map[int]dsmodels.Order),
+		versions: make(map[int][]dsmodels.OrderVersion),
+		now:      time.Now,
 	}
 }
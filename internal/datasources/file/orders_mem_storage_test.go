@@ -2,11 +2,13 @@ package file
 
 import (
 	"context"
+	"fp_kata/common"
 	"fp_kata/internal/datasources"
 	"fp_kata/internal/datasources/dsmodels"
 	"fp_kata/pkg/log"
 	zlog "github.com/rs/zerolog/log"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,7 +17,9 @@ func initTestOrdersStorage(store map[int]dsmodels.Order) (*inMemoryOrdersStorage
 	log.InitLogger()
 	ctx := log.NewBackgroundContext(&zlog.Logger)
 	return &inMemoryOrdersStorage{
-		orders: store,
+		orders:   store,
+		versions: make(map[int][]dsmodels.OrderVersion),
+		now:      time.Now,
 	}, ctx
 }
 
@@ -128,6 +132,148 @@ func TestGetAllOrdersForUser(t *testing.T) {
 	}
 }
 
+func TestQueryOrdersForUser(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 2, d, 0, 0, 0, 0, time.UTC) }
+	initialOrders := map[int]dsmodels.Order{
+		1: {ID: 1, UserId: 123, Price: common.NewMoney(10), OrderDate: day(1)},
+		2: {ID: 2, UserId: 123, Price: common.NewMoney(30), OrderDate: day(3)},
+		3: {ID: 3, UserId: 123, Price: common.NewMoney(20), OrderDate: day(3)},
+		4: {ID: 4, UserId: 123, Price: common.NewMoney(40), OrderDate: day(2)},
+		5: {ID: 5, UserId: 456, Price: common.NewMoney(50), OrderDate: day(4)},
+	}
+	byDateThenPrice := []datasources.OrderSort{
+		{Field: datasources.SortByOrderDate, Descending: true},
+		{Field: datasources.SortByPrice},
+	}
+
+	ids := func(orders []dsmodels.Order) []int {
+		result := make([]int, len(orders))
+		for i, order := range orders {
+			result[i] = order.ID
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		query    datasources.OrdersQuery
+		validate func(*testing.T, *datasources.OrdersPage, error)
+	}{
+		{
+			name:  "AllOrdersSortedById",
+			query: datasources.OrdersQuery{},
+			validate: func(t *testing.T, page *datasources.OrdersPage, err error) {
+				assert.NoError(t, err, "unexpected error")
+				assert.Equal(t, []int{1, 2, 3, 4}, ids(page.Orders), "unexpected orders")
+				assert.Equal(t, 4, page.Total, "unexpected total")
+				assert.Empty(t, page.NextCursor, "expected no next cursor")
+			},
+		},
+		{
+			name:  "SortedByMultipleFields",
+			query: datasources.OrdersQuery{Sort: byDateThenPrice},
+			validate: func(t *testing.T, page *datasources.OrdersPage, err error) {
+				assert.NoError(t, err, "unexpected error")
+				assert.Equal(t, []int{3, 2, 4, 1}, ids(page.Orders), "unexpected orders")
+			},
+		},
+		{
+			name:  "FirstPage",
+			query: datasources.OrdersQuery{Sort: byDateThenPrice, Limit: 3},
+			validate: func(t *testing.T, page *datasources.OrdersPage, err error) {
+				assert.NoError(t, err, "unexpected error")
+				assert.Equal(t, []int{3, 2, 4}, ids(page.Orders), "unexpected orders")
+				assert.Equal(t, 4, page.Total, "unexpected total")
+				assert.NotEmpty(t, page.NextCursor, "expected a next cursor")
+			},
+		},
+		{
+			name: "Filtered",
+			query: datasources.OrdersQuery{
+				Filter: func(order dsmodels.Order) bool { return order.Price.Compare(common.NewMoney(15)) > 0 },
+				Limit:  1,
+			},
+			validate: func(t *testing.T, page *datasources.OrdersPage, err error) {
+				assert.NoError(t, err, "unexpected error")
+				assert.Equal(t, []int{2}, ids(page.Orders), "unexpected orders")
+				assert.Equal(t, 3, page.Total, "unexpected total")
+			},
+		},
+		{
+			name:  "InvalidCursor",
+			query: datasources.OrdersQuery{Cursor: "not a cursor"},
+			validate: func(t *testing.T, page *datasources.OrdersPage, err error) {
+				assert.ErrorIs(t, err, datasources.ErrInvalidCursor, "expected invalid cursor error")
+				assert.Nil(t, page, "expected no page")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			storage, ctx := initTestOrdersStorage(initialOrders)
+			page, err := storage.QueryOrdersForUser(ctx, 123, tc.query)
+			tc.validate(t, page, err)
+		})
+	}
+
+	t.Run("PagesFollowCursor", func(t *testing.T) {
+		storage, ctx := initTestOrdersStorage(initialOrders)
+		query := datasources.OrdersQuery{Sort: byDateThenPrice, Limit: 2}
+
+		first, err := storage.QueryOrdersForUser(ctx, 123, query)
+		assert.NoError(t, err, "unexpected error on first page")
+		assert.Equal(t, []int{3, 2}, ids(first.Orders), "unexpected orders on first page")
+
+		query.Cursor = first.NextCursor
+		second, err := storage.QueryOrdersForUser(ctx, 123, query)
+		assert.NoError(t, err, "unexpected error on second page")
+		assert.Equal(t, []int{4, 1}, ids(second.Orders), "unexpected orders on second page")
+		assert.Empty(t, second.NextCursor, "expected no cursor after the last page")
+
+		query.Sort = nil
+		_, err = storage.QueryOrdersForUser(ctx, 123, query)
+		assert.ErrorIs(t, err, datasources.ErrInvalidCursor, "expected cursor to be rejected for another sort")
+	})
+	t.Run("PricesSortInBaseCurrency", func(t *testing.T) {
+		storage, ctx := initTestOrdersStorage(map[int]dsmodels.Order{
+			1: {ID: 1, UserId: 123, Price: common.NewMoney(30), Currency: common.EUR},
+			2: {ID: 2, UserId: 123, Price: common.NewMoney(40), Currency: common.USD,
+				Conversion: &dsmodels.ExchangeRate{From: common.USD, To: common.EUR, Rate: 0.5}},
+			3: {ID: 3, UserId: 123, Price: common.NewMoney(25), Currency: common.EUR},
+		})
+		query := datasources.OrdersQuery{Sort: []datasources.OrderSort{{Field: datasources.SortByPrice}}, Limit: 2}
+
+		first, err := storage.QueryOrdersForUser(ctx, 123, query)
+		assert.NoError(t, err, "unexpected error on first page")
+		assert.Equal(t, []int{2, 3}, ids(first.Orders), "expected prices in other currencies to sort by their converted price")
+
+		query.Cursor = first.NextCursor
+		second, err := storage.QueryOrdersForUser(ctx, 123, query)
+		assert.NoError(t, err, "unexpected error on second page")
+		assert.Equal(t, []int{1}, ids(second.Orders), "unexpected orders on second page")
+	})
+
+	t.Run("MultiLineOrdersSortByLowestProduct", func(t *testing.T) {
+		storage, ctx := initTestOrdersStorage(map[int]dsmodels.Order{
+			1: {ID: 1, UserId: 123, ProductID: 3, Lines: []dsmodels.OrderLine{{ProductID: 3}}},
+			2: {ID: 2, UserId: 123, Lines: []dsmodels.OrderLine{{ProductID: 5}, {ProductID: 2}}},
+			3: {ID: 3, UserId: 123, ProductID: 4},
+			4: {ID: 4, UserId: 123, Lines: []dsmodels.OrderLine{{ProductID: 6}, {ProductID: 7}}},
+		})
+		query := datasources.OrdersQuery{Sort: []datasources.OrderSort{{Field: datasources.SortByProductId}}, Limit: 2}
+
+		first, err := storage.QueryOrdersForUser(ctx, 123, query)
+		assert.NoError(t, err, "unexpected error on first page")
+		assert.Equal(t, []int{2, 1}, ids(first.Orders), "expected multi-line orders to sort by their lowest product")
+
+		query.Cursor = first.NextCursor
+		second, err := storage.QueryOrdersForUser(ctx, 123, query)
+		assert.NoError(t, err, "unexpected error on second page")
+		assert.Equal(t, []int{3, 4}, ids(second.Orders), "unexpected orders on second page")
+	})
+}
+
 func TestDeleteOrder(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -234,7 +380,7 @@ func TestUpdateOrder(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			storage, ctx := initTestOrdersStorage(tc.initialOrders)
 
-			order, err := storage.UpdateOrder(ctx, tc.updateOrder)
+			order, err := storage.UpdateOrder(ctx, tc.updateOrder, 123)
 			tc.validate(t, order, err)
 		})
 	}
@@ -297,12 +443,46 @@ func TestInsertOrder(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			storage, ctx := initTestOrdersStorage(tc.initialOrders)
-			insertedOrder, err := storage.InsertOrder(ctx, tc.orderToInsert)
+			insertedOrder, err := storage.InsertOrder(ctx, tc.orderToInsert, 123)
 			tc.validate(t, insertedOrder, storage.orders, err)
 		})
 	}
 }
 
+func TestGetOrderVersions(t *testing.T) {
+	storage, ctx := initTestOrdersStorage(make(map[int]dsmodels.Order))
+	changedAt := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+	storage.now = func() time.Time { return changedAt }
+
+	order := dsmodels.Order{ID: 1, UserId: 7, Status: common.Pending, Payments: []int{1}}
+	_, err := storage.InsertOrder(ctx, order, 7)
+	assert.NoError(t, err, "expected the order to be inserted")
+
+	// an unchanged order is no new version
+	_, err = storage.UpdateOrder(ctx, order, 7)
+	assert.NoError(t, err, "expected the order to be updated")
+
+	order.Status = common.Paid
+	order.Payments[0] = 2
+	_, err = storage.UpdateOrder(ctx, order, 9)
+	assert.NoError(t, err, "expected the order to be updated")
+
+	versions, err := storage.GetOrderVersions(ctx, 1)
+	assert.NoError(t, err, "expected the versions to be returned")
+	assert.Equal(t, []dsmodels.OrderVersion{
+		{Version: 1, ChangedAt: changedAt, ChangedBy: 7, Order: dsmodels.Order{ID: 1, UserId: 7, Status: common.Pending, Payments: []int{1}}},
+		{Version: 2, ChangedAt: changedAt, ChangedBy: 9, Order: dsmodels.Order{ID: 1, UserId: 7, Status: common.Paid, Payments: []int{2}}},
+	}, versions, "expected a version per change, unaffected by later changes of the stored slices")
+
+	assert.NoError(t, storage.DeleteOrder(ctx, 1), "expected the order to be deleted")
+	deletedVersions, err := storage.GetOrderVersions(ctx, 1)
+	assert.NoError(t, err, "expected the versions of a deleted order to be kept")
+	assert.Equal(t, versions, deletedVersions, "expected the history to outlive the order")
+
+	_, err = storage.GetOrderVersions(ctx, 2)
+	assert.ErrorIs(t, err, datasources.ErrOrderNotFound, "expected no versions of an unknown order")
+}
+
 func TestNewOrderStorage(t *testing.T) {
 	tests := []struct {
 		name string
@@ -0,0 +1,185 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fp_kata/common/utils"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/dsmodels"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const compProductsStorage = "ProductsDatasource"
+
+// ProductsFile is the path of the JSON file the product catalog is kept in, empty keeps the catalog in memory only.
+type ProductsFile string
+
+// NewProductsFile reads the path of the product catalog from FP_KATA_PRODUCTS_FILE.
+func NewProductsFile() ProductsFile {
+	return ProductsFile(os.Getenv("FP_KATA_PRODUCTS_FILE"))
+}
+
+type fileProductsStorage struct {
+	mu       sync.RWMutex
+	path     ProductsFile
+	products map[int]dsmodels.Product
+	lastID   int
+}
+
+// NewProductsStorage loads the product catalog from the file and writes it back on every change.
+// A missing file starts an empty catalog, a file that cannot be read or parsed fails, so it is never saved over.
+func NewProductsStorage(path ProductsFile) (datasources.ProductsDatasource, error) {
+	storage := &fileProductsStorage{
+		path:     path,
+		products: make(map[int]dsmodels.Product),
+	}
+	if err := storage.load(); err != nil {
+		return nil, fmt.Errorf("loading the product catalog %s: %w", path, err)
+	}
+	return storage, nil
+}
+
+func (s *fileProductsStorage) Create(ctx context.Context, product dsmodels.Product) (dsmodels.Product, error) {
+	utils.LogAction(ctx, compProductsStorage, "Create")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	product.ID = s.lastID
+	s.products[product.ID] = product
+	if err := s.save(); err != nil {
+		delete(s.products, product.ID)
+		return dsmodels.Product{}, err
+	}
+	return product, nil
+}
+
+func (s *fileProductsStorage) Read(ctx context.Context, productId int) (dsmodels.Product, error) {
+	utils.LogAction(ctx, compProductsStorage, "Read")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	product, exists := s.products[productId]
+	if !exists {
+		return dsmodels.Product{}, fmt.Errorf("%w: %d", datasources.ErrProductNotFound, productId)
+	}
+	return product, nil
+}
+
+func (s *fileProductsStorage) Update(ctx context.Context, product dsmodels.Product) (dsmodels.Product, error) {
+	utils.LogAction(ctx, compProductsStorage, "Update")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.products[product.ID]
+	if !exists {
+		return dsmodels.Product{}, fmt.Errorf("%w: %d", datasources.ErrProductNotFound, product.ID)
+	}
+	s.products[product.ID] = product
+	if err := s.save(); err != nil {
+		s.products[product.ID] = previous
+		return dsmodels.Product{}, err
+	}
+	return product, nil
+}
+
+func (s *fileProductsStorage) Delete(ctx context.Context, productId int) error {
+	utils.LogAction(ctx, compProductsStorage, "Delete")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.products[productId]
+	if !exists {
+		return fmt.Errorf("%w: %d", datasources.ErrProductNotFound, productId)
+	}
+	delete(s.products, productId)
+	if err := s.save(); err != nil {
+		s.products[productId] = previous
+		return err
+	}
+	return nil
+}
+
+func (s *fileProductsStorage) Search(ctx context.Context, text string) ([]dsmodels.Product, error) {
+	utils.LogAction(ctx, compProductsStorage, "Search")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	text = strings.ToLower(strings.TrimSpace(text))
+	products := make([]dsmodels.Product, 0)
+	for _, product := range s.products {
+		if strings.Contains(strings.ToLower(product.Name), text) || strings.Contains(strings.ToLower(product.Description), text) {
+			products = append(products, product)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ID < products[j].ID
+	})
+	return products, nil
+}
+
+// load reads the catalog file, a JSON array of products.
+func (s *fileProductsStorage) load() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(string(s.path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var products []dsmodels.Product
+	if err := json.Unmarshal(data, &products); err != nil {
+		return err
+	}
+	for _, product := range products {
+		s.products[product.ID] = product
+		s.lastID = max(s.lastID, product.ID)
+	}
+	return nil
+}
+
+// save writes the catalog to a temporary file first and replaces the catalog file with it,
+// so a failed write never leaves a truncated catalog behind.
+func (s *fileProductsStorage) save() error {
+	if s.path == "" {
+		return nil
+	}
+	products := make([]dsmodels.Product, 0, len(s.products))
+	for _, product := range s.products {
+		products = append(products, product)
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ID < products[j].ID
+	})
+	data, err := json.MarshalIndent(products, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := string(s.path)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
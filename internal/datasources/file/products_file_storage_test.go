@@ -0,0 +1,138 @@
+package file
+
+import (
+	"context"
+	"fp_kata/common"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/pkg/log"
+	zlog "github.com/rs/zerolog/log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func initTestProductsStorage(t *testing.T, products ...dsmodels.Product) (datasources.ProductsDatasource, ProductsFile, context.Context) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	path := ProductsFile(filepath.Join(t.TempDir(), "products.json"))
+	storage, err := NewProductsStorage(path)
+	assert.NoError(t, err, "unexpected error loading the catalog")
+	for _, product := range products {
+		_, err := storage.Create(ctx, product)
+		assert.NoError(t, err, "unexpected error creating a product")
+	}
+	return storage, path, ctx
+}
+
+func TestProductsStorage_CRUD(t *testing.T) {
+	storage, path, ctx := initTestProductsStorage(t)
+
+	apples, err := storage.Create(ctx, dsmodels.Product{Name: "Apples", Price: common.NewMoney(2.5), WeightUnit: common.Kilogram})
+	assert.NoError(t, err, "unexpected error creating a product")
+	assert.Equal(t, 1, apples.ID, "expected the first product id")
+
+	apples.Price = common.NewMoney(2.75)
+	updated, err := storage.Update(ctx, apples)
+	assert.NoError(t, err, "unexpected error updating a product")
+	assert.Equal(t, common.NewMoney(2.75), updated.Price, "expected the updated price")
+
+	read, err := storage.Read(ctx, apples.ID)
+	assert.NoError(t, err, "unexpected error reading a product")
+	assert.Equal(t, updated, read, "expected the updated product to be read")
+
+	assert.NoError(t, storage.Delete(ctx, apples.ID), "unexpected error deleting a product")
+	_, err = storage.Read(ctx, apples.ID)
+	assert.ErrorIs(t, err, datasources.ErrProductNotFound, "expected the deleted product to be gone")
+	assert.EqualError(t, err, "product not found: 1", "unexpected error message")
+
+	_, err = storage.Update(ctx, dsmodels.Product{ID: 9, Name: "Pears", Price: common.NewMoney(3)})
+	assert.ErrorIs(t, err, datasources.ErrProductNotFound, "expected updating an unknown product to fail")
+	assert.ErrorIs(t, storage.Delete(ctx, 9), datasources.ErrProductNotFound, "expected deleting an unknown product to fail")
+
+	reloaded, err := NewProductsStorage(path)
+	assert.NoError(t, err, "unexpected error reloading the catalog")
+	products, err := reloaded.Search(ctx, "")
+	assert.NoError(t, err, "unexpected error searching products")
+	assert.Empty(t, products, "expected the catalog file to reflect the delete")
+}
+
+func TestProductsStorage_Persistence(t *testing.T) {
+	storage, path, ctx := initTestProductsStorage(t,
+		dsmodels.Product{Name: "Apples", Price: common.NewMoney(2.5), WeightUnit: common.Kilogram},
+		dsmodels.Product{Name: "Bread", Description: "Sourdough loaf", Price: common.NewMoney(3.2)},
+	)
+
+	_, err := os.Stat(string(path))
+	assert.NoError(t, err, "expected the catalog file to be written")
+
+	reloaded, err := NewProductsStorage(path)
+	assert.NoError(t, err, "unexpected error reloading the catalog")
+	products, err := reloaded.Search(ctx, "")
+	assert.NoError(t, err, "unexpected error searching products")
+	expected, _ := storage.Search(ctx, "")
+	assert.Equal(t, expected, products, "expected the catalog to be loaded from the file")
+
+	created, err := reloaded.Create(ctx, dsmodels.Product{Name: "Cheese", Price: common.NewMoney(4)})
+	assert.NoError(t, err, "unexpected error creating a product")
+	assert.Equal(t, 3, created.ID, "expected ids to continue after the loaded products")
+}
+
+func TestProductsStorage_Search(t *testing.T) {
+	storage, _, ctx := initTestProductsStorage(t,
+		dsmodels.Product{Name: "Apples", Description: "Crisp and sweet", Price: common.NewMoney(2.5)},
+		dsmodels.Product{Name: "Bread", Description: "Sourdough loaf", Price: common.NewMoney(3.2)},
+		dsmodels.Product{Name: "Apple juice", Price: common.NewMoney(1.9)},
+	)
+
+	tests := []struct {
+		name     string
+		text     string
+		expected []int
+	}{
+		{name: "empty text lists the catalog", text: "", expected: []int{1, 2, 3}},
+		{name: "name ignoring case", text: "APPLE", expected: []int{1, 3}},
+		{name: "description", text: "sourdough", expected: []int{2}},
+		{name: "surrounding spaces are ignored", text: "  bread ", expected: []int{2}},
+		{name: "no match", text: "cheese", expected: []int{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			products, err := storage.Search(ctx, test.text)
+			assert.NoError(t, err, "unexpected error searching products")
+			ids := make([]int, len(products))
+			for i, product := range products {
+				ids[i] = product.ID
+			}
+			assert.Equal(t, test.expected, ids, "expected product ids mismatch")
+		})
+	}
+}
+
+func TestProductsStorage_InMemoryOnly(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	storage, err := NewProductsStorage("")
+	assert.NoError(t, err, "unexpected error without a catalog file")
+
+	product, err := storage.Create(ctx, dsmodels.Product{Name: "Apples", Price: common.NewMoney(2.5)})
+	assert.NoError(t, err, "unexpected error creating a product without a catalog file")
+	read, err := storage.Read(ctx, product.ID)
+	assert.NoError(t, err, "unexpected error reading a product")
+	assert.Equal(t, product, read, "expected the product to be kept in memory")
+}
+
+func TestProductsStorage_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.json")
+	err := os.WriteFile(path, []byte(`[{"ID": 1, "Name": "Apples"`), 0o600)
+	assert.NoError(t, err, "unexpected error writing the catalog file")
+
+	_, err = NewProductsStorage(ProductsFile(path))
+	assert.ErrorContains(t, err, "loading the product catalog", "expected a catalog file that cannot be parsed to fail")
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err, "unexpected error reading the catalog file")
+	assert.Equal(t, `[{"ID": 1, "Name": "Apples"`, string(data), "expected the catalog file to be left alone")
+}
@@ -0,0 +1,23 @@
+package datasources
+
+import (
+	"context"
+	"fp_kata/common"
+	"fp_kata/internal/datasources/dsmodels"
+	"time"
+)
+
+// ErrIdempotencyKeyNotFound is returned when no record is stored for an idempotency key.
+var ErrIdempotencyKeyNotFound = common.NewDomainError(common.NotFound, "idempotency_key_not_found", "idempotency key not found")
+
+type IdempotencyDatasource interface {
+	// Claim stores the record unless an unexpired record is stored for the key of the user already.
+	// It returns the stored record and whether it is the claimed one, records expired at now are replaced.
+	Claim(ctx context.Context, record dsmodels.IdempotencyRecord, now time.Time) (dsmodels.IdempotencyRecord, bool, error)
+	// Read returns the record stored for the key of the user.
+	Read(ctx context.Context, userId int, key string) (dsmodels.IdempotencyRecord, error)
+	// Save replaces the record stored for the key of the user.
+	Save(ctx context.Context, record dsmodels.IdempotencyRecord) error
+	// Delete removes the record stored for the key of the user, the key can be claimed again.
+	Delete(ctx context.Context, userId int, key string) error
+}
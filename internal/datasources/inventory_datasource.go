@@ -0,0 +1,28 @@
+package datasources
+
+import (
+	"context"
+	"fp_kata/common"
+	"fp_kata/internal/datasources/dsmodels"
+)
+
+// ErrInsufficientStock is returned when a reservation or adjustment needs more stock than is available.
+var ErrInsufficientStock = common.NewDomainError(common.Conflict, "insufficient_stock", "insufficient stock")
+
+type InventoryDatasource interface {
+	// Read returns the stock of a product, products that were never stocked have no stock.
+	Read(ctx context.Context, productId int) (dsmodels.Stock, error)
+	// All returns the stock of all stocked products sorted by product id.
+	All(ctx context.Context) ([]dsmodels.Stock, error)
+	// Save sets the quantity on hand and the reorder level of a product, the reserved quantity is kept.
+	Save(ctx context.Context, stock dsmodels.Stock) (dsmodels.Stock, error)
+	// Adjust adds the delta to the quantity on hand of a product, a negative delta removes stock.
+	Adjust(ctx context.Context, productId int, delta int) (dsmodels.Stock, error)
+	// Reserve replaces the reservation of an order with the items, all or none of them are reserved.
+	// It returns the items previously reserved by the order.
+	Reserve(ctx context.Context, orderId int, items []dsmodels.StockItem) ([]dsmodels.StockItem, error)
+	// Release drops the reservation of an order, the reserved quantity is available again.
+	Release(ctx context.Context, orderId int) error
+	// Commit takes the reserved items of an order off hand once the order is fulfilled.
+	Commit(ctx context.Context, orderId int) error
+}
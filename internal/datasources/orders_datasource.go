@@ -2,13 +2,26 @@ package datasources
 
 import (
 	"context"
+	"fp_kata/common"
 	"fp_kata/internal/datasources/dsmodels"
 )
 
+var (
+	// ErrOrderNotFound is returned when no order with the requested id is stored.
+	ErrOrderNotFound = common.NewDomainError(common.NotFound, "order_not_found", "order not found")
+	// ErrOrderExists is returned when an order is inserted with the id of a stored order.
+	ErrOrderExists = common.NewDomainError(common.Conflict, "order_exists", "order already exists")
+)
+
 type OrdersDatasource interface {
 	GetOrder(ctx context.Context, orderID int) (*dsmodels.Order, error)
 	GetAllOrdersForUser(ctx context.Context, userID int) ([]dsmodels.Order, error)
+	QueryOrdersForUser(ctx context.Context, userID int, query OrdersQuery) (*OrdersPage, error)
 	DeleteOrder(ctx context.Context, orderID int) error
-	UpdateOrder(ctx context.Context, order dsmodels.Order) (*dsmodels.Order, error)
-	InsertOrder(ctx context.Context, order dsmodels.Order) (*dsmodels.Order, error)
+	// UpdateOrder and InsertOrder store the order, the new version is attributed to the user changedBy.
+	UpdateOrder(ctx context.Context, order dsmodels.Order, changedBy int) (*dsmodels.Order, error)
+	InsertOrder(ctx context.Context, order dsmodels.Order, changedBy int) (*dsmodels.Order, error)
+	// GetOrderVersions returns every stored version of the order, oldest first. Inserting or updating an order
+	// stores a new version unless the order is unchanged, deleting it keeps its versions.
+	GetOrderVersions(ctx context.Context, orderID int) ([]dsmodels.OrderVersion, error)
 }
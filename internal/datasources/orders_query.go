@@ -0,0 +1,98 @@
+package datasources
+
+import (
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/internal/datasources/dsmodels"
+	"strings"
+)
+
+// OrderSortField is an order field a listing can be sorted by.
+type OrderSortField string
+
+const (
+	SortById        OrderSortField = "id"
+	SortByOrderDate OrderSortField = "order_date"
+	// SortByPrice sorts an order by its price in the base currency.
+	SortByPrice    OrderSortField = "price"
+	SortByQuantity OrderSortField = "quantity"
+	// SortByProductId sorts an order by its product, an order with several lines by the lowest product id of its lines.
+	SortByProductId OrderSortField = "product_id"
+	SortByStatus    OrderSortField = "status"
+)
+
+var sortFields = []OrderSortField{SortById, SortByOrderDate, SortByPrice, SortByQuantity, SortByProductId, SortByStatus}
+
+var (
+	ErrInvalidSort   = common.NewDomainError(common.Validation, "invalid_sort", "invalid sort")
+	ErrInvalidCursor = common.NewDomainError(common.Validation, "invalid_cursor", "invalid cursor")
+)
+
+// OrderSort sorts a listing by a single field.
+type OrderSort struct {
+	Field      OrderSortField
+	Descending bool
+}
+
+// OrdersQuery selects a page of the orders of a user.
+// Orders are always sorted by id last, so pages are stable even when sort keys repeat.
+type OrdersQuery struct {
+	// Filter selects the orders counted and listed, nil selects all orders.
+	Filter func(order dsmodels.Order) bool
+	Sort   []OrderSort
+	// Limit is the maximum number of orders on the page, 0 lists all remaining orders.
+	Limit int
+	// Cursor continues a listing after the last order of the previous page, empty starts at the first order.
+	Cursor string
+}
+
+// OrdersPage is a page of orders together with the number of orders matching the query on all pages.
+type OrdersPage struct {
+	Orders []dsmodels.Order
+	Total  int
+	// NextCursor continues the listing on the next page, empty on the last page.
+	NextCursor string
+}
+
+// ParseOrderSort parses a comma separated list of sort fields, a leading '-' sorts descending,
+// for example "-order_date,price".
+func ParseOrderSort(expression string) ([]OrderSort, error) {
+	if expression == "" {
+		return nil, nil
+	}
+
+	var sorts []OrderSort
+	for _, part := range strings.Split(expression, ",") {
+		sort := OrderSort{Field: OrderSortField(strings.TrimSpace(part))}
+		if strings.HasPrefix(string(sort.Field), "-") {
+			sort.Field = sort.Field[1:]
+			sort.Descending = true
+		}
+		if !isSortField(sort.Field) {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidSort, sort.Field)
+		}
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
+
+// FormatOrderSort renders sorts in the form accepted by ParseOrderSort.
+func FormatOrderSort(sorts []OrderSort) string {
+	parts := make([]string, len(sorts))
+	for i, sort := range sorts {
+		parts[i] = string(sort.Field)
+		if sort.Descending {
+			parts[i] = "-" + parts[i]
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func isSortField(field OrderSortField) bool {
+	for _, sortField := range sortFields {
+		if sortField == field {
+			return true
+		}
+	}
+	return false
+}
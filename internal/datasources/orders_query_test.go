@@ -0,0 +1,51 @@
+package datasources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOrderSort(t *testing.T) {
+	tests := []struct {
+		name          string
+		expression    string
+		expected      []OrderSort
+		expectedError string
+	}{
+		{
+			name:       "empty",
+			expression: "",
+			expected:   nil,
+		},
+		{
+			name:       "multiple fields",
+			expression: "-order_date, price",
+			expected: []OrderSort{
+				{Field: SortByOrderDate, Descending: true},
+				{Field: SortByPrice},
+			},
+		},
+		{
+			name:          "unknown field",
+			expression:    "price,-colour",
+			expectedError: `invalid sort: unknown field "colour"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorts, err := ParseOrderSort(tt.expression)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError, "Unexpected error")
+				return
+			}
+			assert.NoError(t, err, "Expected no error")
+			assert.Equal(t, tt.expected, sorts, "Unexpected sort")
+
+			formatted, err := ParseOrderSort(FormatOrderSort(sorts))
+			assert.NoError(t, err, "Expected formatted sort to parse")
+			assert.Equal(t, tt.expected, formatted, "Expected formatted sort to parse back")
+		})
+	}
+}
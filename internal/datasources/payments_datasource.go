@@ -2,13 +2,21 @@ package datasources
 
 import (
 	"context"
+	"fp_kata/common"
 	"fp_kata/internal/datasources/dsmodels"
 )
 
+// ErrPaymentNotFound is returned when no payment with the requested id is stored.
+var ErrPaymentNotFound = common.NewDomainError(common.NotFound, "payment_not_found", "payment not found")
+
 type PaymentsDatasource interface {
 	Create(ctx context.Context, payment dsmodels.Payment) (dsmodels.Payment, error)
 	Read(ctx context.Context, paymentId int) (dsmodels.Payment, error)
 	Update(ctx context.Context, payment dsmodels.Payment) (dsmodels.Payment, error)
 	Delete(ctx context.Context, paymentId int) error
 	AllByOrderId(ctx context.Context, paymentId int) ([]dsmodels.Payment, error)
+	// AllByUserId returns the payments of the user ordered by id, none when the user has not paid yet.
+	AllByUserId(ctx context.Context, userId int) ([]dsmodels.Payment, error)
+	// CreateRefund stores a refund of an existing payment, refunds are deleted together with their payment.
+	CreateRefund(ctx context.Context, refund dsmodels.Refund) (dsmodels.Refund, error)
 }
@@ -0,0 +1,20 @@
+package datasources
+
+import (
+	"context"
+	"fp_kata/common"
+	"fp_kata/internal/datasources/dsmodels"
+)
+
+// ErrProductNotFound is returned when no product with the requested id is in the catalog.
+var ErrProductNotFound = common.NewDomainError(common.NotFound, "product_not_found", "product not found")
+
+type ProductsDatasource interface {
+	Create(ctx context.Context, product dsmodels.Product) (dsmodels.Product, error)
+	Read(ctx context.Context, productId int) (dsmodels.Product, error)
+	Update(ctx context.Context, product dsmodels.Product) (dsmodels.Product, error)
+	Delete(ctx context.Context, productId int) error
+	// Search returns the products whose name or description contains the text, ignoring case,
+	// sorted by id. An empty text returns the whole catalog.
+	Search(ctx context.Context, text string) ([]dsmodels.Product, error)
+}
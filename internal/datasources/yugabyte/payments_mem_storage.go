@@ -6,24 +6,37 @@ import (
 	"fp_kata/common/utils"
 	"fp_kata/internal/datasources"
 	"fp_kata/internal/datasources/dsmodels"
+	"slices"
 	"sort"
+	"time"
 )
 
 const compPaymentsStorage = "PaymentsStorage"
 
 type inMemoryPaymentsStorage struct {
 	payments map[int]dsmodels.Payment
+	// refunds holds the refunds of every payment by payment id
+	refunds map[int][]dsmodels.Refund
+	ids     utils.IDGenerator
+	now     func() time.Time
 }
 
-func NewPaymentsStorage() datasources.PaymentsDatasource {
-	return &inMemoryPaymentsStorage{make(map[int]dsmodels.Payment)}
+func NewPaymentsStorage(ids utils.IDGenerator) datasources.PaymentsDatasource {
+	return &inMemoryPaymentsStorage{
+		payments: make(map[int]dsmodels.Payment),
+		refunds:  make(map[int][]dsmodels.Refund),
+		ids:      ids,
+		now:      time.Now,
+	}
 }
 
 func (s inMemoryPaymentsStorage) Create(ctx context.Context, p dsmodels.Payment) (dsmodels.Payment, error) {
 	utils.LogAction(ctx, compPaymentsStorage, "Create")
 
-	id := len(s.payments) + 1
+	id := s.ids.NewID()
 	p.Id = id
+	p.PaidAt = s.now()
+	p.Refunds = nil
 	s.payments[id] = p
 	return p, nil
 }
@@ -32,19 +45,21 @@ func (s inMemoryPaymentsStorage) Read(ctx context.Context, id int) (dsmodels.Pay
 	utils.LogAction(ctx, compPaymentsStorage, "Read")
 
 	if p, exists := s.payments[id]; exists {
-		return p, nil
+		return s.withRefunds(p), nil
 	}
-	return dsmodels.Payment{}, fmt.Errorf("payment with id %d not found", id)
+	return dsmodels.Payment{}, fmt.Errorf("%w: %d", datasources.ErrPaymentNotFound, id)
 }
 
 func (s inMemoryPaymentsStorage) Update(ctx context.Context, p dsmodels.Payment) (dsmodels.Payment, error) {
 	utils.LogAction(ctx, compPaymentsStorage, "Update")
 
-	if _, exists := s.payments[p.Id]; exists {
+	if stored, exists := s.payments[p.Id]; exists {
+		p.PaidAt = stored.PaidAt
+		p.Refunds = nil
 		s.payments[p.Id] = p
-		return p, nil
+		return s.withRefunds(p), nil
 	}
-	return dsmodels.Payment{}, fmt.Errorf("payment with id %d not found", p.Id)
+	return dsmodels.Payment{}, fmt.Errorf("%w: %d", datasources.ErrPaymentNotFound, p.Id)
 }
 
 func (s inMemoryPaymentsStorage) Delete(ctx context.Context, id int) error {
@@ -52,18 +67,19 @@ func (s inMemoryPaymentsStorage) Delete(ctx context.Context, id int) error {
 
 	if _, exists := s.payments[id]; exists {
 		delete(s.payments, id)
+		delete(s.refunds, id)
 		return nil
 	}
-	return fmt.Errorf("payment with id %d not found", id)
+	return fmt.Errorf("%w: %d", datasources.ErrPaymentNotFound, id)
 }
 
 func (s inMemoryPaymentsStorage) AllByOrderId(ctx context.Context, orderId int) ([]dsmodels.Payment, error) {
 	utils.LogAction(ctx, compPaymentsStorage, "AllByOrderId")
 
-	var payments []dsmodels.Payment
+	payments := make([]dsmodels.Payment, 0)
 	for _, payment := range s.payments {
 		if payment.OrderId == orderId {
-			payments = append(payments, payment)
+			payments = append(payments, s.withRefunds(payment))
 		}
 	}
 
@@ -71,8 +87,37 @@ func (s inMemoryPaymentsStorage) AllByOrderId(ctx context.Context, orderId int)
 	sort.Slice(payments, func(i, j int) bool {
 		return payments[i].Id < payments[j].Id
 	})
-	if len(payments) == 0 {
-		return nil, fmt.Errorf("no payments found with orderId %d", orderId)
+	return payments, nil
+}
+
+func (s inMemoryPaymentsStorage) AllByUserId(ctx context.Context, userId int) ([]dsmodels.Payment, error) {
+	utils.LogAction(ctx, compPaymentsStorage, "AllByUserId")
+
+	payments := make([]dsmodels.Payment, 0)
+	for _, payment := range s.payments {
+		if payment.UserId == userId {
+			payments = append(payments, s.withRefunds(payment))
+		}
 	}
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].Id < payments[j].Id
+	})
 	return payments, nil
 }
+
+func (s inMemoryPaymentsStorage) CreateRefund(ctx context.Context, refund dsmodels.Refund) (dsmodels.Refund, error) {
+	utils.LogAction(ctx, compPaymentsStorage, "CreateRefund")
+
+	if _, exists := s.payments[refund.PaymentId]; !exists {
+		return dsmodels.Refund{}, fmt.Errorf("%w: %d", datasources.ErrPaymentNotFound, refund.PaymentId)
+	}
+	refund.Id = s.ids.NewID()
+	s.refunds[refund.PaymentId] = append(s.refunds[refund.PaymentId], refund)
+	return refund, nil
+}
+
+// withRefunds attaches the stored refunds to a payment.
+func (s inMemoryPaymentsStorage) withRefunds(p dsmodels.Payment) dsmodels.Payment {
+	p.Refunds = slices.Clone(s.refunds[p.Id])
+	return p
+}
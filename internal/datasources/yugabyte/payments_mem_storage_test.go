@@ -6,17 +6,31 @@ import (
 	"fp_kata/common"
 	zlog "github.com/rs/zerolog/log"
 	"testing"
+	"time"
 
+	"fp_kata/common/utils"
+	"fp_kata/internal/datasources"
 	"fp_kata/internal/datasources/dsmodels"
 	"fp_kata/pkg/log"
 	"github.com/stretchr/testify/assert"
 )
 
+// testPaidAt is the time payments are stored at by the test storage.
+var testPaidAt = time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+
 func initTestPaymentsStorage(store map[int]dsmodels.Payment) (*inMemoryPaymentsStorage, context.Context) {
 	log.InitLogger()
 	ctx := log.NewBackgroundContext(&zlog.Logger)
+	// the ids of the initial payments were issued by the sequence already
+	ids := utils.NewSequenceIDGenerator()
+	for range store {
+		ids.NewID()
+	}
 	return &inMemoryPaymentsStorage{
 		payments: store,
+		refunds:  make(map[int][]dsmodels.Refund),
+		ids:      ids,
+		now:      func() time.Time { return testPaidAt },
 	}, ctx
 }
 
@@ -24,7 +38,7 @@ func initTestPaymentsStorage(store map[int]dsmodels.Payment) (*inMemoryPaymentsS
 func createPayment(id int, amount float64, method common.PaymentMethod, userID, orderID int) dsmodels.Payment {
 	return dsmodels.Payment{
 		Id:      id,
-		Amount:  amount,
+		Amount:  common.NewMoney(amount),
 		Method:  method,
 		UserId:  userID,
 		OrderId: orderID,
@@ -102,6 +116,20 @@ func TestInMemoryPaymentsStorage_Create(t *testing.T) {
 	}
 }
 
+func TestInMemoryPaymentsStorage_CreateAfterDelete(t *testing.T) {
+	storage, ctx := initTestPaymentsStorage(createPaymentsMap(
+		createPayment(1, 100.0, common.CreditCard, 1, 123),
+		createPayment(2, 200.0, common.PayPal, 2, 456),
+	))
+	assert.NoError(t, storage.Delete(ctx, 1), "unexpected error deleting the first payment")
+
+	result, err := storage.Create(ctx, createPayment(0, 300.0, common.BankTransfer, 3, 789))
+
+	assert.NoError(t, err, "unexpected error during valid payment creation")
+	assert.Equal(t, 3, result.Id, "expected the id of the deleted payment not to be reused")
+	assert.Equal(t, common.NewMoney(200.0), storage.payments[2].Amount, "expected the second payment to be kept")
+}
+
 func TestInMemoryPaymentsStorage_Read(t *testing.T) {
 	type ReadPaymentTestCase struct {
 		name            string
@@ -133,10 +161,11 @@ func TestInMemoryPaymentsStorage_Read(t *testing.T) {
 			paymentID:       99,
 			initialPayments: createPaymentsMap(createPayment(1, 100.0, common.CreditCard, 1, 101)),
 			expected:        dsmodels.Payment{},
-			expectedErr:     fmt.Errorf("payment with id 99 not found"),
+			expectedErr:     fmt.Errorf("%w: 99", datasources.ErrPaymentNotFound),
 			assert: func(t *testing.T, result dsmodels.Payment, err error) {
 				assert.Error(t, err, "expected an error when reading non-existent payment")
-				assert.Equal(t, "payment with id 99 not found", err.Error(), "unexpected error message")
+				assert.ErrorIs(t, err, datasources.ErrPaymentNotFound, "expected a payment not found error")
+				assert.Equal(t, "payment not found: 99", err.Error(), "unexpected error message")
 				assert.Equal(t, dsmodels.Payment{}, result, "expected empty payment object for non-existent ID")
 			},
 		},
@@ -173,7 +202,7 @@ func TestInMemoryPaymentsStorage_Update(t *testing.T) {
 			assert: func(t *testing.T, result dsmodels.Payment, err error, storage *inMemoryPaymentsStorage) {
 				assert.NoError(t, err, "error should be nil for valid update")
 				assert.Equal(t, result, storage.payments[result.Id], "updated payment does not match expected payment")
-				assert.Equal(t, result.Amount, 150.0, "updated payment amount mismatch")
+				assert.Equal(t, result.Amount, common.NewMoney(150.0), "updated payment amount mismatch")
 			},
 		},
 		{
@@ -183,10 +212,11 @@ func TestInMemoryPaymentsStorage_Update(t *testing.T) {
 				createPayment(1, 100.0, common.CreditCard, 1, 101),
 			),
 			expected:    dsmodels.Payment{},
-			expectedErr: fmt.Errorf("payment with id 99 not found"),
+			expectedErr: fmt.Errorf("%w: 99", datasources.ErrPaymentNotFound),
 			assert: func(t *testing.T, result dsmodels.Payment, err error, storage *inMemoryPaymentsStorage) {
 				assert.Error(t, err, "expected error for updating non-existent payment")
-				assert.Equal(t, "payment with id 99 not found", err.Error(), "error message mismatch")
+				assert.ErrorIs(t, err, datasources.ErrPaymentNotFound, "expected a payment not found error")
+				assert.Equal(t, "payment not found: 99", err.Error(), "error message mismatch")
 				assert.Equal(t, dsmodels.Payment{}, result, "result should be an empty payment object")
 			},
 		},
@@ -202,7 +232,7 @@ func TestInMemoryPaymentsStorage_Update(t *testing.T) {
 				assert.NoError(t, err, "error should be nil for valid update with modified attributes")
 				assert.Equal(t, result, storage.payments[result.Id], "updated payment does not match expected payment")
 				assert.Equal(t, result.Method, common.PayPal, "updated payment method mismatch")
-				assert.Equal(t, result.Amount, 200.0, "updated payment amount mismatch")
+				assert.Equal(t, result.Amount, common.NewMoney(200.0), "updated payment amount mismatch")
 			},
 		},
 	}
@@ -247,10 +277,11 @@ func TestInMemoryPaymentsStorage_Delete(t *testing.T) {
 			initialPayments: createPaymentsMap(
 				createPayment(1, 100.0, common.CreditCard, 1, 101),
 			),
-			expectedErr: fmt.Errorf("payment with id 99 not found"),
+			expectedErr: fmt.Errorf("%w: 99", datasources.ErrPaymentNotFound),
 			assert: func(t *testing.T, storage *inMemoryPaymentsStorage, err error) {
 				assert.Error(t, err, "expected error while deleting a non-existent payment")
-				assert.Equal(t, "payment with id 99 not found", err.Error(), "unexpected error message")
+				assert.ErrorIs(t, err, datasources.ErrPaymentNotFound, "expected a payment not found error")
+				assert.Equal(t, "payment not found: 99", err.Error(), "unexpected error message")
 				assert.Equal(t, 1, len(storage.payments), "payments count mismatch when deleting non-existent payment")
 			},
 		},
@@ -314,12 +345,11 @@ func TestInMemoryPaymentsStorage_AllByOrderId(t *testing.T) {
 			initialPayments: createPaymentsMap(
 				createPayment(1, 100.0, common.CreditCard, 1, 101),
 			),
-			expected:    nil,
-			expectedErr: fmt.Errorf("no payments found with orderId 999"),
+			expected:    []dsmodels.Payment{},
+			expectedErr: nil,
 			assert: func(t *testing.T, result []dsmodels.Payment, err error) {
-				assert.Error(t, err, "expected an error when no payments are found")
-				assert.Equal(t, "no payments found with orderId 999", err.Error(), "unexpected error message")
-				assert.Nil(t, result, "result should be nil when no payments are found")
+				assert.NoError(t, err, "expected no error for an order without payments")
+				assert.Equal(t, []dsmodels.Payment{}, result, "result should be empty when no payments are found")
 			},
 		},
 		{
@@ -328,12 +358,11 @@ func TestInMemoryPaymentsStorage_AllByOrderId(t *testing.T) {
 			initialPayments: createPaymentsMap(
 			// No payments initialized
 			),
-			expected:    nil,
-			expectedErr: fmt.Errorf("no payments found with orderId 101"),
+			expected:    []dsmodels.Payment{},
+			expectedErr: nil,
 			assert: func(t *testing.T, result []dsmodels.Payment, err error) {
-				assert.Error(t, err, "expected an error when storage is empty")
-				assert.Equal(t, "no payments found with orderId 101", err.Error(), "unexpected error message")
-				assert.Nil(t, result, "result should be nil when storage is empty")
+				assert.NoError(t, err, "expected no error when storage is empty")
+				assert.Equal(t, []dsmodels.Payment{}, result, "result should be empty when storage is empty")
 			},
 		},
 	}
@@ -347,6 +376,60 @@ func TestInMemoryPaymentsStorage_AllByOrderId(t *testing.T) {
 	}
 }
 
+func TestInMemoryPaymentsStorage_AllByUserId(t *testing.T) {
+	tests := []struct {
+		name            string
+		userID          int
+		initialPayments map[int]dsmodels.Payment
+		expectedIDs     []int
+	}{
+		{
+			name:   "payments of the user across orders",
+			userID: 1,
+			initialPayments: createPaymentsMap(
+				createPayment(3, 300.0, common.BankTransfer, 1, 102),
+				createPayment(1, 100.0, common.CreditCard, 1, 101),
+				createPayment(2, 200.0, common.PayPal, 2, 101),
+			),
+			expectedIDs: []int{1, 3},
+		},
+		{
+			name:            "user without payments",
+			userID:          9,
+			initialPayments: createPaymentsMap(createPayment(1, 100.0, common.CreditCard, 1, 101)),
+			expectedIDs:     []int{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			storage, ctx := initTestPaymentsStorage(tc.initialPayments)
+			result, err := storage.AllByUserId(ctx, tc.userID)
+
+			assert.NoError(t, err, "unexpected error when retrieving payments by userId")
+			ids := make([]int, len(result))
+			for i, payment := range result {
+				ids[i] = payment.Id
+			}
+			assert.Equal(t, tc.expectedIDs, ids, "unexpected payments of the user")
+		})
+	}
+}
+
+func TestInMemoryPaymentsStorage_PaidAt(t *testing.T) {
+	storage, ctx := initTestPaymentsStorage(createPaymentsMap())
+
+	created, err := storage.Create(ctx, createPayment(0, 100.0, common.CreditCard, 1, 101))
+	assert.NoError(t, err, "unexpected error during payment creation")
+	assert.Equal(t, testPaidAt, created.PaidAt, "expected the payment to be stamped when created")
+
+	update := createPayment(created.Id, 120.0, common.CreditCard, 1, 101)
+	update.PaidAt = testPaidAt.Add(time.Hour)
+	updated, err := storage.Update(ctx, update)
+	assert.NoError(t, err, "unexpected error during payment update")
+	assert.Equal(t, testPaidAt, updated.PaidAt, "expected an update to keep the time the payment was made")
+}
+
 func TestNewPaymentsStorage(t *testing.T) {
 	type NewPaymentsStorageTestCase struct {
 		name string
@@ -360,7 +443,7 @@ func TestNewPaymentsStorage(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			result := NewPaymentsStorage()
+			result := NewPaymentsStorage(utils.NewSequenceIDGenerator())
 			storage, ok := result.(*inMemoryPaymentsStorage)
 			assert.True(t, ok, "expected result to be of type *inMemoryPaymentsStorage")
 			assert.NotNil(t, storage, "storage instance should not be nil")
@@ -369,3 +452,53 @@ func TestNewPaymentsStorage(t *testing.T) {
 		})
 	}
 }
+
+func TestInMemoryPaymentsStorage_CreateRefund(t *testing.T) {
+	createdAt := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("refunds are attached to their payment", func(t *testing.T) {
+		storage, ctx := initTestPaymentsStorage(createPaymentsMap(
+			createPayment(1, 100.0, common.CreditCard, 1, 101),
+			createPayment(2, 200.0, common.PayPal, 1, 101),
+		))
+
+		first, err := storage.CreateRefund(ctx, dsmodels.Refund{PaymentId: 1, Amount: common.NewMoney(30), Reason: common.DamagedGoods, CreatedAt: createdAt})
+		assert.NoError(t, err, "unexpected error creating a refund")
+		assert.Equal(t, 3, first.Id, "expected the refund to get the next id")
+		second, err := storage.CreateRefund(ctx, dsmodels.Refund{PaymentId: 1, Amount: common.NewMoney(20), Reason: common.Goodwill, CreatedAt: createdAt})
+		assert.NoError(t, err, "unexpected error creating a refund")
+
+		payment, err := storage.Read(ctx, 1)
+		assert.NoError(t, err, "unexpected error reading the payment")
+		assert.Equal(t, []dsmodels.Refund{first, second}, payment.Refunds, "expected both refunds, oldest first")
+
+		payments, err := storage.AllByOrderId(ctx, 101)
+		assert.NoError(t, err, "unexpected error reading the payments of the order")
+		assert.Len(t, payments[0].Refunds, 2, "expected the refunds of the first payment")
+		assert.Empty(t, payments[1].Refunds, "expected no refunds of the second payment")
+	})
+
+	t.Run("updates keep the refunds", func(t *testing.T) {
+		storage, ctx := initTestPaymentsStorage(createPaymentsMap(createPayment(1, 100.0, common.CreditCard, 1, 101)))
+		refund, _ := storage.CreateRefund(ctx, dsmodels.Refund{PaymentId: 1, Amount: common.NewMoney(30), Reason: common.DamagedGoods})
+
+		updated, err := storage.Update(ctx, createPayment(1, 100.0, common.DebitCard, 1, 101))
+		assert.NoError(t, err, "unexpected error updating the payment")
+		assert.Equal(t, []dsmodels.Refund{refund}, updated.Refunds, "expected the refund to be kept")
+	})
+
+	t.Run("deleting a payment deletes its refunds", func(t *testing.T) {
+		storage, ctx := initTestPaymentsStorage(createPaymentsMap(createPayment(1, 100.0, common.CreditCard, 1, 101)))
+		_, _ = storage.CreateRefund(ctx, dsmodels.Refund{PaymentId: 1, Amount: common.NewMoney(30), Reason: common.DamagedGoods})
+
+		assert.NoError(t, storage.Delete(ctx, 1), "unexpected error deleting the payment")
+		assert.Empty(t, storage.refunds, "expected the refunds to be deleted")
+	})
+
+	t.Run("refund of a missing payment", func(t *testing.T) {
+		storage, ctx := initTestPaymentsStorage(createPaymentsMap())
+
+		_, err := storage.CreateRefund(ctx, dsmodels.Refund{PaymentId: 99, Amount: common.NewMoney(30), Reason: common.Goodwill})
+		assert.ErrorIs(t, err, datasources.ErrPaymentNotFound, "expected a payment not found error")
+	})
+}
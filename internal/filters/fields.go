@@ -0,0 +1,324 @@
+package filters
+
+import (
+	"cmp"
+	"fp_kata/common"
+	"fp_kata/internal/models"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type fieldKind int
+
+const (
+	orderedField fieldKind = iota
+	booleanField
+	enumField
+	identifierField
+)
+
+// field describes an order field that can be used in a filter expression.
+type field struct {
+	name             string
+	kind             fieldKind
+	requiresPayments bool
+	// parse converts a value token into the value the field is compared with.
+	parse func(value token) (any, *ParseError)
+	// test builds the order test for an operator, ok is false when the field does not support the operator.
+	test func(operator string, value any) (matches func(order *models.Order) bool, ok bool)
+}
+
+var fields = map[string]*field{
+	"price":    moneyField("price", false, func(order *models.Order) common.Money { return order.BasePrice() }),
+	"quantity": integerField("quantity", false, func(order *models.Order) int { return order.Quantity }),
+	"product_id": identifierFieldOf("product_id", func(order *models.Order) []int {
+		return order.ProductIDs()
+	}),
+	"payment_count": integerField("payment_count", true, func(order *models.Order) int {
+		return len(order.Payments)
+	}),
+	"order_date": dateField("order_date", func(order *models.Order) time.Time { return order.OrderDate }),
+	"has_weightables": booleanFieldOf("has_weightables", func(order *models.Order) bool {
+		return order.HasWeightables
+	}),
+	"status": enumFieldOf("status", false, orderStatuses, func(order *models.Order) []string {
+		return []string{string(order.Status)}
+	}),
+	"payment_method": enumFieldOf("payment_method", true, paymentMethods, func(order *models.Order) []string {
+		methods := make([]string, len(order.Payments))
+		for i, payment := range order.Payments {
+			methods[i] = string(payment.Method)
+		}
+		return methods
+	}),
+}
+
+var orderStatuses = []string{
+	string(common.Pending), string(common.Paid), string(common.Fulfilled),
+	string(common.Delivered), string(common.Cancelled), string(common.Refunded),
+}
+
+var paymentMethods = []string{
+	string(common.CreditCard), string(common.DebitCard), string(common.PayPal), string(common.BankTransfer),
+}
+
+// compare builds the predicate for: field operator value
+func (f *field) compare(operator token, value token) (Predicate, error) {
+	parsed, err := f.parse(value)
+	if err != nil {
+		return nil, err
+	}
+	matches, ok := f.test(operator.text, parsed)
+	if !ok {
+		return nil, operator.errorf("operator not supported for %s", f.name)
+	}
+	return &condition{
+		text:             f.name + " " + operator.text + " " + value.text,
+		requiresPayments: f.requiresPayments,
+		matches:          matches,
+	}, nil
+}
+
+// in builds the predicate for: field IN (values)
+func (f *field) in(keyword token, values []token) (Predicate, error) {
+	tests := make([]func(order *models.Order) bool, len(values))
+	texts := make([]string, len(values))
+	for i, value := range values {
+		parsed, err := f.parse(value)
+		if err != nil {
+			return nil, err
+		}
+		matches, ok := f.test("=", parsed)
+		if !ok {
+			return nil, keyword.errorf("IN not supported for %s", f.name)
+		}
+		tests[i] = matches
+		texts[i] = value.text
+	}
+
+	return &condition{
+		text:             f.name + " IN (" + strings.Join(texts, ", ") + ")",
+		requiresPayments: f.requiresPayments,
+		matches: func(order *models.Order) bool {
+			for _, matches := range tests {
+				if matches(order) {
+					return true
+				}
+			}
+			return false
+		},
+	}, nil
+}
+
+// between builds the predicate for: field BETWEEN lower AND upper, both bounds are inclusive
+func (f *field) between(keyword token, lower token, upper token) (Predicate, error) {
+	if f.kind != orderedField {
+		return nil, keyword.errorf("BETWEEN not supported for %s", f.name)
+	}
+	from, err := f.compare(token{kind: tokenOperator, text: ">=", position: keyword.position}, lower)
+	if err != nil {
+		return nil, err
+	}
+	to, err := f.compare(token{kind: tokenOperator, text: "<=", position: keyword.position}, upper)
+	if err != nil {
+		return nil, err
+	}
+	return And(from, to), nil
+}
+
+func moneyField(name string, requiresPayments bool, get func(order *models.Order) common.Money) *field {
+	return &field{
+		name:             name,
+		kind:             orderedField,
+		requiresPayments: requiresPayments,
+		parse: func(value token) (any, *ParseError) {
+			amount, err := common.ParseMoney(value.text)
+			if err != nil {
+				return nil, value.errorf("expected a number for %s", name)
+			}
+			return amount, nil
+		},
+		test: func(operator string, value any) (func(order *models.Order) bool, bool) {
+			expected := value.(common.Money)
+			return comparisonTest(operator, func(order *models.Order) int { return get(order).Compare(expected) })
+		},
+	}
+}
+
+func integerField(name string, requiresPayments bool, get func(order *models.Order) int) *field {
+	return &field{
+		name:             name,
+		kind:             orderedField,
+		requiresPayments: requiresPayments,
+		parse: func(value token) (any, *ParseError) {
+			number, err := strconv.Atoi(value.text)
+			if err != nil {
+				return nil, value.errorf("expected an integer for %s", name)
+			}
+			return number, nil
+		},
+		test: func(operator string, value any) (func(order *models.Order) bool, bool) {
+			return orderedTest(operator, value.(int), get)
+		},
+	}
+}
+
+// dateRange is the time span a date value stands for: a whole day for a date, a single instant for a timestamp.
+type dateRange struct {
+	start time.Time
+	end   time.Time
+}
+
+func dateField(name string, get func(order *models.Order) time.Time) *field {
+	return &field{
+		name: name,
+		kind: orderedField,
+		parse: func(value token) (any, *ParseError) {
+			if day, err := time.Parse(time.DateOnly, value.text); err == nil {
+				return dateRange{start: day, end: day.AddDate(0, 0, 1)}, nil
+			}
+			if instant, err := time.Parse(time.RFC3339, value.text); err == nil {
+				return dateRange{start: instant, end: instant.Add(time.Nanosecond)}, nil
+			}
+			return nil, value.errorf("expected a date (YYYY-MM-DD) or timestamp (RFC 3339) for %s", name)
+		},
+		test: func(operator string, value any) (func(order *models.Order) bool, bool) {
+			span := value.(dateRange)
+			var matches func(date time.Time) bool
+			switch operator {
+			case "=":
+				matches = func(date time.Time) bool { return !date.Before(span.start) && date.Before(span.end) }
+			case "!=":
+				matches = func(date time.Time) bool { return date.Before(span.start) || !date.Before(span.end) }
+			case "<":
+				matches = func(date time.Time) bool { return date.Before(span.start) }
+			case "<=":
+				matches = func(date time.Time) bool { return date.Before(span.end) }
+			case ">":
+				matches = func(date time.Time) bool { return !date.Before(span.end) }
+			case ">=":
+				matches = func(date time.Time) bool { return !date.Before(span.start) }
+			default:
+				return nil, false
+			}
+			return func(order *models.Order) bool { return matches(get(order)) }, true
+		},
+	}
+}
+
+func booleanFieldOf(name string, get func(order *models.Order) bool) *field {
+	return &field{
+		name: name,
+		kind: booleanField,
+		parse: func(value token) (any, *ParseError) {
+			flag, err := strconv.ParseBool(strings.ToLower(value.text))
+			if err != nil {
+				return nil, value.errorf("expected true or false for %s", name)
+			}
+			return flag, nil
+		},
+		test: func(operator string, value any) (func(order *models.Order) bool, bool) {
+			expected := value.(bool)
+			switch operator {
+			case "=":
+				return func(order *models.Order) bool { return get(order) == expected }, true
+			case "!=":
+				return func(order *models.Order) bool { return get(order) != expected }, true
+			}
+			return nil, false
+		},
+	}
+}
+
+// enumFieldOf describes a field with a fixed set of values; an order can carry several of them,
+// like the methods of its payments, and matches "=" when any of them equals the value.
+func enumFieldOf(name string, requiresPayments bool, allowed []string, get func(order *models.Order) []string) *field {
+	return &field{
+		name:             name,
+		kind:             enumField,
+		requiresPayments: requiresPayments,
+		parse: func(value token) (any, *ParseError) {
+			for _, candidate := range allowed {
+				if strings.EqualFold(candidate, value.text) {
+					return candidate, nil
+				}
+			}
+			return nil, value.errorf("expected one of %s for %s", strings.Join(allowed, ", "), name)
+		},
+		test: func(operator string, value any) (func(order *models.Order) bool, bool) {
+			expected := value.(string)
+			contains := func(order *models.Order) bool {
+				for _, actual := range get(order) {
+					if actual == expected {
+						return true
+					}
+				}
+				return false
+			}
+			switch operator {
+			case "=":
+				return contains, true
+			case "!=":
+				return func(order *models.Order) bool { return !contains(order) }, true
+			}
+			return nil, false
+		},
+	}
+}
+
+// identifierFieldOf describes a field holding ids, like the products of the lines of an order. Ids are not ordered,
+// an order matches "=" when any of its ids equals the value and "!=" when none does.
+func identifierFieldOf(name string, get func(order *models.Order) []int) *field {
+	return &field{
+		name: name,
+		kind: identifierField,
+		parse: func(value token) (any, *ParseError) {
+			id, err := strconv.Atoi(value.text)
+			if err != nil {
+				return nil, value.errorf("expected an integer for %s", name)
+			}
+			return id, nil
+		},
+		test: func(operator string, value any) (func(order *models.Order) bool, bool) {
+			expected := value.(int)
+			contains := func(order *models.Order) bool {
+				return slices.Contains(get(order), expected)
+			}
+			switch operator {
+			case "=":
+				return contains, true
+			case "!=":
+				return func(order *models.Order) bool { return !contains(order) }, true
+			}
+			return nil, false
+		},
+	}
+}
+
+func orderedTest[T cmp.Ordered](operator string, expected T, get func(order *models.Order) T) (func(order *models.Order) bool, bool) {
+	return comparisonTest(operator, func(order *models.Order) int { return cmp.Compare(get(order), expected) })
+}
+
+// comparisonTest builds the order test for an operator from the comparison of an order with the expected value.
+func comparisonTest(operator string, compare func(order *models.Order) int) (func(order *models.Order) bool, bool) {
+	var holds func(comparison int) bool
+	switch operator {
+	case "=":
+		holds = func(comparison int) bool { return comparison == 0 }
+	case "!=":
+		holds = func(comparison int) bool { return comparison != 0 }
+	case "<":
+		holds = func(comparison int) bool { return comparison < 0 }
+	case "<=":
+		holds = func(comparison int) bool { return comparison <= 0 }
+	case ">":
+		holds = func(comparison int) bool { return comparison > 0 }
+	case ">=":
+		holds = func(comparison int) bool { return comparison >= 0 }
+	default:
+		return nil, false
+	}
+	return func(order *models.Order) bool { return holds(compare(order)) }, true
+}
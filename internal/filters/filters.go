@@ -0,0 +1,106 @@
+package filters
+
+import (
+	"fp_kata/internal/models"
+	"strings"
+)
+
+// Predicate is a node of a filter tree that decides whether an order is selected.
+type Predicate interface {
+	// Matches reports whether the order is selected by the predicate.
+	Matches(order *models.Order) bool
+	// RequiresPayments reports whether the predicate looks at the payments of an order,
+	// which then have to be loaded before the predicate is evaluated.
+	RequiresPayments() bool
+	// String renders the predicate as a fully parenthesized filter expression.
+	String() string
+}
+
+// And selects orders matched by all predicates.
+func And(predicates ...Predicate) Predicate {
+	return &junction{operator: "AND", predicates: predicates}
+}
+
+// Or selects orders matched by at least one of the predicates.
+func Or(predicates ...Predicate) Predicate {
+	return &junction{operator: "OR", predicates: predicates}
+}
+
+// Not selects orders the predicate does not match.
+func Not(predicate Predicate) Predicate {
+	return &negation{predicate: predicate}
+}
+
+// Match wraps a plain filter function, that does not need the payments of an order, into a Predicate.
+func Match(description string, filter func(order *models.Order) bool) Predicate {
+	return &condition{text: description, matches: filter}
+}
+
+// junction combines predicates with AND or OR.
+type junction struct {
+	operator   string
+	predicates []Predicate
+}
+
+func (j *junction) Matches(order *models.Order) bool {
+	// AND stops at the first predicate that fails, OR at the first one that holds
+	stopOn := j.operator == "OR"
+	for _, predicate := range j.predicates {
+		if predicate.Matches(order) == stopOn {
+			return stopOn
+		}
+	}
+	return !stopOn
+}
+
+func (j *junction) RequiresPayments() bool {
+	for _, predicate := range j.predicates {
+		if predicate.RequiresPayments() {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *junction) String() string {
+	parts := make([]string, len(j.predicates))
+	for i, predicate := range j.predicates {
+		parts[i] = predicate.String()
+	}
+	return "(" + strings.Join(parts, " "+j.operator+" ") + ")"
+}
+
+type negation struct {
+	predicate Predicate
+}
+
+func (n *negation) Matches(order *models.Order) bool {
+	return !n.predicate.Matches(order)
+}
+
+func (n *negation) RequiresPayments() bool {
+	return n.predicate.RequiresPayments()
+}
+
+func (n *negation) String() string {
+	return "NOT " + n.predicate.String()
+}
+
+// condition is a leaf of the filter tree comparing a single order field.
+type condition struct {
+	text             string
+	requiresPayments bool
+	matches          func(order *models.Order) bool
+}
+
+func (c *condition) Matches(order *models.Order) bool {
+	return c.matches(order)
+}
+
+func (c *condition) RequiresPayments() bool {
+	return c.requiresPayments
+}
+
+func (c *condition) String() string {
+	return c.text
+}
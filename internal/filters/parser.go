@@ -0,0 +1,312 @@
+package filters
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ParseError describes why a filter expression could not be parsed and where.
+type ParseError struct {
+	// Position is the 1-based character position of the offending token.
+	Position int    `json:"position"`
+	Token    string `json:"token"`
+	Message  string `json:"message"`
+}
+
+func (e *ParseError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("%s at end of expression", e.Message)
+	}
+	return fmt.Sprintf("%s at position %d near %q", e.Message, e.Position, e.Token)
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenOpen
+	tokenClose
+	tokenComma
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	position int
+}
+
+// is reports whether the token is the given keyword, keywords are case-insensitive.
+func (t token) is(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (t token) errorf(format string, args ...any) *ParseError {
+	return &ParseError{Position: t.position, Token: t.text, Message: fmt.Sprintf(format, args...)}
+}
+
+// Parse turns a filter expression into a predicate tree.
+//
+// An expression compares order fields and combines comparisons with AND, OR, NOT and parentheses,
+// AND binding stronger than OR:
+//
+//	price BETWEEN 10 AND 50 AND (payment_method IN (PayPal, CreditCard) OR NOT has_weightables)
+//
+// Comparisons support =, !=, <, <=, >, >=, IN (...) and BETWEEN ... AND ...; the fields are
+// price, quantity, product_id, order_date, has_weightables, status, payment_method and payment_count.
+// price is compared in the base currency, product_id with =, != and IN only, an order with several lines matches when any line has the product.
+func Parse(expression string) (Predicate, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	predicate, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEnd {
+		return nil, next.errorf("unexpected token")
+	}
+	return predicate, nil
+}
+
+func tokenize(expression string) ([]token, error) {
+	runes := []rune(expression)
+	tokens := make([]token, 0)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		position := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "(", position: position})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")", position: position})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", position: position})
+			i++
+		case r == '=' || r == '<' || r == '>' || r == '!':
+			operator := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != '=' {
+				operator += "="
+			}
+			if operator == "!" {
+				return nil, &ParseError{Position: position, Token: operator, Message: "unexpected character"}
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, position: position})
+			i += len(operator)
+		case r == '\'' || r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, &ParseError{Position: position, Token: string(runes[i:]), Message: "unterminated string"}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: string(runes[i+1 : end]), position: position})
+			i = end + 1
+		case isWordRune(r):
+			end := i
+			for end < len(runes) && isWordRune(runes[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[i:end]), position: position})
+			i = end
+		default:
+			return nil, &ParseError{Position: position, Token: string(r), Message: "unexpected character"}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEnd, position: len(runes) + 1}), nil
+}
+
+// isWordRune reports whether the rune belongs to a field name, keyword, number or date.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.:+-", r)
+}
+
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.position]
+	if t.kind != tokenEnd {
+		p.position++
+	}
+	return t
+}
+
+// parseOr parses: and ("OR" and)*
+func (p *parser) parseOr() (Predicate, error) {
+	predicates, err := p.parseJoined("OR", p.parseAnd)
+	if err != nil || len(predicates) == 1 {
+		return first(predicates), err
+	}
+	return Or(predicates...), nil
+}
+
+// parseAnd parses: unary ("AND" unary)*
+func (p *parser) parseAnd() (Predicate, error) {
+	predicates, err := p.parseJoined("AND", p.parseUnary)
+	if err != nil || len(predicates) == 1 {
+		return first(predicates), err
+	}
+	return And(predicates...), nil
+}
+
+func (p *parser) parseJoined(keyword string, parseOperand func() (Predicate, error)) ([]Predicate, error) {
+	predicates := make([]Predicate, 0)
+	for {
+		predicate, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, predicate)
+
+		if !p.peek().is(keyword) {
+			return predicates, nil
+		}
+		p.next()
+	}
+}
+
+func first(predicates []Predicate) Predicate {
+	if len(predicates) == 0 {
+		return nil
+	}
+	return predicates[0]
+}
+
+// parseUnary parses: "NOT" unary | "(" or ")" | comparison
+func (p *parser) parseUnary() (Predicate, error) {
+	current := p.peek()
+	switch {
+	case current.is("NOT"):
+		p.next()
+		predicate, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(predicate), nil
+	case current.kind == tokenOpen:
+		p.next()
+		predicate, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenClose {
+			return nil, closing.errorf("expected ')'")
+		}
+		return predicate, nil
+	default:
+		return p.parseComparison()
+	}
+}
+
+// parseComparison parses: field operator value | field "IN" "(" value ("," value)* ")" |
+// field "BETWEEN" value "AND" value | boolean field
+func (p *parser) parseComparison() (Predicate, error) {
+	name := p.next()
+	if name.kind != tokenWord || isKeyword(name) {
+		return nil, name.errorf("expected field name")
+	}
+	f, known := fields[strings.ToLower(name.text)]
+	if !known {
+		return nil, name.errorf("unknown field")
+	}
+
+	current := p.peek()
+	switch {
+	case current.kind == tokenOperator:
+		p.next()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return f.compare(current, value)
+	case current.is("IN"):
+		p.next()
+		values, err := p.parseValueList()
+		if err != nil {
+			return nil, err
+		}
+		return f.in(current, values)
+	case current.is("BETWEEN"):
+		p.next()
+		lower, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if and := p.next(); !and.is("AND") {
+			return nil, and.errorf("expected AND")
+		}
+		upper, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return f.between(current, lower, upper)
+	case f.kind == booleanField:
+		// a bare boolean field selects the orders where it is set
+		equals := token{kind: tokenOperator, text: "=", position: name.position}
+		return f.compare(equals, token{kind: tokenWord, text: "true", position: name.position})
+	default:
+		return nil, current.errorf("expected comparison operator")
+	}
+}
+
+func (p *parser) parseValue() (token, error) {
+	value := p.next()
+	if (value.kind != tokenWord && value.kind != tokenString) || isKeyword(value) {
+		return token{}, value.errorf("expected value")
+	}
+	return value, nil
+}
+
+func (p *parser) parseValueList() ([]token, error) {
+	if open := p.next(); open.kind != tokenOpen {
+		return nil, open.errorf("expected '('")
+	}
+
+	values := make([]token, 0)
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		separator := p.next()
+		switch separator.kind {
+		case tokenComma:
+			continue
+		case tokenClose:
+			return values, nil
+		default:
+			return nil, separator.errorf("expected ',' or ')'")
+		}
+	}
+}
+
+func isKeyword(t token) bool {
+	for _, keyword := range []string{"AND", "OR", "NOT", "IN", "BETWEEN"} {
+		if t.is(keyword) {
+			return true
+		}
+	}
+	return false
+}
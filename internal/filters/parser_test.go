@@ -0,0 +1,274 @@
+package filters
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testOrders() []*models.Order {
+	return []*models.Order{
+		{
+			ID:        1,
+			ProductID: 101,
+			Quantity:  1,
+			Price:     common.NewMoney(19.99),
+			OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+			Status:    common.Pending,
+		},
+		{
+			ID:        2,
+			ProductID: 102,
+			Quantity:  3,
+			Price:     common.NewMoney(120.00),
+			OrderDate: time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC),
+			Payments: []*models.Payment{
+				{Id: 1, Amount: common.NewMoney(100), Method: common.CreditCard},
+				{Id: 2, Amount: common.NewMoney(20), Method: common.PayPal},
+			},
+			HasWeightables: true,
+			Status:         common.Paid,
+		},
+		{
+			ID:        3,
+			ProductID: 103,
+			Quantity:  2,
+			Price:     common.NewMoney(75.50),
+			OrderDate: time.Date(2025, 2, 11, 0, 0, 0, 0, time.UTC),
+			Payments: []*models.Payment{
+				{Id: 3, Amount: common.NewMoney(75.50), Method: common.BankTransfer},
+			},
+			Status: common.Delivered,
+		},
+	}
+}
+
+func matchingIds(predicate Predicate, orders []*models.Order) []int {
+	ids := []int{}
+	for _, order := range orders {
+		if predicate.Matches(order) {
+			ids = append(ids, order.ID)
+		}
+	}
+	return ids
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name             string
+		expression       string
+		expectedIds      []int
+		requiresPayments bool
+		expectedString   string
+	}{
+		{
+			name:           "price comparison",
+			expression:     "price > 50",
+			expectedIds:    []int{2, 3},
+			expectedString: "price > 50",
+		},
+		{
+			name:           "and binds tighter than or",
+			expression:     "price < 20 OR price > 100 AND status = Paid",
+			expectedIds:    []int{1, 2},
+			expectedString: "(price < 20 OR (price > 100 AND status = Paid))",
+		},
+		{
+			name:           "parentheses and not",
+			expression:     "NOT (price < 20 OR status = 'Paid')",
+			expectedIds:    []int{3},
+			expectedString: "NOT (price < 20 OR status = Paid)",
+		},
+		{
+			name:           "keywords and fields are case insensitive",
+			expression:     "Quantity >= 2 and status != delivered",
+			expectedIds:    []int{2},
+			expectedString: "(quantity >= 2 AND status != delivered)",
+		},
+		{
+			name:           "in list",
+			expression:     "status IN (Pending, Delivered)",
+			expectedIds:    []int{1, 3},
+			expectedString: "status IN (Pending, Delivered)",
+		},
+		{
+			name:           "between is inclusive",
+			expression:     "price BETWEEN 19.99 AND 75.50",
+			expectedIds:    []int{1, 3},
+			expectedString: "(price >= 19.99 AND price <= 75.50)",
+		},
+		{
+			name:           "date equals matches the whole day",
+			expression:     "order_date = 2025-02-10",
+			expectedIds:    []int{2},
+			expectedString: "order_date = 2025-02-10",
+		},
+		{
+			name:           "date range",
+			expression:     "order_date BETWEEN 2025-02-01 AND 2025-02-10",
+			expectedIds:    []int{2},
+			expectedString: "(order_date >= 2025-02-01 AND order_date <= 2025-02-10)",
+		},
+		{
+			name:           "timestamp",
+			expression:     "order_date > 2025-02-10T12:00:00Z",
+			expectedIds:    []int{3},
+			expectedString: "order_date > 2025-02-10T12:00:00Z",
+		},
+		{
+			name:           "bare boolean field",
+			expression:     "has_weightables",
+			expectedIds:    []int{2},
+			expectedString: "has_weightables = true",
+		},
+		{
+			name:             "payment method matches any payment",
+			expression:       "payment_method = PayPal",
+			expectedIds:      []int{2},
+			requiresPayments: true,
+			expectedString:   "payment_method = PayPal",
+		},
+		{
+			name:             "payment method not equal matches orders without such payment",
+			expression:       "payment_method != PayPal",
+			expectedIds:      []int{1, 3},
+			requiresPayments: true,
+			expectedString:   "payment_method != PayPal",
+		},
+		{
+			name:             "payment count requires payments",
+			expression:       "product_id != 101 AND payment_count = 1",
+			expectedIds:      []int{3},
+			requiresPayments: true,
+			expectedString:   "(product_id != 101 AND payment_count = 1)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predicate, err := Parse(tt.expression)
+
+			assert.NoError(t, err, "Expected the expression to parse")
+			assert.Equal(t, tt.expectedIds, matchingIds(predicate, testOrders()), "Unexpected matching orders")
+			assert.Equal(t, tt.requiresPayments, predicate.RequiresPayments(), "Unexpected payments requirement")
+			assert.Equal(t, tt.expectedString, predicate.String(), "Unexpected rendered expression")
+		})
+	}
+}
+
+func TestParse_ProductIdMatchesAnyLine(t *testing.T) {
+	orders := []*models.Order{
+		{ID: 1, ProductID: 101, Lines: []*models.OrderLine{{ProductID: 101, Quantity: 1}}},
+		{ID: 2, Lines: []*models.OrderLine{{ProductID: 101, Quantity: 1}, {ProductID: 102, Quantity: 2}}},
+		{ID: 3, Lines: []*models.OrderLine{{ProductID: 102, Quantity: 1}, {ProductID: 103, Quantity: 1}}},
+		{ID: 4, ProductID: 104},
+	}
+
+	tests := []struct {
+		expression  string
+		expectedIds []int
+	}{
+		{expression: "product_id = 101", expectedIds: []int{1, 2}},
+		{expression: "product_id = 102", expectedIds: []int{2, 3}},
+		{expression: "product_id != 101", expectedIds: []int{3, 4}},
+		{expression: "product_id IN (103, 104)", expectedIds: []int{3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			predicate, err := Parse(tt.expression)
+
+			assert.NoError(t, err, "Expected the expression to parse")
+			assert.Equal(t, tt.expectedIds, matchingIds(predicate, orders), "Unexpected matching orders")
+		})
+	}
+}
+
+func TestParse_PriceInBaseCurrency(t *testing.T) {
+	orders := []*models.Order{
+		{ID: 1, Price: common.NewMoney(30), Currency: common.EUR},
+		{ID: 2, Price: common.NewMoney(30), Currency: common.USD, Conversion: &models.ExchangeRate{From: common.USD, To: common.EUR, Rate: 0.5}},
+	}
+
+	predicate, err := Parse("price > 20")
+
+	assert.NoError(t, err, "Expected the expression to parse")
+	assert.Equal(t, []int{1}, matchingIds(predicate, orders), "Expected prices to be compared in the base currency")
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		expression    string
+		expectedError *ParseError
+	}{
+		{
+			name:          "empty expression",
+			expression:    "",
+			expectedError: &ParseError{Position: 1, Message: "expected field name"},
+		},
+		{
+			name:          "unknown field",
+			expression:    "price > 10 AND colour = red",
+			expectedError: &ParseError{Position: 16, Token: "colour", Message: "unknown field"},
+		},
+		{
+			name:          "missing operator",
+			expression:    "price 10",
+			expectedError: &ParseError{Position: 7, Token: "10", Message: "expected comparison operator"},
+		},
+		{
+			name:          "invalid number",
+			expression:    "price > cheap",
+			expectedError: &ParseError{Position: 9, Token: "cheap", Message: "expected a number for price"},
+		},
+		{
+			name:          "unbalanced parenthesis",
+			expression:    "(price > 10",
+			expectedError: &ParseError{Position: 12, Message: "expected ')'"},
+		},
+		{
+			name:          "trailing token",
+			expression:    "price > 10 10",
+			expectedError: &ParseError{Position: 12, Token: "10", Message: "unexpected token"},
+		},
+		{
+			name:          "unsupported operator",
+			expression:    "status > Paid",
+			expectedError: &ParseError{Position: 8, Token: ">", Message: "operator not supported for status"},
+		},
+		{
+			name:       "unknown status",
+			expression: "status = Lost",
+			expectedError: &ParseError{Position: 10, Token: "Lost",
+				Message: "expected one of Pending, Paid, Fulfilled, Delivered, Cancelled, Refunded for status"},
+		},
+		{
+			name:          "between on a boolean field",
+			expression:    "has_weightables BETWEEN true AND false",
+			expectedError: &ParseError{Position: 17, Token: "BETWEEN", Message: "BETWEEN not supported for has_weightables"},
+		},
+		{
+			name:          "ordering product ids",
+			expression:    "product_id > 101",
+			expectedError: &ParseError{Position: 12, Token: ">", Message: "operator not supported for product_id"},
+		},
+		{
+			name:          "unterminated string",
+			expression:    "status = 'Paid",
+			expectedError: &ParseError{Position: 10, Token: "'Paid", Message: "unterminated string"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predicate, err := Parse(tt.expression)
+
+			assert.Nil(t, predicate, "Expected no predicate for an invalid expression")
+			assert.Equal(t, tt.expectedError, err, "Unexpected parse error")
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/internal/gateways"
+	"sync"
+)
+
+// Operation is an operation of the payment gateway, the outcomes of every operation are scripted on their own.
+type Operation string
+
+const (
+	Authorize Operation = "Authorize"
+	Capture   Operation = "Capture"
+	Void      Operation = "Void"
+	Refund    Operation = "Refund"
+)
+
+// Outcome is the scripted result of a call of an operation.
+type Outcome int
+
+const (
+	// Succeed performs the operation, the outcome of every call beyond the script.
+	Succeed Outcome = iota
+	// Decline refuses the operation with gateways.ErrPaymentDeclined.
+	Decline
+	// Timeout fails the operation with gateways.ErrGatewayTimeout. Unlike a real processor the fake never
+	// performs an operation that timed out, so retrying it is always safe.
+	Timeout
+)
+
+// Transaction is the state of a transaction of the fake gateway.
+type Transaction struct {
+	Method   common.PaymentMethod
+	Amount   common.Money
+	Captured common.Money
+	Refunded common.Money
+	Voided   bool
+}
+
+// PaymentGateway is an in-process payment gateway. Its operations succeed unless scripted otherwise, but they
+// keep the rules of a processor: only authorized transactions are captured or voided, and only captured
+// amounts are refunded. It is safe for concurrent use.
+type PaymentGateway struct {
+	mu           sync.Mutex
+	script       map[Operation][]Outcome
+	transactions map[string]*Transaction
+	lastId       int
+}
+
+func NewPaymentGateway() *PaymentGateway {
+	return &PaymentGateway{
+		script:       make(map[Operation][]Outcome),
+		transactions: make(map[string]*Transaction),
+	}
+}
+
+// Script queues the outcomes of the next calls of the operation, one outcome per call.
+func (g *PaymentGateway) Script(operation Operation, outcomes ...Outcome) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.script[operation] = append(g.script[operation], outcomes...)
+}
+
+// Transaction returns the state of the transaction with the id, false for an unknown id.
+func (g *PaymentGateway) Transaction(transactionId string) (Transaction, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	transaction, exists := g.transactions[transactionId]
+	if !exists {
+		return Transaction{}, false
+	}
+	return *transaction, true
+}
+
+func (g *PaymentGateway) Authorize(ctx context.Context, amount common.Money, method common.PaymentMethod) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if amount.Sign() <= 0 {
+		return "", fmt.Errorf("%w: authorization of %s", gateways.ErrInvalidTransaction, amount)
+	}
+	if err := g.outcome(ctx, Authorize); err != nil {
+		return "", err
+	}
+	g.lastId++
+	transactionId := fmt.Sprintf("txn_%d", g.lastId)
+	g.transactions[transactionId] = &Transaction{Method: method, Amount: amount}
+	return transactionId, nil
+}
+
+func (g *PaymentGateway) Capture(ctx context.Context, transactionId string, amount common.Money) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	transaction, err := g.transaction(transactionId)
+	if err != nil {
+		return err
+	}
+	if transaction.Voided || transaction.Captured.Sign() > 0 {
+		return fmt.Errorf("%w: %s is not authorized", gateways.ErrInvalidTransaction, transactionId)
+	}
+	if amount.Sign() <= 0 || amount.Compare(transaction.Amount) > 0 {
+		return fmt.Errorf("%w: capture of %s, %s authorized", gateways.ErrInvalidTransaction, amount, transaction.Amount)
+	}
+	if err := g.outcome(ctx, Capture); err != nil {
+		return err
+	}
+	transaction.Captured = amount
+	return nil
+}
+
+func (g *PaymentGateway) Void(ctx context.Context, transactionId string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	transaction, err := g.transaction(transactionId)
+	if err != nil {
+		return err
+	}
+	if transaction.Voided || transaction.Captured.Sign() > 0 {
+		return fmt.Errorf("%w: %s is not authorized", gateways.ErrInvalidTransaction, transactionId)
+	}
+	if err := g.outcome(ctx, Void); err != nil {
+		return err
+	}
+	transaction.Voided = true
+	return nil
+}
+
+func (g *PaymentGateway) Refund(ctx context.Context, transactionId string, amount common.Money) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	transaction, err := g.transaction(transactionId)
+	if err != nil {
+		return err
+	}
+	refundable := transaction.Captured.Sub(transaction.Refunded)
+	if amount.Sign() <= 0 || amount.Compare(refundable) > 0 {
+		return fmt.Errorf("%w: refund of %s, %s refundable", gateways.ErrInvalidTransaction, amount, refundable)
+	}
+	if err := g.outcome(ctx, Refund); err != nil {
+		return err
+	}
+	transaction.Refunded = transaction.Refunded.Add(amount)
+	return nil
+}
+
+func (g *PaymentGateway) transaction(transactionId string) (*Transaction, error) {
+	transaction, exists := g.transactions[transactionId]
+	if !exists {
+		return nil, fmt.Errorf("%w: unknown transaction %s", gateways.ErrInvalidTransaction, transactionId)
+	}
+	return transaction, nil
+}
+
+// outcome takes the next scripted outcome of the operation. A cancelled context times out like a slow processor.
+func (g *PaymentGateway) outcome(ctx context.Context, operation Operation) error {
+	outcome := Succeed
+	if outcomes := g.script[operation]; len(outcomes) > 0 {
+		outcome, g.script[operation] = outcomes[0], outcomes[1:]
+	}
+	if ctx.Err() != nil {
+		outcome = Timeout
+	}
+
+	switch outcome {
+	case Decline:
+		return fmt.Errorf("%w: %s", gateways.ErrPaymentDeclined, operation)
+	case Timeout:
+		return fmt.Errorf("%w: %s", gateways.ErrGatewayTimeout, operation)
+	}
+	return nil
+}
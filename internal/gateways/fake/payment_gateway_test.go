@@ -0,0 +1,79 @@
+package fake
+
+import (
+	"context"
+	"fp_kata/common"
+	"fp_kata/internal/gateways"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentGateway(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("authorize, capture and refund", func(t *testing.T) {
+		gateway := NewPaymentGateway()
+
+		transactionId, err := gateway.Authorize(ctx, common.NewMoney(20), common.CreditCard)
+		assert.NoError(t, err, "expected the payment to be authorized")
+		assert.NoError(t, gateway.Capture(ctx, transactionId, common.NewMoney(20)), "expected the payment to be captured")
+		assert.NoError(t, gateway.Refund(ctx, transactionId, common.NewMoney(5)), "expected a part of the payment to be refunded")
+
+		transaction, exists := gateway.Transaction(transactionId)
+		assert.True(t, exists, "expected the transaction to exist")
+		assert.Equal(t, Transaction{Method: common.CreditCard, Amount: common.NewMoney(20), Captured: common.NewMoney(20), Refunded: common.NewMoney(5)}, transaction)
+	})
+
+	t.Run("authorize and void", func(t *testing.T) {
+		gateway := NewPaymentGateway()
+
+		transactionId, _ := gateway.Authorize(ctx, common.NewMoney(20), common.PayPal)
+		assert.NoError(t, gateway.Void(ctx, transactionId), "expected the authorization to be voided")
+
+		assert.ErrorIs(t, gateway.Capture(ctx, transactionId, common.NewMoney(20)), gateways.ErrInvalidTransaction, "expected a voided authorization not to be captured")
+		assert.ErrorIs(t, gateway.Void(ctx, transactionId), gateways.ErrInvalidTransaction, "expected a voided authorization not to be voided again")
+	})
+
+	t.Run("rules of the transactions", func(t *testing.T) {
+		gateway := NewPaymentGateway()
+
+		_, err := gateway.Authorize(ctx, common.Money{}, common.PayPal)
+		assert.ErrorIs(t, err, gateways.ErrInvalidTransaction, "expected nothing to authorize")
+
+		transactionId, _ := gateway.Authorize(ctx, common.NewMoney(20), common.PayPal)
+		assert.ErrorIs(t, gateway.Refund(ctx, transactionId, common.NewMoney(5)), gateways.ErrInvalidTransaction, "expected nothing captured to refund")
+		assert.EqualError(t, gateway.Capture(ctx, transactionId, common.NewMoney(25)), "invalid payment transaction: capture of 25.00, 20.00 authorized")
+		assert.NoError(t, gateway.Capture(ctx, transactionId, common.NewMoney(15)), "expected a part of the authorization to be captured")
+		assert.ErrorIs(t, gateway.Capture(ctx, transactionId, common.NewMoney(5)), gateways.ErrInvalidTransaction, "expected a transaction to be captured once")
+		assert.ErrorIs(t, gateway.Void(ctx, transactionId), gateways.ErrInvalidTransaction, "expected a captured transaction not to be voided")
+		assert.EqualError(t, gateway.Refund(ctx, transactionId, common.NewMoney(15.01)), "invalid payment transaction: refund of 15.01, 15.00 refundable")
+		assert.ErrorIs(t, gateway.Refund(ctx, "txn_9", common.NewMoney(1)), gateways.ErrInvalidTransaction, "expected an unknown transaction")
+	})
+
+	t.Run("scripted outcomes", func(t *testing.T) {
+		gateway := NewPaymentGateway()
+		gateway.Script(Authorize, Decline, Timeout)
+
+		_, err := gateway.Authorize(ctx, common.NewMoney(20), common.PayPal)
+		assert.EqualError(t, err, "payment declined: Authorize")
+		_, err = gateway.Authorize(ctx, common.NewMoney(20), common.PayPal)
+		assert.EqualError(t, err, "payment gateway timed out: Authorize")
+
+		transactionId, err := gateway.Authorize(ctx, common.NewMoney(20), common.PayPal)
+		assert.NoError(t, err, "expected calls beyond the script to succeed")
+		assert.Equal(t, "txn_1", transactionId, "expected failed calls not to create transactions")
+
+		gateway.Script(Capture, Timeout)
+		assert.ErrorIs(t, gateway.Capture(ctx, transactionId, common.NewMoney(20)), gateways.ErrGatewayTimeout)
+		assert.NoError(t, gateway.Capture(ctx, transactionId, common.NewMoney(20)), "expected a timed out capture to be retried")
+	})
+
+	t.Run("cancelled context times out", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := NewPaymentGateway().Authorize(cancelled, common.NewMoney(20), common.PayPal)
+		assert.ErrorIs(t, err, gateways.ErrGatewayTimeout)
+	})
+}
@@ -0,0 +1,30 @@
+package gateways
+
+import (
+	"context"
+	"fp_kata/common"
+)
+
+var (
+	// ErrPaymentDeclined is returned when the processor refuses an operation, for example for insufficient funds.
+	ErrPaymentDeclined = common.NewDomainError(common.Declined, "payment_declined", "payment declined")
+	// ErrGatewayTimeout is returned when the processor did not answer in time, the operation may be retried.
+	ErrGatewayTimeout = common.NewDomainError(common.Unavailable, "payment_gateway_timeout", "payment gateway timed out")
+	// ErrInvalidTransaction is returned for an operation the state of the transaction does not allow,
+	// like capturing a voided authorization or refunding more than was captured.
+	ErrInvalidTransaction = common.NewDomainError(common.Conflict, "invalid_transaction", "invalid payment transaction")
+)
+
+// PaymentGateway processes payments with a payment processor. A payment is authorized first, which reserves
+// its amount, and captured afterwards, which collects it. Authorizations that are not captured are voided,
+// captured amounts are given back by refunds.
+type PaymentGateway interface {
+	// Authorize reserves the amount with the payment method and returns the id of the transaction.
+	Authorize(ctx context.Context, amount common.Money, method common.PaymentMethod) (string, error)
+	// Capture collects the amount, at most the authorized amount, of an authorized transaction.
+	Capture(ctx context.Context, transactionId string, amount common.Money) error
+	// Void releases an authorization that was not captured.
+	Void(ctx context.Context, transactionId string) error
+	// Refund gives back the amount, at most what is left of the captured amount, of a captured transaction.
+	Refund(ctx context.Context, transactionId string, amount common.Money) error
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/datasources/dsmodels"
+	"time"
+)
+
+// ExchangeRate converts amounts of one currency to another, it is effective from its date on.
+type ExchangeRate struct {
+	From          common.Currency
+	To            common.Currency
+	Rate          float64
+	EffectiveFrom time.Time
+}
+
+// Convert converts an amount of the From currency to the To currency, rounded to cents.
+func (r ExchangeRate) Convert(amount common.Money) common.Money {
+	return amount.Mul(r.Rate, common.HalfUp)
+}
+
+// Inverse is the rate converting the To currency back to the From currency.
+func (r ExchangeRate) Inverse() ExchangeRate {
+	return ExchangeRate{From: r.To, To: r.From, Rate: 1 / r.Rate, EffectiveFrom: r.EffectiveFrom}
+}
+
+func (r ExchangeRate) ToDSModel() *dsmodels.ExchangeRate {
+	return &dsmodels.ExchangeRate{
+		From:          r.From,
+		To:            r.To,
+		Rate:          r.Rate,
+		EffectiveFrom: r.EffectiveFrom,
+	}
+}
+
+func MapToExchangeRate(dsRate dsmodels.ExchangeRate) *ExchangeRate {
+	return &ExchangeRate{
+		From:          dsRate.From,
+		To:            dsRate.To,
+		Rate:          dsRate.Rate,
+		EffectiveFrom: dsRate.EffectiveFrom,
+	}
+}
@@ -0,0 +1,8 @@
+package models
+
+// StoredResponse is the response to a request sent with an idempotency key, it is replayed to retries of the request.
+type StoredResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
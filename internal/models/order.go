@@ -1,19 +1,28 @@
 package models
 
 import (
+	"fp_kata/common"
 	"fp_kata/internal/datasources/dsmodels"
 	"time"
 )
 
 type Order struct {
-	ID             int
+	ID int
+	// Number is the human-friendly number of the order, for example ORD-2026-000123, empty when orders are not numbered.
+	Number         string
 	ProductID      int
 	Quantity       int
-	Price          float64
+	Price          common.Money
+	Currency       common.Currency
 	OrderDate      time.Time
 	Payments       []*Payment
 	User           *User
 	HasWeightables bool
+	Status         common.OrderStatus
+	Lines          []*OrderLine
+	// Conversion is the rate the amounts of the order convert to the base currency with, effective on the order date;
+	// nil when the order is priced in the base currency.
+	Conversion *ExchangeRate
 }
 
 // ToDSModel converts the Order struct to the dsmodels.Order struct
@@ -28,29 +37,91 @@ func (o *Order) ToDSModel() *dsmodels.Order {
 		dsPayments[i] = payment.Id
 	}
 
+	var dsLines []dsmodels.OrderLine
+	for _, line := range o.Lines {
+		dsLines = append(dsLines, line.ToDSModel())
+	}
+
 	return &dsmodels.Order{
 		ID:             o.ID,
+		Number:         o.Number,
 		ProductID:      o.ProductID,
 		Quantity:       o.Quantity,
 		Price:          o.Price,
+		Currency:       o.Currency,
 		OrderDate:      o.OrderDate,
 		Payments:       dsPayments,
 		UserId:         o.User.ID,
 		HasWeightables: o.HasWeightables,
+		Status:         o.Status,
+		Lines:          dsLines,
+		Conversion:     mapToDSConversion(o.Conversion),
 	}
 }
 
 // MapToOrder maps the fields from a dsmodels.Order struct to the Order struct
 func MapToOrder(dso dsmodels.Order) *Order {
+	var lines []*OrderLine
+	for _, dsLine := range dso.Lines {
+		lines = append(lines, MapToOrderLine(dsLine))
+	}
+
 	return &Order{
 		ID:             dso.ID,
+		Number:         dso.Number,
 		ProductID:      dso.ProductID,
 		Quantity:       dso.Quantity,
 		Price:          dso.Price,
+		Currency:       dso.Currency,
 		OrderDate:      dso.OrderDate,
 		Payments:       []*Payment{},
 		User:           &User{ID: dso.UserId},
 		HasWeightables: dso.HasWeightables,
+		Status:         dso.Status,
+		Lines:          lines,
+		Conversion:     mapToConversion(dso.Conversion),
+	}
+
+}
+
+// ToBaseCurrency converts an amount of the currency of the order to the base currency.
+func (o *Order) ToBaseCurrency(amount common.Money) common.Money {
+	if o.Conversion == nil {
+		return amount
 	}
+	return o.Conversion.Convert(amount)
+}
+
+// BasePrice is the price of the order in the base currency, orders in different currencies are compared by it.
+func (o *Order) BasePrice() common.Money {
+	return o.ToBaseCurrency(o.Price)
+}
+
+// AmountDue is the amount the order has to be paid with, its total price.
+func (o *Order) AmountDue() common.Money {
+	return o.Price.Round(common.HalfUp)
+}
+
+// AmountPaid is the sum of the payments of the order in the currency of the order.
+func (o *Order) AmountPaid() common.Money {
+	paid := common.Money{}
+	for _, payment := range o.Payments {
+		paid = paid.Add(payment.OrderAmount())
+	}
+	return paid.Round(common.HalfUp)
+}
+
+// AmountRefunded is the sum of the refunds of the payments of the order in the currency of the order.
+func (o *Order) AmountRefunded() common.Money {
+	refunded := common.Money{}
+	for _, payment := range o.Payments {
+		refunded = refunded.Add(payment.toOrderCurrency(payment.AmountRefunded()))
+	}
+	return refunded.Round(common.HalfUp)
+}
 
+// Balance is the amount still outstanding, 0 once the order is settled and negative when it is overpaid.
+// Refunded amounts are no longer paid, so they count as outstanding again.
+func (o *Order) Balance() common.Money {
+	return o.AmountDue().Sub(o.AmountPaid()).Add(o.AmountRefunded())
 }
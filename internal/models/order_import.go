@@ -0,0 +1,39 @@
+package models
+
+// ImportRowStatus is the outcome of a row of an order import.
+type ImportRowStatus string
+
+const (
+	// ImportCreated rows were stored as a new order.
+	ImportCreated ImportRowStatus = "created"
+	// ImportSkipped rows are valid but were not stored, because of a dry run or a failed all-or-nothing import.
+	ImportSkipped ImportRowStatus = "skipped"
+	// ImportFailed rows are invalid or could not be stored.
+	ImportFailed ImportRowStatus = "failed"
+)
+
+// OrderImportRow is an order read from a row of an import, Err is set instead of the order for a row that could not be read.
+type OrderImportRow struct {
+	// Row is the number of the line the order starts on.
+	Row   int
+	Order *Order
+	Err   error
+}
+
+// OrderImportResult reports what became of a row of an import.
+type OrderImportResult struct {
+	Row    int
+	Status ImportRowStatus
+	// OrderID is the id of the created order.
+	OrderID int
+	// Reason explains why a row was skipped or failed.
+	Reason string
+}
+
+// OrderImportOptions select how an import stores its orders.
+type OrderImportOptions struct {
+	// DryRun checks the rows without storing any order.
+	DryRun bool
+	// AllOrNothing stores no order at all unless every row can be stored.
+	AllOrNothing bool
+}
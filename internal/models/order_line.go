@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/datasources/dsmodels"
+)
+
+// OrderLine is a single product of an order.
+// Weighted lines are priced per unit of weight: the unit price is the price of one WeightUnit and
+// the line total follows the actual weight once the line is weighed, the estimated weight before.
+type OrderLine struct {
+	ProductID       int
+	Quantity        int
+	UnitPrice       common.Money
+	LineTotal       common.Money
+	WeightUnit      common.WeightUnit
+	EstimatedWeight float64
+	ActualWeight    float64
+}
+
+// NewOrderLine creates an order line, the line total is the unit price times the quantity rounded to cents.
+func NewOrderLine(productID int, quantity int, unitPrice common.Money) *OrderLine {
+	return &OrderLine{
+		ProductID: productID,
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
+		LineTotal: unitPrice.Mul(float64(quantity), common.HalfUp),
+	}
+}
+
+// NewWeightedOrderLine creates an order line priced per unit of weight, its line total is based on the estimated weight.
+func NewWeightedOrderLine(productID int, quantity int, unitPrice common.Money, unit common.WeightUnit, estimatedWeight float64) *OrderLine {
+	return &OrderLine{
+		ProductID:       productID,
+		Quantity:        quantity,
+		UnitPrice:       unitPrice,
+		LineTotal:       unitPrice.Mul(estimatedWeight, common.HalfUp),
+		WeightUnit:      unit,
+		EstimatedWeight: estimatedWeight,
+	}
+}
+
+// IsWeighted reports whether the line is priced per unit of weight.
+func (l *OrderLine) IsWeighted() bool {
+	return l.WeightUnit != ""
+}
+
+// Weigh records the actual weight of a weighted line, given in the unit of the line, and recalculates the line total.
+func (l *OrderLine) Weigh(actualWeight float64) {
+	l.ActualWeight = actualWeight
+	l.LineTotal = l.UnitPrice.Mul(actualWeight, common.HalfUp)
+}
+
+// Reprice sets the unit price of the line and recalculates the line total, from the actual weight of
+// a weighed line, the estimated weight of a weighted line or the quantity otherwise.
+func (l *OrderLine) Reprice(unitPrice common.Money) {
+	l.UnitPrice = unitPrice
+	switch {
+	case l.IsWeighted() && l.ActualWeight > 0:
+		l.LineTotal = unitPrice.Mul(l.ActualWeight, common.HalfUp)
+	case l.IsWeighted():
+		l.LineTotal = unitPrice.Mul(l.EstimatedWeight, common.HalfUp)
+	default:
+		l.LineTotal = unitPrice.Mul(float64(l.Quantity), common.HalfUp)
+	}
+}
+
+// ApplyLines computes the order totals from its lines: the price is the sum of the line totals
+// and the quantity the sum of the line quantities. The product id is only kept for single-line orders,
+// an order with a weighted line has weightables.
+func (o *Order) ApplyLines() {
+	o.Price = common.Money{}
+	o.Quantity = 0
+	o.ProductID = 0
+	for _, line := range o.Lines {
+		o.Price = o.Price.Add(line.LineTotal)
+		o.Quantity += line.Quantity
+		if line.IsWeighted() {
+			o.HasWeightables = true
+		}
+	}
+	if len(o.Lines) == 1 {
+		o.ProductID = o.Lines[0].ProductID
+	}
+}
+
+// ProductIDs are the products of the order, one per line, or the product of an order without lines.
+func (o *Order) ProductIDs() []int {
+	if len(o.Lines) == 0 {
+		return []int{o.ProductID}
+	}
+	ids := make([]int, len(o.Lines))
+	for i, line := range o.Lines {
+		ids[i] = line.ProductID
+	}
+	return ids
+}
+
+func (l OrderLine) ToDSModel() dsmodels.OrderLine {
+	return dsmodels.OrderLine{
+		ProductID:       l.ProductID,
+		Quantity:        l.Quantity,
+		UnitPrice:       l.UnitPrice,
+		LineTotal:       l.LineTotal,
+		WeightUnit:      l.WeightUnit,
+		EstimatedWeight: l.EstimatedWeight,
+		ActualWeight:    l.ActualWeight,
+	}
+}
+
+func MapToOrderLine(dsLine dsmodels.OrderLine) *OrderLine {
+	return &OrderLine{
+		ProductID:       dsLine.ProductID,
+		Quantity:        dsLine.Quantity,
+		UnitPrice:       dsLine.UnitPrice,
+		LineTotal:       dsLine.LineTotal,
+		WeightUnit:      dsLine.WeightUnit,
+		EstimatedWeight: dsLine.EstimatedWeight,
+		ActualWeight:    dsLine.ActualWeight,
+	}
+}
+
+// LineWeight is the actual weight of an order line measured when the order is weighed.
+type LineWeight struct {
+	// Index is the position of the line in the order, starting at 0.
+	Index  int
+	Weight float64
+	// Unit of the weight, empty for the unit of the line.
+	Unit common.WeightUnit
+}
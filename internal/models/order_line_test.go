@@ -0,0 +1,138 @@
+package models
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/datasources/dsmodels"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrderLine(t *testing.T) {
+	line := NewOrderLine(101, 3, common.NewMoney(0.1))
+
+	assert.Equal(t, &OrderLine{ProductID: 101, Quantity: 3, UnitPrice: common.NewMoney(0.1), LineTotal: common.NewMoney(0.3)}, line, "line total should be rounded to cents")
+}
+
+func TestOrderApplyLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []*OrderLine
+		expected Order
+	}{
+		{
+			name:  "single_line_keeps_product",
+			lines: []*OrderLine{NewOrderLine(101, 2, common.NewMoney(10.5))},
+			expected: Order{
+				ProductID: 101,
+				Quantity:  2,
+				Price:     common.NewMoney(21),
+			},
+		},
+		{
+			name: "multiple_lines",
+			lines: []*OrderLine{
+				NewOrderLine(101, 3, common.NewMoney(1.1)),
+				NewOrderLine(102, 1, common.NewMoney(20.2)),
+			},
+			expected: Order{
+				Quantity: 4,
+				Price:    common.NewMoney(23.5),
+			},
+		},
+		{
+			name:     "no_lines",
+			lines:    nil,
+			expected: Order{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &Order{ProductID: 999, Quantity: 9, Price: common.NewMoney(99), Lines: tt.lines}
+			order.ApplyLines()
+
+			tt.expected.Lines = tt.lines
+			assert.Equal(t, tt.expected, *order, "order totals should be computed from the lines")
+		})
+	}
+}
+
+func TestOrderLinesRoundTrip(t *testing.T) {
+	order := &Order{
+		ID:        1,
+		Quantity:  4,
+		Price:     common.NewMoney(23.5),
+		OrderDate: time.Date(2023, 10, 10, 12, 0, 0, 0, time.UTC),
+		Payments:  []*Payment{},
+		User:      &User{ID: 301},
+		Lines: []*OrderLine{
+			NewOrderLine(101, 3, common.NewMoney(1.1)),
+			NewOrderLine(102, 1, common.NewMoney(20.2)),
+		},
+	}
+
+	dsOrder := order.ToDSModel()
+
+	assert.Equal(t, []dsmodels.OrderLine{
+		{ProductID: 101, Quantity: 3, UnitPrice: common.NewMoney(1.1), LineTotal: common.NewMoney(3.3)},
+		{ProductID: 102, Quantity: 1, UnitPrice: common.NewMoney(20.2), LineTotal: common.NewMoney(20.2)},
+	}, dsOrder.Lines, "lines should be mapped to the datasource model")
+	assert.Equal(t, order, MapToOrder(*dsOrder), "lines should round-trip through the datasource model")
+}
+
+func TestWeightedOrderLine(t *testing.T) {
+	line := NewWeightedOrderLine(102, 1, common.NewMoney(2.99), common.Kilogram, 1.5)
+	assert.True(t, line.IsWeighted(), "expected a weighted line")
+	assert.Equal(t, common.NewMoney(4.49), line.LineTotal, "line total should be based on the estimated weight")
+
+	line.Weigh(1.42)
+	assert.Equal(t, 1.42, line.ActualWeight, "actual weight should be recorded")
+	assert.Equal(t, common.NewMoney(4.25), line.LineTotal, "line total should be based on the actual weight")
+
+	order := &Order{Lines: []*OrderLine{NewOrderLine(101, 1, common.NewMoney(1)), line}}
+	order.ApplyLines()
+	assert.True(t, order.HasWeightables, "an order with a weighted line has weightables")
+	assert.Equal(t, common.NewMoney(5.25), order.Price, "order total should include the weighed line")
+}
+
+func TestOrderLineReprice(t *testing.T) {
+	tests := []struct {
+		name          string
+		line          *OrderLine
+		unitPrice     common.Money
+		expectedTotal common.Money
+	}{
+		{name: "by_quantity", line: NewOrderLine(101, 3, common.NewMoney(1)), unitPrice: common.NewMoney(0.35), expectedTotal: common.NewMoney(1.05)},
+		{name: "by_estimated_weight", line: NewWeightedOrderLine(7, 1, common.NewMoney(1), common.Kilogram, 1.5), unitPrice: common.NewMoney(4), expectedTotal: common.NewMoney(6)},
+		{
+			name: "by_actual_weight",
+			line: func() *OrderLine {
+				line := NewWeightedOrderLine(7, 1, common.NewMoney(1), common.Kilogram, 1.5)
+				line.Weigh(1.6)
+				return line
+			}(),
+			unitPrice:     common.NewMoney(4),
+			expectedTotal: common.NewMoney(6.4),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.line.Reprice(test.unitPrice)
+			assert.Equal(t, test.unitPrice, test.line.UnitPrice, "unit price should be replaced")
+			assert.Equal(t, test.expectedTotal, test.line.LineTotal, "line total should follow the new unit price")
+		})
+	}
+}
+
+func TestOrderStockItems(t *testing.T) {
+	order := Order{Lines: []*OrderLine{
+		NewOrderLine(102, 1, common.NewMoney(3)),
+		NewWeightedOrderLine(101, 2, common.NewMoney(4), common.Kilogram, 1.5),
+		NewOrderLine(102, 4, common.NewMoney(3)),
+	}}
+
+	assert.Equal(t, []StockItem{{ProductID: 102, Quantity: 5}, {ProductID: 101, Quantity: 2}}, order.StockItems(), "quantities should be added up per product")
+}
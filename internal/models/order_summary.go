@@ -0,0 +1,123 @@
+package models
+
+import (
+	"fmt"
+	"fp_kata/common"
+	"time"
+)
+
+// SummaryPeriod is the length of the periods an order summary groups the orders by.
+type SummaryPeriod string
+
+const (
+	SummaryByDay   SummaryPeriod = "day"
+	SummaryByWeek  SummaryPeriod = "week"
+	SummaryByMonth SummaryPeriod = "month"
+)
+
+// ErrInvalidSummaryPeriod is returned for an unknown summary period.
+var ErrInvalidSummaryPeriod = common.NewDomainError(common.Validation, "invalid_period", "invalid period")
+
+// ParseSummaryPeriod parses the period of an order summary, month when empty.
+func ParseSummaryPeriod(period string) (SummaryPeriod, error) {
+	switch SummaryPeriod(period) {
+	case "", SummaryByMonth:
+		return SummaryByMonth, nil
+	case SummaryByDay, SummaryByWeek:
+		return SummaryPeriod(period), nil
+	}
+	return "", fmt.Errorf("%w: unknown period %q", ErrInvalidSummaryPeriod, period)
+}
+
+// Key names the period a date falls in: 2025-02-10 for a day, the ISO week 2025-W07 for a week and 2025-02 for a month.
+// Dates are grouped in UTC.
+func (p SummaryPeriod) Key(date time.Time) string {
+	date = date.UTC()
+	switch p {
+	case SummaryByDay:
+		return date.Format(time.DateOnly)
+	case SummaryByWeek:
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	default:
+		return date.Format("2006-01")
+	}
+}
+
+// OrderAggregate sums the spend of a group of orders.
+type OrderAggregate struct {
+	TotalSpend common.Money
+	OrderCount int
+}
+
+// Add counts an order that spent the amount.
+func (a *OrderAggregate) Add(spend common.Money) {
+	a.TotalSpend = a.TotalSpend.Add(spend)
+	a.OrderCount++
+}
+
+// AverageBasket is the average spend per order, rounded half up to cents, 0 without orders.
+func (a OrderAggregate) AverageBasket() common.Money {
+	return a.TotalSpend.Div(a.OrderCount, common.HalfUp)
+}
+
+// PeriodAggregate sums the orders placed in a period.
+type PeriodAggregate struct {
+	Period string
+	OrderAggregate
+}
+
+// PaymentMethodAggregate sums the payments made with a payment method, counting every order paid with it once.
+type PaymentMethodAggregate struct {
+	Method common.PaymentMethod
+	OrderAggregate
+}
+
+// ProductAggregate sums the spend on a product, counting every order containing it once.
+type ProductAggregate struct {
+	ProductID int
+	OrderAggregate
+}
+
+// OrderSummary aggregates the spend of orders in total and grouped by period, payment method and product.
+// The groups are sorted by their period, payment method and product id. All amounts are in the currency of the summary,
+// the base currency.
+type OrderSummary struct {
+	Period   SummaryPeriod
+	Currency common.Currency
+	OrderAggregate
+	ByPeriod        []*PeriodAggregate
+	ByPaymentMethod []*PaymentMethodAggregate
+	ByProduct       []*ProductAggregate
+}
+
+// CountsAsSpend reports whether the order is part of the spend of its user, cancelled and refunded orders are not.
+func (o *Order) CountsAsSpend() bool {
+	return o.Status != common.Cancelled && o.Status != common.Refunded
+}
+
+// ProductSpend is the amount spent on every product of the order, from its lines or its single product without lines.
+func (o *Order) ProductSpend() map[int]common.Money {
+	spend := make(map[int]common.Money)
+	if len(o.Lines) == 0 {
+		if o.ProductID != 0 {
+			spend[o.ProductID] = o.AmountDue()
+		}
+		return spend
+	}
+	for _, line := range o.Lines {
+		spend[line.ProductID] = spend[line.ProductID].Add(line.LineTotal)
+	}
+	return spend
+}
+
+// PaymentMethodSpend is the amount paid with every payment method used for the order, less what was refunded,
+// in the currency of the order like AmountPaid and AmountRefunded.
+func (o *Order) PaymentMethodSpend() map[common.PaymentMethod]common.Money {
+	spend := make(map[common.PaymentMethod]common.Money)
+	for _, payment := range o.Payments {
+		paid := payment.OrderAmount().Sub(payment.toOrderCurrency(payment.AmountRefunded()))
+		spend[payment.Method] = spend[payment.Method].Add(paid)
+	}
+	return spend
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fp_kata/common"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummaryPeriod_Key(t *testing.T) {
+	date := time.Date(2025, 1, 1, 23, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name     string
+		period   string
+		expected string
+	}{
+		{name: "month by default", period: "", expected: "2025-01"},
+		{name: "day in UTC", period: "day", expected: "2025-01-01"},
+		{name: "ISO week", period: "week", expected: "2025-W01"},
+		{name: "month", period: "month", expected: "2025-01"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			period, err := ParseSummaryPeriod(test.period)
+			assert.NoError(t, err, "expected the period to parse")
+			assert.Equal(t, test.expected, period.Key(date), "unexpected period key")
+		})
+	}
+
+	// the last days of 2024 belong to the first ISO week of 2025
+	assert.Equal(t, "2025-W01", SummaryByWeek.Key(time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)), "unexpected week key")
+
+	_, err := ParseSummaryPeriod("year")
+	assert.ErrorIs(t, err, ErrInvalidSummaryPeriod, "expected an unknown period to be rejected")
+}
+
+func TestOrderAggregate(t *testing.T) {
+	var aggregate OrderAggregate
+	assert.Equal(t, common.Money{}, aggregate.AverageBasket(), "expected no average without orders")
+
+	aggregate.Add(common.NewMoney(10.1))
+	aggregate.Add(common.NewMoney(0.2))
+	aggregate.Add(common.NewMoney(5))
+
+	assert.Equal(t, common.NewMoney(15.3), aggregate.TotalSpend, "unexpected total spend")
+	assert.Equal(t, 3, aggregate.OrderCount, "unexpected order count")
+	assert.Equal(t, common.NewMoney(5.1), aggregate.AverageBasket(), "unexpected average basket")
+}
+
+func TestOrder_ProductSpend(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    Order
+		expected map[int]common.Money
+	}{
+		{
+			name: "lines of the same product are added up",
+			order: Order{Lines: []*OrderLine{
+				NewOrderLine(1, 2, common.NewMoney(1.5)),
+				NewOrderLine(2, 1, common.NewMoney(4)),
+				NewOrderLine(1, 1, common.NewMoney(0.25)),
+			}},
+			expected: map[int]common.Money{1: common.NewMoney(3.25), 2: common.NewMoney(4)},
+		},
+		{
+			name:     "order without lines",
+			order:    Order{ProductID: 3, Price: common.NewMoney(9.99)},
+			expected: map[int]common.Money{3: common.NewMoney(9.99)},
+		},
+		{
+			name:     "order without product",
+			order:    Order{Price: common.NewMoney(9.99)},
+			expected: map[int]common.Money{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.order.ProductSpend(), "unexpected product spend")
+		})
+	}
+}
+
+func TestOrder_PaymentMethodSpend(t *testing.T) {
+	order := Order{Payments: []*Payment{
+		{Amount: common.NewMoney(10), Method: common.CreditCard},
+		{Amount: common.NewMoney(2.5), Method: common.PayPal},
+		{Amount: common.NewMoney(-1.25), Method: common.CreditCard},
+		{Amount: common.NewMoney(4), Method: common.PayPal, Refunds: []*Refund{{Amount: common.NewMoney(1.5)}}},
+	}}
+
+	assert.Equal(t, map[common.PaymentMethod]common.Money{
+		common.CreditCard: common.NewMoney(8.75),
+		common.PayPal:     common.NewMoney(5),
+	}, order.PaymentMethodSpend(), "unexpected payment method spend")
+}
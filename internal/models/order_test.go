@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fp_kata/common"
 	"fp_kata/internal/datasources/dsmodels"
 	"testing"
 	"time"
@@ -22,7 +23,7 @@ func TestOrderToDSModel(t *testing.T) {
 					ID:        1,
 					ProductID: 101,
 					Quantity:  2,
-					Price:     15.5,
+					Price:     common.NewMoney(15.5),
 					OrderDate: time.Date(2023, 10, 10, 12, 0, 0, 0, time.UTC),
 					Payments: []*Payment{
 						{Id: 201},
@@ -36,7 +37,7 @@ func TestOrderToDSModel(t *testing.T) {
 					ID:             1,
 					ProductID:      101,
 					Quantity:       2,
-					Price:          15.5,
+					Price:          common.NewMoney(15.5),
 					OrderDate:      time.Date(2023, 10, 10, 12, 0, 0, 0, time.UTC),
 					Payments:       []int{201},
 					UserId:         301,
@@ -52,7 +53,7 @@ func TestOrderToDSModel(t *testing.T) {
 					ID:        2,
 					ProductID: 102,
 					Quantity:  5,
-					Price:     50.0,
+					Price:     common.NewMoney(50.0),
 					OrderDate: time.Date(2023, 8, 1, 8, 0, 0, 0, time.UTC),
 					Payments: []*Payment{
 						{Id: 202},
@@ -67,7 +68,7 @@ func TestOrderToDSModel(t *testing.T) {
 					ID:             2,
 					ProductID:      102,
 					Quantity:       5,
-					Price:          50.0,
+					Price:          common.NewMoney(50.0),
 					OrderDate:      time.Date(2023, 8, 1, 8, 0, 0, 0, time.UTC),
 					Payments:       []int{202, 203},
 					UserId:         302,
@@ -83,7 +84,7 @@ func TestOrderToDSModel(t *testing.T) {
 					ID:        3,
 					ProductID: 103,
 					Quantity:  1,
-					Price:     25.0,
+					Price:     common.NewMoney(25.0),
 					OrderDate: time.Date(2023, 5, 15, 15, 0, 0, 0, time.UTC),
 					Payments:  []*Payment{},
 					User:      &User{ID: 303},
@@ -94,7 +95,7 @@ func TestOrderToDSModel(t *testing.T) {
 					ID:             3,
 					ProductID:      103,
 					Quantity:       1,
-					Price:          25.0,
+					Price:          common.NewMoney(25.0),
 					OrderDate:      time.Date(2023, 5, 15, 15, 0, 0, 0, time.UTC),
 					Payments:       []int{},
 					UserId:         303,
@@ -110,7 +111,7 @@ func TestOrderToDSModel(t *testing.T) {
 					ID:        4,
 					ProductID: 104,
 					Quantity:  3,
-					Price:     75.0,
+					Price:     common.NewMoney(75.0),
 					OrderDate: time.Date(2023, 7, 20, 0, 0, 0, 0, time.UTC),
 					Payments: []*Payment{
 						{Id: 204},
@@ -152,7 +153,7 @@ func TestMapToOrder(t *testing.T) {
 					ID:             1,
 					ProductID:      101,
 					Quantity:       2,
-					Price:          15.5,
+					Price:          common.NewMoney(15.5),
 					OrderDate:      time.Date(2023, 10, 10, 12, 0, 0, 0, time.UTC),
 					Payments:       []int{201},
 					UserId:         301,
@@ -164,7 +165,7 @@ func TestMapToOrder(t *testing.T) {
 					ID:             1,
 					ProductID:      101,
 					Quantity:       2,
-					Price:          15.5,
+					Price:          common.NewMoney(15.5),
 					OrderDate:      time.Date(2023, 10, 10, 12, 0, 0, 0, time.UTC),
 					Payments:       []*Payment{},
 					User:           &User{ID: 301},
@@ -180,7 +181,7 @@ func TestMapToOrder(t *testing.T) {
 					ID:             2,
 					ProductID:      102,
 					Quantity:       3,
-					Price:          20.0,
+					Price:          common.NewMoney(20.0),
 					OrderDate:      time.Date(2023, 11, 5, 0, 0, 0, 0, time.UTC),
 					Payments:       []int{202},
 					UserId:         0,
@@ -192,7 +193,7 @@ func TestMapToOrder(t *testing.T) {
 					ID:             2,
 					ProductID:      102,
 					Quantity:       3,
-					Price:          20.0,
+					Price:          common.NewMoney(20.0),
 					OrderDate:      time.Date(2023, 11, 5, 0, 0, 0, 0, time.UTC),
 					Payments:       []*Payment{},
 					User:           &User{ID: 0},
@@ -208,7 +209,7 @@ func TestMapToOrder(t *testing.T) {
 					ID:             3,
 					ProductID:      103,
 					Quantity:       1,
-					Price:          30.0,
+					Price:          common.NewMoney(30.0),
 					OrderDate:      time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC),
 					Payments:       nil,
 					UserId:         303,
@@ -220,7 +221,7 @@ func TestMapToOrder(t *testing.T) {
 					ID:             3,
 					ProductID:      103,
 					Quantity:       1,
-					Price:          30.0,
+					Price:          common.NewMoney(30.0),
 					OrderDate:      time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC),
 					Payments:       []*Payment{},
 					User:           &User{ID: 303},
@@ -242,3 +243,114 @@ func TestMapToOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestOrderBalance(t *testing.T) {
+	tests := []struct {
+		name         string
+		order        Order
+		expectedPaid common.Money
+		expected     common.Money
+	}{
+		{
+			name:         "unpaid order",
+			order:        Order{Price: common.NewMoney(20.5)},
+			expectedPaid: common.Money{},
+			expected:     common.NewMoney(20.5),
+		},
+		{
+			name:         "partially paid order",
+			order:        Order{Price: common.NewMoney(20.5), Payments: []*Payment{{Amount: common.NewMoney(10.1)}, {Amount: common.NewMoney(0.2)}}},
+			expectedPaid: common.NewMoney(10.3),
+			expected:     common.NewMoney(10.2),
+		},
+		{
+			name:         "settled order",
+			order:        Order{Price: common.NewMoney(0.3), Payments: []*Payment{{Amount: common.NewMoney(0.1)}, {Amount: common.NewMoney(0.2)}}},
+			expectedPaid: common.NewMoney(0.3),
+			expected:     common.Money{},
+		},
+		{
+			name:         "refunded overpayment",
+			order:        Order{Price: common.NewMoney(8.4), Payments: []*Payment{{Amount: common.NewMoney(9)}, {Amount: common.NewMoney(-0.6)}}},
+			expectedPaid: common.NewMoney(8.4),
+			expected:     common.Money{},
+		},
+		{
+			name:         "overpaid order",
+			order:        Order{Price: common.NewMoney(8.4), Payments: []*Payment{{Amount: common.NewMoney(9)}}},
+			expectedPaid: common.NewMoney(9),
+			expected:     common.NewMoney(-0.6),
+		},
+		{
+			name:         "partially refunded order",
+			order:        Order{Price: common.NewMoney(20), Payments: []*Payment{{Amount: common.NewMoney(20), Refunds: []*Refund{{Amount: common.NewMoney(5.1)}, {Amount: common.NewMoney(2.2)}}}}},
+			expectedPaid: common.NewMoney(20),
+			expected:     common.NewMoney(7.3),
+		},
+		{
+			name: "order paid in another currency",
+			order: Order{Price: common.NewMoney(20), Currency: common.EUR, Payments: []*Payment{
+				{Amount: common.NewMoney(10), Currency: common.EUR},
+				{Amount: common.NewMoney(10), Currency: common.USD, Conversion: &ExchangeRate{From: common.USD, To: common.EUR, Rate: 0.9}},
+			}},
+			expectedPaid: common.NewMoney(19),
+			expected:     common.NewMoney(1),
+		},
+		{
+			name: "refund of a payment in another currency",
+			order: Order{Price: common.NewMoney(10), Currency: common.EUR, Payments: []*Payment{
+				{Amount: common.NewMoney(20), Currency: common.USD, Conversion: &ExchangeRate{From: common.USD, To: common.EUR, Rate: 0.5}, Refunds: []*Refund{{Amount: common.NewMoney(4)}}},
+			}},
+			expectedPaid: common.NewMoney(10),
+			expected:     common.NewMoney(2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedPaid, tt.order.AmountPaid(), "unexpected amount paid")
+			assert.Equal(t, tt.expected, tt.order.Balance(), "unexpected balance")
+		})
+	}
+}
+
+func TestPaymentRefundableAmount(t *testing.T) {
+	tests := []struct {
+		name             string
+		payment          Payment
+		expectedRefunded common.Money
+		expected         common.Money
+	}{
+		{
+			name:             "payment without refunds",
+			payment:          Payment{Amount: common.NewMoney(12.5)},
+			expectedRefunded: common.Money{},
+			expected:         common.NewMoney(12.5),
+		},
+		{
+			name:             "partially refunded payment",
+			payment:          Payment{Amount: common.NewMoney(12.5), Refunds: []*Refund{{Amount: common.NewMoney(0.1)}, {Amount: common.NewMoney(0.2)}}},
+			expectedRefunded: common.NewMoney(0.3),
+			expected:         common.NewMoney(12.2),
+		},
+		{
+			name:             "fully refunded payment",
+			payment:          Payment{Amount: common.NewMoney(12.5), Refunds: []*Refund{{Amount: common.NewMoney(12.5)}}},
+			expectedRefunded: common.NewMoney(12.5),
+			expected:         common.Money{},
+		},
+		{
+			name:             "adjustment refunded after weighing",
+			payment:          Payment{Amount: common.NewMoney(-0.6)},
+			expectedRefunded: common.Money{},
+			expected:         common.Money{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedRefunded, tt.payment.AmountRefunded(), "unexpected amount refunded")
+			assert.Equal(t, tt.expected, tt.payment.RefundableAmount(), "unexpected refundable amount")
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fp_kata/internal/datasources/dsmodels"
+	"reflect"
+	"time"
+)
+
+// OrderVersion is an order as it was stored at a point in time. Payments are kept apart from their orders,
+// so the order of a version only carries the ids of its payments.
+type OrderVersion struct {
+	Version   int
+	ChangedAt time.Time
+	// ChangedBy is the id of the user who stored the version, 0 when unknown.
+	ChangedBy int
+	Order     *Order
+	// Changes are the fields changed since the previous version, none for the first version.
+	Changes []FieldChange
+}
+
+// MapToOrderVersion maps a stored version, its order lists its payments by id only.
+func MapToOrderVersion(dsv dsmodels.OrderVersion) *OrderVersion {
+	order := MapToOrder(dsv.Order)
+	for _, paymentId := range dsv.Order.Payments {
+		order.Payments = append(order.Payments, &Payment{Id: paymentId})
+	}
+	return &OrderVersion{
+		Version:   dsv.Version,
+		ChangedAt: dsv.ChangedAt,
+		ChangedBy: dsv.ChangedBy,
+		Order:     order,
+	}
+}
+
+// FieldChange is a field that differs between two versions of an order.
+type FieldChange struct {
+	Field string
+	From  any
+	To    any
+}
+
+// orderField is a field of an order compared between versions.
+type orderField struct {
+	name  string
+	value func(order *Order) any
+}
+
+// orderFields are the fields of an order compared between versions, in the order changes are reported.
+var orderFields = []orderField{
+	{"order_number", func(order *Order) any { return order.Number }},
+	{"user_id", func(order *Order) any {
+		if order.User == nil {
+			return 0
+		}
+		return order.User.ID
+	}},
+	{"status", func(order *Order) any { return order.Status }},
+	{"order_date", func(order *Order) any { return order.OrderDate.UTC() }},
+	{"product_id", func(order *Order) any { return order.ProductID }},
+	{"quantity", func(order *Order) any { return order.Quantity }},
+	{"price", func(order *Order) any { return order.Price }},
+	{"has_weightables", func(order *Order) any { return order.HasWeightables }},
+	{"lines", func(order *Order) any {
+		lines := make([]OrderLine, len(order.Lines))
+		for i, line := range order.Lines {
+			lines[i] = *line
+		}
+		return lines
+	}},
+	{"payment_ids", func(order *Order) any {
+		ids := make([]int, len(order.Payments))
+		for i, payment := range order.Payments {
+			ids[i] = payment.Id
+		}
+		return ids
+	}},
+}
+
+// DiffOrders lists the fields that differ between two versions of an order. Lines and payments are compared as a whole.
+func DiffOrders(from *Order, to *Order) []FieldChange {
+	changes := make([]FieldChange, 0)
+	for _, field := range orderFields {
+		fromValue, toValue := field.value(from), field.value(to)
+		if !reflect.DeepEqual(fromValue, toValue) {
+			changes = append(changes, FieldChange{Field: field.name, From: fromValue, To: toValue})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/datasources/dsmodels"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffOrders(t *testing.T) {
+	orderDate := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+	newOrder := func() *Order {
+		return &Order{
+			ID:        1,
+			User:      &User{ID: 1},
+			Status:    common.Pending,
+			OrderDate: orderDate,
+			ProductID: 1,
+			Quantity:  2,
+			Price:     common.NewMoney(20),
+			Lines:     []*OrderLine{NewOrderLine(1, 2, common.NewMoney(10))},
+			Payments:  []*Payment{{Id: 1, Amount: common.NewMoney(20)}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		change   func(order *Order)
+		expected []FieldChange
+	}{
+		{
+			name:     "unchanged order",
+			change:   func(order *Order) {},
+			expected: []FieldChange{},
+		},
+		{
+			name: "changed fields",
+			change: func(order *Order) {
+				order.Status = common.Paid
+				order.Price = common.NewMoney(25)
+				// the same date in another location is no change
+				order.OrderDate = orderDate.In(time.FixedZone("CET", 3600))
+				// the amounts of payments are not part of an order version
+				order.Payments = []*Payment{{Id: 1, Amount: common.NewMoney(25)}, {Id: 2}}
+			},
+			expected: []FieldChange{
+				{Field: "status", From: common.Pending, To: common.Paid},
+				{Field: "price", From: common.NewMoney(20), To: common.NewMoney(25)},
+				{Field: "payment_ids", From: []int{1}, To: []int{1, 2}},
+			},
+		},
+		{
+			name: "changed lines",
+			change: func(order *Order) {
+				order.Lines = append(order.Lines, NewOrderLine(2, 1, common.NewMoney(5)))
+			},
+			expected: []FieldChange{
+				{Field: "lines", From: []OrderLine{*NewOrderLine(1, 2, common.NewMoney(10))}, To: []OrderLine{*NewOrderLine(1, 2, common.NewMoney(10)), *NewOrderLine(2, 1, common.NewMoney(5))}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changed := newOrder()
+			test.change(changed)
+			assert.Equal(t, test.expected, DiffOrders(newOrder(), changed), "unexpected changes")
+		})
+	}
+}
+
+func TestMapToOrderVersion(t *testing.T) {
+	changedAt := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+
+	version := MapToOrderVersion(dsmodels.OrderVersion{
+		Version:   2,
+		ChangedAt: changedAt,
+		ChangedBy: 1,
+		Order:     dsmodels.Order{ID: 1, UserId: 1, Payments: []int{3, 4}},
+	})
+
+	assert.Equal(t, 2, version.Version, "unexpected version")
+	assert.Equal(t, changedAt, version.ChangedAt, "unexpected change time")
+	assert.Equal(t, 1, version.ChangedBy, "unexpected user")
+	assert.Equal(t, []*Payment{{Id: 3}, {Id: 4}}, version.Order.Payments, "expected the payments by id")
+	assert.Nil(t, version.Changes, "expected no changes before they are compared")
+}
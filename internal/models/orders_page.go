@@ -0,0 +1,9 @@
+package models
+
+// OrdersPage is a page of orders of a listing together with the number of orders on all pages.
+type OrdersPage struct {
+	Orders []*Order
+	Total  int
+	// NextCursor continues the listing on the next page, empty on the last page.
+	NextCursor string
+}
@@ -3,14 +3,42 @@ package models
 import (
 	"fp_kata/common"
 	"fp_kata/internal/datasources/dsmodels"
+	"time"
 )
 
 type Payment struct {
 	Id     int
-	Amount float64
+	Amount common.Money
 	Method common.PaymentMethod
 	User   *User
 	Order  *Order
+	// PaidAt is the time the payment was made.
+	PaidAt time.Time
+	// Details are the details particular to the method, nil for payments made without them.
+	Details PaymentDetails
+	// Fee is what the payment method charged for the payment.
+	Fee common.Money
+	// Status is the state of the payment with the payment gateway.
+	Status common.PaymentStatus
+	// TransactionId is the transaction of the payment with the payment gateway, empty for payments
+	// that were not processed by the gateway.
+	TransactionId string
+	// Refunds are the refunds of the payment, oldest first.
+	Refunds []*Refund
+	// Currency is the currency the amount was paid in.
+	Currency common.Currency
+	// Conversion is the rate the amount was converted to the currency of the order with,
+	// nil when it was paid in the currency of the order.
+	Conversion *ExchangeRate
+}
+
+// Refund gives back the amount, or a part of it, of a payment.
+type Refund struct {
+	Id        int
+	PaymentId int
+	Amount    common.Money
+	Reason    common.RefundReason
+	CreatedAt time.Time
 }
 
 func (p Payment) ToDSModel() *dsmodels.Payment {
@@ -18,22 +46,159 @@ func (p Payment) ToDSModel() *dsmodels.Payment {
 		return nil
 	}
 	return &dsmodels.Payment{
-		Id:      p.Id,
-		Amount:  p.Amount,
-		Method:  p.Method,
-		UserId:  p.User.ID,
-		OrderId: p.Order.ID,
+		Id:            p.Id,
+		Amount:        p.Amount,
+		Currency:      p.Currency,
+		Conversion:    mapToDSConversion(p.Conversion),
+		Method:        p.Method,
+		UserId:        p.User.ID,
+		OrderId:       p.Order.ID,
+		PaidAt:        p.PaidAt,
+		Details:       mapToDSPaymentDetails(p.Details),
+		Fee:           p.Fee,
+		Status:        p.Status,
+		TransactionId: p.TransactionId,
 	}
 }
 func MapToPayment(dsPayment dsmodels.Payment, user *User, order *Order) *Payment {
 	if user == nil || order == nil {
 		return nil
 	}
+	refunds := make([]*Refund, len(dsPayment.Refunds))
+	for i, dsRefund := range dsPayment.Refunds {
+		refunds[i] = MapToRefund(dsRefund)
+	}
 	return &Payment{
-		Id:     dsPayment.Id,
-		Amount: dsPayment.Amount,
-		Method: dsPayment.Method,
-		User:   user,
-		Order:  order,
+		Id:            dsPayment.Id,
+		Amount:        dsPayment.Amount,
+		Currency:      dsPayment.Currency,
+		Conversion:    mapToConversion(dsPayment.Conversion),
+		Method:        dsPayment.Method,
+		User:          user,
+		Order:         order,
+		PaidAt:        dsPayment.PaidAt,
+		Details:       mapToPaymentDetails(dsPayment.Method, dsPayment.Details),
+		Fee:           dsPayment.Fee,
+		Refunds:       refunds,
+		Status:        dsPayment.Status,
+		TransactionId: dsPayment.TransactionId,
+	}
+}
+
+func mapToDSConversion(conversion *ExchangeRate) *dsmodels.ExchangeRate {
+	if conversion == nil {
+		return nil
+	}
+	return conversion.ToDSModel()
+}
+
+func mapToConversion(dsConversion *dsmodels.ExchangeRate) *ExchangeRate {
+	if dsConversion == nil {
+		return nil
+	}
+	return MapToExchangeRate(*dsConversion)
+}
+
+func mapToDSPaymentDetails(details PaymentDetails) *dsmodels.PaymentDetails {
+	if details == nil {
+		return nil
+	}
+	return MatchPaymentDetails(details,
+		func(card Card) *dsmodels.PaymentDetails {
+			return &dsmodels.PaymentDetails{CardBrand: string(card.Brand), CardLast4: card.Last4}
+		},
+		func(bankTransfer BankTransfer) *dsmodels.PaymentDetails {
+			return &dsmodels.PaymentDetails{IBAN: bankTransfer.IBAN}
+		},
+		func(payPal PayPal) *dsmodels.PaymentDetails {
+			return &dsmodels.PaymentDetails{PayPalEmail: payPal.Email}
+		})
+}
+
+// mapToPaymentDetails maps the stored details by the method of their payment, the details were validated when they were stored.
+func mapToPaymentDetails(method common.PaymentMethod, dsDetails *dsmodels.PaymentDetails) PaymentDetails {
+	if dsDetails == nil {
+		return nil
+	}
+	switch method {
+	case common.CreditCard, common.DebitCard:
+		return Card{Debit: method == common.DebitCard, Brand: CardBrand(dsDetails.CardBrand), Last4: dsDetails.CardLast4}
+	case common.BankTransfer:
+		return BankTransfer{IBAN: dsDetails.IBAN}
+	case common.PayPal:
+		return PayPal{Email: dsDetails.PayPalEmail}
+	}
+	return nil
+}
+
+// PaymentFilter selects payments by method and by the time they were made, zero fields select every payment.
+type PaymentFilter struct {
+	Method common.PaymentMethod
+	// From is the earliest time selected.
+	From time.Time
+	// To is the end of the selected period, payments made at To are not selected.
+	To time.Time
+}
+
+// Matches reports whether the filter selects the payment.
+func (f PaymentFilter) Matches(payment *Payment) bool {
+	if f.Method != "" && payment.Method != f.Method {
+		return false
+	}
+	if !f.From.IsZero() && payment.PaidAt.Before(f.From) {
+		return false
+	}
+	return f.To.IsZero() || payment.PaidAt.Before(f.To)
+}
+
+// AmountRefunded is the sum of the refunds of the payment.
+func (p Payment) AmountRefunded() common.Money {
+	refunded := common.Money{}
+	for _, refund := range p.Refunds {
+		refunded = refunded.Add(refund.Amount)
+	}
+	return refunded.Round(common.HalfUp)
+}
+
+// OrderAmount is the amount of the payment in the currency of its order.
+func (p Payment) OrderAmount() common.Money {
+	return p.toOrderCurrency(p.Amount)
+}
+
+// toOrderCurrency converts an amount of the currency of the payment to the currency of its order.
+func (p Payment) toOrderCurrency(amount common.Money) common.Money {
+	if p.Conversion == nil {
+		return amount
+	}
+	return p.Conversion.Convert(amount)
+}
+
+// RefundableAmount is the part of the payment that has not been refunded yet, payments without
+// an amount have nothing to refund.
+func (p Payment) RefundableAmount() common.Money {
+	refundable := p.Amount.Sub(p.AmountRefunded()).Round(common.HalfUp)
+	if refundable.Sign() < 0 {
+		return common.Money{}
+	}
+	return refundable
+}
+
+func (r Refund) ToDSModel() *dsmodels.Refund {
+	return &dsmodels.Refund{
+		Id:        r.Id,
+		PaymentId: r.PaymentId,
+		Amount:    r.Amount,
+		Reason:    r.Reason,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+func MapToRefund(dsRefund dsmodels.Refund) *Refund {
+	return &Refund{
+		Id:        dsRefund.Id,
+		PaymentId: dsRefund.PaymentId,
+		Amount:    dsRefund.Amount,
+		Reason:    dsRefund.Reason,
+		CreatedAt: dsRefund.CreatedAt,
 	}
 }
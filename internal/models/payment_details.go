@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"fp_kata/common"
+	"net/mail"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPaymentDetails is returned for payment details that fail the validation of their method.
+var ErrInvalidPaymentDetails = common.NewDomainError(common.Validation, "invalid_payment_details", "invalid payment details")
+
+// PaymentDetails are the details of a payment particular to its method, one of Card, BankTransfer and PayPal.
+// The interface is sealed, MatchPaymentDetails handles every variant there is.
+type PaymentDetails interface {
+	// Method is the payment method the details belong to.
+	Method() common.PaymentMethod
+	isPaymentDetails()
+}
+
+// CardBrand is the network of a card, recognized by the leading digits of its number.
+type CardBrand string
+
+const (
+	Visa       CardBrand = "Visa"
+	Mastercard CardBrand = "Mastercard"
+	Amex       CardBrand = "Amex"
+	Discover   CardBrand = "Discover"
+	OtherBrand CardBrand = "Other"
+)
+
+// Card is a credit or debit card, only its brand and last four digits are kept.
+type Card struct {
+	Debit bool
+	Brand CardBrand
+	Last4 string
+}
+
+// NewCard validates a card number with the Luhn check, spaces and dashes between the digits are ignored.
+func NewCard(number string, debit bool) (Card, error) {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(number)
+	if len(digits) < 12 || len(digits) > 19 || strings.Trim(digits, "0123456789") != "" {
+		return Card{}, fmt.Errorf("%w: card number must have 12 to 19 digits", ErrInvalidPaymentDetails)
+	}
+	if !hasLuhnChecksum(digits) {
+		return Card{}, fmt.Errorf("%w: card number fails the Luhn check", ErrInvalidPaymentDetails)
+	}
+	return Card{Debit: debit, Brand: cardBrand(digits), Last4: digits[len(digits)-4:]}, nil
+}
+
+func (c Card) Method() common.PaymentMethod {
+	if c.Debit {
+		return common.DebitCard
+	}
+	return common.CreditCard
+}
+
+func (Card) isPaymentDetails() {}
+
+// hasLuhnChecksum reports whether the last digit is the Luhn check digit of the digits before it.
+func hasLuhnChecksum(digits string) bool {
+	sum := 0
+	for i := range len(digits) {
+		digit := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+func cardBrand(digits string) CardBrand {
+	prefix := func(from, to int, length int) bool {
+		value, _ := strconv.Atoi(digits[:length])
+		return value >= from && value <= to
+	}
+	switch {
+	case digits[0] == '4':
+		return Visa
+	case prefix(51, 55, 2), prefix(2221, 2720, 4):
+		return Mastercard
+	case prefix(34, 34, 2), prefix(37, 37, 2):
+		return Amex
+	case prefix(6011, 6011, 4), prefix(65, 65, 2):
+		return Discover
+	}
+	return OtherBrand
+}
+
+// BankTransfer is paid from the bank account of an IBAN, kept without spaces in upper case.
+type BankTransfer struct {
+	IBAN string
+}
+
+// NewBankTransfer validates an IBAN by its length, country code and mod-97 check digits.
+func NewBankTransfer(iban string) (BankTransfer, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+	if len(normalized) < 15 || len(normalized) > 34 {
+		return BankTransfer{}, fmt.Errorf("%w: IBAN must have 15 to 34 characters", ErrInvalidPaymentDetails)
+	}
+	for i, char := range normalized {
+		isLetter, isDigit := char >= 'A' && char <= 'Z', char >= '0' && char <= '9'
+		if (i < 2 && !isLetter) || (i >= 2 && i < 4 && !isDigit) || (!isLetter && !isDigit) {
+			return BankTransfer{}, fmt.Errorf("%w: IBAN must start with a country code and check digits", ErrInvalidPaymentDetails)
+		}
+	}
+	if ibanRemainder(normalized) != 1 {
+		return BankTransfer{}, fmt.Errorf("%w: IBAN fails the mod-97 check", ErrInvalidPaymentDetails)
+	}
+	return BankTransfer{IBAN: normalized}, nil
+}
+
+func (BankTransfer) Method() common.PaymentMethod {
+	return common.BankTransfer
+}
+
+func (BankTransfer) isPaymentDetails() {}
+
+// MaskedIBAN shows the country code, check digits and last four characters of the IBAN only.
+func (b BankTransfer) MaskedIBAN() string {
+	if len(b.IBAN) < 8 {
+		return b.IBAN
+	}
+	return b.IBAN[:4] + strings.Repeat("*", len(b.IBAN)-8) + b.IBAN[len(b.IBAN)-4:]
+}
+
+// ibanRemainder moves the country code and check digits to the end, replaces letters by 10 to 35
+// and returns the remainder of the resulting number divided by 97.
+func ibanRemainder(iban string) int {
+	remainder := 0
+	for _, char := range iban[4:] + iban[:4] {
+		if char >= 'A' && char <= 'Z' {
+			remainder = (remainder*100 + int(char-'A') + 10) % 97
+		} else {
+			remainder = (remainder*10 + int(char-'0')) % 97
+		}
+	}
+	return remainder
+}
+
+// PayPal is paid from the PayPal account of an email address.
+type PayPal struct {
+	Email string
+}
+
+// NewPayPal validates the email address of a PayPal account, a bare address without display name.
+func NewPayPal(email string) (PayPal, error) {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return PayPal{}, fmt.Errorf("%w: invalid PayPal email %q", ErrInvalidPaymentDetails, email)
+	}
+	return PayPal{Email: email}, nil
+}
+
+func (PayPal) Method() common.PaymentMethod {
+	return common.PayPal
+}
+
+func (PayPal) isPaymentDetails() {}
+
+// MatchPaymentDetails folds payment details with the function of their variant, so adding a variant breaks
+// every caller until it is handled. Details must not be nil.
+func MatchPaymentDetails[T any](details PaymentDetails, card func(Card) T, bankTransfer func(BankTransfer) T, payPal func(PayPal) T) T {
+	switch variant := details.(type) {
+	case Card:
+		return card(variant)
+	case BankTransfer:
+		return bankTransfer(variant)
+	case PayPal:
+		return payPal(variant)
+	}
+	// unreachable, the interface is sealed
+	panic(fmt.Sprintf("unknown payment details %T", details))
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/datasources/dsmodels"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCard(t *testing.T) {
+	tests := []struct {
+		name        string
+		number      string
+		debit       bool
+		expected    Card
+		expectedErr string
+	}{
+		{name: "visa", number: "4111 1111 1111 1111", expected: Card{Brand: Visa, Last4: "1111"}},
+		{name: "mastercard debit", number: "5555-5555-5555-4444", debit: true, expected: Card{Debit: true, Brand: Mastercard, Last4: "4444"}},
+		{name: "mastercard 2-series", number: "2223003122003222", expected: Card{Brand: Mastercard, Last4: "3222"}},
+		{name: "amex", number: "378282246310005", expected: Card{Brand: Amex, Last4: "0005"}},
+		{name: "discover", number: "6011111111111117", expected: Card{Brand: Discover, Last4: "1117"}},
+		{name: "other brand", number: "3530111333300000", expected: Card{Brand: OtherBrand, Last4: "0000"}},
+		{name: "failing Luhn check", number: "4111111111111112", expectedErr: "invalid payment details: card number fails the Luhn check"},
+		{name: "too short", number: "41111", expectedErr: "invalid payment details: card number must have 12 to 19 digits"},
+		{name: "letters", number: "4111a11111111111", expectedErr: "invalid payment details: card number must have 12 to 19 digits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card, err := NewCard(tt.number, tt.debit)
+			if tt.expectedErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidPaymentDetails, "expected invalid payment details")
+				assert.EqualError(t, err, tt.expectedErr, "unexpected error message")
+				return
+			}
+			assert.NoError(t, err, "expected a valid card")
+			assert.Equal(t, tt.expected, card, "unexpected card")
+		})
+	}
+}
+
+func TestNewBankTransfer(t *testing.T) {
+	tests := []struct {
+		name        string
+		iban        string
+		expected    string
+		expectedErr string
+	}{
+		{name: "german IBAN with spaces", iban: "DE89 3704 0044 0532 0130 00", expected: "DE89370400440532013000"},
+		{name: "lower case british IBAN", iban: "gb82west12345698765432", expected: "GB82WEST12345698765432"},
+		{name: "wrong check digits", iban: "DE88370400440532013000", expectedErr: "invalid payment details: IBAN fails the mod-97 check"},
+		{name: "missing country code", iban: "8937040044053201300000", expectedErr: "invalid payment details: IBAN must start with a country code and check digits"},
+		{name: "too short", iban: "DE89", expectedErr: "invalid payment details: IBAN must have 15 to 34 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bankTransfer, err := NewBankTransfer(tt.iban)
+			if tt.expectedErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidPaymentDetails, "expected invalid payment details")
+				assert.EqualError(t, err, tt.expectedErr, "unexpected error message")
+				return
+			}
+			assert.NoError(t, err, "expected a valid IBAN")
+			assert.Equal(t, tt.expected, bankTransfer.IBAN, "unexpected IBAN")
+		})
+	}
+
+	assert.Equal(t, "DE89**************3000", BankTransfer{IBAN: "DE89370400440532013000"}.MaskedIBAN(), "unexpected masked IBAN")
+}
+
+func TestNewPayPal(t *testing.T) {
+	payPal, err := NewPayPal("jane@example.com")
+	assert.NoError(t, err, "expected a valid email")
+	assert.Equal(t, PayPal{Email: "jane@example.com"}, payPal, "unexpected PayPal account")
+
+	for _, email := range []string{"", "jane", "Jane <jane@example.com>"} {
+		_, err := NewPayPal(email)
+		assert.ErrorIs(t, err, ErrInvalidPaymentDetails, "expected %q to be rejected", email)
+	}
+}
+
+func TestPaymentDetailsMapping(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    common.PaymentMethod
+		details   PaymentDetails
+		dsDetails *dsmodels.PaymentDetails
+	}{
+		{name: "credit card", method: common.CreditCard, details: Card{Brand: Visa, Last4: "1111"},
+			dsDetails: &dsmodels.PaymentDetails{CardBrand: "Visa", CardLast4: "1111"}},
+		{name: "debit card", method: common.DebitCard, details: Card{Debit: true, Brand: Mastercard, Last4: "4444"},
+			dsDetails: &dsmodels.PaymentDetails{CardBrand: "Mastercard", CardLast4: "4444"}},
+		{name: "bank transfer", method: common.BankTransfer, details: BankTransfer{IBAN: "DE89370400440532013000"},
+			dsDetails: &dsmodels.PaymentDetails{IBAN: "DE89370400440532013000"}},
+		{name: "paypal", method: common.PayPal, details: PayPal{Email: "jane@example.com"},
+			dsDetails: &dsmodels.PaymentDetails{PayPalEmail: "jane@example.com"}},
+		{name: "no details", method: common.PayPal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := Payment{Id: 1, Amount: common.NewMoney(10), Method: tt.method, User: &User{ID: 1}, Order: &Order{ID: 2}, Details: tt.details}
+
+			dsPayment := payment.ToDSModel()
+			assert.Equal(t, tt.dsDetails, dsPayment.Details, "unexpected stored details")
+			assert.Equal(t, tt.details, MapToPayment(*dsPayment, payment.User, payment.Order).Details, "expected the details to round-trip")
+		})
+	}
+}
+
+func TestMatchPaymentDetails(t *testing.T) {
+	name := func(details PaymentDetails) string {
+		return MatchPaymentDetails(details,
+			func(card Card) string { return "card " + card.Last4 },
+			func(bankTransfer BankTransfer) string { return "bank transfer " + bankTransfer.IBAN },
+			func(payPal PayPal) string { return "paypal " + payPal.Email })
+	}
+
+	assert.Equal(t, "card 1111", name(Card{Last4: "1111"}), "unexpected card variant")
+	assert.Equal(t, "bank transfer DE89", name(BankTransfer{IBAN: "DE89"}), "unexpected bank transfer variant")
+	assert.Equal(t, "paypal jane@example.com", name(PayPal{Email: "jane@example.com"}), "unexpected PayPal variant")
+	assert.Equal(t, common.DebitCard, Card{Debit: true}.Method(), "unexpected method of a debit card")
+}
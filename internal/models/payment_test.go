@@ -32,14 +32,14 @@ func TestPaymentToDSModel(t *testing.T) {
 			name: "valid_full_data",
 			payment: Payment{
 				Id:     1,
-				Amount: 100.50,
+				Amount: common.NewMoney(100.50),
 				Method: common.CreditCard,
 				User:   &User{ID: 42},
 				Order:  &Order{ID: 84},
 			},
 			expectedResult: &dsmodels.Payment{
 				Id:      1,
-				Amount:  100.50,
+				Amount:  common.NewMoney(100.50),
 				Method:  common.CreditCard,
 				UserId:  42,
 				OrderId: 84,
@@ -50,7 +50,7 @@ func TestPaymentToDSModel(t *testing.T) {
 			name: "user_nil",
 			payment: Payment{
 				Id:     2,
-				Amount: 200.75,
+				Amount: common.NewMoney(200.75),
 				Method: common.PayPal,
 				User:   nil,
 				Order:  &Order{ID: 105},
@@ -62,7 +62,7 @@ func TestPaymentToDSModel(t *testing.T) {
 			name: "order_nil",
 			payment: Payment{
 				Id:     3,
-				Amount: 300.00,
+				Amount: common.NewMoney(300.00),
 				Method: common.DebitCard,
 				User:   &User{ID: 56},
 				Order:  nil,
@@ -74,14 +74,14 @@ func TestPaymentToDSModel(t *testing.T) {
 			name: "negative_amount",
 			payment: Payment{
 				Id:     4,
-				Amount: -50.00,
+				Amount: common.NewMoney(-50.00),
 				Method: common.DebitCard,
 				User:   &User{ID: 77},
 				Order:  &Order{ID: 88},
 			},
 			expectedResult: &dsmodels.Payment{
 				Id:      4,
-				Amount:  -50.00,
+				Amount:  common.NewMoney(-50.00),
 				Method:  common.DebitCard,
 				UserId:  77,
 				OrderId: 88,
@@ -124,7 +124,7 @@ func TestMapToPayment(t *testing.T) {
 			name: "valid_full_data",
 			dsPayment: dsmodels.Payment{
 				Id:      1,
-				Amount:  100.50,
+				Amount:  common.NewMoney(100.50),
 				Method:  common.CreditCard,
 				UserId:  42,
 				OrderId: 84,
@@ -133,7 +133,7 @@ func TestMapToPayment(t *testing.T) {
 			order: &Order{ID: 84},
 			expectedResult: &Payment{
 				Id:     1,
-				Amount: 100.50,
+				Amount: common.NewMoney(100.50),
 				Method: common.CreditCard,
 				User:   &User{ID: 42},
 				Order:  &Order{ID: 84},
@@ -144,7 +144,7 @@ func TestMapToPayment(t *testing.T) {
 			name: "missing_user",
 			dsPayment: dsmodels.Payment{
 				Id:      2,
-				Amount:  200.75,
+				Amount:  common.NewMoney(200.75),
 				Method:  common.PayPal,
 				UserId:  0,
 				OrderId: 105,
@@ -158,7 +158,7 @@ func TestMapToPayment(t *testing.T) {
 			name: "missing_order",
 			dsPayment: dsmodels.Payment{
 				Id:      3,
-				Amount:  300.00,
+				Amount:  common.NewMoney(300.00),
 				Method:  common.DebitCard,
 				UserId:  56,
 				OrderId: 0,
@@ -172,7 +172,7 @@ func TestMapToPayment(t *testing.T) {
 			name: "negative_amount",
 			dsPayment: dsmodels.Payment{
 				Id:      4,
-				Amount:  -50.00,
+				Amount:  common.NewMoney(-50.00),
 				Method:  common.DebitCard,
 				UserId:  77,
 				OrderId: 88,
@@ -181,7 +181,7 @@ func TestMapToPayment(t *testing.T) {
 			order: &Order{ID: 88},
 			expectedResult: &Payment{
 				Id:     4,
-				Amount: -50.00,
+				Amount: common.NewMoney(-50.00),
 				Method: common.DebitCard,
 				User:   &User{ID: 77},
 				Order:  &Order{ID: 88},
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/datasources/dsmodels"
+)
+
+// Product is an entry of the product catalog.
+// Products with a weight unit are sold by weight, their price is the price of one weight unit.
+type Product struct {
+	ID          int
+	Name        string
+	Description string
+	Price       common.Money
+	WeightUnit  common.WeightUnit
+}
+
+// IsWeighted reports whether the product is sold by weight.
+func (p Product) IsWeighted() bool {
+	return p.WeightUnit != ""
+}
+
+func (p Product) ToDSModel() dsmodels.Product {
+	return dsmodels.Product{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		WeightUnit:  p.WeightUnit,
+	}
+}
+
+func MapToProduct(dsProduct dsmodels.Product) *Product {
+	return &Product{
+		ID:          dsProduct.ID,
+		Name:        dsProduct.Name,
+		Description: dsProduct.Description,
+		Price:       dsProduct.Price,
+		WeightUnit:  dsProduct.WeightUnit,
+	}
+}
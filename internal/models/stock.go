@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fp_kata/internal/datasources/dsmodels"
+)
+
+// Stock is the stock level of a product.
+// OnHand is the quantity in the warehouse, Reserved the part of it held by open orders.
+type Stock struct {
+	ProductID    int
+	OnHand       int
+	Reserved     int
+	ReorderLevel int
+}
+
+// Available is the quantity that can still be ordered.
+func (s Stock) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+func (s Stock) ToDSModel() dsmodels.Stock {
+	return dsmodels.Stock{
+		ProductID:    s.ProductID,
+		OnHand:       s.OnHand,
+		Reserved:     s.Reserved,
+		ReorderLevel: s.ReorderLevel,
+	}
+}
+
+func MapToStock(dsStock dsmodels.Stock) *Stock {
+	return &Stock{
+		ProductID:    dsStock.ProductID,
+		OnHand:       dsStock.OnHand,
+		Reserved:     dsStock.Reserved,
+		ReorderLevel: dsStock.ReorderLevel,
+	}
+}
+
+// StockItem is a quantity of a product an order reserves.
+type StockItem struct {
+	ProductID int
+	Quantity  int
+}
+
+// StockItems returns the quantities the lines of the order reserve, one item per product in the order of the lines.
+func (o *Order) StockItems() []StockItem {
+	items := make([]StockItem, 0, len(o.Lines))
+	index := make(map[int]int, len(o.Lines))
+	for _, line := range o.Lines {
+		if i, exists := index[line.ProductID]; exists {
+			items[i].Quantity += line.Quantity
+			continue
+		}
+		index[line.ProductID] = len(items)
+		items = append(items, StockItem{ProductID: line.ProductID, Quantity: line.Quantity})
+	}
+	return items
+}
+
+func (i StockItem) ToDSModel() dsmodels.StockItem {
+	return dsmodels.StockItem{ProductID: i.ProductID, Quantity: i.Quantity}
+}
+
+func MapToStockItem(dsItem dsmodels.StockItem) StockItem {
+	return StockItem{ProductID: dsItem.ProductID, Quantity: dsItem.Quantity}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"fp_kata/common"
 	"fp_kata/common/utils"
 	"fp_kata/internal/models"
 	"fp_kata/pkg/log"
@@ -12,6 +13,13 @@ import (
 
 const compAuthenticationService = "AuthenticationService"
 
+var (
+	// ErrAuthTokenMissing is returned for a request without an auth token.
+	ErrAuthTokenMissing = common.NewDomainError(common.Unauthenticated, "auth_token_missing", "authorization token is missing")
+	// ErrInvalidAuthToken is returned for an auth token that was not generated or whose user is gone.
+	ErrInvalidAuthToken = common.NewDomainError(common.Unauthenticated, "invalid_auth_token", "invalid or expired token")
+)
+
 // AuthService is the interface for the authentication service.
 type AuthService interface {
 	// GenerateAuthToken takes a User object, generates an auth token, and stores the relationship in memory.
@@ -60,7 +68,7 @@ func (s *authService) GetUserIDByToken(ctx context.Context, authToken string) (i
 	utils.LogAction(ctx, compAuthenticationService, "GetUserIDByToken")
 
 	if authToken == "" {
-		return 0, errors.New("invalid auth token")
+		return 0, ErrAuthTokenMissing
 	}
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -70,7 +78,7 @@ func (s *authService) GetUserIDByToken(ctx context.Context, authToken string) (i
 	userID, exists := s.tokenStorage[authToken]
 	logger.Debug().Str(log.Comp, compAuthenticationService).Str("func", "GetUserIDByToken").Str("auth_token", authToken).Int("user_id", userID).Bool("exists", exists).Send()
 	if !exists {
-		return 0, errors.New("auth token not found")
+		return 0, ErrInvalidAuthToken
 	}
 
 	return userID, nil
@@ -41,9 +41,9 @@ func TestGetUserIDByToken(t *testing.T) {
 			authToken:     "",
 			tokenStorage:  map[string]int{},
 			expectedUser:  0,
-			expectedError: "invalid auth token",
+			expectedError: ErrAuthTokenMissing.Error(),
 			validate: func(t *testing.T, userID int, err error) {
-				assert.EqualError(t, err, "invalid auth token", "expected error does not match actual error")
+				assert.ErrorIs(t, err, ErrAuthTokenMissing, "expected error does not match actual error")
 				assert.Equal(t, 0, userID, "expected user ID to be zero for missing token")
 			},
 		},
@@ -54,9 +54,9 @@ func TestGetUserIDByToken(t *testing.T) {
 				"validToken": 42,
 			},
 			expectedUser:  0,
-			expectedError: "auth token not found",
+			expectedError: ErrInvalidAuthToken.Error(),
 			validate: func(t *testing.T, userID int, err error) {
-				assert.EqualError(t, err, "auth token not found", "expected error does not match actual error")
+				assert.ErrorIs(t, err, ErrInvalidAuthToken, "expected error does not match actual error")
 				assert.Equal(t, 0, userID, "expected user ID to be zero for invalid token")
 			},
 		},
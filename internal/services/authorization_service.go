@@ -2,13 +2,16 @@ package services
 
 import (
 	"context"
-	"errors"
+	"fp_kata/common"
 	"fp_kata/common/utils"
 	"fp_kata/internal/models"
 )
 
 const compAuthorizationService = "AuthorizationService"
 
+// ErrOrderOwnerMissing is returned for an order without a user, nobody is authorized for it.
+var ErrOrderOwnerMissing = common.NewDomainError(common.Forbidden, "order_owner_missing", "missing user on order")
+
 type AuthorizationService interface {
 	IsAuthorized(ctx context.Context, userId int, order *models.Order) (bool, error)
 }
@@ -23,10 +26,10 @@ func (a *authorizationService) IsAuthorized(ctx context.Context, userId int, ord
 	utils.LogAction(ctx, compAuthorizationService, "isAuthorized")
 
 	if userId == 0 {
-		return false, errors.New("userId is required")
+		return false, ErrUserRequired
 	}
 	if order.User == nil {
-		return false, errors.New("missing user on order")
+		return false, ErrOrderOwnerMissing
 	}
 	return order.User.ID == userId, nil
 }
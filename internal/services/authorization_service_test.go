@@ -49,7 +49,7 @@ func TestAuthorizationService_IsAuthorized(t *testing.T) {
 			order:  models.Order{User: &models.User{ID: 1}},
 			assertFunc: func(t *testing.T, res bool, err error) {
 
-				assert.EqualError(t, err, "userId is required", "Expected no error but got one")
+				assert.ErrorIs(t, err, ErrUserRequired, "Expected no error but got one")
 				assert.Equal(t, false, res, "Expected result did not match")
 
 			},
@@ -60,7 +60,7 @@ func TestAuthorizationService_IsAuthorized(t *testing.T) {
 			order:  models.Order{User: nil},
 			assertFunc: func(t *testing.T, res bool, err error) {
 
-				assert.EqualError(t, err, "missing user on order", "Expected no error but got one")
+				assert.ErrorIs(t, err, ErrOrderOwnerMissing, "Expected a missing owner error")
 				assert.Equal(t, false, res, "Expected result did not match")
 
 			},
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/common/utils"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/internal/models"
+	"os"
+	"regexp"
+	"time"
+)
+
+const compExchangeRatesService = "ExchangeRatesService"
+
+// ErrNoExchangeRate is returned when no rate between two currencies is effective at the time of a conversion.
+var ErrNoExchangeRate = common.NewDomainError(common.Unprocessable, "no_exchange_rate", "no exchange rate")
+
+// currencyCode is the format of an ISO 4217 currency code.
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type ExchangeRatesService interface {
+	// BaseCurrency is the currency of the product catalog, orders placed without a currency are priced in it.
+	BaseCurrency() common.Currency
+	// GetRate returns the rate from one currency to the other effective at the time. Rates are looked up
+	// the other way round as well; a currency converts to itself at the rate 1.
+	GetRate(ctx context.Context, from common.Currency, to common.Currency, at time.Time) (*models.ExchangeRate, error)
+}
+
+// ExchangeRatesConfig configures the currencies of the shop.
+type ExchangeRatesConfig struct {
+	BaseCurrency common.Currency
+}
+
+// NewExchangeRatesConfig reads the base currency from FP_KATA_BASE_CURRENCY, EUR by default.
+func NewExchangeRatesConfig() ExchangeRatesConfig {
+	config := ExchangeRatesConfig{BaseCurrency: common.EUR}
+	if currency := os.Getenv("FP_KATA_BASE_CURRENCY"); currencyCode.MatchString(currency) {
+		config.BaseCurrency = common.Currency(currency)
+	}
+	return config
+}
+
+type exchangeRatesService struct {
+	config  ExchangeRatesConfig
+	storage datasources.ExchangeRatesDatasource
+}
+
+func NewExchangeRatesService(config ExchangeRatesConfig, storage datasources.ExchangeRatesDatasource) ExchangeRatesService {
+	return &exchangeRatesService{
+		config:  config,
+		storage: storage,
+	}
+}
+
+func (service *exchangeRatesService) BaseCurrency() common.Currency {
+	return service.config.BaseCurrency
+}
+
+func (service *exchangeRatesService) GetRate(ctx context.Context, from common.Currency, to common.Currency, at time.Time) (*models.ExchangeRate, error) {
+	utils.LogAction(ctx, compExchangeRatesService, "GetRate")
+
+	if from == to {
+		return &models.ExchangeRate{From: from, To: to, Rate: 1}, nil
+	}
+
+	rates, err := service.storage.Rates(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if rate := effectiveRate(rates, at); rate != nil {
+		return rate, nil
+	}
+
+	inverseRates, err := service.storage.Rates(ctx, to, from)
+	if err != nil {
+		return nil, err
+	}
+	if rate := effectiveRate(inverseRates, at); rate != nil {
+		inverse := rate.Inverse()
+		return &inverse, nil
+	}
+	return nil, fmt.Errorf("%w: %s to %s on %s", ErrNoExchangeRate, from, to, at.Format(time.DateOnly))
+}
+
+// effectiveRate returns the latest of the rates, ordered by their effective date, that took effect at the time.
+func effectiveRate(rates []dsmodels.ExchangeRate, at time.Time) *models.ExchangeRate {
+	for i := len(rates) - 1; i >= 0; i-- {
+		if !rates[i].EffectiveFrom.After(at) && rates[i].Rate > 0 {
+			return models.MapToExchangeRate(rates[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"fp_kata/common"
+	"fp_kata/internal/datasources/file"
+	"fp_kata/internal/models"
+	"fp_kata/pkg/log"
+	zlog "github.com/rs/zerolog/log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exchangeRatesFrom is a service with EUR as base currency and the rates of the JSON rates file.
+func exchangeRatesFrom(t *testing.T, rates string) ExchangeRatesService {
+	path := filepath.Join(t.TempDir(), "exchange_rates.json")
+	err := os.WriteFile(path, []byte(rates), 0o600)
+	assert.NoError(t, err, "unexpected error writing the rates file")
+	storage, err := file.NewExchangeRatesStorage(file.ExchangeRatesFile(path))
+	assert.NoError(t, err, "unexpected error loading the rates file")
+	return NewExchangeRatesService(ExchangeRatesConfig{BaseCurrency: common.EUR}, storage)
+}
+
+func TestExchangeRatesService_GetRate(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	january := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	service := exchangeRatesFrom(t, `[
+		{"From": "EUR", "To": "USD", "Rate": 1.05, "EffectiveFrom": "2025-01-01T00:00:00Z"},
+		{"From": "EUR", "To": "USD", "Rate": 1.1, "EffectiveFrom": "2025-03-01T00:00:00Z"},
+		{"From": "GBP", "To": "EUR", "Rate": 1.25, "EffectiveFrom": "2025-01-01T00:00:00Z"}
+	]`)
+
+	tests := []struct {
+		name          string
+		from          common.Currency
+		to            common.Currency
+		at            time.Time
+		expected      *models.ExchangeRate
+		expectedError error
+	}{
+		{
+			name:     "same currency",
+			from:     common.USD,
+			to:       common.USD,
+			at:       march,
+			expected: &models.ExchangeRate{From: common.USD, To: common.USD, Rate: 1},
+		},
+		{
+			name:     "rate effective at the time",
+			from:     common.EUR,
+			to:       common.USD,
+			at:       time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC),
+			expected: &models.ExchangeRate{From: common.EUR, To: common.USD, Rate: 1.05, EffectiveFrom: january},
+		},
+		{
+			name:     "latest rate",
+			from:     common.EUR,
+			to:       common.USD,
+			at:       time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			expected: &models.ExchangeRate{From: common.EUR, To: common.USD, Rate: 1.1, EffectiveFrom: march},
+		},
+		{
+			name:     "inverse rate",
+			from:     common.EUR,
+			to:       common.GBP,
+			at:       march,
+			expected: &models.ExchangeRate{From: common.EUR, To: common.GBP, Rate: 0.8, EffectiveFrom: january},
+		},
+		{
+			name:          "no rate effective yet",
+			from:          common.EUR,
+			to:            common.USD,
+			at:            time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+			expectedError: ErrNoExchangeRate,
+		},
+		{
+			name:          "no rate between the currencies",
+			from:          common.USD,
+			to:            common.CHF,
+			at:            march,
+			expectedError: ErrNoExchangeRate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := service.GetRate(ctx, tt.from, tt.to, tt.at)
+
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError), "expected error %v, got %v", tt.expectedError, err)
+				assert.Nil(t, rate)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, rate, "unexpected rate")
+		})
+	}
+}
+
+func TestNewExchangeRatesConfig(t *testing.T) {
+	t.Setenv("FP_KATA_BASE_CURRENCY", "")
+	assert.Equal(t, common.EUR, NewExchangeRatesConfig().BaseCurrency, "expected EUR by default")
+
+	t.Setenv("FP_KATA_BASE_CURRENCY", "CHF")
+	assert.Equal(t, common.CHF, NewExchangeRatesConfig().BaseCurrency, "expected the configured currency")
+
+	t.Setenv("FP_KATA_BASE_CURRENCY", "swiss francs")
+	assert.Equal(t, common.EUR, NewExchangeRatesConfig().BaseCurrency, "expected an invalid currency to be ignored")
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/common/utils"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/internal/models"
+	"os"
+	"time"
+)
+
+const compIdempotencyService = "IdempotencyService"
+
+// defaultIdempotencyTTL keeps the responses to requests with an idempotency key for a day.
+const defaultIdempotencyTTL = 24 * time.Hour
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a different request.
+	ErrIdempotencyKeyReused = common.NewDomainError(common.Unprocessable, "idempotency_key_reused", "idempotency key was used for a different request")
+	// ErrIdempotencyKeyInProgress is returned when a key is sent again while its first request is still processed.
+	ErrIdempotencyKeyInProgress = common.NewDomainError(common.Conflict, "idempotency_key_in_progress", "request with this idempotency key is still in progress")
+)
+
+type IdempotencyService interface {
+	// Begin claims the key of a user for a request. It returns the stored response when the request was
+	// completed before, and nil when the request is new and has to be processed and then completed or abandoned.
+	Begin(ctx context.Context, userId int, key string, request []byte) (*models.StoredResponse, error)
+	// Complete stores the response to the request of a claimed key, retries of the request get it replayed.
+	Complete(ctx context.Context, userId int, key string, response models.StoredResponse) error
+	// Abandon releases a claimed key without a response, the request can then be retried.
+	Abandon(ctx context.Context, userId int, key string) error
+}
+
+// IdempotencyConfig configures how long the responses to requests with an idempotency key are kept.
+type IdempotencyConfig struct {
+	TTL time.Duration
+}
+
+// NewIdempotencyConfig reads the time to live of idempotency keys from FP_KATA_IDEMPOTENCY_TTL, for example 1h30m.
+func NewIdempotencyConfig() IdempotencyConfig {
+	config := IdempotencyConfig{TTL: defaultIdempotencyTTL}
+	if ttl, err := time.ParseDuration(os.Getenv("FP_KATA_IDEMPOTENCY_TTL")); err == nil && ttl > 0 {
+		config.TTL = ttl
+	}
+	return config
+}
+
+type idempotencyService struct {
+	config  IdempotencyConfig
+	storage datasources.IdempotencyDatasource
+	now     func() time.Time
+}
+
+func NewIdempotencyService(config IdempotencyConfig, storage datasources.IdempotencyDatasource) IdempotencyService {
+	return &idempotencyService{
+		config:  config,
+		storage: storage,
+		now:     time.Now,
+	}
+}
+
+func (service *idempotencyService) Begin(ctx context.Context, userId int, key string, request []byte) (*models.StoredResponse, error) {
+	utils.LogAction(ctx, compIdempotencyService, "Begin")
+
+	now := service.now()
+	fingerprint := sha256.Sum256(request)
+	claim := dsmodels.IdempotencyRecord{
+		UserId:      userId,
+		Key:         key,
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		ExpiresAt:   now.Add(service.config.TTL),
+	}
+
+	stored, claimed, err := service.storage.Claim(ctx, claim, now)
+	if err != nil {
+		return nil, err
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	if stored.Fingerprint != claim.Fingerprint {
+		return nil, fmt.Errorf("%w: %q", ErrIdempotencyKeyReused, key)
+	}
+	if stored.StatusCode == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrIdempotencyKeyInProgress, key)
+	}
+	return &models.StoredResponse{
+		StatusCode:  stored.StatusCode,
+		ContentType: stored.ContentType,
+		Body:        stored.Body,
+	}, nil
+}
+
+func (service *idempotencyService) Complete(ctx context.Context, userId int, key string, response models.StoredResponse) error {
+	utils.LogAction(ctx, compIdempotencyService, "Complete")
+
+	stored, err := service.storage.Read(ctx, userId, key)
+	if err != nil {
+		return err
+	}
+
+	stored.StatusCode = response.StatusCode
+	stored.ContentType = response.ContentType
+	stored.Body = response.Body
+	return service.storage.Save(ctx, stored)
+}
+
+func (service *idempotencyService) Abandon(ctx context.Context, userId int, key string) error {
+	utils.LogAction(ctx, compIdempotencyService, "Abandon")
+
+	return service.storage.Delete(ctx, userId, key)
+}
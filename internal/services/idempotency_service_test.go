@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/internal/datasources/file"
+	"fp_kata/internal/models"
+	"fp_kata/mocks"
+	"fp_kata/pkg/log"
+	zlog "github.com/rs/zerolog/log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIdempotencyService_Begin(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	request := []byte(`{"product_id":1,"quantity":2}`)
+	response := models.StoredResponse{StatusCode: 201, ContentType: "application/json", Body: []byte(`{"id":42}`)}
+
+	tests := []struct {
+		name       string
+		setup      func(service IdempotencyService)
+		userId     int
+		request    []byte
+		elapsed    time.Duration
+		assertFunc func(t *testing.T, stored *models.StoredResponse, err error)
+	}{
+		{
+			name:    "new key is claimed",
+			setup:   func(service IdempotencyService) {},
+			userId:  1,
+			request: request,
+			assertFunc: func(t *testing.T, stored *models.StoredResponse, err error) {
+				assert.NoError(t, err, "expected no error")
+				assert.Nil(t, stored, "expected the request to be processed")
+			},
+		},
+		{
+			name: "completed request is replayed",
+			setup: func(service IdempotencyService) {
+				_, _ = service.Begin(ctx, 1, "key-1", request)
+				_ = service.Complete(ctx, 1, "key-1", response)
+			},
+			userId:  1,
+			request: request,
+			elapsed: 59 * time.Minute,
+			assertFunc: func(t *testing.T, stored *models.StoredResponse, err error) {
+				assert.NoError(t, err, "expected no error")
+				assert.Equal(t, &response, stored, "expected the stored response")
+			},
+		},
+		{
+			name: "key is reused for a different request",
+			setup: func(service IdempotencyService) {
+				_, _ = service.Begin(ctx, 1, "key-1", request)
+				_ = service.Complete(ctx, 1, "key-1", response)
+			},
+			userId:  1,
+			request: []byte(`{"product_id":1,"quantity":3}`),
+			assertFunc: func(t *testing.T, stored *models.StoredResponse, err error) {
+				assert.ErrorIs(t, err, ErrIdempotencyKeyReused, "expected a reused key")
+				assert.EqualError(t, err, `idempotency key was used for a different request: "key-1"`, "unexpected error message")
+			},
+		},
+		{
+			name: "request is still in progress",
+			setup: func(service IdempotencyService) {
+				_, _ = service.Begin(ctx, 1, "key-1", request)
+			},
+			userId:  1,
+			request: request,
+			assertFunc: func(t *testing.T, stored *models.StoredResponse, err error) {
+				assert.ErrorIs(t, err, ErrIdempotencyKeyInProgress, "expected a request in progress")
+			},
+		},
+		{
+			name: "abandoned key is claimed again",
+			setup: func(service IdempotencyService) {
+				_, _ = service.Begin(ctx, 1, "key-1", request)
+				_ = service.Abandon(ctx, 1, "key-1")
+			},
+			userId:  1,
+			request: request,
+			assertFunc: func(t *testing.T, stored *models.StoredResponse, err error) {
+				assert.NoError(t, err, "expected no error")
+				assert.Nil(t, stored, "expected the request to be processed")
+			},
+		},
+		{
+			name: "expired key is claimed again",
+			setup: func(service IdempotencyService) {
+				_, _ = service.Begin(ctx, 1, "key-1", request)
+				_ = service.Complete(ctx, 1, "key-1", response)
+			},
+			userId:  1,
+			request: []byte(`{"product_id":1,"quantity":3}`),
+			elapsed: time.Hour,
+			assertFunc: func(t *testing.T, stored *models.StoredResponse, err error) {
+				assert.NoError(t, err, "expected no error")
+				assert.Nil(t, stored, "expected the request to be processed")
+			},
+		},
+		{
+			name: "keys of other users are not shared",
+			setup: func(service IdempotencyService) {
+				_, _ = service.Begin(ctx, 1, "key-1", request)
+				_ = service.Complete(ctx, 1, "key-1", response)
+			},
+			userId:  2,
+			request: request,
+			assertFunc: func(t *testing.T, stored *models.StoredResponse, err error) {
+				assert.NoError(t, err, "expected no error")
+				assert.Nil(t, stored, "expected the request to be processed")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+			service := &idempotencyService{
+				config:  IdempotencyConfig{TTL: time.Hour},
+				storage: file.NewIdempotencyStorage(),
+				now:     func() time.Time { return now },
+			}
+			test.setup(service)
+			now = now.Add(test.elapsed)
+
+			stored, err := service.Begin(ctx, test.userId, "key-1", test.request)
+			test.assertFunc(t, stored, err)
+		})
+	}
+}
+
+func TestIdempotencyService_Complete(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	storage := mocks.NewIdempotencyDatasource(t)
+	storage.On("Read", ctx, 1, "key-1").Return(dsmodels.IdempotencyRecord{}, errors.New("idempotency key not found"))
+	service := NewIdempotencyService(IdempotencyConfig{TTL: time.Hour}, storage)
+
+	err := service.Complete(ctx, 1, "key-1", models.StoredResponse{StatusCode: 201})
+	assert.EqualError(t, err, "idempotency key not found", "expected the storage error")
+	storage.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestNewIdempotencyConfig(t *testing.T) {
+	t.Setenv("FP_KATA_IDEMPOTENCY_TTL", "90m")
+	assert.Equal(t, IdempotencyConfig{TTL: 90 * time.Minute}, NewIdempotencyConfig(), "expected the ttl to be read from the environment")
+
+	t.Setenv("FP_KATA_IDEMPOTENCY_TTL", "soon")
+	assert.Equal(t, IdempotencyConfig{TTL: defaultIdempotencyTTL}, NewIdempotencyConfig(), "expected the default ttl")
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"fp_kata/common/utils"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/internal/models"
+)
+
+const compInventoryService = "InventoryService"
+
+type InventoryService interface {
+	GetStock(ctx context.Context, productId int) (*models.Stock, error)
+	GetAllStock(ctx context.Context) ([]*models.Stock, error)
+	SetStock(ctx context.Context, stock models.Stock) (*models.Stock, error)
+	AdjustStock(ctx context.Context, productId int, delta int) (*models.Stock, error)
+	GetLowStock(ctx context.Context, threshold *int) ([]*models.Stock, error)
+	ReserveStock(ctx context.Context, orderId int, items []models.StockItem) ([]models.StockItem, error)
+	ReleaseStock(ctx context.Context, orderId int) error
+	CommitStock(ctx context.Context, orderId int) error
+}
+
+type inventoryService struct {
+	storage         datasources.InventoryDatasource
+	productsService ProductsService
+}
+
+func NewInventoryService(storage datasources.InventoryDatasource, productsService ProductsService) InventoryService {
+	return &inventoryService{storage: storage, productsService: productsService}
+}
+
+// GetStock returns the stock of a catalog product, products that were never stocked have no stock.
+func (service *inventoryService) GetStock(ctx context.Context, productId int) (*models.Stock, error) {
+	utils.LogAction(ctx, compInventoryService, "GetStock")
+
+	if _, err := service.productsService.GetProduct(ctx, productId); err != nil {
+		return nil, err
+	}
+	dsStock, err := service.storage.Read(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	return models.MapToStock(dsStock), nil
+}
+
+func (service *inventoryService) GetAllStock(ctx context.Context) ([]*models.Stock, error) {
+	utils.LogAction(ctx, compInventoryService, "GetAllStock")
+
+	dsStocks, err := service.storage.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mapToStocks(dsStocks), nil
+}
+
+// SetStock sets the quantity on hand and the reorder level of a catalog product.
+func (service *inventoryService) SetStock(ctx context.Context, stock models.Stock) (*models.Stock, error) {
+	utils.LogAction(ctx, compInventoryService, "SetStock")
+
+	if _, err := service.productsService.GetProduct(ctx, stock.ProductID); err != nil {
+		return nil, err
+	}
+	dsStock, err := service.storage.Save(ctx, stock.ToDSModel())
+	if err != nil {
+		return nil, err
+	}
+	return models.MapToStock(dsStock), nil
+}
+
+// AdjustStock adds stock to a catalog product, or removes it for a negative delta.
+// Reserved stock cannot be removed.
+func (service *inventoryService) AdjustStock(ctx context.Context, productId int, delta int) (*models.Stock, error) {
+	utils.LogAction(ctx, compInventoryService, "AdjustStock")
+
+	if _, err := service.productsService.GetProduct(ctx, productId); err != nil {
+		return nil, err
+	}
+	dsStock, err := service.storage.Adjust(ctx, productId, delta)
+	if err != nil {
+		return nil, err
+	}
+	return models.MapToStock(dsStock), nil
+}
+
+// GetLowStock returns the products whose available stock is at or below the threshold,
+// or at or below their own reorder level when no threshold is given.
+func (service *inventoryService) GetLowStock(ctx context.Context, threshold *int) ([]*models.Stock, error) {
+	utils.LogAction(ctx, compInventoryService, "GetLowStock")
+
+	stocks, err := service.GetAllStock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lowStocks := make([]*models.Stock, 0)
+	for _, stock := range stocks {
+		level := stock.ReorderLevel
+		if threshold != nil {
+			level = *threshold
+		}
+		if stock.Available() <= level {
+			lowStocks = append(lowStocks, stock)
+		}
+	}
+	return lowStocks, nil
+}
+
+// ReserveStock replaces the reservation of an order, it returns the items the order reserved before.
+func (service *inventoryService) ReserveStock(ctx context.Context, orderId int, items []models.StockItem) ([]models.StockItem, error) {
+	utils.LogAction(ctx, compInventoryService, "ReserveStock")
+
+	dsItems := make([]dsmodels.StockItem, len(items))
+	for i, item := range items {
+		dsItems[i] = item.ToDSModel()
+	}
+	dsPrevious, err := service.storage.Reserve(ctx, orderId, dsItems)
+	if err != nil {
+		return nil, err
+	}
+	previous := make([]models.StockItem, len(dsPrevious))
+	for i, dsItem := range dsPrevious {
+		previous[i] = models.MapToStockItem(dsItem)
+	}
+	return previous, nil
+}
+
+func (service *inventoryService) ReleaseStock(ctx context.Context, orderId int) error {
+	utils.LogAction(ctx, compInventoryService, "ReleaseStock")
+
+	return service.storage.Release(ctx, orderId)
+}
+
+func (service *inventoryService) CommitStock(ctx context.Context, orderId int) error {
+	utils.LogAction(ctx, compInventoryService, "CommitStock")
+
+	return service.storage.Commit(ctx, orderId)
+}
+
+func mapToStocks(dsStocks []dsmodels.Stock) []*models.Stock {
+	stocks := make([]*models.Stock, len(dsStocks))
+	for i, dsStock := range dsStocks {
+		stocks[i] = models.MapToStock(dsStock)
+	}
+	return stocks
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/internal/models"
+	"fp_kata/mocks"
+	"fp_kata/pkg/log"
+	zlog "github.com/rs/zerolog/log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInventoryService_GetLowStock(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	threshold := 3
+	tests := []struct {
+		name      string
+		threshold *int
+		expected  []int
+	}{
+		{name: "reorder levels", threshold: nil, expected: []int{1, 3}},
+		{name: "threshold", threshold: &threshold, expected: []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := mocks.NewInventoryDatasource(t)
+			mockStorage.On("All", mock.Anything).Return([]dsmodels.Stock{
+				{ProductID: 1, OnHand: 5, Reserved: 4, ReorderLevel: 2},
+				{ProductID: 2, OnHand: 3, Reserved: 0, ReorderLevel: 0},
+				{ProductID: 3, OnHand: 20, Reserved: 10, ReorderLevel: 10},
+			}, nil)
+
+			service := NewInventoryService(mockStorage, mocks.NewProductsService(t))
+			result, err := service.GetLowStock(ctx, tt.threshold)
+
+			assert.NoError(t, err, "Expected no error but got one")
+			ids := make([]int, len(result))
+			for i, stock := range result {
+				ids[i] = stock.ProductID
+			}
+			assert.Equal(t, tt.expected, ids, "Low stock products mismatch")
+		})
+	}
+}
+
+func TestInventoryService_AdjustStock(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	tests := []struct {
+		name      string
+		productId int
+		delta     int
+		mockSetup func(*mocks.InventoryDatasource, *mocks.ProductsService)
+		validate  func(*testing.T, *models.Stock, error)
+	}{
+		{
+			name:      "stock added",
+			productId: 1,
+			delta:     5,
+			mockSetup: func(mockStorage *mocks.InventoryDatasource, productsService *mocks.ProductsService) {
+				productsService.On("GetProduct", mock.Anything, 1).Return(&models.Product{ID: 1}, nil)
+				mockStorage.On("Adjust", mock.Anything, 1, 5).Return(dsmodels.Stock{ProductID: 1, OnHand: 7, Reserved: 1}, nil)
+			},
+			validate: func(t *testing.T, result *models.Stock, err error) {
+				assert.NoError(t, err, "Expected no error but got one")
+				assert.Equal(t, &models.Stock{ProductID: 1, OnHand: 7, Reserved: 1}, result, "Stock mismatch")
+				assert.Equal(t, 6, result.Available(), "Available stock mismatch")
+			},
+		},
+		{
+			name:      "unknown product",
+			productId: 9,
+			delta:     5,
+			mockSetup: func(mockStorage *mocks.InventoryDatasource, productsService *mocks.ProductsService) {
+				productsService.On("GetProduct", mock.Anything, 9).Return(nil, fmt.Errorf("%w: %d", datasources.ErrProductNotFound, 9))
+			},
+			validate: func(t *testing.T, result *models.Stock, err error) {
+				assert.ErrorIs(t, err, datasources.ErrProductNotFound, "Expected a not found error")
+				assert.Nil(t, result, "Expected result to be nil on error")
+			},
+		},
+		{
+			name:      "reserved stock cannot be removed",
+			productId: 1,
+			delta:     -5,
+			mockSetup: func(mockStorage *mocks.InventoryDatasource, productsService *mocks.ProductsService) {
+				productsService.On("GetProduct", mock.Anything, 1).Return(&models.Product{ID: 1}, nil)
+				mockStorage.On("Adjust", mock.Anything, 1, -5).Return(dsmodels.Stock{}, datasources.ErrInsufficientStock)
+			},
+			validate: func(t *testing.T, result *models.Stock, err error) {
+				assert.ErrorIs(t, err, datasources.ErrInsufficientStock, "Expected an insufficient stock error")
+				assert.Nil(t, result, "Expected result to be nil on error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := mocks.NewInventoryDatasource(t)
+			productsService := mocks.NewProductsService(t)
+			tt.mockSetup(mockStorage, productsService)
+
+			service := NewInventoryService(mockStorage, productsService)
+			result, err := service.AdjustStock(ctx, tt.productId, tt.delta)
+
+			tt.validate(t, result, err)
+		})
+	}
+}
+
+func TestInventoryService_ReserveStock(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	mockStorage := mocks.NewInventoryDatasource(t)
+	mockStorage.On("Reserve", mock.Anything, 42, []dsmodels.StockItem{{ProductID: 1, Quantity: 2}}).
+		Return([]dsmodels.StockItem{{ProductID: 1, Quantity: 1}}, nil).Once()
+	mockStorage.On("Reserve", mock.Anything, 43, []dsmodels.StockItem{{ProductID: 1, Quantity: 9}}).
+		Return(nil, errors.New("reserve failed")).Once()
+	mockStorage.On("Release", mock.Anything, 42).Return(nil)
+	mockStorage.On("Commit", mock.Anything, 42).Return(nil)
+
+	service := NewInventoryService(mockStorage, mocks.NewProductsService(t))
+
+	previous, err := service.ReserveStock(ctx, 42, []models.StockItem{{ProductID: 1, Quantity: 2}})
+	assert.NoError(t, err, "Expected no error but got one")
+	assert.Equal(t, []models.StockItem{{ProductID: 1, Quantity: 1}}, previous, "Previous reservation mismatch")
+
+	_, err = service.ReserveStock(ctx, 43, []models.StockItem{{ProductID: 1, Quantity: 9}})
+	assert.EqualError(t, err, "reserve failed", "Error message mismatch")
+
+	assert.NoError(t, service.ReleaseStock(ctx, 42), "Expected no error releasing stock")
+	assert.NoError(t, service.CommitStock(ctx, 42), "Expected no error committing stock")
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// OrderNumberConfig configures the human-friendly numbers given to orders alongside their ids.
+type OrderNumberConfig struct {
+	// Prefix starts every order number, orders are not numbered without a prefix.
+	Prefix string
+}
+
+// NewOrderNumberConfig reads the prefix of order numbers from FP_KATA_ORDER_NUMBER_PREFIX, for example ORD.
+func NewOrderNumberConfig() OrderNumberConfig {
+	return OrderNumberConfig{Prefix: os.Getenv("FP_KATA_ORDER_NUMBER_PREFIX")}
+}
+
+// OrderNumberGenerator creates the numbers of new orders.
+type OrderNumberGenerator interface {
+	// NewOrderNumber returns the next order number, or an empty number when orders are not numbered.
+	NewOrderNumber() string
+}
+
+type orderNumberGenerator struct {
+	mu     sync.Mutex
+	config OrderNumberConfig
+	now    func() time.Time
+	year   int
+	last   int
+}
+
+// NewOrderNumberGenerator numbers orders per year, for example ORD-2026-000123 is the 123rd order of 2026.
+func NewOrderNumberGenerator(config OrderNumberConfig) OrderNumberGenerator {
+	return &orderNumberGenerator{config: config, now: time.Now}
+}
+
+func (g *orderNumberGenerator) NewOrderNumber() string {
+	if g.config.Prefix == "" {
+		return ""
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// the numbers start again at 1 every year
+	if year := g.now().Year(); year != g.year {
+		g.year = year
+		g.last = 0
+	}
+	g.last++
+	return fmt.Sprintf("%s-%d-%06d", g.config.Prefix, g.year, g.last)
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderNumberGenerator(t *testing.T) {
+	now := time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC)
+	generator := &orderNumberGenerator{config: OrderNumberConfig{Prefix: "ORD"}, now: func() time.Time { return now }}
+
+	assert.Equal(t, "ORD-2026-000001", generator.NewOrderNumber(), "unexpected first number")
+	assert.Equal(t, "ORD-2026-000002", generator.NewOrderNumber(), "unexpected second number")
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, "ORD-2027-000001", generator.NewOrderNumber(), "expected the numbers to restart in a new year")
+
+	generator.last = 999999
+	assert.Equal(t, "ORD-2027-1000000", generator.NewOrderNumber(), "expected numbers to grow beyond six digits")
+}
+
+func TestOrderNumberGenerator_Disabled(t *testing.T) {
+	t.Setenv("FP_KATA_ORDER_NUMBER_PREFIX", "")
+	assert.Empty(t, NewOrderNumberGenerator(NewOrderNumberConfig()).NewOrderNumber(), "expected orders not to be numbered")
+
+	t.Setenv("FP_KATA_ORDER_NUMBER_PREFIX", "WEB")
+	assert.Regexp(t, `^WEB-\d{4}-000001$`, NewOrderNumberGenerator(NewOrderNumberConfig()).NewOrderNumber(), "expected the configured prefix")
+}
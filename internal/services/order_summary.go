@@ -0,0 +1,79 @@
+package services
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/models"
+	"sort"
+)
+
+// orderSummarizer folds orders into an OrderSummary one at a time, so the orders need not be held in memory.
+type orderSummarizer struct {
+	summary         models.OrderSummary
+	byPeriod        map[string]*models.PeriodAggregate
+	byPaymentMethod map[common.PaymentMethod]*models.PaymentMethodAggregate
+	byProduct       map[int]*models.ProductAggregate
+}
+
+func newOrderSummarizer(period models.SummaryPeriod, currency common.Currency) *orderSummarizer {
+	return &orderSummarizer{
+		summary:         models.OrderSummary{Period: period, Currency: currency},
+		byPeriod:        make(map[string]*models.PeriodAggregate),
+		byPaymentMethod: make(map[common.PaymentMethod]*models.PaymentMethodAggregate),
+		byProduct:       make(map[int]*models.ProductAggregate),
+	}
+}
+
+// add folds an order into the summary, orders that do not count as spend are skipped.
+// Amounts are converted to the base currency at the rate of the order, so orders in different currencies add up.
+func (s *orderSummarizer) add(order *models.Order) error {
+	if !order.CountsAsSpend() {
+		return nil
+	}
+	spend := order.ToBaseCurrency(order.AmountDue())
+	s.summary.Add(spend)
+
+	key := s.summary.Period.Key(order.OrderDate)
+	period, found := s.byPeriod[key]
+	if !found {
+		period = &models.PeriodAggregate{Period: key}
+		s.byPeriod[key] = period
+		s.summary.ByPeriod = append(s.summary.ByPeriod, period)
+	}
+	period.Add(spend)
+
+	for method, paid := range order.PaymentMethodSpend() {
+		aggregate, found := s.byPaymentMethod[method]
+		if !found {
+			aggregate = &models.PaymentMethodAggregate{Method: method}
+			s.byPaymentMethod[method] = aggregate
+			s.summary.ByPaymentMethod = append(s.summary.ByPaymentMethod, aggregate)
+		}
+		aggregate.Add(order.ToBaseCurrency(paid))
+	}
+
+	for productID, productSpend := range order.ProductSpend() {
+		aggregate, found := s.byProduct[productID]
+		if !found {
+			aggregate = &models.ProductAggregate{ProductID: productID}
+			s.byProduct[productID] = aggregate
+			s.summary.ByProduct = append(s.summary.ByProduct, aggregate)
+		}
+		aggregate.Add(order.ToBaseCurrency(productSpend))
+	}
+	return nil
+}
+
+// result returns the summary with its groups sorted.
+func (s *orderSummarizer) result() *models.OrderSummary {
+	summary := s.summary
+	sort.Slice(summary.ByPeriod, func(i, j int) bool {
+		return summary.ByPeriod[i].Period < summary.ByPeriod[j].Period
+	})
+	sort.Slice(summary.ByPaymentMethod, func(i, j int) bool {
+		return summary.ByPaymentMethod[i].Method < summary.ByPaymentMethod[j].Method
+	})
+	sort.Slice(summary.ByProduct, func(i, j int) bool {
+		return summary.ByProduct[i].ProductID < summary.ByProduct[j].ProductID
+	})
+	return &summary
+}
@@ -3,11 +3,17 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"fp_kata/common"
 	"fp_kata/common/constants"
 	"fp_kata/common/utils"
 	"fp_kata/internal/datasources"
 	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/internal/filters"
 	"fp_kata/internal/models"
+	"fp_kata/pkg/fp"
+	"slices"
+	"time"
 )
 
 const compOrdersService = "OrdersService"
@@ -17,6 +23,30 @@ type OrdersService interface {
 	GetOrder(ctx context.Context, userId int, id int) (*models.Order, error)
 	GetOrders(ctx context.Context, userId int) ([]*models.Order, error)
 	GetOrdersWithFilter(ctx context.Context, userId int, filter func(order *models.Order) bool) ([]*models.Order, error)
+	GetOrdersPage(ctx context.Context, userId int, predicate filters.Predicate, query datasources.OrdersQuery) (*models.OrdersPage, error)
+	// ExportOrders passes all orders of the user selected by the predicate to export, in the order of the sorts.
+	// The orders are listed once and enriched one at a time as they are exported, so no enriched history is held in memory at once.
+	ExportOrders(ctx context.Context, userId int, predicate filters.Predicate, sorts []datasources.OrderSort, export func(order *models.Order) error) error
+	// GetOrderHistory returns every version of the order of the user, oldest first, with the changes of each version.
+	GetOrderHistory(ctx context.Context, userId int, id int) ([]*models.OrderVersion, error)
+	GetOrderVersion(ctx context.Context, userId int, id int, version int) (*models.OrderVersion, error)
+	// DiffOrderVersions lists the fields changed from one version of the order to another.
+	DiffOrderVersions(ctx context.Context, userId int, id int, from int, to int) ([]models.FieldChange, error)
+	// ImportOrders stores the orders of the rows of an import as new orders and reports the outcome of every row.
+	// Imported orders keep their exported prices and payments, the payments are not charged again.
+	// A dry run checks the rows like an import does without storing them.
+	// It fails only when the orders of an all-or-nothing import cannot be rolled back.
+	ImportOrders(ctx context.Context, userId int, rows []*models.OrderImportRow, options models.OrderImportOptions) ([]*models.OrderImportResult, error)
+	// SummarizeOrders aggregates the spend of the orders of the user selected by the predicate.
+	SummarizeOrders(ctx context.Context, userId int, predicate filters.Predicate, period models.SummaryPeriod) (*models.OrderSummary, error)
+	CancelOrder(ctx context.Context, userId int, id int) error
+	TransitionOrder(ctx context.Context, userId int, id int, status common.OrderStatus) (*models.Order, error)
+	UpdateOrder(ctx context.Context, userId int, order models.Order) (*models.Order, error)
+	AddPayment(ctx context.Context, userId int, id int, payment models.Payment) (*models.Order, error)
+	// RefundOrder refunds a payment of an order of the user, fully or in part.
+	RefundOrder(ctx context.Context, userId int, id int, refund models.Refund) (*models.Refund, error)
+	// GetRefunds lists the refunds of the payments of an order of the user.
+	GetRefunds(ctx context.Context, userId int, id int) ([]*models.Refund, error)
 }
 
 type ordersService struct {
@@ -24,63 +54,370 @@ type ordersService struct {
 	paymentService       PaymentsService
 	authorizationService AuthorizationService
 	userService          UsersService
+	productsService      ProductsService
+	inventoryService     InventoryService
+	ids                  utils.IDGenerator
+	orderNumbers         OrderNumberGenerator
+	exchangeRates        ExchangeRatesService
 }
 
-func NewOrdersService(storage datasources.OrdersDatasource, paymentService PaymentsService, authorizationService AuthorizationService) OrdersService {
-	return &ordersService{storage: storage, paymentService: paymentService, authorizationService: authorizationService}
+func NewOrdersService(storage datasources.OrdersDatasource, paymentService PaymentsService, authorizationService AuthorizationService,
+	productsService ProductsService, inventoryService InventoryService, ids utils.IDGenerator, orderNumbers OrderNumberGenerator,
+	exchangeRates ExchangeRatesService) OrdersService {
+	return &ordersService{
+		storage:              storage,
+		paymentService:       paymentService,
+		authorizationService: authorizationService,
+		productsService:      productsService,
+		inventoryService:     inventoryService,
+		ids:                  ids,
+		orderNumbers:         orderNumbers,
+		exchangeRates:        exchangeRates,
+	}
 }
 
-const errUserRequired = "user id is required"
+// ErrUserRequired is returned when an operation is not given the id of the authenticated user.
+var ErrUserRequired = common.NewDomainError(common.Validation, "user_required", "user id is required")
+
+// ErrNotAuthorized is returned when a user accesses an order of another user.
+var ErrNotAuthorized = common.NewDomainError(common.Forbidden, "not_authorized", "user is not authorized to access this order")
+
+// ErrOrderIdRequired is returned when an order is updated without its id.
+var ErrOrderIdRequired = common.NewDomainError(common.Validation, "order_id_required", "order id is required")
+
+// ErrIllegalTransition is returned when an order is moved to a status its current status does not allow.
+var ErrIllegalTransition = common.NewDomainError(common.Conflict, "illegal_transition", "illegal order status transition")
+
+// ErrImmutableField is returned when an update changes a field that cannot change (anymore).
+var ErrImmutableField = common.NewDomainError(common.Unprocessable, "immutable_field", "field cannot be changed")
+
+// ErrUnknownPayment is returned when an order references a payment that is not stored for it.
+var ErrUnknownPayment = common.NewDomainError(common.Unprocessable, "unknown_payment", "payment does not belong to the order")
+
+// ErrOverpayment is returned when the payments of an order add up to more than its price.
+var ErrOverpayment = common.NewDomainError(common.Unprocessable, "overpayment", "payments exceed the amount due")
+
+// ErrOrderNotPaid is returned for marking an order paid while part of its price is still outstanding.
+var ErrOrderNotPaid = common.NewDomainError(common.Conflict, "order_not_paid", "order is not paid in full")
+
+// ErrOrderClosed is returned when a payment is added to a cancelled or refunded order.
+var ErrOrderClosed = common.NewDomainError(common.Conflict, "order_closed", "order does not accept payments")
+
+// ErrOrderNotEditable is returned for updating a cancelled, refunded or delivered order.
+var ErrOrderNotEditable = common.NewDomainError(common.Conflict, "order_not_editable", "order can no longer be changed")
+
+// ErrOrderNotCancellable is returned for deleting an order that has already left the warehouse, it can only be refunded.
+var ErrOrderNotCancellable = common.NewDomainError(common.Conflict, "order_not_cancellable", "order can no longer be cancelled")
+
+// ErrOrderVersionNotFound is returned for a version an order does not have.
+var ErrOrderVersionNotFound = common.NewDomainError(common.NotFound, "order_version_not_found", "order version not found")
+
+// ErrUnknownProduct is returned when an order line references a product that is not in the catalog.
+var ErrUnknownProduct = common.NewDomainError(common.Unprocessable, "unknown_product", "unknown product")
+
+// ErrProductMismatch is returned when an order line is weighed but its product is not sold by weight, or the other way round.
+var ErrProductMismatch = common.NewDomainError(common.Unprocessable, "product_mismatch", "order line does not match the product")
+
+// allowedTransitions defines the order lifecycle: each status maps to the statuses it may move to.
+var allowedTransitions = map[common.OrderStatus][]common.OrderStatus{
+	common.Pending:   {common.Paid, common.Cancelled},
+	common.Paid:      {common.Fulfilled, common.Refunded},
+	common.Fulfilled: {common.Delivered},
+	common.Delivered: {common.Refunded},
+	common.Cancelled: {},
+	common.Refunded:  {},
+}
 
 func (service *ordersService) StoreOrder(ctx context.Context, userId int, order models.Order) (*models.Order, error) {
 	utils.LogAction(ctx, compOrdersService, "StoreOrder")
 
-	// Validate user
-	if userId == 0 || order.User == nil || order.User.ID != userId {
-		return nil, errors.New(errUserRequired)
-	}
+	return service.storeOrder(ctx, userId, order, paymentRemoval{})
+}
+
+// paymentRemoval is the stored order an update replaces and the ids of the stored payments the update no longer lists.
+type paymentRemoval struct {
+	storedOrder dsmodels.Order
+	paymentIds  []int
+}
 
+// storeOrder validates, prices and places the order, removing the payments of the removal once the order is stored.
+func (service *ordersService) storeOrder(ctx context.Context, userId int, order models.Order, removal paymentRemoval) (*models.Order, error) {
 	isNewOrder := order.ID == 0
-	// Generate new order ID if not present
+	prepared := fp.Ok(&order).
+		Check(func(order *models.Order) error {
+			// Validate user
+			if userId == 0 || order.User == nil || order.User.ID != userId {
+				return ErrUserRequired
+			}
+			return nil
+		}).
+		Check(func(order *models.Order) error { return service.priceOrder(ctx, order, isNewOrder) }).
+		// Orders may be paid partially, but never by more than their price
+		Check(checkPayments).
+		Check(func(order *models.Order) error { return service.numberOrder(order, isNewOrder) })
+
+	return fp.FlatMap(prepared, func(order *models.Order) fp.Result[*models.Order] {
+		return service.placeOrder(ctx, userId, order, isNewOrder, removal)
+	}).Get()
+}
+
+// priceOrder prices the order in its currency. New orders are priced from the catalog, the order totals are
+// always derived from its lines. Payments in other currencies count with the amount they convert to.
+func (service *ordersService) priceOrder(ctx context.Context, order *models.Order, isNewOrder bool) error {
+	service.defaultCurrency(order)
+	if err := service.convertToBase(ctx, order); err != nil {
+		return err
+	}
 	if isNewOrder {
-		order.ID = utils.GenerateNewId()
+		if err := service.priceLines(ctx, order); err != nil {
+			return err
+		}
 	}
+	if len(order.Lines) > 0 {
+		order.ApplyLines()
+	}
+	return service.convertPayments(ctx, order)
+}
+
+// numberOrder generates the ID and number of a new order, every new order starts its lifecycle as pending.
+// Payments of new orders cannot be stored already.
+func (service *ordersService) numberOrder(order *models.Order, isNewOrder bool) error {
+	if !isNewOrder {
+		return nil
+	}
+	order.ID = service.ids.NewID()
+	order.Number = service.orderNumbers.NewOrderNumber()
+	order.Status = common.Pending
+
+	for _, payment := range order.Payments {
+		if payment.Id != 0 {
+			return fmt.Errorf("%w: %d", ErrUnknownPayment, payment.Id)
+		}
+	}
+	return nil
+}
+
+// placeOrder reserves the stock of the order, processes its payments, stores it as changed by the user and
+// removes the payments it no longer lists. Placing an order is all-or-nothing, every completed step is undone
+// again when a later step fails.
+func (service *ordersService) placeOrder(ctx context.Context, userId int, order *models.Order, isNewOrder bool, removal paymentRemoval) fp.Result[*models.Order] {
+	placement := &saga{}
+
+	reserved := fp.Ok(order).Check(func(order *models.Order) error {
+		return service.reserveStock(ctx, order, placement)
+	})
 
 	// Process payments
 	// payment Ids inside order will be updated <-- side effect
-	storedPayments, err := service.processPayments(ctx, &order)
+	storedPayments := fp.FlatMap(reserved, func(order *models.Order) fp.Result[[]*models.Payment] {
+		return fp.Of(service.processPayments(ctx, order, placement))
+	})
+
+	// Store order in database
+	storedOrder := fp.FlatMap(storedPayments, func([]*models.Payment) fp.Result[*dsmodels.Order] {
+		if isNewOrder {
+			return fp.Of(service.storage.InsertOrder(ctx, *order.ToDSModel(), userId))
+		}
+		return fp.Of(service.storage.UpdateOrder(ctx, *order.ToDSModel(), userId))
+	})
+
+	// Remove payments only once the stored order no longer references them
+	prunedOrder := storedOrder.Check(func(*dsmodels.Order) error {
+		return service.removePayments(ctx, userId, removal, placement)
+	})
+
+	// Map stored order to the response model
+	placedOrder := fp.Map(fp.Zip(prunedOrder, storedPayments), func(stored fp.Pair[*dsmodels.Order, []*models.Payment]) *models.Order {
+		newOrder := models.MapToOrder(*stored.First)
+		newOrder.Payments = stored.Second
+		return newOrder
+	})
+	return placedOrder.MapErr(func(err error) error {
+		return placement.rollback(ctx, err)
+	})
+}
+
+// checkPayments rejects orders whose payments add up to more than the amount due.
+// Refunded amounts are no longer paid, so the order may be paid again up to what was refunded.
+func checkPayments(order *models.Order) error {
+	if order.Balance().Sign() < 0 {
+		paid := order.AmountPaid().Sub(order.AmountRefunded())
+		return fmt.Errorf("%w: %s paid, %s due", ErrOverpayment, paid, order.AmountDue())
+	}
+	return nil
+}
+
+// defaultCurrency prices orders placed without a currency in the base currency.
+func (service *ordersService) defaultCurrency(order *models.Order) {
+	if order.Currency == "" {
+		order.Currency = service.exchangeRates.BaseCurrency()
+	}
+}
+
+// convertToBase sets the rate the amounts of an order in another currency convert to the base currency with,
+// the rate effective on the order date, so orders in different currencies can be compared and summed.
+func (service *ordersService) convertToBase(ctx context.Context, order *models.Order) error {
+	order.Conversion = nil
+	if order.Currency == service.exchangeRates.BaseCurrency() {
+		return nil
+	}
+	rate, err := service.exchangeRates.GetRate(ctx, order.Currency, service.exchangeRates.BaseCurrency(), order.OrderDate)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	order.Conversion = rate
+	return nil
+}
 
-	// Store order in database
-	var storedOrderModel *dsmodels.Order
-	if isNewOrder {
-		storedOrderModel, err = service.storage.InsertOrder(ctx, *order.ToDSModel())
-	} else {
-		storedOrderModel, err = service.storage.UpdateOrder(ctx, *order.ToDSModel())
+// priceLines checks that the products of all order lines are in the catalog and sets the unit prices of
+// the lines to the catalog prices; prices sent by the client are never trusted. Weighted lines are priced
+// per unit of weight, converted to the weight unit of the line. Catalog prices are in the base currency,
+// they are converted to the currency of the order at the rate effective on the order date.
+func (service *ordersService) priceLines(ctx context.Context, order *models.Order) error {
+	if len(order.Lines) == 0 {
+		return nil
+	}
+	rate, err := service.exchangeRates.GetRate(ctx, service.exchangeRates.BaseCurrency(), order.Currency, order.OrderDate)
+	if err != nil {
+		return err
+	}
+
+	for i, line := range order.Lines {
+		product, err := service.lineProduct(ctx, i, line)
+		if err != nil {
+			return err
+		}
+
+		unitPrice := rate.Convert(product.Price)
+		if product.IsWeighted() {
+			unitPrice = product.Price.Scale(rate.Rate * line.WeightUnit.Convert(1, product.WeightUnit))
+		}
+		line.Reprice(unitPrice)
+	}
+	return nil
+}
+
+// lineProduct returns the catalog product of an order line, provided the line is weighted like the product is sold.
+func (service *ordersService) lineProduct(ctx context.Context, i int, line *models.OrderLine) (*models.Product, error) {
+	product, err := service.productsService.GetProduct(ctx, line.ProductID)
+	if errors.Is(err, datasources.ErrProductNotFound) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownProduct, line.ProductID)
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Map stored order to the response model
-	newOrder := models.MapToOrder(*storedOrderModel)
-	newOrder.Payments = storedPayments
+	if product.IsWeighted() != line.IsWeighted() {
+		if product.IsWeighted() {
+			return nil, fmt.Errorf("%w: line %d needs a weight, product %d is sold by %s", ErrProductMismatch, i, product.ID, product.WeightUnit)
+		}
+		return nil, fmt.Errorf("%w: line %d has a weight, product %d is not sold by weight", ErrProductMismatch, i, product.ID)
+	}
+	return product, nil
+}
+
+// convertPayments records the rate a payment in another currency than its order converts with, the rate
+// effective when it was paid. Payments without a currency are made in the currency of the order,
+// stored payments keep the rate they were converted with.
+func (service *ordersService) convertPayments(ctx context.Context, order *models.Order) error {
+	for _, payment := range order.Payments {
+		if payment.Currency == "" {
+			payment.Currency = order.Currency
+		}
+		if payment.Currency == order.Currency {
+			payment.Conversion = nil
+			continue
+		}
+
+		if payment.Conversion == nil && payment.Id != 0 {
+			storedPayment, err := service.paymentService.GetPaymentByID(ctx, payment.Id)
+			if err != nil {
+				return err
+			}
+			payment.Conversion = storedPayment.Conversion
+			payment.PaidAt = storedPayment.PaidAt
+		}
+		if payment.Conversion != nil && payment.Conversion.From == payment.Currency && payment.Conversion.To == order.Currency {
+			continue
+		}
+
+		paidAt := payment.PaidAt
+		if paidAt.IsZero() {
+			paidAt = time.Now()
+		}
+		rate, err := service.exchangeRates.GetRate(ctx, payment.Currency, order.Currency, paidAt)
+		if err != nil {
+			return err
+		}
+		payment.Conversion = rate
+	}
+	return nil
+}
+
+// reserveStock reserves the stock of open orders, the reservation is restored to the items it held before on rollback.
+func (service *ordersService) reserveStock(ctx context.Context, order *models.Order, placement *saga) error {
+	if len(order.Lines) == 0 || (order.Status != common.Pending && order.Status != common.Paid) {
+		return nil
+	}
+	previousItems, err := service.inventoryService.ReserveStock(ctx, order.ID, order.StockItems())
+	if err != nil {
+		return err
+	}
+	placement.onRollback(func(ctx context.Context) error {
+		return service.restoreReservation(ctx, order.ID, previousItems)
+	})
+	return nil
+}
 
-	return newOrder, nil
+// restoreReservation puts the reservation of an order back to the items it held before, releasing it when it held none.
+func (service *ordersService) restoreReservation(ctx context.Context, orderId int, previousItems []models.StockItem) error {
+	if len(previousItems) == 0 {
+		return service.inventoryService.ReleaseStock(ctx, orderId)
+	}
+	_, err := service.inventoryService.ReserveStock(ctx, orderId, previousItems)
+	return err
 }
 
 // processPayments handles storing payments and updating payment IDs.
-func (service *ordersService) processPayments(ctx context.Context, order *models.Order) ([]*models.Payment, error) {
+// New payments are deleted again on rollback, updated payments are restored to their stored version.
+func (service *ordersService) processPayments(ctx context.Context, order *models.Order, placement *saga) ([]*models.Payment, error) {
 	storedPayments := make([]*models.Payment, len(order.Payments))
 
 	for i, payment := range order.Payments {
 		payment.Order = order
+
+		var previousPayment *models.Payment
+		if payment.Id != 0 {
+			var err error
+			previousPayment, err = service.paymentService.GetPaymentByID(ctx, payment.Id)
+			if err != nil {
+				return nil, err
+			}
+			// updates without details, like merge patches, keep the details of the stored payment
+			if payment.Details == nil && payment.Method == previousPayment.Method {
+				payment.Details = previousPayment.Details
+			}
+			// updates are stored only, the payment keeps the transaction it was charged with
+			payment.Status = previousPayment.Status
+			payment.TransactionId = previousPayment.TransactionId
+		}
+
 		storedPayment, err := service.paymentService.StorePayment(ctx, *payment)
 		if err != nil {
 			return nil, err
 		}
+		if previousPayment == nil {
+			placement.onRollback(func(ctx context.Context) error {
+				payment.Id = 0
+				return service.paymentService.DeletePayment(ctx, storedPayment.Id)
+			})
+		} else {
+			placement.onRollback(func(ctx context.Context) error {
+				_, err := service.paymentService.StorePayment(ctx, *previousPayment)
+				return err
+			})
+		}
+
 		order.Payments[i].Id = storedPayment.Id
 		storedPayments[i] = storedPayment
 	}
@@ -88,11 +425,43 @@ func (service *ordersService) processPayments(ctx context.Context, order *models
 	return storedPayments, nil
 }
 
+// removePayments deletes the payments of the removal. On rollback the deleted payments are stored again
+// and the order is put back to the stored order the update replaced, pointing to the restored payments.
+func (service *ordersService) removePayments(ctx context.Context, userId int, removal paymentRemoval, placement *saga) error {
+	if len(removal.paymentIds) == 0 {
+		return nil
+	}
+
+	deletedPayments := make([]*models.Payment, 0, len(removal.paymentIds))
+	placement.onRollback(func(ctx context.Context) error {
+		storedOrder := removal.storedOrder
+		paymentIds, err := service.storeRemovedPayments(ctx, storedOrder.Payments, deletedPayments)
+		if err != nil {
+			return err
+		}
+		storedOrder.Payments = paymentIds
+		_, err = service.storage.UpdateOrder(ctx, storedOrder, userId)
+		return err
+	})
+
+	for _, paymentId := range removal.paymentIds {
+		payment, err := service.paymentService.GetPaymentByID(ctx, paymentId)
+		if err != nil {
+			return err
+		}
+		if err := service.paymentService.DeletePayment(ctx, paymentId); err != nil {
+			return err
+		}
+		deletedPayments = append(deletedPayments, payment)
+	}
+	return nil
+}
+
 func (service *ordersService) GetOrder(ctx context.Context, userId int, id int) (*models.Order, error) {
 	utils.LogAction(ctx, compOrdersService, "GetOrder")
 
 	if userId == 0 {
-		return nil, errors.New("user id is required")
+		return nil, ErrUserRequired
 	}
 
 	dsOrder, err := service.storage.GetOrder(ctx, id)
@@ -112,7 +481,7 @@ func (service *ordersService) GetOrders(ctx context.Context, userId int) ([]*mod
 	utils.LogAction(ctx, compOrdersService, "GetOrders")
 
 	if userId == 0 {
-		return nil, errors.New("user id is required")
+		return nil, ErrUserRequired
 	}
 	dsOrders, err := service.storage.GetAllOrdersForUser(ctx, userId)
 	if err != nil {
@@ -135,7 +504,7 @@ func (service *ordersService) GetOrdersWithFilter(ctx context.Context, userId in
 	utils.LogAction(ctx, compOrdersService, "GetOrdersWithFilter")
 
 	if userId == 0 {
-		return nil, errors.New("user id is required")
+		return nil, ErrUserRequired
 	}
 	allDsOrders, err := service.storage.GetAllOrdersForUser(ctx, userId)
 	if err != nil {
@@ -157,44 +526,781 @@ func (service *ordersService) GetOrdersWithFilter(ctx context.Context, userId in
 	return filteredOrders, nil
 }
 
-func (service *ordersService) processDsOrder(ctx context.Context, userId int, storedOrder dsmodels.Order) (*models.Order, error) {
-	// Map the stored order
-	order := models.MapToOrder(storedOrder)
+// ExportOrders reads the orders page by page, so exports never hold all orders of the user at once.
+func (service *ordersService) ExportOrders(ctx context.Context, userId int, predicate filters.Predicate, sorts []datasources.OrderSort, export func(order *models.Order) error) error {
+	utils.LogAction(ctx, compOrdersService, "ExportOrders")
 
-	order, err := service.processOrder(ctx, userId, order)
+	// paging the export would filter and sort the whole list again for every page
+	dsPage, err := service.queryOrders(ctx, userId, predicate, datasources.OrdersQuery{Sort: sorts})
+	if err != nil {
+		return err
+	}
+	for _, dsOrder := range dsPage.Orders {
+		order, err := service.processDsOrder(ctx, userId, dsOrder)
+		if err != nil {
+			return err
+		}
+		if err := export(order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (service *ordersService) SummarizeOrders(ctx context.Context, userId int, predicate filters.Predicate, period models.SummaryPeriod) (*models.OrderSummary, error) {
+	utils.LogAction(ctx, compOrdersService, "SummarizeOrders")
+
+	summarizer := newOrderSummarizer(period, service.exchangeRates.BaseCurrency())
+	if err := service.ExportOrders(ctx, userId, predicate, nil, summarizer.add); err != nil {
+		return nil, err
+	}
+	return summarizer.result(), nil
+}
+
+// GetOrdersPage returns a page of the orders of the user matching the predicate of a filter expression,
+// a nil predicate selects all orders. Filtering, sorting and paging are done by the storage; payments are
+// only loaded up front for predicates that look at them, all other orders are enriched once they are on the page.
+func (service *ordersService) GetOrdersPage(ctx context.Context, userId int, predicate filters.Predicate, query datasources.OrdersQuery) (*models.OrdersPage, error) {
+	utils.LogAction(ctx, compOrdersService, "GetOrdersPage")
 
+	dsPage, err := service.queryOrders(ctx, userId, predicate, query)
 	if err != nil {
 		return nil, err
 	}
-	return order, nil
+
+	page := &models.OrdersPage{
+		Orders:     make([]*models.Order, len(dsPage.Orders)),
+		Total:      dsPage.Total,
+		NextCursor: dsPage.NextCursor,
+	}
+	for i, dsOrder := range dsPage.Orders {
+		page.Orders[i], err = service.processDsOrder(ctx, userId, dsOrder)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
 }
 
-func (service *ordersService) processOrder(ctx context.Context, userId int, order *models.Order) (*models.Order, error) {
+// queryOrders queries the stored orders of the user matching the predicate, loading the payments of the orders
+// only for predicates that look at them.
+func (service *ordersService) queryOrders(ctx context.Context, userId int, predicate filters.Predicate, query datasources.OrdersQuery) (*datasources.OrdersPage, error) {
+	if userId == 0 {
+		return nil, ErrUserRequired
+	}
+
+	var filterErr error
+	if predicate != nil {
+		query.Filter = func(dsOrder dsmodels.Order) bool {
+			order := models.MapToOrder(dsOrder)
+			if predicate.RequiresPayments() && filterErr == nil {
+				order, filterErr = service.addPayments(ctx, order)
+			}
+			return filterErr == nil && predicate.Matches(order)
+		}
+	}
+
+	dsPage, err := service.storage.QueryOrdersForUser(ctx, userId, query)
+	if err == nil {
+		err = filterErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dsPage, nil
+}
+
+func (service *ordersService) GetOrderHistory(ctx context.Context, userId int, id int) ([]*models.OrderVersion, error) {
+	utils.LogAction(ctx, compOrdersService, "GetOrderHistory")
+
+	return service.orderVersions(ctx, userId, id)
+}
+
+func (service *ordersService) GetOrderVersion(ctx context.Context, userId int, id int, version int) (*models.OrderVersion, error) {
+	utils.LogAction(ctx, compOrdersService, "GetOrderVersion")
+
+	versions, err := service.orderVersions(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	return findOrderVersion(versions, version)
+}
+
+func (service *ordersService) DiffOrderVersions(ctx context.Context, userId int, id int, from int, to int) ([]models.FieldChange, error) {
+	utils.LogAction(ctx, compOrdersService, "DiffOrderVersions")
+
+	versions, err := service.orderVersions(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	fromVersion, err := findOrderVersion(versions, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := findOrderVersion(versions, to)
+	if err != nil {
+		return nil, err
+	}
+	return models.DiffOrders(fromVersion.Order, toVersion.Order), nil
+}
+
+// orderVersions loads the versions of an order the user is authorized for, each with its changes to the previous version.
+func (service *ordersService) orderVersions(ctx context.Context, userId int, id int) ([]*models.OrderVersion, error) {
+	if userId == 0 {
+		return nil, ErrUserRequired
+	}
+
+	// the history outlives the order, so access is checked against its latest version
+	dsVersions, err := service.storage.GetOrderVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	isAuthorized, err := service.authorizationService.IsAuthorized(ctx, userId, models.MapToOrder(dsVersions[len(dsVersions)-1].Order))
+	if err != nil {
+		return nil, err
+	}
+	if !isAuthorized {
+		return nil, ErrNotAuthorized
+	}
+
+	versions := make([]*models.OrderVersion, len(dsVersions))
+	for i, dsVersion := range dsVersions {
+		versions[i] = models.MapToOrderVersion(dsVersion)
+		if i > 0 {
+			versions[i].Changes = models.DiffOrders(versions[i-1].Order, versions[i].Order)
+		}
+	}
+	return versions, nil
+}
+
+func findOrderVersion(versions []*models.OrderVersion, version int) (*models.OrderVersion, error) {
+	for _, orderVersion := range versions {
+		if orderVersion.Version == version {
+			return orderVersion, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", ErrOrderVersionNotFound, version)
+}
+
+func (service *ordersService) ImportOrders(ctx context.Context, userId int, rows []*models.OrderImportRow, options models.OrderImportOptions) ([]*models.OrderImportResult, error) {
+	utils.LogAction(ctx, compOrdersService, "ImportOrders")
+
+	if userId == 0 {
+		return nil, ErrUserRequired
+	}
+
+	results := make([]*models.OrderImportResult, len(rows))
+	available := importStock{}
+	firstFailedRow := 0
+	for i, row := range rows {
+		results[i] = &models.OrderImportResult{Row: row.Row}
+		err := row.Err
+		if err == nil {
+			err = service.checkImport(ctx, userId, row.Order, available)
+		}
+		if err != nil {
+			results[i].Status = models.ImportFailed
+			results[i].Reason = err.Error()
+			if firstFailedRow == 0 {
+				firstFailedRow = row.Row
+			}
+		}
+	}
+
+	if options.DryRun {
+		skipImportRows(results, "dry run")
+		return results, nil
+	}
+	if options.AllOrNothing && firstFailedRow != 0 {
+		skipImportRows(results, fmt.Sprintf("not imported, row %d failed", firstFailedRow))
+		return results, nil
+	}
+
+	imported := &saga{}
+	for i, row := range rows {
+		if results[i].Status == models.ImportFailed {
+			continue
+		}
+
+		stored, err := service.importOrder(ctx, userId, row.Order).Get()
+		if err != nil {
+			results[i].Status = models.ImportFailed
+			results[i].Reason = err.Error()
+			if !options.AllOrNothing {
+				continue
+			}
+			// the error is reported on its row, the import only fails when an imported order cannot be removed again
+			if err := imported.rollback(ctx, nil); err != nil {
+				return nil, fmt.Errorf("rolling back the import: %w", err)
+			}
+			skipImportRows(results, fmt.Sprintf("rolled back, row %d failed", row.Row))
+			return results, nil
+		}
+
+		results[i].Status = models.ImportCreated
+		results[i].OrderID = stored.ID
+		imported.onRollback(func(ctx context.Context) error {
+			return service.CancelOrder(ctx, userId, stored.ID)
+		})
+	}
+	return results, nil
+}
+
+// importStock is the stock available to the orders of an import by product, read once per product
+// and lowered by every order that passes the checks.
+type importStock map[int]int
+
+// checkImport prices an imported order and checks it can be stored, without storing anything. Imported orders
+// keep the prices they were exported with, their products must still be in the catalog. Their payments may not
+// exceed their price and the stock of the import must cover their lines.
+func (service *ordersService) checkImport(ctx context.Context, userId int, order *models.Order, available importStock) error {
+	_, err := fp.Ok(order).
+		Check(func(order *models.Order) error {
+			if order.User == nil || order.User.ID != userId {
+				return ErrUserRequired
+			}
+			return nil
+		}).
+		Check(func(order *models.Order) error {
+			for i, line := range order.Lines {
+				if _, err := service.lineProduct(ctx, i, line); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).
+		Check(func(order *models.Order) error { return service.priceOrder(ctx, order, false) }).
+		Check(checkPayments).
+		Check(func(order *models.Order) error { return service.checkImportStock(ctx, order, available) }).
+		Get()
+	return err
+}
+
+// checkImportStock checks that the stock still available to an import covers the stock items of the order
+// and takes them off the available stock.
+func (service *ordersService) checkImportStock(ctx context.Context, order *models.Order, available importStock) error {
+	items := order.StockItems()
+	for _, item := range items {
+		if _, read := available[item.ProductID]; !read {
+			stock, err := service.inventoryService.GetStock(ctx, item.ProductID)
+			if err != nil {
+				return err
+			}
+			available[item.ProductID] = stock.Available()
+		}
+		if item.Quantity > available[item.ProductID] {
+			return fmt.Errorf("%w: product %d has %d available, %d requested",
+				datasources.ErrInsufficientStock, item.ProductID, available[item.ProductID], item.Quantity)
+		}
+	}
+	for _, item := range items {
+		available[item.ProductID] -= item.Quantity
+	}
+	return nil
+}
+
+// importOrder stores a checked order of an import as a new pending order, reserving its stock and storing its
+// payments as they were made. Like placing an order it is all-or-nothing.
+func (service *ordersService) importOrder(ctx context.Context, userId int, order *models.Order) fp.Result[*models.Order] {
+	placement := &saga{}
+
+	numbered := fp.Ok(order).
+		Check(func(order *models.Order) error { return service.numberOrder(order, true) }).
+		Check(func(order *models.Order) error { return service.reserveStock(ctx, order, placement) })
+
+	storedPayments := fp.FlatMap(numbered, func(order *models.Order) fp.Result[[]*models.Payment] {
+		return fp.Of(service.importPayments(ctx, order, placement))
+	})
+
+	storedOrder := fp.FlatMap(storedPayments, func([]*models.Payment) fp.Result[*dsmodels.Order] {
+		return fp.Of(service.storage.InsertOrder(ctx, *order.ToDSModel(), userId))
+	})
+
+	importedOrder := fp.Map(fp.Zip(storedOrder, storedPayments), func(stored fp.Pair[*dsmodels.Order, []*models.Payment]) *models.Order {
+		newOrder := models.MapToOrder(*stored.First)
+		newOrder.Payments = stored.Second
+		return newOrder
+	})
+	return importedOrder.MapErr(func(err error) error {
+		return placement.rollback(ctx, err)
+	})
+}
+
+// importPayments stores the payments of an imported order without charging them, they are deleted again on rollback.
+func (service *ordersService) importPayments(ctx context.Context, order *models.Order, placement *saga) ([]*models.Payment, error) {
+	storedPayments := make([]*models.Payment, len(order.Payments))
+	for i, payment := range order.Payments {
+		payment.Order = order
+		storedPayment, err := service.paymentService.ImportPayment(ctx, *payment)
+		if err != nil {
+			return nil, err
+		}
+		placement.onRollback(func(ctx context.Context) error {
+			payment.Id = 0
+			return service.paymentService.DeletePayment(ctx, storedPayment.Id)
+		})
+
+		order.Payments[i].Id = storedPayment.Id
+		storedPayments[i] = storedPayment
+	}
+	return storedPayments, nil
+}
+
+// skipImportRows marks all rows of an import that did not fail as skipped for the reason.
+func skipImportRows(results []*models.OrderImportResult, reason string) {
+	for _, result := range results {
+		if result.Status != models.ImportFailed {
+			result.Status = models.ImportSkipped
+			result.OrderID = 0
+			result.Reason = reason
+		}
+	}
+}
+
+// CancelOrder deletes the order of the given user together with all of its payments and releases its stock.
+// Payments are removed before the order; if any step fails, the already removed payments
+// are stored again and linked to the order, so no order or payment is left orphaned.
+// Fulfilled and delivered orders cannot be deleted anymore, they are refunded instead.
+func (service *ordersService) CancelOrder(ctx context.Context, userId int, id int) error {
+	utils.LogAction(ctx, compOrdersService, "CancelOrder")
+
+	if userId == 0 {
+		return ErrUserRequired
+	}
+
+	dsOrder, err := service.storage.GetOrder(ctx, id)
+	if err != nil {
+		return err
+	}
 
 	// Authorization check
-	isAuthorized, err := service.authorizationService.IsAuthorized(ctx, userId, order)
+	isAuthorized, err := service.authorizationService.IsAuthorized(ctx, userId, models.MapToOrder(*dsOrder))
+	if err != nil {
+		return err
+	}
+	if !isAuthorized {
+		return ErrNotAuthorized
+	}
+
+	// the stock of fulfilled and delivered orders is gone, there is no reservation left to give back
+	if dsOrder.Status == common.Fulfilled || dsOrder.Status == common.Delivered {
+		return fmt.Errorf("%w: %s", ErrOrderNotCancellable, dsOrder.Status)
+	}
+
+	payments := make([]*models.Payment, 0)
+	if len(dsOrder.Payments) > 0 {
+		payments, err = service.paymentService.GetPaymentsByOrder(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	removedPayments := make([]*models.Payment, 0, len(payments))
+	for _, payment := range payments {
+		if err := service.paymentService.DeletePayment(ctx, payment.Id); err != nil {
+			return service.restorePayments(ctx, userId, *dsOrder, removedPayments, err)
+		}
+		removedPayments = append(removedPayments, payment)
+	}
+
+	if err := service.storage.DeleteOrder(ctx, id); err != nil {
+		return service.restorePayments(ctx, userId, *dsOrder, removedPayments, err)
+	}
+
+	// The stock held by the order is available again
+	if len(dsOrder.Lines) > 0 {
+		return service.inventoryService.ReleaseStock(ctx, id)
+	}
+	return nil
+}
+
+// UpdateOrder replaces an existing order of the given user.
+// Payments without an id are added, payments with an id are updated and stored payments
+// no longer listed on the order are removed. The lines are priced from the catalog again;
+// the owner, the status and, once the order is no longer pending, the price cannot be changed.
+// Cancelled, refunded and delivered orders cannot be updated anymore.
+func (service *ordersService) UpdateOrder(ctx context.Context, userId int, order models.Order) (*models.Order, error) {
+	utils.LogAction(ctx, compOrdersService, "UpdateOrder")
+
+	// Validate user
+	if userId == 0 || order.User == nil || order.User.ID != userId {
+		return nil, ErrUserRequired
+	}
+	if order.ID == 0 {
+		return nil, ErrOrderIdRequired
+	}
+
+	dsOrder, err := service.storage.GetOrder(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	storedOrder := models.MapToOrder(*dsOrder)
+
+	// Authorization check
+	isAuthorized, err := service.authorizationService.IsAuthorized(ctx, userId, storedOrder)
 	if err != nil {
 		return nil, err
 	}
 	if !isAuthorized {
-		return nil, errors.New("user is not authorized to access this order")
+		return nil, ErrNotAuthorized
 	}
 
-	// Add payments to the order
-	order, err = service.addPayments(ctx, order)
+	// closed orders are settled, an update would charge or reprice them again
+	if storedOrder.Status == common.Cancelled || storedOrder.Status == common.Refunded || storedOrder.Status == common.Delivered {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotEditable, storedOrder.Status)
+	}
+
+	// updates without a currency keep the currency of the stored order
+	if order.Currency == "" {
+		order.Currency = storedOrder.Currency
+	}
+	service.defaultCurrency(&order)
+
+	if err := service.priceLines(ctx, &order); err != nil {
+		return nil, err
+	}
+	if len(order.Lines) > 0 {
+		order.ApplyLines()
+	}
+
+	if err := checkImmutableFields(*storedOrder, order); err != nil {
+		return nil, err
+	}
+
+	removedPaymentIds, err := removedPayments(dsOrder.Payments, order.Payments)
 	if err != nil {
 		return nil, err
 	}
 
-	// Add user to the order
-	order, err = service.addUser(ctx, order)
+	order.Status = storedOrder.Status
+	return service.storeOrder(ctx, userId, order, paymentRemoval{storedOrder: *dsOrder, paymentIds: removedPaymentIds})
+}
+
+// AddPayment adds a payment to an order of the given user, for example to pay the outstanding balance
+// of a partially paid order. Payments beyond the amount due are rejected.
+func (service *ordersService) AddPayment(ctx context.Context, userId int, id int, payment models.Payment) (*models.Order, error) {
+	utils.LogAction(ctx, compOrdersService, "AddPayment")
+
+	order, err := service.GetOrder(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == common.Cancelled || order.Status == common.Refunded {
+		return nil, fmt.Errorf("%w: %s", ErrOrderClosed, order.Status)
+	}
+
+	payment.Id = 0
+	payment.User = order.User
+	order.Payments = append(order.Payments, &payment)
+
+	return service.StoreOrder(ctx, userId, *order)
+}
+
+// RefundOrder refunds a payment of an order of the given user. The payment has to belong to the order,
+// and is never refunded beyond its amount.
+func (service *ordersService) RefundOrder(ctx context.Context, userId int, id int, refund models.Refund) (*models.Refund, error) {
+	utils.LogAction(ctx, compOrdersService, "RefundOrder")
+
+	order, err := service.GetOrder(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(order.Payments, func(payment *models.Payment) bool { return payment.Id == refund.PaymentId }) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownPayment, refund.PaymentId)
+	}
+
+	return service.paymentService.RefundPayment(ctx, refund)
+}
+
+func (service *ordersService) GetRefunds(ctx context.Context, userId int, id int) ([]*models.Refund, error) {
+	utils.LogAction(ctx, compOrdersService, "GetRefunds")
+
+	order, err := service.GetOrder(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	refunds := make([]*models.Refund, 0)
+	for _, payment := range order.Payments {
+		refunds = append(refunds, payment.Refunds...)
+	}
+	return refunds, nil
+}
+
+// checkImmutableFields verifies that an update keeps the owner and, once paid, the price of the stored order.
+func checkImmutableFields(storedOrder models.Order, order models.Order) error {
+	if storedOrder.User.ID != order.User.ID {
+		return fmt.Errorf("%w: owner", ErrImmutableField)
+	}
+	if storedOrder.Status != common.Pending && storedOrder.Price != order.Price {
+		return fmt.Errorf("%w: price of a %s order", ErrImmutableField, storedOrder.Status)
+	}
+	if storedOrder.Status != common.Pending && storedOrder.Currency != "" && storedOrder.Currency != order.Currency {
+		return fmt.Errorf("%w: currency of a %s order", ErrImmutableField, storedOrder.Status)
+	}
+	return nil
+}
+
+// removedPayments returns the stored payment ids the updated payments no longer reference.
+// Updated payments may only reference payments stored for the order.
+func removedPayments(storedPaymentIds []int, payments []*models.Payment) ([]int, error) {
+	stored := make(map[int]bool, len(storedPaymentIds))
+	for _, paymentId := range storedPaymentIds {
+		stored[paymentId] = true
+	}
+
+	referenced := make(map[int]bool, len(payments))
+	for _, payment := range payments {
+		if payment.Id == 0 {
+			continue
+		}
+		if !stored[payment.Id] {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownPayment, payment.Id)
+		}
+		referenced[payment.Id] = true
+	}
+
+	removed := make([]int, 0)
+	for _, paymentId := range storedPaymentIds {
+		if !referenced[paymentId] {
+			removed = append(removed, paymentId)
+		}
+	}
+	return removed, nil
+}
+
+// restorePayments stores removed payments again and points the order to their new ids, as changed by the user.
+// It returns the error that caused the rollback, joined with any error raised while restoring.
+func (service *ordersService) restorePayments(ctx context.Context, userId int, dsOrder dsmodels.Order, removedPayments []*models.Payment, cause error) error {
+	utils.LogAction(ctx, compOrdersService, "restorePayments")
+
+	if len(removedPayments) == 0 {
+		return cause
+	}
+
+	paymentIds, err := service.storeRemovedPayments(ctx, dsOrder.Payments, removedPayments)
+	if err != nil {
+		return errors.Join(cause, err)
+	}
+	dsOrder.Payments = paymentIds
+
+	if _, err := service.storage.UpdateOrder(ctx, dsOrder, userId); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
+
+// storeRemovedPayments stores removed payments again and returns the payment ids with the ids of the removed
+// payments replaced by the ids of the restored ones.
+func (service *ordersService) storeRemovedPayments(ctx context.Context, paymentIds []int, removedPayments []*models.Payment) ([]int, error) {
+	restoredIds := make(map[int]int, len(removedPayments))
+	for _, payment := range removedPayments {
+		removedId := payment.Id
+		// the money of a removed payment was given back, restoring the payment charges it again
+		payment.Id = 0
+		payment.Status = ""
+		payment.TransactionId = ""
+		restoredPayment, err := service.paymentService.StorePayment(ctx, *payment)
+		if err != nil {
+			return nil, err
+		}
+		restoredIds[removedId] = restoredPayment.Id
+	}
+
+	restoredPaymentIds := make([]int, len(paymentIds))
+	for i, paymentId := range paymentIds {
+		if restoredId, restored := restoredIds[paymentId]; restored {
+			paymentId = restoredId
+		}
+		restoredPaymentIds[i] = paymentId
+	}
+	return restoredPaymentIds, nil
+}
+
+// TransitionOrder moves the order of the given user to the requested status,
+// provided the lifecycle allows the transition from its current status. Orders are only paid once
+// their payments cover their price. Fulfilling an order takes its reserved stock off hand, cancelling
+// or refunding it releases the stock and refunds its payments.
+// The transition is all-or-nothing: the stock is settled and the status stored as undoable steps, the
+// refunds come last, as refunds given back by the gateway cannot be taken back.
+func (service *ordersService) TransitionOrder(ctx context.Context, userId int, id int, status common.OrderStatus) (*models.Order, error) {
+	utils.LogAction(ctx, compOrdersService, "TransitionOrder")
+
+	if userId == 0 {
+		return nil, ErrUserRequired
+	}
+
+	dsOrder, err := service.storage.GetOrder(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	// Authorization check
+	isAuthorized, err := service.authorizationService.IsAuthorized(ctx, userId, models.MapToOrder(*dsOrder))
+	if err != nil {
+		return nil, err
+	}
+	if !isAuthorized {
+		return nil, ErrNotAuthorized
+	}
+
+	if !canTransition(dsOrder.Status, status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrIllegalTransition, dsOrder.Status, status)
+	}
+	if status == common.Paid {
+		if err := service.checkPaidInFull(ctx, *dsOrder); err != nil {
+			return nil, err
+		}
+	}
+
+	transition := &saga{}
+	previousOrder := *dsOrder
+	dsOrder.Status = status
+	if err := service.settleStock(ctx, previousOrder, status, transition); err != nil {
+		return nil, transition.rollback(ctx, err)
+	}
+
+	updatedDsOrder, err := service.storage.UpdateOrder(ctx, *dsOrder, userId)
+	if err != nil {
+		return nil, transition.rollback(ctx, err)
+	}
+	transition.onRollback(func(ctx context.Context) error {
+		_, err := service.storage.UpdateOrder(ctx, previousOrder, userId)
+		return err
+	})
+
+	if err := service.settlePayments(ctx, *updatedDsOrder); err != nil {
+		return nil, transition.rollback(ctx, err)
+	}
+	return service.processDsOrder(ctx, userId, *updatedDsOrder)
+}
+
+// checkPaidInFull rejects marking an order paid while its payments do not cover its price.
+func (service *ordersService) checkPaidInFull(ctx context.Context, dsOrder dsmodels.Order) error {
+	order, err := service.addPayments(ctx, models.MapToOrder(dsOrder))
+	if err != nil {
+		return err
+	}
+	if balance := order.Balance(); balance.Sign() > 0 {
+		return fmt.Errorf("%w: %s outstanding", ErrOrderNotPaid, balance)
+	}
+	return nil
+}
+
+// settlePayments refunds what is left of the payments of cancelled or refunded orders.
+// Payments refunded in full already are skipped, so a transition rolled back after some refunds can be retried.
+func (service *ordersService) settlePayments(ctx context.Context, dsOrder dsmodels.Order) error {
+	reasons := map[common.OrderStatus]common.RefundReason{
+		common.Cancelled: common.OrderCancelled,
+		common.Refunded:  common.OrderRefunded,
+	}
+	reason, closed := reasons[dsOrder.Status]
+	if !closed || len(dsOrder.Payments) == 0 {
+		return nil
+	}
+
+	payments, err := service.paymentService.GetPaymentsByOrder(ctx, dsOrder.ID)
+	if err != nil {
+		return err
+	}
+	for _, payment := range payments {
+		if payment.RefundableAmount().Sign() <= 0 {
+			continue
+		}
+		// refunds without an amount give back everything refundable
+		if _, err := service.paymentService.RefundPayment(ctx, models.Refund{PaymentId: payment.Id, Reason: reason}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settleStock takes the reserved stock of an order moving to fulfilled off hand and releases the stock of
+// orders moving to cancelled or refunded; refunds of delivered orders find no reservation left to release.
+// On rollback a fulfilled order puts its stock back on hand and reserves it again, a released reservation is reserved again.
+func (service *ordersService) settleStock(ctx context.Context, dsOrder dsmodels.Order, status common.OrderStatus, transition *saga) error {
+	if len(dsOrder.Lines) == 0 {
+		return nil
+	}
+	items := models.MapToOrder(dsOrder).StockItems()
+	held := dsOrder.Status == common.Pending || dsOrder.Status == common.Paid
+
+	switch status {
+	case common.Fulfilled:
+		if err := service.inventoryService.CommitStock(ctx, dsOrder.ID); err != nil {
+			return err
+		}
+		transition.onRollback(func(ctx context.Context) error {
+			return service.restoreCommittedStock(ctx, dsOrder.ID, items)
+		})
+	case common.Cancelled, common.Refunded:
+		if err := service.inventoryService.ReleaseStock(ctx, dsOrder.ID); err != nil {
+			return err
+		}
+		if held {
+			transition.onRollback(func(ctx context.Context) error {
+				_, err := service.inventoryService.ReserveStock(ctx, dsOrder.ID, items)
+				return err
+			})
+		}
+	}
+	return nil
+}
+
+// restoreCommittedStock puts the committed items of an order back on hand and reserves them for the order again.
+func (service *ordersService) restoreCommittedStock(ctx context.Context, orderId int, items []models.StockItem) error {
+	for _, item := range items {
+		if _, err := service.inventoryService.AdjustStock(ctx, item.ProductID, item.Quantity); err != nil {
+			return err
+		}
+	}
+	_, err := service.inventoryService.ReserveStock(ctx, orderId, items)
+	return err
+}
+
+// canTransition reports whether the lifecycle allows moving from one status to another.
+func canTransition(from common.OrderStatus, to common.OrderStatus) bool {
+	for _, allowed := range allowedTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (service *ordersService) processDsOrder(ctx context.Context, userId int, storedOrder dsmodels.Order) (*models.Order, error) {
+	// Map the stored order
+	order := models.MapToOrder(storedOrder)
+
+	order, err := service.processOrder(ctx, userId, order)
+
+	if err != nil {
+		return nil, err
+	}
 	return order, nil
 }
 
+func (service *ordersService) processOrder(ctx context.Context, userId int, order *models.Order) (*models.Order, error) {
+
+	// Authorization check
+	authorized := fp.FlatMap(fp.Of(service.authorizationService.IsAuthorized(ctx, userId, order)), func(isAuthorized bool) fp.Result[*models.Order] {
+		if !isAuthorized {
+			return fp.Err[*models.Order](ErrNotAuthorized)
+		}
+		return fp.Ok(order)
+	})
+
+	// Add payments to the order
+	withPayments := fp.FlatMap(authorized, func(order *models.Order) fp.Result[*models.Order] {
+		return fp.Of(service.addPayments(ctx, order))
+	})
+
+	// Add user to the order
+	return fp.FlatMap(withPayments, func(order *models.Order) fp.Result[*models.Order] {
+		return fp.Of(service.addUser(ctx, order))
+	}).Get()
+}
+
 func (service *ordersService) addPayments(ctx context.Context, order *models.Order) (*models.Order, error) {
 	utils.LogAction(ctx, compOrdersService, "addPayment")
 
@@ -212,7 +1318,7 @@ func (service *ordersService) addUser(ctx context.Context, order *models.Order)
 	user := ctx.Value(constants.AuthenticatedUserKey).(*models.User)
 
 	if user == nil {
-		return nil, errors.New(errUserRequired)
+		return nil, ErrUserRequired
 	}
 	order.User = user
 	return order, nil
@@ -3,12 +3,23 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"fp_kata/common"
 	"fp_kata/common/constants"
+	"fp_kata/common/utils"
+	"fp_kata/internal/datasources"
 	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/internal/datasources/file"
+	"fp_kata/internal/datasources/yugabyte"
+	"fp_kata/internal/filters"
+	"fp_kata/internal/gateways"
+	"fp_kata/internal/gateways/fake"
 	"fp_kata/pkg/log"
 	zlog "github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/mock"
+	"strings"
 	"testing"
+	"time"
 
 	"fp_kata/internal/models"
 	"fp_kata/mocks"
@@ -16,6 +27,13 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// noExchangeRates has no exchange rates, it prices orders in EUR, the currency of the catalog.
+func noExchangeRates() ExchangeRatesService {
+	// without a rates file there is nothing to load, so the storage cannot fail
+	storage, _ := file.NewExchangeRatesStorage("")
+	return NewExchangeRatesService(ExchangeRatesConfig{BaseCurrency: common.EUR}, storage)
+}
+
 func TestOrderService_StoreOrder(t *testing.T) {
 	log.InitLogger()
 	ctx := log.NewBackgroundContext(&zlog.Logger)
@@ -24,28 +42,238 @@ func TestOrderService_StoreOrder(t *testing.T) {
 		name       string
 		userId     int
 		order      models.Order
-		mockSetup  func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService)
+		mockSetup  func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService)
 		assertFunc func(t *testing.T, err error, createdOrder *models.Order)
 	}{
 		{
 			name:   "new order success",
 			userId: 1,
 			order: models.Order{
-				User: &models.User{ID: 1},
+				User:  &models.User{ID: 1},
+				Price: common.NewMoney(20.0),
 				Payments: []*models.Payment{
-					{Amount: 20.0},
+					{Amount: common.NewMoney(20.0)},
 				},
 			},
-			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService) {
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
 				paymentService.On("StorePayment", ctx, mock.MatchedBy(func(payment models.Payment) bool {
-					return payment.Amount == 20.0
-				})).Return(&models.Payment{Id: 1, Amount: 20.0}, nil)
-				storage.On("InsertOrder", ctx, mock.Anything).Return(&dsmodels.Order{ID: 1, UserId: 1}, nil)
+					return payment.Amount == common.NewMoney(20.0)
+				})).Return(&models.Payment{Id: 1, Amount: common.NewMoney(20.0)}, nil)
+				storage.On("InsertOrder", ctx, mock.MatchedBy(func(order dsmodels.Order) bool {
+					return order.Status == common.Pending
+				}), mock.Anything).Return(&dsmodels.Order{ID: 1, UserId: 1, Status: common.Pending}, nil)
 			},
 			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
 				assert.NoError(t, err, "expected no error on storing new order")
 				assert.NotNil(t, createdOrder, "expected a created order object")
 				assert.Equal(t, 1, createdOrder.ID, "expected order ID to match")
+				assert.Equal(t, common.Pending, createdOrder.Status, "expected new order to be pending")
+			},
+		},
+		{
+			name:   "new order total computed from lines",
+			userId: 1,
+			order: models.Order{
+				User:  &models.User{ID: 1},
+				Price: common.NewMoney(1),
+				Lines: []*models.OrderLine{
+					models.NewOrderLine(101, 2, common.NewMoney(1)),
+					models.NewOrderLine(102, 1, common.NewMoney(1)),
+				},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				productsService.On("GetProduct", ctx, 101).Return(&models.Product{ID: 101, Price: common.NewMoney(5.25)}, nil)
+				productsService.On("GetProduct", ctx, 102).Return(&models.Product{ID: 102, Price: common.NewMoney(3)}, nil)
+				inventoryService.On("ReserveStock", ctx, mock.Anything, []models.StockItem{{ProductID: 101, Quantity: 2}, {ProductID: 102, Quantity: 1}}).Return([]models.StockItem{}, nil)
+				storage.On("InsertOrder", ctx, mock.MatchedBy(func(order dsmodels.Order) bool {
+					return order.Price == common.NewMoney(13.5) && order.Quantity == 3 && len(order.Lines) == 2
+				}), mock.Anything).Return(&dsmodels.Order{ID: 1, UserId: 1, Price: common.NewMoney(13.5), Quantity: 3, Lines: []dsmodels.OrderLine{
+					{ProductID: 101, Quantity: 2, UnitPrice: common.NewMoney(5.25), LineTotal: common.NewMoney(10.5)},
+					{ProductID: 102, Quantity: 1, UnitPrice: common.NewMoney(3), LineTotal: common.NewMoney(3)},
+				}}, nil)
+			},
+			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
+				assert.NoError(t, err, "expected no error on storing new order")
+				assert.Equal(t, common.NewMoney(13.5), createdOrder.Price, "expected order total to be the sum of the catalog priced line totals")
+				assert.Len(t, createdOrder.Lines, 2, "expected the order lines to be stored")
+			},
+		},
+		{
+			name:   "weighted line priced per catalog weight unit",
+			userId: 1,
+			order: models.Order{
+				User: &models.User{ID: 1},
+				Lines: []*models.OrderLine{
+					models.NewWeightedOrderLine(7, 1, common.Money{}, common.Gram, 500),
+				},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				productsService.On("GetProduct", ctx, 7).Return(&models.Product{ID: 7, Price: common.NewMoney(12), WeightUnit: common.Kilogram}, nil)
+				inventoryService.On("ReserveStock", ctx, mock.Anything, []models.StockItem{{ProductID: 7, Quantity: 1}}).Return([]models.StockItem{}, nil)
+				storage.On("InsertOrder", ctx, mock.MatchedBy(func(order dsmodels.Order) bool {
+					return order.Price == common.NewMoney(6) && order.Lines[0].UnitPrice == common.NewMoney(0.012) && order.HasWeightables
+				}), mock.Anything).Return(&dsmodels.Order{ID: 1, UserId: 1, Price: common.NewMoney(6)}, nil)
+			},
+			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
+				assert.NoError(t, err, "expected no error on storing new order")
+				assert.Equal(t, common.NewMoney(6.0), createdOrder.Price, "expected the price of the estimated weight")
+			},
+		},
+		{
+			name:   "unknown product",
+			userId: 1,
+			order: models.Order{
+				User:  &models.User{ID: 1},
+				Lines: []*models.OrderLine{models.NewOrderLine(404, 1, common.NewMoney(10))},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				productsService.On("GetProduct", ctx, 404).Return(nil, fmt.Errorf("%w: %d", datasources.ErrProductNotFound, 404))
+			},
+			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrUnknownProduct, "expected an unknown product error")
+				assert.EqualError(t, err, "unknown product: 404", "unexpected error message")
+				assert.Nil(t, createdOrder, "expected no created order for an unknown product")
+			},
+		},
+		{
+			name:   "weight for a product not sold by weight",
+			userId: 1,
+			order: models.Order{
+				User:  &models.User{ID: 1},
+				Lines: []*models.OrderLine{models.NewWeightedOrderLine(101, 1, common.NewMoney(5), common.Kilogram, 1)},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				productsService.On("GetProduct", ctx, 101).Return(&models.Product{ID: 101, Price: common.NewMoney(5.25)}, nil)
+			},
+			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrProductMismatch, "expected a product mismatch error")
+				assert.Nil(t, createdOrder, "expected no created order for a mismatching line")
+			},
+		},
+		{
+			name:   "catalog error",
+			userId: 1,
+			order: models.Order{
+				User:  &models.User{ID: 1},
+				Lines: []*models.OrderLine{models.NewOrderLine(101, 1, common.NewMoney(5))},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				productsService.On("GetProduct", ctx, 101).Return(nil, errors.New("catalog unavailable"))
+			},
+			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
+				assert.EqualError(t, err, "catalog unavailable", "expected the catalog error")
+				assert.Nil(t, createdOrder, "expected no created order on a catalog error")
+			},
+		},
+		{
+			name:   "insufficient stock",
+			userId: 1,
+			order: models.Order{
+				User:     &models.User{ID: 1},
+				Lines:    []*models.OrderLine{models.NewOrderLine(101, 5, common.NewMoney(1))},
+				Payments: []*models.Payment{{Amount: common.NewMoney(26.25)}},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				productsService.On("GetProduct", ctx, 101).Return(&models.Product{ID: 101, Price: common.NewMoney(5.25)}, nil)
+				inventoryService.On("ReserveStock", ctx, mock.Anything, []models.StockItem{{ProductID: 101, Quantity: 5}}).
+					Return(nil, fmt.Errorf("%w: product 101 has 2 available, 5 requested", datasources.ErrInsufficientStock))
+			},
+			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
+				assert.ErrorIs(t, err, datasources.ErrInsufficientStock, "expected an insufficient stock error")
+				assert.Nil(t, createdOrder, "expected no created order when the stock does not suffice")
+			},
+		},
+		{
+			name:   "payment failure releases the reservation",
+			userId: 1,
+			order: models.Order{
+				User:     &models.User{ID: 1},
+				Lines:    []*models.OrderLine{models.NewOrderLine(101, 2, common.NewMoney(1))},
+				Payments: []*models.Payment{{Amount: common.NewMoney(10.5)}},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				productsService.On("GetProduct", ctx, 101).Return(&models.Product{ID: 101, Price: common.NewMoney(5.25)}, nil)
+				inventoryService.On("ReserveStock", ctx, mock.Anything, []models.StockItem{{ProductID: 101, Quantity: 2}}).Return([]models.StockItem{}, nil)
+				paymentService.On("StorePayment", ctx, mock.Anything).Return(nil, errors.New("payment processing failed"))
+				inventoryService.On("ReleaseStock", ctx, mock.Anything).Return(nil)
+			},
+			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
+				assert.EqualError(t, err, "payment processing failed", "expected the payment error")
+				assert.Nil(t, createdOrder, "expected no created order for a failed payment")
+			},
+		},
+		{
+			name:   "storage failure releases the reservation",
+			userId: 1,
+			order: models.Order{
+				User:  &models.User{ID: 1},
+				Lines: []*models.OrderLine{models.NewOrderLine(101, 2, common.NewMoney(1))},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				productsService.On("GetProduct", ctx, 101).Return(&models.Product{ID: 101, Price: common.NewMoney(5.25)}, nil)
+				inventoryService.On("ReserveStock", ctx, mock.Anything, []models.StockItem{{ProductID: 101, Quantity: 2}}).Return([]models.StockItem{}, nil)
+				storage.On("InsertOrder", ctx, mock.Anything, mock.Anything).Return(nil, errors.New("insert failed"))
+				inventoryService.On("ReleaseStock", ctx, mock.Anything).Return(errors.New("release failed"))
+			},
+			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
+				assert.EqualError(t, err, "insert failed\nrelease failed", "expected the storage error joined with the release error")
+				assert.Nil(t, createdOrder, "expected no created order on storage insert failure")
+			},
+		},
+		{
+			name:   "failed update restores the previous reservation",
+			userId: 1,
+			order: models.Order{
+				ID:       5,
+				Status:   common.Pending,
+				User:     &models.User{ID: 1},
+				Lines:    []*models.OrderLine{models.NewOrderLine(101, 3, common.NewMoney(5.25))},
+				Payments: []*models.Payment{{Amount: common.NewMoney(15.75)}},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				inventoryService.On("ReserveStock", ctx, 5, []models.StockItem{{ProductID: 101, Quantity: 3}}).
+					Return([]models.StockItem{{ProductID: 101, Quantity: 2}}, nil).Once()
+				paymentService.On("StorePayment", ctx, mock.Anything).Return(nil, errors.New("payment processing failed"))
+				inventoryService.On("ReserveStock", ctx, 5, []models.StockItem{{ProductID: 101, Quantity: 2}}).
+					Return([]models.StockItem{{ProductID: 101, Quantity: 3}}, nil).Once()
+			},
+			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
+				assert.EqualError(t, err, "payment processing failed", "expected the payment error")
+				assert.Nil(t, createdOrder, "expected no updated order for a failed payment")
+			},
+		},
+		{
+			name:   "partial payment",
+			userId: 1,
+			order: models.Order{
+				User:     &models.User{ID: 1},
+				Price:    common.NewMoney(20.0),
+				Payments: []*models.Payment{{Amount: common.NewMoney(5.0)}},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				paymentService.On("StorePayment", ctx, mock.Anything).Return(&models.Payment{Id: 1, Amount: common.NewMoney(5.0)}, nil)
+				storage.On("InsertOrder", ctx, mock.Anything, mock.Anything).Return(&dsmodels.Order{ID: 1, UserId: 1, Price: common.NewMoney(20.0), Payments: []int{1}}, nil)
+			},
+			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
+				assert.NoError(t, err, "expected partial payments to be allowed")
+				assert.Equal(t, common.NewMoney(15.0), createdOrder.Balance(), "expected the outstanding balance")
+			},
+		},
+		{
+			name:   "overpayment",
+			userId: 1,
+			order: models.Order{
+				User:     &models.User{ID: 1},
+				Price:    common.NewMoney(20.0),
+				Payments: []*models.Payment{{Amount: common.NewMoney(15.0)}, {Amount: common.NewMoney(5.01)}},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				// No mocks needed
+			},
+			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrOverpayment, "expected an overpayment error")
+				assert.EqualError(t, err, "payments exceed the amount due: 20.01 paid, 20.00 due", "unexpected error message")
+				assert.Nil(t, createdOrder, "expected no created order for an overpayment")
 			},
 		},
 		{
@@ -54,7 +282,7 @@ func TestOrderService_StoreOrder(t *testing.T) {
 			order: models.Order{
 				User: &models.User{ID: 1},
 			},
-			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService) {
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
 				// No mocks needed
 			},
 			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
@@ -68,7 +296,7 @@ func TestOrderService_StoreOrder(t *testing.T) {
 			order: models.Order{
 				User: &models.User{ID: 1},
 			},
-			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService) {
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
 				// No mocks needed
 			},
 			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
@@ -80,12 +308,13 @@ func TestOrderService_StoreOrder(t *testing.T) {
 			name:   "payment service error",
 			userId: 1,
 			order: models.Order{
-				User: &models.User{ID: 1},
+				User:  &models.User{ID: 1},
+				Price: common.NewMoney(50.0),
 				Payments: []*models.Payment{
-					{Amount: 50.0},
+					{Amount: common.NewMoney(50.0)},
 				},
 			},
-			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService) {
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
 				paymentService.On("StorePayment", ctx, mock.Anything).Return(nil, errors.New("payment processing failed"))
 			},
 			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
@@ -97,33 +326,55 @@ func TestOrderService_StoreOrder(t *testing.T) {
 			name:   "storage insert error",
 			userId: 1,
 			order: models.Order{
-				User: &models.User{ID: 1},
+				User:  &models.User{ID: 1},
+				Price: common.NewMoney(20.0),
 				Payments: []*models.Payment{
-					{Amount: 20.0},
+					{Amount: common.NewMoney(20.0)},
 				},
 			},
-			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService) {
-				paymentService.On("StorePayment", ctx, mock.Anything).Return(&models.Payment{Id: 1, Amount: 20.0}, nil)
-				storage.On("InsertOrder", ctx, mock.Anything).Return(nil, errors.New("insert failed"))
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				paymentService.On("StorePayment", ctx, mock.Anything).Return(&models.Payment{Id: 1, Amount: common.NewMoney(20.0)}, nil)
+				storage.On("InsertOrder", ctx, mock.Anything, mock.Anything).Return(nil, errors.New("insert failed"))
+				paymentService.On("DeletePayment", ctx, 1).Return(nil)
 			},
 			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
 				assert.EqualError(t, err, "insert failed", "expected error for storage insert failure")
 				assert.Nil(t, createdOrder, "expected no created order on storage insert failure")
 			},
 		},
+		{
+			name:   "new order referencing a stored payment",
+			userId: 1,
+			order: models.Order{
+				User:  &models.User{ID: 1},
+				Price: common.NewMoney(20.0),
+				Payments: []*models.Payment{
+					{Id: 5, Amount: common.NewMoney(20.0)},
+				},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				// No mocks needed
+			},
+			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrUnknownPayment, "expected error for a foreign payment")
+				assert.Nil(t, createdOrder, "expected no created order for a foreign payment")
+			},
+		},
 		{
 			name:   "update order success",
 			userId: 1,
 			order: models.Order{
-				ID:   1,
-				User: &models.User{ID: 1},
+				ID:    1,
+				User:  &models.User{ID: 1},
+				Price: common.NewMoney(30.0),
 				Payments: []*models.Payment{
-					{Id: 1, Amount: 30.0},
+					{Id: 1, Amount: common.NewMoney(30.0)},
 				},
 			},
-			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService) {
-				paymentService.On("StorePayment", ctx, mock.Anything).Return(&models.Payment{Id: 1, Amount: 30.0}, nil)
-				storage.On("UpdateOrder", ctx, mock.Anything).Return(&dsmodels.Order{ID: 1, UserId: 1}, nil)
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				paymentService.On("GetPaymentByID", ctx, 1).Return(&models.Payment{Id: 1, Amount: common.NewMoney(30.0)}, nil)
+				paymentService.On("StorePayment", ctx, mock.Anything).Return(&models.Payment{Id: 1, Amount: common.NewMoney(30.0)}, nil)
+				storage.On("UpdateOrder", ctx, mock.Anything, mock.Anything).Return(&dsmodels.Order{ID: 1, UserId: 1}, nil)
 			},
 			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
 				assert.NoError(t, err, "expected no error on updating order")
@@ -135,15 +386,23 @@ func TestOrderService_StoreOrder(t *testing.T) {
 			name:   "storage update error",
 			userId: 1,
 			order: models.Order{
-				ID:   1,
-				User: &models.User{ID: 1},
+				ID:    1,
+				User:  &models.User{ID: 1},
+				Price: common.NewMoney(30.0),
 				Payments: []*models.Payment{
-					{Id: 1, Amount: 30.0},
+					{Id: 1, Amount: common.NewMoney(30.0)},
 				},
 			},
-			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService) {
-				paymentService.On("StorePayment", ctx, mock.Anything).Return(&models.Payment{Id: 1, Amount: 30.0}, nil)
-				storage.On("UpdateOrder", ctx, mock.Anything).Return(nil, errors.New("update failed"))
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, productsService *mocks.ProductsService, inventoryService *mocks.InventoryService) {
+				paymentService.On("GetPaymentByID", ctx, 1).Return(&models.Payment{Id: 1, Amount: common.NewMoney(20.0)}, nil)
+				paymentService.On("StorePayment", ctx, mock.MatchedBy(func(payment models.Payment) bool {
+					return payment.Amount == common.NewMoney(30.0)
+				})).Return(&models.Payment{Id: 1, Amount: common.NewMoney(30.0)}, nil).Once()
+				storage.On("UpdateOrder", ctx, mock.Anything, mock.Anything).Return(nil, errors.New("update failed"))
+				// the updated payment is restored to its stored version
+				paymentService.On("StorePayment", ctx, mock.MatchedBy(func(payment models.Payment) bool {
+					return payment.Id == 1 && payment.Amount == common.NewMoney(20.0)
+				})).Return(&models.Payment{Id: 1, Amount: common.NewMoney(20.0)}, nil).Once()
 			},
 			assertFunc: func(t *testing.T, err error, createdOrder *models.Order) {
 				assert.EqualError(t, err, "update failed", "expected error for storage update failure")
@@ -157,9 +416,11 @@ func TestOrderService_StoreOrder(t *testing.T) {
 			storage := mocks.NewOrdersDatasource(t)
 			paymentService := mocks.NewPaymentsService(t)
 			authorizationService := mocks.NewAuthorizationService(t)
-			test.mockSetup(storage, paymentService)
+			productsService := mocks.NewProductsService(t)
+			inventoryService := mocks.NewInventoryService(t)
+			test.mockSetup(storage, paymentService, productsService, inventoryService)
 
-			service := NewOrdersService(storage, paymentService, authorizationService)
+			service := NewOrdersService(storage, paymentService, authorizationService, productsService, inventoryService, utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
 
 			createdOrder, err := service.StoreOrder(ctx, test.userId, test.order)
 			test.assertFunc(t, err, createdOrder)
@@ -167,6 +428,8 @@ func TestOrderService_StoreOrder(t *testing.T) {
 			storage.AssertExpectations(t)
 			paymentService.AssertExpectations(t)
 			authorizationService.AssertExpectations(t)
+			productsService.AssertExpectations(t)
+			inventoryService.AssertExpectations(t)
 		})
 	}
 }
@@ -176,31 +439,67 @@ func TestOrderService_processPayments(t *testing.T) {
 	ctx := log.NewBackgroundContext(&zlog.Logger)
 
 	tests := []struct {
-		name       string
-		order      models.Order
-		mockSetup  func(paymentService *mocks.PaymentsService)
-		assertFunc func(t *testing.T, payments []*models.Payment, err error)
+		name                  string
+		order                 models.Order
+		mockSetup             func(paymentService *mocks.PaymentsService)
+		assertFunc            func(t *testing.T, payments []*models.Payment, err error)
+		expectedCompensations int
 	}{
 		{
 			name: "successfully process all payments",
 			order: models.Order{
 				ID: 1,
 				Payments: []*models.Payment{
-					{Amount: 50.0},
-					{Amount: 25.0},
+					{Amount: common.NewMoney(50.0)},
+					{Amount: common.NewMoney(25.0)},
 				},
 			},
 			mockSetup: func(paymentService *mocks.PaymentsService) {
-				paymentService.On("StorePayment", ctx, mock.MatchedBy(func(p models.Payment) bool { return p.Amount == 50.0 })).Return(&models.Payment{Id: 1, Amount: 50.0}, nil)
-				paymentService.On("StorePayment", ctx, mock.MatchedBy(func(p models.Payment) bool { return p.Amount == 25.0 })).Return(&models.Payment{Id: 2, Amount: 25.0}, nil)
+				paymentService.On("StorePayment", ctx, mock.MatchedBy(func(p models.Payment) bool { return p.Amount == common.NewMoney(50.0) })).Return(&models.Payment{Id: 1, Amount: common.NewMoney(50.0)}, nil)
+				paymentService.On("StorePayment", ctx, mock.MatchedBy(func(p models.Payment) bool { return p.Amount == common.NewMoney(25.0) })).Return(&models.Payment{Id: 2, Amount: common.NewMoney(25.0)}, nil)
 			},
 			assertFunc: func(t *testing.T, payments []*models.Payment, err error) {
 				assert.NoError(t, err, "expected no error")
 				assert.Len(t, payments, 2, "expected exactly 2 payments processed")
 				assert.Equal(t, 1, payments[0].Id, "unexpected first payment ID")
-				assert.Equal(t, 50.0, payments[0].Amount, "unexpected first payment amount")
+				assert.Equal(t, common.NewMoney(50.0), payments[0].Amount, "unexpected first payment amount")
 				assert.Equal(t, 2, payments[1].Id, "unexpected second payment ID")
-				assert.Equal(t, 25.0, payments[1].Amount, "unexpected second payment amount")
+				assert.Equal(t, common.NewMoney(25.0), payments[1].Amount, "unexpected second payment amount")
+			},
+			expectedCompensations: 2,
+		},
+		{
+			name: "stored payment is looked up before it is updated",
+			order: models.Order{
+				ID: 1,
+				Payments: []*models.Payment{
+					{Id: 3, Amount: common.NewMoney(40.0)},
+				},
+			},
+			mockSetup: func(paymentService *mocks.PaymentsService) {
+				paymentService.On("GetPaymentByID", ctx, 3).Return(&models.Payment{Id: 3, Amount: common.NewMoney(30.0)}, nil)
+				paymentService.On("StorePayment", ctx, mock.MatchedBy(func(p models.Payment) bool { return p.Id == 3 })).Return(&models.Payment{Id: 3, Amount: common.NewMoney(40.0)}, nil)
+			},
+			assertFunc: func(t *testing.T, payments []*models.Payment, err error) {
+				assert.NoError(t, err, "expected no error")
+				assert.Equal(t, common.NewMoney(40.0), payments[0].Amount, "unexpected payment amount")
+			},
+			expectedCompensations: 1,
+		},
+		{
+			name: "unknown stored payment",
+			order: models.Order{
+				ID: 1,
+				Payments: []*models.Payment{
+					{Id: 3, Amount: common.NewMoney(40.0)},
+				},
+			},
+			mockSetup: func(paymentService *mocks.PaymentsService) {
+				paymentService.On("GetPaymentByID", ctx, 3).Return(nil, errors.New("payment not found"))
+			},
+			assertFunc: func(t *testing.T, payments []*models.Payment, err error) {
+				assert.Nil(t, payments, "expected no processed payments")
+				assert.EqualError(t, err, "payment not found", "unexpected error message")
 			},
 		},
 		{
@@ -208,7 +507,7 @@ func TestOrderService_processPayments(t *testing.T) {
 			order: models.Order{
 				ID: 1,
 				Payments: []*models.Payment{
-					{Amount: 100.0},
+					{Amount: common.NewMoney(100.0)},
 				},
 			},
 			mockSetup: func(paymentService *mocks.PaymentsService) {
@@ -241,9 +540,11 @@ func TestOrderService_processPayments(t *testing.T) {
 			test.mockSetup(paymentService)
 
 			service := &ordersService{paymentService: paymentService}
-			payments, err := service.processPayments(ctx, &test.order)
+			placement := &saga{}
+			payments, err := service.processPayments(ctx, &test.order, placement)
 
 			test.assertFunc(t, payments, err)
+			assert.Len(t, placement.compensations, test.expectedCompensations, "unexpected number of compensations")
 			paymentService.AssertExpectations(t)
 		})
 	}
@@ -379,7 +680,7 @@ func TestOrderService_GetOrdersWithFilter(t *testing.T) {
 			authorizationService := mocks.NewAuthorizationService(t)
 			test.mockSetup(storage, paymentService, authorizationService)
 
-			service := NewOrdersService(storage, paymentService, authorizationService)
+			service := NewOrdersService(storage, paymentService, authorizationService, mocks.NewProductsService(t), mocks.NewInventoryService(t), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
 
 			testCtx := context.WithValue(ctx, constants.AuthenticatedUserIdKey, test.userId)
 			testCtx = context.WithValue(testCtx, constants.AuthenticatedUserKey, test.ctxUser)
@@ -394,114 +695,129 @@ func TestOrderService_GetOrdersWithFilter(t *testing.T) {
 	}
 }
 
-func assertError(t *testing.T, err error, expectedErr error) {
-	assert.Nil(t, nil, "expected result to be nil")
-	assert.EqualError(t, err, expectedErr.Error(), "unexpected error message")
-}
-
-func assertSuccess(t *testing.T, err error, expectedOrder *models.Order, actualOrder *models.Order) {
-	assert.NoError(t, err, "expected no error")
-	assert.Equal(t, expectedOrder, actualOrder, "unexpected order result")
-}
-
-func TestOrderService_GetOrder(t *testing.T) {
+func TestOrderService_GetOrdersPage(t *testing.T) {
 	log.InitLogger()
 	ctx := log.NewBackgroundContext(&zlog.Logger)
+	user := &models.User{ID: 1}
+
+	// queryReturning mocks a storage applying the filter of the query to the stored orders.
+	queryReturning := func(storage *mocks.OrdersDatasource, stored []dsmodels.Order, nextCursor string) {
+		call := storage.On("QueryOrdersForUser", mock.Anything, 1, mock.Anything)
+		call.Run(func(args mock.Arguments) {
+			query := args.Get(2).(datasources.OrdersQuery)
+			page := &datasources.OrdersPage{Orders: []dsmodels.Order{}, NextCursor: nextCursor}
+			for _, order := range stored {
+				if query.Filter == nil || query.Filter(order) {
+					page.Orders = append(page.Orders, order)
+				}
+			}
+			page.Total = len(page.Orders)
+			call.Return(page, nil)
+		})
+	}
 
 	tests := []struct {
 		name       string
 		userId     int
-		ctxUser    *models.User
-		orderId    int
+		expression string
 		mockSetup  func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService)
-		assertFunc func(t *testing.T, err error, actualOrder *models.Order)
+		assertFunc func(t *testing.T, err error, page *models.OrdersPage)
 	}{
 		{
-			name:    "success case",
-			userId:  1,
-			ctxUser: &models.User{ID: 1},
-			orderId: 123,
-			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
-				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1}, nil)
-				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return([]*models.Payment{}, nil)
-				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(true, nil)
-			},
-			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
-				expectedOrder := &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}
-				assertSuccess(t, err, expectedOrder, actualOrder)
-			},
-		},
-		{
-			name:    "missing user id",
-			userId:  0,
-			orderId: 123,
+			name:   "page without filter",
+			userId: 1,
 			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
-				// No mocks needed since the function returns at the beginning
+				queryReturning(storage, []dsmodels.Order{{ID: 1, UserId: 1}}, "next")
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 1).Return([]*models.Payment{}, nil).Once()
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil).Once()
 			},
-			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
-				assertError(t, err, errors.New("user id is required"))
+			assertFunc: func(t *testing.T, err error, page *models.OrdersPage) {
+				assert.NoError(t, err, "expected no error")
+				assert.Equal(t, &models.OrdersPage{
+					Orders:     []*models.Order{{ID: 1, User: user, Payments: []*models.Payment{}}},
+					Total:      1,
+					NextCursor: "next",
+				}, page, "unexpected page")
 			},
 		},
 		{
-			name:    "order not found in storage",
-			userId:  1,
-			orderId: 123,
+			name:       "predicate without payments is evaluated before payments are loaded",
+			userId:     1,
+			expression: "price > 10",
 			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
-				storage.On("GetOrder", mock.Anything, 123).Return(nil, errors.New("order not found"))
-			},
-			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
-				assertError(t, err, errors.New("order not found"))
+				queryReturning(storage, []dsmodels.Order{
+					{ID: 1, UserId: 1, Price: common.NewMoney(5)},
+					{ID: 2, UserId: 1, Price: common.NewMoney(20)},
+				}, "")
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 2).Return([]*models.Payment{}, nil).Once()
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil).Once()
+			},
+			assertFunc: func(t *testing.T, err error, page *models.OrdersPage) {
+				assert.NoError(t, err, "expected no error")
+				assert.Equal(t, &models.OrdersPage{
+					Orders: []*models.Order{{ID: 2, Price: common.NewMoney(20), User: user, Payments: []*models.Payment{}}},
+					Total:  1,
+				}, page, "unexpected page")
 			},
 		},
 		{
-			name:    "user not authorized",
-			userId:  2,
-			orderId: 123,
+			name:       "predicate on payments loads payments for filtering",
+			userId:     1,
+			expression: "payment_method = PayPal",
 			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
-				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1}, nil)
-				authorizationService.On("IsAuthorized", mock.Anything, 2, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(false, nil)
-			},
-			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
-				assertError(t, err, errors.New("user is not authorized to access this order"))
+				queryReturning(storage, []dsmodels.Order{
+					{ID: 1, UserId: 1},
+					{ID: 2, UserId: 1},
+				}, "")
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 1).Return([]*models.Payment{
+					{Id: 1, Method: common.CreditCard},
+				}, nil).Once()
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 2).Return([]*models.Payment{
+					{Id: 2, Method: common.PayPal},
+				}, nil).Twice()
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil).Once()
+			},
+			assertFunc: func(t *testing.T, err error, page *models.OrdersPage) {
+				assert.NoError(t, err, "expected no error")
+				assert.Equal(t, &models.OrdersPage{
+					Orders: []*models.Order{{ID: 2, User: user, Payments: []*models.Payment{{Id: 2, Method: common.PayPal}}}},
+					Total:  1,
+				}, page, "unexpected page")
 			},
 		},
 		{
-			name:    "user authorization error",
-			userId:  1,
-			orderId: 123,
+			name:       "payments error while filtering",
+			userId:     1,
+			expression: "payment_count > 0",
 			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
-				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1}, nil)
-				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(false, errors.New("userId is required"))
+				queryReturning(storage, []dsmodels.Order{{ID: 1, UserId: 1}}, "")
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 1).Return(nil, errors.New("payments error")).Once()
 			},
-			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
-				assertError(t, err, errors.New("userId is required"))
+			assertFunc: func(t *testing.T, err error, page *models.OrdersPage) {
+				assert.EqualError(t, err, "payments error", "expected payments error")
+				assert.Nil(t, page, "expected no page when payments fail")
 			},
 		},
 		{
-			name:    "context missing authenticated user",
-			userId:  1,
-			orderId: 123,
+			name:   "storage error",
+			userId: 1,
 			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
-				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1}, nil)
-				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return([]*models.Payment{}, nil)
-				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(true, nil)
+				storage.On("QueryOrdersForUser", mock.Anything, 1, mock.Anything).Return(nil, datasources.ErrInvalidCursor)
 			},
-			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
-				assertError(t, err, errors.New("user id is required"))
+			assertFunc: func(t *testing.T, err error, page *models.OrdersPage) {
+				assert.ErrorIs(t, err, datasources.ErrInvalidCursor, "expected storage error")
+				assert.Nil(t, page, "expected no page when storage fails")
 			},
 		},
 		{
-			name:    "error fetching payments",
-			userId:  1,
-			ctxUser: &models.User{ID: 1},
-			orderId: 123,
+			name:   "missing user id",
+			userId: 0,
 			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
-				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1}, nil)
-				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return(nil, errors.New("payment fetch error"))
-				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(true, nil)
+				// No mocks needed
 			},
-			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
-				assertError(t, err, errors.New("payment fetch error"))
+			assertFunc: func(t *testing.T, err error, page *models.OrdersPage) {
+				assert.EqualError(t, err, "user id is required", "expected error when user id is missing")
+				assert.Nil(t, page, "expected no page when user id is missing")
 			},
 		},
 	}
@@ -513,35 +829,374 @@ func TestOrderService_GetOrder(t *testing.T) {
 			authorizationService := mocks.NewAuthorizationService(t)
 			test.mockSetup(storage, paymentService, authorizationService)
 
-			service := NewOrdersService(storage, paymentService, authorizationService)
+			service := NewOrdersService(storage, paymentService, authorizationService, mocks.NewProductsService(t), mocks.NewInventoryService(t), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
 
-			testCtx := context.WithValue(ctx, constants.AuthenticatedUserIdKey, test.userId)
-			testCtx = context.WithValue(testCtx, constants.AuthenticatedUserKey, test.ctxUser)
+			testCtx := context.WithValue(ctx, constants.AuthenticatedUserIdKey, user.ID)
+			testCtx = context.WithValue(testCtx, constants.AuthenticatedUserKey, user)
 
-			actualOrder, err := service.GetOrder(testCtx, test.userId, test.orderId)
-			test.assertFunc(t, err, actualOrder)
+			var predicate filters.Predicate
+			if test.expression != "" {
+				var err error
+				predicate, err = filters.Parse(test.expression)
+				assert.NoError(t, err, "expected the filter expression to parse")
+			}
 
-			storage.AssertExpectations(t)
-			paymentService.AssertExpectations(t)
-			authorizationService.AssertExpectations(t)
+			page, err := service.GetOrdersPage(testCtx, test.userId, predicate, datasources.OrdersQuery{Limit: 10})
+			test.assertFunc(t, err, page)
 		})
 	}
 }
 
-func TestOrderService_GetOrders(t *testing.T) {
+func TestOrderService_ExportOrders(t *testing.T) {
 	log.InitLogger()
 	ctx := log.NewBackgroundContext(&zlog.Logger)
+	user := &models.User{ID: 1}
+
+	// listing mocks a storage listing the orders at once, without paging.
+	listing := func(storage *mocks.OrdersDatasource, orders ...dsmodels.Order) {
+		storage.On("QueryOrdersForUser", mock.Anything, 1, mock.MatchedBy(func(query datasources.OrdersQuery) bool {
+			return query.Cursor == "" && query.Limit == 0
+		})).Return(&datasources.OrdersPage{Orders: orders, Total: len(orders)}, nil).Once()
+	}
 
 	tests := []struct {
-		name       string
-		userId     int
-		ctxUser    *models.User
-		mockSetup  func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService)
-		assertFunc func(t *testing.T, err error, orders []*models.Order)
+		name        string
+		exportErr   error
+		mockSetup   func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService)
+		expectedIds []int
+		expectedErr string
 	}{
 		{
-			name:    "success case",
-			userId:  1,
+			name: "all orders are exported",
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				listing(storage, dsmodels.Order{ID: 1, UserId: 1}, dsmodels.Order{ID: 2, UserId: 1}, dsmodels.Order{ID: 3, UserId: 1})
+				paymentService.On("GetPaymentsByOrder", mock.Anything, mock.Anything).Return([]*models.Payment{}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil)
+			},
+			expectedIds: []int{1, 2, 3},
+		},
+		{
+			name:      "failing export stops enriching orders",
+			exportErr: errors.New("connection closed"),
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				listing(storage, dsmodels.Order{ID: 1, UserId: 1}, dsmodels.Order{ID: 2, UserId: 1})
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 1).Return([]*models.Payment{}, nil).Once()
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil).Once()
+			},
+			expectedIds: []int{1},
+			expectedErr: "connection closed",
+		},
+		{
+			name: "storage error",
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("QueryOrdersForUser", mock.Anything, 1, mock.Anything).Return(nil, errors.New("storage error")).Once()
+			},
+			expectedErr: "storage error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := mocks.NewOrdersDatasource(t)
+			paymentService := mocks.NewPaymentsService(t)
+			authorizationService := mocks.NewAuthorizationService(t)
+			test.mockSetup(storage, paymentService, authorizationService)
+
+			service := NewOrdersService(storage, paymentService, authorizationService, mocks.NewProductsService(t), mocks.NewInventoryService(t), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+			testCtx := context.WithValue(ctx, constants.AuthenticatedUserIdKey, user.ID)
+			testCtx = context.WithValue(testCtx, constants.AuthenticatedUserKey, user)
+
+			var exportedIds []int
+			err := service.ExportOrders(testCtx, 1, nil, nil, func(order *models.Order) error {
+				exportedIds = append(exportedIds, order.ID)
+				return test.exportErr
+			})
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr, "unexpected error")
+			} else {
+				assert.NoError(t, err, "expected no error")
+			}
+			assert.Equal(t, test.expectedIds, exportedIds, "unexpected exported orders")
+		})
+	}
+}
+
+func TestOrderService_SummarizeOrders(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	user := &models.User{ID: 1}
+
+	february := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+	march := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		mockSetup   func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService)
+		expected    *models.OrderSummary
+		expectedErr string
+	}{
+		{
+			name: "orders are aggregated by period, payment method and product",
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("QueryOrdersForUser", mock.Anything, 1, mock.Anything).Return(&datasources.OrdersPage{Orders: []dsmodels.Order{
+					{ID: 1, UserId: 1, OrderDate: march, Price: common.NewMoney(10), Status: common.Paid, Lines: []dsmodels.OrderLine{
+						{ProductID: 2, Quantity: 1, UnitPrice: common.NewMoney(4), LineTotal: common.NewMoney(4)},
+						{ProductID: 1, Quantity: 2, UnitPrice: common.NewMoney(3), LineTotal: common.NewMoney(6)},
+					}},
+					{ID: 2, UserId: 1, OrderDate: february, Price: common.NewMoney(5), Status: common.Pending, ProductID: 1},
+					{ID: 3, UserId: 1, OrderDate: february, Price: common.NewMoney(100), Status: common.Cancelled, ProductID: 3},
+				}, Total: 3}, nil).Once()
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 1).Return([]*models.Payment{
+					{Id: 1, Amount: common.NewMoney(7), Method: common.PayPal},
+					{Id: 2, Amount: common.NewMoney(3), Method: common.CreditCard},
+				}, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 2).Return([]*models.Payment{
+					{Id: 3, Amount: common.NewMoney(2), Method: common.PayPal},
+				}, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 3).Return([]*models.Payment{}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil)
+			},
+			expected: &models.OrderSummary{
+				Period:         models.SummaryByMonth,
+				Currency:       common.EUR,
+				OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(15), OrderCount: 2},
+				ByPeriod: []*models.PeriodAggregate{
+					{Period: "2025-02", OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(5), OrderCount: 1}},
+					{Period: "2025-03", OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(10), OrderCount: 1}},
+				},
+				ByPaymentMethod: []*models.PaymentMethodAggregate{
+					{Method: common.CreditCard, OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(3), OrderCount: 1}},
+					{Method: common.PayPal, OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(9), OrderCount: 2}},
+				},
+				ByProduct: []*models.ProductAggregate{
+					{ProductID: 1, OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(11), OrderCount: 2}},
+					{ProductID: 2, OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(4), OrderCount: 1}},
+				},
+			},
+		},
+		{
+			name: "no orders",
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("QueryOrdersForUser", mock.Anything, 1, mock.Anything).Return(&datasources.OrdersPage{Orders: []dsmodels.Order{}}, nil).Once()
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil).Maybe()
+			},
+			expected: &models.OrderSummary{Period: models.SummaryByMonth, Currency: common.EUR},
+		},
+		{
+			name: "orders in other currencies are converted to the base currency",
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("QueryOrdersForUser", mock.Anything, 1, mock.Anything).Return(&datasources.OrdersPage{Orders: []dsmodels.Order{
+					{ID: 1, UserId: 1, OrderDate: march, Price: common.NewMoney(10), Currency: common.EUR, Status: common.Paid, ProductID: 1},
+					{ID: 2, UserId: 1, OrderDate: march, Price: common.NewMoney(10), Currency: common.USD, Status: common.Pending, ProductID: 1,
+						Conversion: &dsmodels.ExchangeRate{From: common.USD, To: common.EUR, Rate: 0.5, EffectiveFrom: february}},
+				}, Total: 2}, nil).Once()
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 1).Return([]*models.Payment{
+					{Id: 1, Amount: common.NewMoney(10), Currency: common.EUR, Method: common.PayPal},
+				}, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 2).Return([]*models.Payment{
+					{Id: 2, Amount: common.NewMoney(4), Currency: common.USD, Method: common.PayPal},
+				}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil)
+			},
+			expected: &models.OrderSummary{
+				Period:         models.SummaryByMonth,
+				Currency:       common.EUR,
+				OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(15), OrderCount: 2},
+				ByPeriod: []*models.PeriodAggregate{
+					{Period: "2025-03", OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(15), OrderCount: 2}},
+				},
+				ByPaymentMethod: []*models.PaymentMethodAggregate{
+					{Method: common.PayPal, OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(12), OrderCount: 2}},
+				},
+				ByProduct: []*models.ProductAggregate{
+					{ProductID: 1, OrderAggregate: models.OrderAggregate{TotalSpend: common.NewMoney(15), OrderCount: 2}},
+				},
+			},
+		},
+		{
+			name: "storage error",
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("QueryOrdersForUser", mock.Anything, 1, mock.Anything).Return(nil, errors.New("storage error")).Once()
+			},
+			expectedErr: "storage error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := mocks.NewOrdersDatasource(t)
+			paymentService := mocks.NewPaymentsService(t)
+			authorizationService := mocks.NewAuthorizationService(t)
+			test.mockSetup(storage, paymentService, authorizationService)
+
+			service := NewOrdersService(storage, paymentService, authorizationService, mocks.NewProductsService(t), mocks.NewInventoryService(t), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+			testCtx := context.WithValue(ctx, constants.AuthenticatedUserIdKey, user.ID)
+			testCtx = context.WithValue(testCtx, constants.AuthenticatedUserKey, user)
+
+			summary, err := service.SummarizeOrders(testCtx, 1, nil, models.SummaryByMonth)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr, "unexpected error")
+				assert.Nil(t, summary, "expected no summary on error")
+				return
+			}
+			assert.NoError(t, err, "expected no error")
+			assert.Equal(t, test.expected, summary, "unexpected summary")
+		})
+	}
+}
+
+func assertError(t *testing.T, err error, expectedErr error) {
+	assert.Nil(t, nil, "expected result to be nil")
+	assert.EqualError(t, err, expectedErr.Error(), "unexpected error message")
+}
+
+func assertSuccess(t *testing.T, err error, expectedOrder *models.Order, actualOrder *models.Order) {
+	assert.NoError(t, err, "expected no error")
+	assert.Equal(t, expectedOrder, actualOrder, "unexpected order result")
+}
+
+func TestOrderService_GetOrder(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	tests := []struct {
+		name       string
+		userId     int
+		ctxUser    *models.User
+		orderId    int
+		mockSetup  func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService)
+		assertFunc func(t *testing.T, err error, actualOrder *models.Order)
+	}{
+		{
+			name:    "success case",
+			userId:  1,
+			ctxUser: &models.User{ID: 1},
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1}, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return([]*models.Payment{}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(true, nil)
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				expectedOrder := &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}
+				assertSuccess(t, err, expectedOrder, actualOrder)
+			},
+		},
+		{
+			name:    "missing user id",
+			userId:  0,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				// No mocks needed since the function returns at the beginning
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assertError(t, err, errors.New("user id is required"))
+			},
+		},
+		{
+			name:    "order not found in storage",
+			userId:  1,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(nil, errors.New("order not found"))
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assertError(t, err, errors.New("order not found"))
+			},
+		},
+		{
+			name:    "user not authorized",
+			userId:  2,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 2, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(false, nil)
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assertError(t, err, errors.New("user is not authorized to access this order"))
+			},
+		},
+		{
+			name:    "user authorization error",
+			userId:  1,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(false, errors.New("userId is required"))
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assertError(t, err, errors.New("userId is required"))
+			},
+		},
+		{
+			name:    "context missing authenticated user",
+			userId:  1,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1}, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return([]*models.Payment{}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(true, nil)
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assertError(t, err, errors.New("user id is required"))
+			},
+		},
+		{
+			name:    "error fetching payments",
+			userId:  1,
+			ctxUser: &models.User{ID: 1},
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1}, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return(nil, errors.New("payment fetch error"))
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(true, nil)
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assertError(t, err, errors.New("payment fetch error"))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := mocks.NewOrdersDatasource(t)
+			paymentService := mocks.NewPaymentsService(t)
+			authorizationService := mocks.NewAuthorizationService(t)
+			test.mockSetup(storage, paymentService, authorizationService)
+
+			service := NewOrdersService(storage, paymentService, authorizationService, mocks.NewProductsService(t), mocks.NewInventoryService(t), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+			testCtx := context.WithValue(ctx, constants.AuthenticatedUserIdKey, test.userId)
+			testCtx = context.WithValue(testCtx, constants.AuthenticatedUserKey, test.ctxUser)
+
+			actualOrder, err := service.GetOrder(testCtx, test.userId, test.orderId)
+			test.assertFunc(t, err, actualOrder)
+
+			storage.AssertExpectations(t)
+			paymentService.AssertExpectations(t)
+			authorizationService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrderService_GetOrders(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	tests := []struct {
+		name       string
+		userId     int
+		ctxUser    *models.User
+		mockSetup  func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService)
+		assertFunc func(t *testing.T, err error, orders []*models.Order)
+	}{
+		{
+			name:    "success case",
+			userId:  1,
 			ctxUser: &models.User{ID: 1},
 			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
 				storage.On("GetAllOrdersForUser", mock.Anything, 1).Return(
@@ -640,7 +1295,7 @@ func TestOrderService_GetOrders(t *testing.T) {
 			authorizationService := mocks.NewAuthorizationService(t)
 			test.mockSetup(storage, paymentService, authorizationService)
 
-			service := NewOrdersService(storage, paymentService, authorizationService)
+			service := NewOrdersService(storage, paymentService, authorizationService, mocks.NewProductsService(t), mocks.NewInventoryService(t), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
 
 			testCtx := context.WithValue(ctx, constants.AuthenticatedUserIdKey, test.userId)
 			testCtx = context.WithValue(testCtx, constants.AuthenticatedUserKey, test.ctxUser)
@@ -654,3 +1309,1654 @@ func TestOrderService_GetOrders(t *testing.T) {
 		})
 	}
 }
+
+func TestOrderService_CancelOrder(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	storedPayments := func() []*models.Payment {
+		return []*models.Payment{
+			{Id: 1, Amount: common.NewMoney(10.0), User: &models.User{ID: 1}, Order: &models.Order{ID: 123}},
+			{Id: 2, Amount: common.NewMoney(20.0), User: &models.User{ID: 1}, Order: &models.Order{ID: 123}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		userId     int
+		orderId    int
+		mockSetup  func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService)
+		assertFunc func(t *testing.T, err error)
+	}{
+		{
+			name:    "success case",
+			userId:  1,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Payments: []int{1, 2}}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(true, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return(storedPayments(), nil)
+				paymentService.On("DeletePayment", mock.Anything, 1).Return(nil)
+				paymentService.On("DeletePayment", mock.Anything, 2).Return(nil)
+				storage.On("DeleteOrder", mock.Anything, 123).Return(nil)
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.NoError(t, err, "expected no error on cancelling order")
+			},
+		},
+		{
+			name:    "delivered order cannot be cancelled",
+			userId:  1,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Payments: []int{1, 2}, Status: common.Delivered}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}, Status: common.Delivered}).Return(true, nil)
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrOrderNotCancellable, "expected the delivered order to be kept")
+				assert.EqualError(t, err, "order can no longer be cancelled: Delivered", "unexpected error message")
+			},
+		},
+		{
+			name:    "success case without payments",
+			userId:  1,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(true, nil)
+				storage.On("DeleteOrder", mock.Anything, 123).Return(nil)
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.NoError(t, err, "expected no error on cancelling order without payments")
+			},
+		},
+		{
+			name:    "missing user id",
+			userId:  0,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				// No mocks needed since the function returns at the beginning
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "user id is required", "expected error for missing user ID")
+			},
+		},
+		{
+			name:    "order not found in storage",
+			userId:  1,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(nil, errors.New("order not found"))
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "order not found", "expected error for missing order")
+			},
+		},
+		{
+			name:    "user not authorized",
+			userId:  2,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Payments: []int{1, 2}}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 2, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(false, nil)
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "user is not authorized to access this order", "expected error for foreign order")
+			},
+		},
+		{
+			name:    "error fetching payments",
+			userId:  1,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Payments: []int{1, 2}}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(true, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return(nil, errors.New("payment fetch error"))
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "payment fetch error", "expected payment fetch error")
+			},
+		},
+		{
+			name:    "payment deletion fails halfway and removed payments are restored",
+			userId:  1,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Payments: []int{1, 2}}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(true, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return(storedPayments(), nil)
+				paymentService.On("DeletePayment", mock.Anything, 1).Return(nil)
+				paymentService.On("DeletePayment", mock.Anything, 2).Return(errors.New("delete failed"))
+				paymentService.On("StorePayment", mock.Anything, mock.MatchedBy(func(payment models.Payment) bool {
+					return payment.Id == 0 && payment.Amount == common.NewMoney(10.0)
+				})).Return(&models.Payment{Id: 3, Amount: common.NewMoney(10.0)}, nil)
+				storage.On("UpdateOrder", mock.Anything, dsmodels.Order{ID: 123, UserId: 1, Payments: []int{3, 2}}, mock.Anything).Return(&dsmodels.Order{ID: 123, UserId: 1, Payments: []int{3, 2}}, nil)
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "delete failed", "expected the original deletion error")
+			},
+		},
+		{
+			name:    "order deletion fails and all payments are restored",
+			userId:  1,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Payments: []int{1, 2}}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(true, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return(storedPayments(), nil)
+				paymentService.On("DeletePayment", mock.Anything, 1).Return(nil)
+				paymentService.On("DeletePayment", mock.Anything, 2).Return(nil)
+				storage.On("DeleteOrder", mock.Anything, 123).Return(errors.New("order delete failed"))
+				paymentService.On("StorePayment", mock.Anything, mock.MatchedBy(func(payment models.Payment) bool {
+					return payment.Id == 0 && payment.Amount == common.NewMoney(10.0)
+				})).Return(&models.Payment{Id: 3, Amount: common.NewMoney(10.0)}, nil)
+				paymentService.On("StorePayment", mock.Anything, mock.MatchedBy(func(payment models.Payment) bool {
+					return payment.Id == 0 && payment.Amount == common.NewMoney(20.0)
+				})).Return(&models.Payment{Id: 4, Amount: common.NewMoney(20.0)}, nil)
+				storage.On("UpdateOrder", mock.Anything, dsmodels.Order{ID: 123, UserId: 1, Payments: []int{3, 4}}, mock.Anything).Return(&dsmodels.Order{ID: 123, UserId: 1, Payments: []int{3, 4}}, nil)
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "order delete failed", "expected the original deletion error")
+			},
+		},
+		{
+			name:    "restoring a payment fails",
+			userId:  1,
+			orderId: 123,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Payments: []int{1, 2}}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}}).Return(true, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return(storedPayments(), nil)
+				paymentService.On("DeletePayment", mock.Anything, 1).Return(nil)
+				paymentService.On("DeletePayment", mock.Anything, 2).Return(errors.New("delete failed"))
+				paymentService.On("StorePayment", mock.Anything, mock.Anything).Return(nil, errors.New("restore failed"))
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "delete failed\nrestore failed", "expected both errors to be reported")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := mocks.NewOrdersDatasource(t)
+			paymentService := mocks.NewPaymentsService(t)
+			authorizationService := mocks.NewAuthorizationService(t)
+			test.mockSetup(storage, paymentService, authorizationService)
+
+			service := NewOrdersService(storage, paymentService, authorizationService, mocks.NewProductsService(t), mocks.NewInventoryService(t), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+			err := service.CancelOrder(ctx, test.userId, test.orderId)
+			test.assertFunc(t, err)
+
+			storage.AssertExpectations(t)
+			paymentService.AssertExpectations(t)
+			authorizationService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrderService_TransitionOrder(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	tests := []struct {
+		name       string
+		userId     int
+		ctxUser    *models.User
+		orderId    int
+		status     common.OrderStatus
+		mockSetup  func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService)
+		assertFunc func(t *testing.T, err error, actualOrder *models.Order)
+	}{
+		{
+			name:    "pending order is paid",
+			userId:  1,
+			ctxUser: &models.User{ID: 1},
+			orderId: 123,
+			status:  common.Paid,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Status: common.Pending}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}, Status: common.Pending}).Return(true, nil).Once()
+				storage.On("UpdateOrder", mock.Anything, dsmodels.Order{ID: 123, UserId: 1, Status: common.Paid}, mock.Anything).Return(&dsmodels.Order{ID: 123, UserId: 1, Status: common.Paid}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}, Status: common.Paid}).Return(true, nil).Once()
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return([]*models.Payment{}, nil)
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				expectedOrder := &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}, Status: common.Paid}
+				assertSuccess(t, err, expectedOrder, actualOrder)
+			},
+		},
+		{
+			name:    "order with an outstanding balance cannot be paid",
+			userId:  1,
+			orderId: 123,
+			status:  common.Paid,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Price: common.NewMoney(20.0), Payments: []int{1}, Status: common.Pending}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return([]*models.Payment{{Id: 1, Amount: common.NewMoney(15.0)}}, nil)
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrOrderNotPaid, "expected the order not to be paid")
+				assert.EqualError(t, err, "order is not paid in full: 5.00 outstanding", "unexpected error message")
+				assert.Nil(t, actualOrder, "expected no order")
+			},
+		},
+		{
+			name:    "order without payments cannot be paid",
+			userId:  1,
+			orderId: 123,
+			status:  common.Paid,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Price: common.NewMoney(20.0), Status: common.Pending}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return([]*models.Payment{}, nil)
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrOrderNotPaid, "expected the order not to be paid")
+				assert.Nil(t, actualOrder, "expected no order")
+			},
+		},
+		{
+			name:    "illegal transition from pending to delivered",
+			userId:  1,
+			orderId: 123,
+			status:  common.Delivered,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Status: common.Pending}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}, Status: common.Pending}).Return(true, nil)
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrIllegalTransition, "expected an illegal transition error")
+				assert.EqualError(t, err, "illegal order status transition: Pending -> Delivered", "unexpected error message")
+				assert.Nil(t, actualOrder, "expected no order on illegal transition")
+			},
+		},
+		{
+			name:    "cancelled order is final",
+			userId:  1,
+			orderId: 123,
+			status:  common.Paid,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Status: common.Cancelled}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}, Status: common.Cancelled}).Return(true, nil)
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrIllegalTransition, "expected an illegal transition error")
+				assert.Nil(t, actualOrder, "expected no order on illegal transition")
+			},
+		},
+		{
+			name:    "missing user id",
+			userId:  0,
+			orderId: 123,
+			status:  common.Paid,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				// No mocks needed since the function returns at the beginning
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assertError(t, err, errors.New("user id is required"))
+			},
+		},
+		{
+			name:    "order not found in storage",
+			userId:  1,
+			orderId: 123,
+			status:  common.Paid,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(nil, errors.New("order not found"))
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assertError(t, err, errors.New("order not found"))
+			},
+		},
+		{
+			name:    "user not authorized",
+			userId:  2,
+			orderId: 123,
+			status:  common.Paid,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Status: common.Pending}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 2, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}, Status: common.Pending}).Return(false, nil)
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assertError(t, err, errors.New("user is not authorized to access this order"))
+			},
+		},
+		{
+			name:    "cancelled order refunds its payments",
+			userId:  1,
+			ctxUser: &models.User{ID: 1},
+			orderId: 123,
+			status:  common.Cancelled,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Payments: []int{1, 2}, Status: common.Pending}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil)
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return([]*models.Payment{
+					{Id: 1, Amount: common.NewMoney(10.0), Refunds: []*models.Refund{{PaymentId: 1, Amount: common.NewMoney(4.0)}}},
+					{Id: 2, Amount: common.NewMoney(5.0), Refunds: []*models.Refund{{PaymentId: 2, Amount: common.NewMoney(5.0)}}},
+				}, nil)
+				// the payment refunded in full already is skipped
+				paymentService.On("RefundPayment", mock.Anything, models.Refund{PaymentId: 1, Reason: common.OrderCancelled}).
+					Return(&models.Refund{Id: 7, PaymentId: 1, Amount: common.NewMoney(6.0), Reason: common.OrderCancelled}, nil).Once()
+				storage.On("UpdateOrder", mock.Anything, dsmodels.Order{ID: 123, UserId: 1, Payments: []int{1, 2}, Status: common.Cancelled}, mock.Anything).
+					Return(&dsmodels.Order{ID: 123, UserId: 1, Payments: []int{1, 2}, Status: common.Cancelled}, nil)
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assert.NoError(t, err, "expected no error cancelling the order")
+				assert.Equal(t, common.Cancelled, actualOrder.Status, "expected the order to be cancelled")
+			},
+		},
+		{
+			name:    "failed refund restores the status",
+			userId:  1,
+			orderId: 123,
+			status:  common.Refunded,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				paid := dsmodels.Order{ID: 123, UserId: 1, Payments: []int{1}, Status: common.Paid}
+				refunded := paid
+				refunded.Status = common.Refunded
+				storage.On("GetOrder", mock.Anything, 123).Return(&paid, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil)
+				storage.On("UpdateOrder", mock.Anything, refunded, 1).Return(&refunded, nil).Once()
+				storage.On("UpdateOrder", mock.Anything, paid, 1).Return(&paid, nil).Once()
+				paymentService.On("GetPaymentsByOrder", mock.Anything, 123).Return([]*models.Payment{{Id: 1, Amount: common.NewMoney(10.0)}}, nil)
+				paymentService.On("RefundPayment", mock.Anything, models.Refund{PaymentId: 1, Reason: common.OrderRefunded}).Return(nil, errors.New("gateway unavailable"))
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assertError(t, err, errors.New("gateway unavailable"))
+			},
+		},
+		{
+			name:    "storage update error",
+			userId:  1,
+			orderId: 123,
+			status:  common.Cancelled,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 1, Status: common.Pending}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, &models.Order{ID: 123, User: &models.User{ID: 1}, Payments: []*models.Payment{}, Status: common.Pending}).Return(true, nil)
+				storage.On("UpdateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("update failed"))
+			},
+			assertFunc: func(t *testing.T, err error, actualOrder *models.Order) {
+				assertError(t, err, errors.New("update failed"))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := mocks.NewOrdersDatasource(t)
+			paymentService := mocks.NewPaymentsService(t)
+			authorizationService := mocks.NewAuthorizationService(t)
+			test.mockSetup(storage, paymentService, authorizationService)
+
+			service := NewOrdersService(storage, paymentService, authorizationService, mocks.NewProductsService(t), mocks.NewInventoryService(t), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+			testCtx := context.WithValue(ctx, constants.AuthenticatedUserIdKey, test.userId)
+			testCtx = context.WithValue(testCtx, constants.AuthenticatedUserKey, test.ctxUser)
+
+			actualOrder, err := service.TransitionOrder(testCtx, test.userId, test.orderId, test.status)
+			test.assertFunc(t, err, actualOrder)
+
+			storage.AssertExpectations(t)
+			paymentService.AssertExpectations(t)
+			authorizationService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrderService_StockSettlement(t *testing.T) {
+	log.InitLogger()
+	ctx := context.WithValue(log.NewBackgroundContext(&zlog.Logger), constants.AuthenticatedUserKey, &models.User{ID: 1})
+
+	lines := []dsmodels.OrderLine{{ProductID: 101, Quantity: 2, UnitPrice: common.NewMoney(5), LineTotal: common.NewMoney(10)}}
+	storedOrder := func(status common.OrderStatus) *dsmodels.Order {
+		return &dsmodels.Order{ID: 123, UserId: 1, Price: common.NewMoney(10), Status: status, Lines: lines}
+	}
+
+	tests := []struct {
+		name       string
+		act        func(service OrdersService) error
+		mockSetup  func(storage *mocks.OrdersDatasource, inventoryService *mocks.InventoryService)
+		assertFunc func(t *testing.T, err error)
+	}{
+		{
+			name: "fulfilled order takes its stock off hand",
+			act: func(service OrdersService) error {
+				_, err := service.TransitionOrder(ctx, 1, 123, common.Fulfilled)
+				return err
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, inventoryService *mocks.InventoryService) {
+				storage.On("GetOrder", ctx, 123).Return(storedOrder(common.Paid), nil)
+				storage.On("UpdateOrder", ctx, *storedOrder(common.Fulfilled), mock.Anything).Return(storedOrder(common.Fulfilled), nil)
+				inventoryService.On("CommitStock", ctx, 123).Return(nil)
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.NoError(t, err, "expected no error fulfilling the order")
+			},
+		},
+		{
+			name: "cancelled order releases its stock",
+			act: func(service OrdersService) error {
+				_, err := service.TransitionOrder(ctx, 1, 123, common.Cancelled)
+				return err
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, inventoryService *mocks.InventoryService) {
+				storage.On("GetOrder", ctx, 123).Return(storedOrder(common.Pending), nil)
+				storage.On("UpdateOrder", ctx, *storedOrder(common.Cancelled), mock.Anything).Return(storedOrder(common.Cancelled), nil)
+				inventoryService.On("ReleaseStock", ctx, 123).Return(nil)
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.NoError(t, err, "expected no error cancelling the order")
+			},
+		},
+		{
+			name: "paid order keeps its reservation",
+			act: func(service OrdersService) error {
+				_, err := service.TransitionOrder(ctx, 1, 123, common.Paid)
+				return err
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, inventoryService *mocks.InventoryService) {
+				storage.On("GetOrder", ctx, 123).Return(storedOrder(common.Pending), nil)
+				storage.On("UpdateOrder", ctx, *storedOrder(common.Paid), mock.Anything).Return(storedOrder(common.Paid), nil)
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.NoError(t, err, "expected no error paying the order")
+			},
+		},
+		{
+			name: "release failure keeps the status",
+			act: func(service OrdersService) error {
+				_, err := service.TransitionOrder(ctx, 1, 123, common.Refunded)
+				return err
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, inventoryService *mocks.InventoryService) {
+				storage.On("GetOrder", ctx, 123).Return(storedOrder(common.Paid), nil)
+				inventoryService.On("ReleaseStock", ctx, 123).Return(errors.New("release failed"))
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "release failed", "expected the release error")
+			},
+		},
+		{
+			name: "failed update puts the committed stock back",
+			act: func(service OrdersService) error {
+				_, err := service.TransitionOrder(ctx, 1, 123, common.Fulfilled)
+				return err
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, inventoryService *mocks.InventoryService) {
+				items := []models.StockItem{{ProductID: 101, Quantity: 2}}
+				storage.On("GetOrder", ctx, 123).Return(storedOrder(common.Paid), nil)
+				inventoryService.On("CommitStock", ctx, 123).Return(nil)
+				storage.On("UpdateOrder", ctx, *storedOrder(common.Fulfilled), mock.Anything).Return(nil, errors.New("update failed"))
+				inventoryService.On("AdjustStock", ctx, 101, 2).Return(&models.Stock{ProductID: 101, OnHand: 2}, nil)
+				inventoryService.On("ReserveStock", ctx, 123, items).Return(items, nil)
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "update failed", "expected the update error")
+			},
+		},
+		{
+			name: "failed update reserves the released stock again",
+			act: func(service OrdersService) error {
+				_, err := service.TransitionOrder(ctx, 1, 123, common.Cancelled)
+				return err
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, inventoryService *mocks.InventoryService) {
+				items := []models.StockItem{{ProductID: 101, Quantity: 2}}
+				storage.On("GetOrder", ctx, 123).Return(storedOrder(common.Pending), nil)
+				inventoryService.On("ReleaseStock", ctx, 123).Return(nil)
+				storage.On("UpdateOrder", ctx, *storedOrder(common.Cancelled), mock.Anything).Return(nil, errors.New("update failed"))
+				inventoryService.On("ReserveStock", ctx, 123, items).Return(items, nil)
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "update failed", "expected the update error")
+			},
+		},
+		{
+			name: "deleted order releases its stock",
+			act: func(service OrdersService) error {
+				return service.CancelOrder(ctx, 1, 123)
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, inventoryService *mocks.InventoryService) {
+				storage.On("GetOrder", ctx, 123).Return(storedOrder(common.Pending), nil)
+				storage.On("DeleteOrder", ctx, 123).Return(nil)
+				inventoryService.On("ReleaseStock", ctx, 123).Return(nil)
+			},
+			assertFunc: func(t *testing.T, err error) {
+				assert.NoError(t, err, "expected no error deleting the order")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := mocks.NewOrdersDatasource(t)
+			paymentService := mocks.NewPaymentsService(t)
+			authorizationService := mocks.NewAuthorizationService(t)
+			inventoryService := mocks.NewInventoryService(t)
+			authorizationService.On("IsAuthorized", ctx, 1, mock.Anything).Return(true, nil)
+			// the order of 10 is paid in full
+			paymentService.On("GetPaymentsByOrder", ctx, 123).Return([]*models.Payment{{Id: 1, Amount: common.NewMoney(10)}}, nil).Maybe()
+			test.mockSetup(storage, inventoryService)
+
+			service := NewOrdersService(storage, paymentService, authorizationService, mocks.NewProductsService(t), inventoryService, utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+			test.assertFunc(t, test.act(service))
+		})
+	}
+}
+
+func TestOrderService_StoreOrder_Identifiers(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	expectedId := utils.NewSeededIDGenerator(3).NewID()
+
+	storage := mocks.NewOrdersDatasource(t)
+	storage.On("InsertOrder", ctx, mock.MatchedBy(func(order dsmodels.Order) bool {
+		return order.ID == expectedId && strings.HasPrefix(order.Number, "ORD-") && strings.HasSuffix(order.Number, "-000001")
+	}), mock.Anything).Return(func(ctx context.Context, order dsmodels.Order, changedBy int) (*dsmodels.Order, error) {
+		return &order, nil
+	})
+
+	service := NewOrdersService(storage, mocks.NewPaymentsService(t), mocks.NewAuthorizationService(t), mocks.NewProductsService(t),
+		mocks.NewInventoryService(t), utils.NewSeededIDGenerator(3), NewOrderNumberGenerator(OrderNumberConfig{Prefix: "ORD"}), noExchangeRates())
+
+	order, err := service.StoreOrder(ctx, 1, models.Order{User: &models.User{ID: 1}})
+	assert.NoError(t, err, "expected no error storing the order")
+	assert.Equal(t, expectedId, order.ID, "expected the id of the generator")
+	assert.Regexp(t, `^ORD-\d{4}-000001$`, order.Number, "expected the order to be numbered")
+}
+
+func TestOrderService_StoreOrder_Currencies(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	orderDate := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	rates := `[
+		{"From": "EUR", "To": "USD", "Rate": 1.1, "EffectiveFrom": "2025-01-01T00:00:00Z"},
+		{"From": "GBP", "To": "USD", "Rate": 1.25, "EffectiveFrom": "2025-01-01T00:00:00Z"}
+	]`
+	newOrder := func(payments ...*models.Payment) models.Order {
+		return models.Order{
+			Currency:  common.USD,
+			OrderDate: orderDate,
+			User:      &models.User{ID: 1},
+			Lines:     []*models.OrderLine{{ProductID: 101, Quantity: 2}},
+			Payments:  payments,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		order      models.Order
+		mockSetup  func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, inventoryService *mocks.InventoryService)
+		assertFunc func(t *testing.T, err error, order *models.Order)
+	}{
+		{
+			name: "order priced in another currency than the catalog and paid in a third one",
+			order: newOrder(
+				&models.Payment{Amount: common.NewMoney(9.5), Method: common.PayPal},
+				&models.Payment{Amount: common.NewMoney(10), Currency: common.GBP, Method: common.PayPal, PaidAt: orderDate},
+			),
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, inventoryService *mocks.InventoryService) {
+				inventoryService.On("ReserveStock", ctx, mock.Anything, mock.Anything).Return(nil, nil)
+				paymentService.On("StorePayment", ctx, mock.Anything).Return(func(ctx context.Context, payment models.Payment) (*models.Payment, error) {
+					return &payment, nil
+				})
+				storage.On("InsertOrder", ctx, mock.MatchedBy(func(order dsmodels.Order) bool {
+					return order.Currency == common.USD && order.Price == common.NewMoney(22)
+				}), mock.Anything).Return(func(ctx context.Context, order dsmodels.Order, changedBy int) (*dsmodels.Order, error) {
+					return &order, nil
+				})
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.NoError(t, err, "expected no error storing the order")
+				assert.Equal(t, common.USD, order.Currency, "expected the currency of the order")
+				assert.Equal(t, common.NewMoney(22.0), order.AmountDue(), "expected the catalog price converted to the currency of the order")
+				assert.Equal(t, common.USD, order.Payments[0].Currency, "expected the payment in the currency of the order")
+				assert.Nil(t, order.Payments[0].Conversion, "expected no conversion of a payment in the currency of the order")
+				assert.Equal(t, &models.ExchangeRate{From: common.GBP, To: common.USD, Rate: 1.25, EffectiveFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+					order.Payments[1].Conversion, "expected the conversion to be recorded on the payment")
+				assert.Equal(t, common.NewMoney(22.0), order.AmountPaid(), "expected the converted amounts to settle the order")
+				assert.Equal(t, common.EUR, order.Conversion.To, "expected the conversion of the order to the base currency")
+				assert.Equal(t, common.NewMoney(20.0), order.BasePrice(), "expected the price in the base currency at the rate of the order date")
+			},
+		},
+		{
+			name:  "payment without an exchange rate is rejected",
+			order: newOrder(&models.Payment{Amount: common.NewMoney(10), Currency: common.CHF, Method: common.PayPal, PaidAt: orderDate}),
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, inventoryService *mocks.InventoryService) {
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.ErrorIs(t, err, ErrNoExchangeRate, "expected a missing exchange rate")
+				assert.Nil(t, order, "expected no order")
+			},
+		},
+		{
+			name:  "converted overpayment is rejected",
+			order: newOrder(&models.Payment{Amount: common.NewMoney(18), Currency: common.GBP, Method: common.PayPal, PaidAt: orderDate}),
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, inventoryService *mocks.InventoryService) {
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.ErrorIs(t, err, ErrOverpayment, "expected an overpayment in the currency of the order")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := mocks.NewOrdersDatasource(t)
+			paymentService := mocks.NewPaymentsService(t)
+			productsService := mocks.NewProductsService(t)
+			inventoryService := mocks.NewInventoryService(t)
+			productsService.On("GetProduct", ctx, 101).Return(&models.Product{ID: 101, Price: common.NewMoney(10)}, nil)
+			test.mockSetup(storage, paymentService, inventoryService)
+
+			service := NewOrdersService(storage, paymentService, mocks.NewAuthorizationService(t), productsService, inventoryService,
+				utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), exchangeRatesFrom(t, rates))
+
+			order, err := service.StoreOrder(ctx, 1, test.order)
+			test.assertFunc(t, err, order)
+		})
+	}
+}
+
+func TestOrderService_AddPayment(t *testing.T) {
+	log.InitLogger()
+	ctx := context.WithValue(log.NewBackgroundContext(&zlog.Logger), constants.AuthenticatedUserKey, &models.User{ID: 1})
+
+	storedOrder := func(status common.OrderStatus) *dsmodels.Order {
+		return &dsmodels.Order{ID: 123, UserId: 1, Price: common.NewMoney(30.0), Payments: []int{1}, Status: status}
+	}
+
+	tests := []struct {
+		name       string
+		payment    models.Payment
+		status     common.OrderStatus
+		mockSetup  func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService)
+		assertFunc func(t *testing.T, err error, order *models.Order)
+	}{
+		{
+			name:    "payment settles the order",
+			payment: models.Payment{Id: 99, Amount: common.NewMoney(20.0), Method: common.PayPal},
+			status:  common.Pending,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService) {
+				paymentService.On("GetPaymentByID", ctx, 1).Return(&models.Payment{Id: 1, Amount: common.NewMoney(10.0)}, nil)
+				paymentService.On("StorePayment", ctx, mock.MatchedBy(func(payment models.Payment) bool {
+					return payment.Id == 1
+				})).Return(&models.Payment{Id: 1, Amount: common.NewMoney(10.0)}, nil)
+				paymentService.On("StorePayment", ctx, mock.MatchedBy(func(payment models.Payment) bool {
+					return payment.Id == 0 && payment.Amount == common.NewMoney(20.0) && payment.User.ID == 1
+				})).Return(&models.Payment{Id: 2, Amount: common.NewMoney(20.0), Method: common.PayPal}, nil)
+				storage.On("UpdateOrder", ctx, mock.MatchedBy(func(order dsmodels.Order) bool {
+					return assert.ObjectsAreEqual([]int{1, 2}, order.Payments)
+				}), mock.Anything).Return(&dsmodels.Order{ID: 123, UserId: 1, Price: common.NewMoney(30.0), Payments: []int{1, 2}, Status: common.Pending}, nil)
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.NoError(t, err, "expected no error adding a payment")
+				assert.Equal(t, common.NewMoney(30.0), order.AmountPaid(), "expected the payments to add up")
+				assert.Equal(t, common.Money{}, order.Balance(), "expected the order to be settled")
+			},
+		},
+		{
+			name:    "overpayment is rejected",
+			payment: models.Payment{Amount: common.NewMoney(20.01), Method: common.PayPal},
+			status:  common.Paid,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService) {
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.ErrorIs(t, err, ErrOverpayment, "expected an overpayment error")
+				assert.Nil(t, order, "expected no order")
+			},
+		},
+		{
+			name:    "cancelled order accepts no payments",
+			payment: models.Payment{Amount: common.NewMoney(5.0), Method: common.PayPal},
+			status:  common.Cancelled,
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService) {
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.ErrorIs(t, err, ErrOrderClosed, "expected an order closed error")
+				assert.EqualError(t, err, "order does not accept payments: Cancelled", "unexpected error message")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := mocks.NewOrdersDatasource(t)
+			paymentService := mocks.NewPaymentsService(t)
+			authorizationService := mocks.NewAuthorizationService(t)
+			storage.On("GetOrder", ctx, 123).Return(storedOrder(test.status), nil)
+			authorizationService.On("IsAuthorized", ctx, 1, mock.Anything).Return(true, nil)
+			paymentService.On("GetPaymentsByOrder", ctx, 123).Return([]*models.Payment{{Id: 1, Amount: common.NewMoney(10.0), User: &models.User{ID: 1}}}, nil)
+			test.mockSetup(storage, paymentService)
+
+			service := NewOrdersService(storage, paymentService, authorizationService, mocks.NewProductsService(t), mocks.NewInventoryService(t), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+			order, err := service.AddPayment(ctx, 1, 123, test.payment)
+			test.assertFunc(t, err, order)
+		})
+	}
+}
+
+func TestOrderService_UpdateOrder(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	storedOrder := func(status common.OrderStatus) *dsmodels.Order {
+		return &dsmodels.Order{ID: 123, UserId: 1, Price: common.NewMoney(30.0), Payments: []int{1, 2}, Status: status}
+	}
+	authorizedOrder := func(status common.OrderStatus) *models.Order {
+		return &models.Order{ID: 123, User: &models.User{ID: 1}, Price: common.NewMoney(30.0), Payments: []*models.Payment{}, Status: status}
+	}
+
+	tests := []struct {
+		name       string
+		userId     int
+		order      models.Order
+		mockSetup  func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService, productsService *mocks.ProductsService)
+		assertFunc func(t *testing.T, err error, updatedOrder *models.Order)
+	}{
+		{
+			name:   "payments are added, updated and removed",
+			userId: 1,
+			order: models.Order{
+				ID:    123,
+				Price: common.NewMoney(30.0),
+				User:  &models.User{ID: 1},
+				Payments: []*models.Payment{
+					{Id: 1, Amount: common.NewMoney(10.0)},
+					{Amount: common.NewMoney(20.0)},
+				},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService, productsService *mocks.ProductsService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(storedOrder(common.Pending), nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, authorizedOrder(common.Pending)).Return(true, nil)
+				paymentService.On("GetPaymentByID", mock.Anything, 1).Return(&models.Payment{Id: 1, Amount: common.NewMoney(10.0)}, nil)
+				paymentService.On("StorePayment", mock.Anything, mock.MatchedBy(func(payment models.Payment) bool {
+					return payment.Id == 1 && payment.Amount == common.NewMoney(10.0)
+				})).Return(&models.Payment{Id: 1, Amount: common.NewMoney(10.0)}, nil)
+				paymentService.On("StorePayment", mock.Anything, mock.MatchedBy(func(payment models.Payment) bool {
+					return payment.Id == 0 && payment.Amount == common.NewMoney(20.0)
+				})).Return(&models.Payment{Id: 3, Amount: common.NewMoney(20.0)}, nil)
+				storage.On("UpdateOrder", mock.Anything, mock.MatchedBy(func(order dsmodels.Order) bool {
+					return order.ID == 123 && order.Status == common.Pending && assert.ObjectsAreEqual([]int{1, 3}, order.Payments)
+				}), mock.Anything).Return(&dsmodels.Order{ID: 123, UserId: 1, Price: common.NewMoney(30.0), Payments: []int{1, 3}, Status: common.Pending}, nil)
+				paymentService.On("GetPaymentByID", mock.Anything, 2).Return(&models.Payment{Id: 2, Amount: common.NewMoney(20.0)}, nil)
+				paymentService.On("DeletePayment", mock.Anything, 2).Return(nil)
+			},
+			assertFunc: func(t *testing.T, err error, updatedOrder *models.Order) {
+				assert.NoError(t, err, "expected no error on updating order")
+				assert.Equal(t, 123, updatedOrder.ID, "expected updated order ID to match")
+				assert.Equal(t, common.Pending, updatedOrder.Status, "expected status to be kept")
+				assert.Len(t, updatedOrder.Payments, 2, "expected two payments on the updated order")
+			},
+		},
+		{
+			name:   "price of a paid order cannot change",
+			userId: 1,
+			order: models.Order{
+				ID:    123,
+				Price: common.NewMoney(35.0),
+				User:  &models.User{ID: 1},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService, productsService *mocks.ProductsService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(storedOrder(common.Paid), nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, authorizedOrder(common.Paid)).Return(true, nil)
+			},
+			assertFunc: func(t *testing.T, err error, updatedOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrImmutableField, "expected an immutable field error")
+				assert.EqualError(t, err, "field cannot be changed: price of a Paid order", "unexpected error message")
+				assert.Nil(t, updatedOrder, "expected no updated order")
+			},
+		},
+		{
+			name:   "lines of a paid order are priced from the catalog",
+			userId: 1,
+			order: models.Order{
+				ID:    123,
+				Price: common.NewMoney(30.0),
+				User:  &models.User{ID: 1},
+				Lines: []*models.OrderLine{models.NewOrderLine(101, 3, common.NewMoney(10))},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService, productsService *mocks.ProductsService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(storedOrder(common.Paid), nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, authorizedOrder(common.Paid)).Return(true, nil)
+				productsService.On("GetProduct", mock.Anything, 101).Return(&models.Product{ID: 101, Price: common.NewMoney(12)}, nil)
+			},
+			assertFunc: func(t *testing.T, err error, updatedOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrImmutableField, "expected the catalog price to change the order price")
+				assert.Nil(t, updatedOrder, "expected no updated order")
+			},
+		},
+		{
+			name:   "closed order cannot change",
+			userId: 1,
+			order: models.Order{
+				ID:       123,
+				Price:    common.NewMoney(30.0),
+				User:     &models.User{ID: 1},
+				Payments: []*models.Payment{{Amount: common.NewMoney(5.0)}},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService, productsService *mocks.ProductsService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(storedOrder(common.Cancelled), nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, authorizedOrder(common.Cancelled)).Return(true, nil)
+			},
+			assertFunc: func(t *testing.T, err error, updatedOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrOrderNotEditable, "expected the cancelled order to be kept")
+				assert.EqualError(t, err, "order can no longer be changed: Cancelled", "unexpected error message")
+				assert.Nil(t, updatedOrder, "expected no updated order")
+			},
+		},
+		{
+			name:   "owner cannot change",
+			userId: 1,
+			order: models.Order{
+				ID:    123,
+				Price: common.NewMoney(30.0),
+				User:  &models.User{ID: 1},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService, productsService *mocks.ProductsService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(&dsmodels.Order{ID: 123, UserId: 2, Price: common.NewMoney(30.0), Status: common.Pending}, nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, mock.Anything).Return(true, nil)
+			},
+			assertFunc: func(t *testing.T, err error, updatedOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrImmutableField, "expected an immutable field error")
+				assert.EqualError(t, err, "field cannot be changed: owner", "unexpected error message")
+			},
+		},
+		{
+			name:   "payment of another order is rejected",
+			userId: 1,
+			order: models.Order{
+				ID:    123,
+				Price: common.NewMoney(30.0),
+				User:  &models.User{ID: 1},
+				Payments: []*models.Payment{
+					{Id: 99, Amount: common.NewMoney(30.0)},
+				},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService, productsService *mocks.ProductsService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(storedOrder(common.Pending), nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, authorizedOrder(common.Pending)).Return(true, nil)
+			},
+			assertFunc: func(t *testing.T, err error, updatedOrder *models.Order) {
+				assert.ErrorIs(t, err, ErrUnknownPayment, "expected an unknown payment error")
+				assert.EqualError(t, err, "payment does not belong to the order: 99", "unexpected error message")
+			},
+		},
+		{
+			name:   "user not authorized",
+			userId: 2,
+			order: models.Order{
+				ID:   123,
+				User: &models.User{ID: 2},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService, productsService *mocks.ProductsService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(storedOrder(common.Pending), nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 2, authorizedOrder(common.Pending)).Return(false, nil)
+			},
+			assertFunc: func(t *testing.T, err error, updatedOrder *models.Order) {
+				assertError(t, err, errors.New("user is not authorized to access this order"))
+			},
+		},
+		{
+			name:   "user ID mismatch",
+			userId: 2,
+			order: models.Order{
+				ID:   123,
+				User: &models.User{ID: 1},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService, productsService *mocks.ProductsService) {
+				// No mocks needed
+			},
+			assertFunc: func(t *testing.T, err error, updatedOrder *models.Order) {
+				assertError(t, err, errors.New("user id is required"))
+			},
+		},
+		{
+			name:   "missing order ID",
+			userId: 1,
+			order: models.Order{
+				User: &models.User{ID: 1},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService, productsService *mocks.ProductsService) {
+				// No mocks needed
+			},
+			assertFunc: func(t *testing.T, err error, updatedOrder *models.Order) {
+				assertError(t, err, errors.New("order id is required"))
+			},
+		},
+		{
+			name:   "order not found in storage",
+			userId: 1,
+			order: models.Order{
+				ID:   123,
+				User: &models.User{ID: 1},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService, productsService *mocks.ProductsService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(nil, errors.New("order not found"))
+			},
+			assertFunc: func(t *testing.T, err error, updatedOrder *models.Order) {
+				assertError(t, err, errors.New("order not found"))
+			},
+		},
+		{
+			name:   "storage update error",
+			userId: 1,
+			order: models.Order{
+				ID:    123,
+				Price: common.NewMoney(30.0),
+				User:  &models.User{ID: 1},
+				Payments: []*models.Payment{
+					{Id: 1, Amount: common.NewMoney(10.0)},
+					{Id: 2, Amount: common.NewMoney(20.0)},
+				},
+			},
+			mockSetup: func(storage *mocks.OrdersDatasource, paymentService *mocks.PaymentsService, authorizationService *mocks.AuthorizationService, productsService *mocks.ProductsService) {
+				storage.On("GetOrder", mock.Anything, 123).Return(storedOrder(common.Paid), nil)
+				authorizationService.On("IsAuthorized", mock.Anything, 1, authorizedOrder(common.Paid)).Return(true, nil)
+				paymentService.On("GetPaymentByID", mock.Anything, 1).Return(&models.Payment{Id: 1, Amount: common.NewMoney(10.0)}, nil)
+				paymentService.On("GetPaymentByID", mock.Anything, 2).Return(&models.Payment{Id: 2, Amount: common.NewMoney(20.0)}, nil)
+				// both payments are stored, and restored again once the update failed
+				paymentService.On("StorePayment", mock.Anything, mock.Anything).Return(&models.Payment{Id: 1, Amount: common.NewMoney(10.0)}, nil).Times(4)
+				storage.On("UpdateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("update failed"))
+			},
+			assertFunc: func(t *testing.T, err error, updatedOrder *models.Order) {
+				assertError(t, err, errors.New("update failed"))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := mocks.NewOrdersDatasource(t)
+			paymentService := mocks.NewPaymentsService(t)
+			authorizationService := mocks.NewAuthorizationService(t)
+			productsService := mocks.NewProductsService(t)
+			test.mockSetup(storage, paymentService, authorizationService, productsService)
+
+			service := NewOrdersService(storage, paymentService, authorizationService, productsService, mocks.NewInventoryService(t), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+			updatedOrder, err := service.UpdateOrder(ctx, test.userId, test.order)
+			test.assertFunc(t, err, updatedOrder)
+
+			storage.AssertExpectations(t)
+			paymentService.AssertExpectations(t)
+			authorizationService.AssertExpectations(t)
+			productsService.AssertExpectations(t)
+		})
+	}
+}
+
+// failingPaymentsStorage fails the creation of the n-th payment and the n-th delete of a payment.
+type failingPaymentsStorage struct {
+	datasources.PaymentsDatasource
+	failOnCreate int
+	creates      int
+	failOnDelete int
+	deletes      int
+}
+
+func (s *failingPaymentsStorage) Delete(ctx context.Context, id int) error {
+	s.deletes++
+	if s.deletes == s.failOnDelete {
+		return errors.New("delete failed")
+	}
+	return s.PaymentsDatasource.Delete(ctx, id)
+}
+
+func (s *failingPaymentsStorage) Create(ctx context.Context, payment dsmodels.Payment) (dsmodels.Payment, error) {
+	s.creates++
+	if s.creates == s.failOnCreate {
+		return dsmodels.Payment{}, errors.New("payment declined")
+	}
+	return s.PaymentsDatasource.Create(ctx, payment)
+}
+
+// failingOrdersStorage fails every insert and update of an order with writeErr and every delete with deleteErr, once they are set.
+// The first insertsBeforeFailure inserts succeed nonetheless.
+type failingOrdersStorage struct {
+	datasources.OrdersDatasource
+	writeErr             error
+	deleteErr            error
+	insertsBeforeFailure int
+}
+
+func (s *failingOrdersStorage) DeleteOrder(ctx context.Context, orderID int) error {
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	return s.OrdersDatasource.DeleteOrder(ctx, orderID)
+}
+
+func (s *failingOrdersStorage) InsertOrder(ctx context.Context, order dsmodels.Order, changedBy int) (*dsmodels.Order, error) {
+	if s.writeErr != nil && s.insertsBeforeFailure == 0 {
+		return nil, s.writeErr
+	}
+	s.insertsBeforeFailure--
+	return s.OrdersDatasource.InsertOrder(ctx, order, changedBy)
+}
+
+func (s *failingOrdersStorage) UpdateOrder(ctx context.Context, order dsmodels.Order, changedBy int) (*dsmodels.Order, error) {
+	if s.writeErr != nil {
+		return nil, s.writeErr
+	}
+	return s.OrdersDatasource.UpdateOrder(ctx, order, changedBy)
+}
+
+func TestOrderService_StoreOrder_Compensation(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	user := &models.User{ID: 1}
+
+	newOrder := func() models.Order {
+		return models.Order{
+			User: user,
+			Lines: []*models.OrderLine{
+				{ProductID: 1, Quantity: 2},
+				{ProductID: 2, Quantity: 1},
+			},
+			Payments: []*models.Payment{
+				{Amount: common.NewMoney(10.0), Method: common.CreditCard, User: user},
+				{Amount: common.NewMoney(10.0), Method: common.PayPal, User: user},
+				{Amount: common.NewMoney(5.0), Method: common.PayPal, User: user},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		onHand        int
+		failOnPayment int
+		orderErr      error
+		expectedErr   string
+	}{
+		{
+			name:        "stock reservation fails",
+			onHand:      1,
+			expectedErr: "insufficient stock: product 1 has 1 available, 2 requested",
+		},
+		{
+			name:          "first payment fails",
+			onHand:        5,
+			failOnPayment: 1,
+			expectedErr:   "payment declined",
+		},
+		{
+			name:          "third payment fails",
+			onHand:        5,
+			failOnPayment: 3,
+			expectedErr:   "payment declined",
+		},
+		{
+			name:        "order insert fails",
+			onHand:      5,
+			orderErr:    errors.New("order already exists"),
+			expectedErr: "order already exists",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ordersStorage := &failingOrdersStorage{OrdersDatasource: file.NewOrdersStorage(), writeErr: test.orderErr}
+			paymentsStorage := &failingPaymentsStorage{PaymentsDatasource: yugabyte.NewPaymentsStorage(utils.NewSequenceIDGenerator()), failOnCreate: test.failOnPayment}
+			inventoryStorage := file.NewInventoryStorage()
+			for _, productId := range []int{1, 2} {
+				_, err := inventoryStorage.Save(ctx, dsmodels.Stock{ProductID: productId, OnHand: test.onHand})
+				assert.NoError(t, err, "expected the stock to be set")
+			}
+
+			productsService := mocks.NewProductsService(t)
+			productsService.On("GetProduct", ctx, 1).Return(&models.Product{ID: 1, Price: common.NewMoney(10.0)}, nil)
+			productsService.On("GetProduct", ctx, 2).Return(&models.Product{ID: 2, Price: common.NewMoney(5.0)}, nil)
+
+			service := NewOrdersService(ordersStorage, NewPaymentsService(paymentsStorage, PaymentMethodsConfig{}, fake.NewPaymentGateway()), mocks.NewAuthorizationService(t),
+				productsService, NewInventoryService(inventoryStorage, productsService), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+			order, err := service.StoreOrder(ctx, user.ID, newOrder())
+			assert.EqualError(t, err, test.expectedErr, "unexpected error")
+			assert.Nil(t, order, "expected no order")
+
+			orders, err := ordersStorage.GetAllOrdersForUser(ctx, user.ID)
+			assert.NoError(t, err, "expected the orders to be listed")
+			assert.Empty(t, orders, "expected no stored order")
+			for paymentId := 1; paymentId <= 3; paymentId++ {
+				_, err := paymentsStorage.Read(ctx, paymentId)
+				assert.Error(t, err, "expected payment %d to be removed", paymentId)
+			}
+			for _, productId := range []int{1, 2} {
+				stock, err := inventoryStorage.Read(ctx, productId)
+				assert.NoError(t, err, "expected the stock to be read")
+				assert.Equal(t, 0, stock.Reserved, "expected no stock reserved for product %d", productId)
+			}
+		})
+	}
+
+	t.Run("order update fails", func(t *testing.T) {
+		ordersStorage := &failingOrdersStorage{OrdersDatasource: file.NewOrdersStorage()}
+		paymentsStorage := yugabyte.NewPaymentsStorage(utils.NewSequenceIDGenerator())
+		inventoryStorage := file.NewInventoryStorage()
+		for _, productId := range []int{1, 2} {
+			_, err := inventoryStorage.Save(ctx, dsmodels.Stock{ProductID: productId, OnHand: 5})
+			assert.NoError(t, err, "expected the stock to be set")
+		}
+
+		productsService := mocks.NewProductsService(t)
+		productsService.On("GetProduct", ctx, 1).Return(&models.Product{ID: 1, Price: common.NewMoney(10.0)}, nil)
+		productsService.On("GetProduct", ctx, 2).Return(&models.Product{ID: 2, Price: common.NewMoney(5.0)}, nil)
+
+		service := NewOrdersService(ordersStorage, NewPaymentsService(paymentsStorage, PaymentMethodsConfig{}, fake.NewPaymentGateway()), mocks.NewAuthorizationService(t),
+			productsService, NewInventoryService(inventoryStorage, productsService), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+		placed, err := service.StoreOrder(ctx, user.ID, newOrder())
+		assert.NoError(t, err, "expected the order to be placed")
+
+		// the update reserves more stock, changes a payment and adds another one before it fails
+		update := *placed
+		update.User = user
+		update.Lines = []*models.OrderLine{
+			{ProductID: 1, Quantity: 3, UnitPrice: common.NewMoney(10.0), LineTotal: common.NewMoney(30.0)},
+			{ProductID: 2, Quantity: 1, UnitPrice: common.NewMoney(5.0), LineTotal: common.NewMoney(5.0)},
+		}
+		update.Payments = []*models.Payment{
+			{Id: placed.Payments[0].Id, Amount: common.NewMoney(20.0), Method: common.CreditCard, User: user},
+			{Id: placed.Payments[1].Id, Amount: common.NewMoney(10.0), Method: common.PayPal, User: user},
+			{Id: placed.Payments[2].Id, Amount: common.NewMoney(5.0), Method: common.PayPal, User: user},
+		}
+		ordersStorage.writeErr = errors.New("database unavailable")
+
+		order, err := service.StoreOrder(ctx, user.ID, update)
+		assert.EqualError(t, err, "database unavailable", "unexpected error")
+		assert.Nil(t, order, "expected no order")
+
+		storedOrder, err := ordersStorage.GetOrder(ctx, placed.ID)
+		assert.NoError(t, err, "expected the placed order to be kept")
+		assert.Equal(t, common.NewMoney(25.0), storedOrder.Price, "expected the placed order to be unchanged")
+		payment, err := paymentsStorage.Read(ctx, placed.Payments[0].Id)
+		assert.NoError(t, err, "expected the payment to be kept")
+		assert.Equal(t, common.NewMoney(10.0), payment.Amount, "expected the payment to be restored")
+		stock, err := inventoryStorage.Read(ctx, 1)
+		assert.NoError(t, err, "expected the stock to be read")
+		assert.Equal(t, 2, stock.Reserved, "expected the previous reservation to be restored")
+	})
+
+	t.Run("removing payments fails", func(t *testing.T) {
+		ordersStorage := file.NewOrdersStorage()
+		paymentsStorage := &failingPaymentsStorage{PaymentsDatasource: yugabyte.NewPaymentsStorage(utils.NewSequenceIDGenerator())}
+		inventoryStorage := file.NewInventoryStorage()
+		for _, productId := range []int{1, 2} {
+			_, err := inventoryStorage.Save(ctx, dsmodels.Stock{ProductID: productId, OnHand: 5})
+			assert.NoError(t, err, "expected the stock to be set")
+		}
+
+		productsService := mocks.NewProductsService(t)
+		productsService.On("GetProduct", ctx, 1).Return(&models.Product{ID: 1, Price: common.NewMoney(10.0)}, nil)
+		productsService.On("GetProduct", ctx, 2).Return(&models.Product{ID: 2, Price: common.NewMoney(5.0)}, nil)
+
+		service := NewOrdersService(ordersStorage, NewPaymentsService(paymentsStorage, PaymentMethodsConfig{}, fake.NewPaymentGateway()), NewAuthorizationService(),
+			productsService, NewInventoryService(inventoryStorage, productsService), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+		placed, err := service.StoreOrder(ctx, user.ID, newOrder())
+		assert.NoError(t, err, "expected the order to be placed")
+
+		// the update keeps the first payment only, the second of the two deletes fails
+		update := *placed
+		update.User = user
+		update.Payments = []*models.Payment{
+			{Id: placed.Payments[0].Id, Amount: common.NewMoney(10.0), Method: common.CreditCard, User: user},
+		}
+		paymentsStorage.failOnDelete = 2
+
+		order, err := service.UpdateOrder(ctx, user.ID, update)
+		assert.EqualError(t, err, "delete failed", "unexpected error")
+		assert.Nil(t, order, "expected no order")
+
+		storedOrder, err := ordersStorage.GetOrder(ctx, placed.ID)
+		assert.NoError(t, err, "expected the placed order to be kept")
+		assert.Len(t, storedOrder.Payments, 3, "expected the order to keep all of its payments")
+		assert.Equal(t, placed.Payments[0].Id, storedOrder.Payments[0], "expected the kept payment to stay")
+		assert.NotEqual(t, placed.Payments[1].Id, storedOrder.Payments[1], "expected the deleted payment to be restored")
+		assert.Equal(t, placed.Payments[2].Id, storedOrder.Payments[2], "expected the payment that failed to delete to stay")
+		paid := common.NewMoney(0)
+		for _, paymentId := range storedOrder.Payments {
+			payment, err := paymentsStorage.Read(ctx, paymentId)
+			assert.NoError(t, err, "expected payment %d of the order to be stored", paymentId)
+			paid = paid.Add(payment.Amount)
+		}
+		assert.Equal(t, common.NewMoney(25.0), paid, "expected the order to be paid in full again")
+	})
+
+	t.Run("payment declined by the gateway", func(t *testing.T) {
+		for _, scripted := range []struct {
+			operation fake.Operation
+			outcome   fake.Outcome
+			err       error
+		}{
+			{operation: fake.Authorize, outcome: fake.Decline, err: gateways.ErrPaymentDeclined},
+			{operation: fake.Capture, outcome: fake.Timeout, err: gateways.ErrGatewayTimeout},
+		} {
+			ordersStorage := file.NewOrdersStorage()
+			paymentsStorage := yugabyte.NewPaymentsStorage(utils.NewSequenceIDGenerator())
+			inventoryStorage := file.NewInventoryStorage()
+			for _, productId := range []int{1, 2} {
+				_, err := inventoryStorage.Save(ctx, dsmodels.Stock{ProductID: productId, OnHand: 5})
+				assert.NoError(t, err, "expected the stock to be set")
+			}
+
+			productsService := mocks.NewProductsService(t)
+			productsService.On("GetProduct", ctx, 1).Return(&models.Product{ID: 1, Price: common.NewMoney(10.0)}, nil)
+			productsService.On("GetProduct", ctx, 2).Return(&models.Product{ID: 2, Price: common.NewMoney(5.0)}, nil)
+
+			// the first two payments are charged, the third one fails
+			gateway := fake.NewPaymentGateway()
+			gateway.Script(scripted.operation, fake.Succeed, fake.Succeed, scripted.outcome)
+
+			service := NewOrdersService(ordersStorage, NewPaymentsService(paymentsStorage, PaymentMethodsConfig{}, gateway), mocks.NewAuthorizationService(t),
+				productsService, NewInventoryService(inventoryStorage, productsService), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+			order, err := service.StoreOrder(ctx, user.ID, newOrder())
+			assert.ErrorIs(t, err, scripted.err, "unexpected error")
+			assert.Nil(t, order, "expected no order")
+
+			for transaction := 1; transaction <= 2; transaction++ {
+				state, _ := gateway.Transaction(fmt.Sprintf("txn_%d", transaction))
+				assert.Equal(t, state.Captured, state.Refunded, "expected transaction %d to be refunded", transaction)
+			}
+			if scripted.operation == fake.Capture {
+				state, _ := gateway.Transaction("txn_3")
+				assert.True(t, state.Voided, "expected the authorization that was not captured to be voided")
+			}
+			for paymentId := 1; paymentId <= 3; paymentId++ {
+				_, err := paymentsStorage.Read(ctx, paymentId)
+				assert.Error(t, err, "expected payment %d to be removed", paymentId)
+			}
+		}
+	})
+}
+
+func TestOrderService_ImportOrders(t *testing.T) {
+	log.InitLogger()
+	user := &models.User{ID: 1}
+	ctx := context.WithValue(log.NewBackgroundContext(&zlog.Logger), constants.AuthenticatedUserKey, user)
+
+	// rows imports three orders of product 1, the second one needs more than its stock of 5, and an unreadable row.
+	// The orders were exported at a price of 2, the catalog sells product 1 for 1 today.
+	rows := func() []*models.OrderImportRow {
+		newOrder := func(quantity int) *models.Order {
+			return &models.Order{
+				User:     user,
+				Lines:    []*models.OrderLine{models.NewOrderLine(1, quantity, common.NewMoney(2))},
+				Payments: []*models.Payment{{Amount: common.NewMoney(1), Method: common.PayPal, User: user}},
+			}
+		}
+		return []*models.OrderImportRow{
+			{Row: 2, Order: newOrder(2)},
+			{Row: 3, Order: newOrder(10)},
+			{Row: 4, Err: errors.New(`invalid quantity "many"`)},
+			{Row: 5, Order: newOrder(1)},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		options         models.OrderImportOptions
+		deleteErr       error
+		expected        []*models.OrderImportResult
+		expectedErr     string
+		expectedOrders  int
+		expectedReserve int
+	}{
+		{
+			name: "row by row stores every valid row",
+			expected: []*models.OrderImportResult{
+				{Row: 2, Status: models.ImportCreated, OrderID: 1},
+				{Row: 3, Status: models.ImportFailed, Reason: "insufficient stock: product 1 has 3 available, 10 requested"},
+				{Row: 4, Status: models.ImportFailed, Reason: `invalid quantity "many"`},
+				{Row: 5, Status: models.ImportCreated, OrderID: 2},
+			},
+			expectedOrders:  2,
+			expectedReserve: 3,
+		},
+		{
+			name:    "dry run stores nothing",
+			options: models.OrderImportOptions{DryRun: true},
+			expected: []*models.OrderImportResult{
+				{Row: 2, Status: models.ImportSkipped, Reason: "dry run"},
+				{Row: 3, Status: models.ImportFailed, Reason: "insufficient stock: product 1 has 3 available, 10 requested"},
+				{Row: 4, Status: models.ImportFailed, Reason: `invalid quantity "many"`},
+				{Row: 5, Status: models.ImportSkipped, Reason: "dry run"},
+			},
+		},
+		{
+			name:    "all or nothing stores nothing with a failed row",
+			options: models.OrderImportOptions{AllOrNothing: true},
+			expected: []*models.OrderImportResult{
+				{Row: 2, Status: models.ImportSkipped, Reason: "not imported, row 3 failed"},
+				{Row: 3, Status: models.ImportFailed, Reason: "insufficient stock: product 1 has 3 available, 10 requested"},
+				{Row: 4, Status: models.ImportFailed, Reason: `invalid quantity "many"`},
+				{Row: 5, Status: models.ImportSkipped, Reason: "not imported, row 3 failed"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ordersStorage := file.NewOrdersStorage()
+			inventoryStorage := file.NewInventoryStorage()
+			_, err := inventoryStorage.Save(ctx, dsmodels.Stock{ProductID: 1, OnHand: 5})
+			assert.NoError(t, err, "expected the stock to be set")
+
+			productsService := mocks.NewProductsService(t)
+			productsService.On("GetProduct", mock.Anything, 1).Return(&models.Product{ID: 1, Price: common.NewMoney(1)}, nil).Maybe()
+
+			service := NewOrdersService(ordersStorage, NewPaymentsService(yugabyte.NewPaymentsStorage(utils.NewSequenceIDGenerator()), PaymentMethodsConfig{}, fake.NewPaymentGateway()), NewAuthorizationService(),
+				productsService, NewInventoryService(inventoryStorage, productsService), utils.NewSequenceIDGenerator(), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+			results, err := service.ImportOrders(ctx, user.ID, rows(), test.options)
+			assert.NoError(t, err, "expected no error")
+			assert.Equal(t, test.expected, results, "unexpected import report")
+
+			orders, err := ordersStorage.GetAllOrdersForUser(ctx, user.ID)
+			assert.NoError(t, err, "expected the orders to be listed")
+			assert.Len(t, orders, test.expectedOrders, "unexpected number of stored orders")
+			stock, err := inventoryStorage.Read(ctx, 1)
+			assert.NoError(t, err, "expected the stock to be read")
+			assert.Equal(t, test.expectedReserve, stock.Reserved, "unexpected reserved stock")
+		})
+	}
+
+	t.Run("imported orders keep their prices and payments", func(t *testing.T) {
+		ordersStorage := file.NewOrdersStorage()
+		inventoryStorage := file.NewInventoryStorage()
+		_, err := inventoryStorage.Save(ctx, dsmodels.Stock{ProductID: 1, OnHand: 5})
+		assert.NoError(t, err, "expected the stock to be set")
+
+		productsService := mocks.NewProductsService(t)
+		productsService.On("GetProduct", mock.Anything, 1).Return(&models.Product{ID: 1, Price: common.NewMoney(1)}, nil)
+
+		// a declining gateway proves the payments are not charged again
+		gateway := fake.NewPaymentGateway()
+		gateway.Script(fake.Authorize, fake.Decline)
+		paymentsStorage := yugabyte.NewPaymentsStorage(utils.NewSequenceIDGenerator())
+		service := NewOrdersService(ordersStorage, NewPaymentsService(paymentsStorage, PaymentMethodsConfig{}, gateway), NewAuthorizationService(),
+			productsService, NewInventoryService(inventoryStorage, productsService), utils.NewSequenceIDGenerator(), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+		results, err := service.ImportOrders(ctx, user.ID, rows()[:1], models.OrderImportOptions{})
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, []*models.OrderImportResult{{Row: 2, Status: models.ImportCreated, OrderID: 1}}, results, "unexpected import report")
+
+		order, err := ordersStorage.GetOrder(ctx, 1)
+		assert.NoError(t, err, "expected the imported order to be stored")
+		assert.Equal(t, common.NewMoney(4), order.Price, "expected the exported price")
+		payment, err := paymentsStorage.Read(ctx, 1)
+		assert.NoError(t, err, "expected the imported payment to be stored")
+		assert.Equal(t, common.PaymentCaptured, payment.Status, "expected the imported payment to be captured")
+		assert.Empty(t, payment.TransactionId, "expected no transaction with the gateway")
+	})
+
+	t.Run("all or nothing rolls back the stored orders", func(t *testing.T) {
+		for _, deleteErr := range []error{nil, errors.New("delete failed")} {
+			ordersStorage := &failingOrdersStorage{OrdersDatasource: file.NewOrdersStorage(), writeErr: errors.New("insert failed"), deleteErr: deleteErr, insertsBeforeFailure: 1}
+			inventoryStorage := file.NewInventoryStorage()
+			_, err := inventoryStorage.Save(ctx, dsmodels.Stock{ProductID: 1, OnHand: 5})
+			assert.NoError(t, err, "expected the stock to be set")
+
+			productsService := mocks.NewProductsService(t)
+			productsService.On("GetProduct", mock.Anything, 1).Return(&models.Product{ID: 1, Price: common.NewMoney(1)}, nil)
+
+			paymentsStorage := yugabyte.NewPaymentsStorage(utils.NewSequenceIDGenerator())
+			service := NewOrdersService(ordersStorage, NewPaymentsService(paymentsStorage, PaymentMethodsConfig{}, fake.NewPaymentGateway()), NewAuthorizationService(),
+				productsService, NewInventoryService(inventoryStorage, productsService), utils.NewSequenceIDGenerator(), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+			// both orders pass the checks, the second one cannot be stored
+			importRows := rows()[:2]
+			importRows[1].Order.Lines = []*models.OrderLine{models.NewOrderLine(1, 1, common.NewMoney(2))}
+			results, err := service.ImportOrders(ctx, user.ID, importRows, models.OrderImportOptions{AllOrNothing: true})
+
+			if deleteErr != nil {
+				assert.ErrorContains(t, err, "rolling back the import: delete failed", "expected the failed rollback to fail the import")
+				assert.Nil(t, results, "expected no report")
+				continue
+			}
+			assert.NoError(t, err, "expected no error")
+			assert.Equal(t, []*models.OrderImportResult{
+				{Row: 2, Status: models.ImportSkipped, Reason: "rolled back, row 3 failed"},
+				{Row: 3, Status: models.ImportFailed, Reason: "insert failed"},
+			}, results, "unexpected import report")
+
+			orders, err := ordersStorage.GetAllOrdersForUser(ctx, user.ID)
+			assert.NoError(t, err, "expected the orders to be listed")
+			assert.Empty(t, orders, "expected the stored order to be removed")
+			_, err = paymentsStorage.Read(ctx, 1)
+			assert.Error(t, err, "expected the payment of the stored order to be removed")
+			stock, err := inventoryStorage.Read(ctx, 1)
+			assert.NoError(t, err, "expected the stock to be read")
+			assert.Equal(t, 0, stock.Reserved, "expected the stock to be released")
+		}
+	})
+}
+
+func TestOrderService_Refunds(t *testing.T) {
+	log.InitLogger()
+	user := &models.User{ID: 1}
+	ctx := context.WithValue(log.NewBackgroundContext(&zlog.Logger), constants.AuthenticatedUserKey, user)
+
+	productsService := mocks.NewProductsService(t)
+	productsService.On("GetProduct", mock.Anything, 1).Return(&models.Product{ID: 1, Price: common.NewMoney(10)}, nil)
+	inventoryStorage := file.NewInventoryStorage()
+	_, err := inventoryStorage.Save(ctx, dsmodels.Stock{ProductID: 1, OnHand: 5})
+	assert.NoError(t, err, "expected the stock to be set")
+
+	service := NewOrdersService(file.NewOrdersStorage(), NewPaymentsService(yugabyte.NewPaymentsStorage(utils.NewSequenceIDGenerator()), PaymentMethodsConfig{}, fake.NewPaymentGateway()), NewAuthorizationService(),
+		productsService, NewInventoryService(inventoryStorage, productsService), utils.NewSequenceIDGenerator(), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+	// the order of 20 is paid with payment 1 of 12 and payment 2 of 8
+	order, err := service.StoreOrder(ctx, user.ID, models.Order{
+		User:  user,
+		Lines: []*models.OrderLine{{ProductID: 1, Quantity: 2}},
+		Payments: []*models.Payment{
+			{Amount: common.NewMoney(12), Method: common.CreditCard, User: user},
+			{Amount: common.NewMoney(8), Method: common.PayPal, User: user},
+		},
+	})
+	assert.NoError(t, err, "expected the order to be stored")
+
+	partial, err := service.RefundOrder(ctx, user.ID, order.ID, models.Refund{PaymentId: 1, Amount: common.NewMoney(5), Reason: common.DamagedGoods})
+	assert.NoError(t, err, "expected a partial refund")
+	assert.Equal(t, common.NewMoney(5.0), partial.Amount, "unexpected amount of the partial refund")
+
+	_, err = service.RefundOrder(ctx, user.ID, order.ID, models.Refund{PaymentId: 1, Amount: common.NewMoney(7.01), Reason: common.Goodwill})
+	assert.ErrorIs(t, err, ErrRefundExceedsPayment, "expected no more than the rest of the payment to be refunded")
+
+	full, err := service.RefundOrder(ctx, user.ID, order.ID, models.Refund{PaymentId: 2, Reason: common.NotDelivered})
+	assert.NoError(t, err, "expected a full refund")
+	assert.Equal(t, common.NewMoney(8.0), full.Amount, "expected the whole payment to be refunded")
+
+	_, err = service.RefundOrder(ctx, user.ID, order.ID, models.Refund{PaymentId: 99, Amount: common.NewMoney(1), Reason: common.Goodwill})
+	assert.ErrorIs(t, err, ErrUnknownPayment, "expected payments of other orders to be rejected")
+
+	refunds, err := service.GetRefunds(ctx, user.ID, order.ID)
+	assert.NoError(t, err, "expected the refunds to be listed")
+	assert.Equal(t, []*models.Refund{partial, full}, refunds, "unexpected refunds")
+
+	order, err = service.GetOrder(ctx, user.ID, order.ID)
+	assert.NoError(t, err, "expected the order to be read")
+	assert.Equal(t, common.NewMoney(13.0), order.AmountRefunded(), "unexpected amount refunded")
+	assert.Equal(t, common.NewMoney(13.0), order.Balance(), "expected the refunds to be outstanding again")
+
+	otherCtx := context.WithValue(ctx, constants.AuthenticatedUserKey, &models.User{ID: 2})
+	_, err = service.GetRefunds(otherCtx, 2, order.ID)
+	assert.ErrorIs(t, err, ErrNotAuthorized, "expected the refunds of another user to be denied")
+	_, err = service.RefundOrder(otherCtx, 2, order.ID, models.Refund{PaymentId: 1, Amount: common.NewMoney(1), Reason: common.Goodwill})
+	assert.ErrorIs(t, err, ErrNotAuthorized, "expected refunds of another user's order to be denied")
+}
+
+func TestOrderService_OrderWithoutPayments(t *testing.T) {
+	log.InitLogger()
+	user := &models.User{ID: 1}
+	ctx := context.WithValue(log.NewBackgroundContext(&zlog.Logger), constants.AuthenticatedUserKey, user)
+
+	productsService := mocks.NewProductsService(t)
+	productsService.On("GetProduct", mock.Anything, 1).Return(&models.Product{ID: 1, Price: common.NewMoney(10)}, nil)
+	inventoryStorage := file.NewInventoryStorage()
+	_, err := inventoryStorage.Save(ctx, dsmodels.Stock{ProductID: 1, OnHand: 5})
+	assert.NoError(t, err, "expected the stock to be set")
+
+	service := NewOrdersService(file.NewOrdersStorage(), NewPaymentsService(yugabyte.NewPaymentsStorage(utils.NewSequenceIDGenerator()), PaymentMethodsConfig{}, fake.NewPaymentGateway()), NewAuthorizationService(),
+		productsService, NewInventoryService(inventoryStorage, productsService), utils.NewSequenceIDGenerator(), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+	order, err := service.StoreOrder(ctx, user.ID, models.Order{
+		User:     user,
+		Lines:    []*models.OrderLine{{ProductID: 1, Quantity: 2}},
+		Payments: []*models.Payment{},
+	})
+	assert.NoError(t, err, "expected an order without payments to be stored")
+
+	order, err = service.GetOrder(ctx, user.ID, order.ID)
+	assert.NoError(t, err, "expected an order without payments to be read")
+	assert.Empty(t, order.Payments, "expected the order to have no payments")
+	assert.Equal(t, common.Pending, order.Status, "expected an unpaid order to be pending")
+
+	order, err = service.AddPayment(ctx, user.ID, order.ID, models.Payment{Amount: common.NewMoney(20), Method: common.CreditCard})
+	assert.NoError(t, err, "expected a payment to be added to an order without payments")
+	assert.Len(t, order.Payments, 1, "expected the added payment")
+	assert.True(t, order.Balance().IsZero(), "expected the order to be paid in full")
+}
+
+func TestOrderService_RefundThenRepay(t *testing.T) {
+	log.InitLogger()
+	user := &models.User{ID: 1}
+	ctx := context.WithValue(log.NewBackgroundContext(&zlog.Logger), constants.AuthenticatedUserKey, user)
+
+	productsService := mocks.NewProductsService(t)
+	productsService.On("GetProduct", mock.Anything, 1).Return(&models.Product{ID: 1, Price: common.NewMoney(10)}, nil)
+	inventoryStorage := file.NewInventoryStorage()
+	_, err := inventoryStorage.Save(ctx, dsmodels.Stock{ProductID: 1, OnHand: 5})
+	assert.NoError(t, err, "expected the stock to be set")
+
+	service := NewOrdersService(file.NewOrdersStorage(), NewPaymentsService(yugabyte.NewPaymentsStorage(utils.NewSequenceIDGenerator()), PaymentMethodsConfig{}, fake.NewPaymentGateway()), NewAuthorizationService(),
+		productsService, NewInventoryService(inventoryStorage, productsService), utils.NewSequenceIDGenerator(), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+	// the order of 20 is paid in full, 2 of it is refunded and paid again
+	order, err := service.StoreOrder(ctx, user.ID, models.Order{
+		User:     user,
+		Lines:    []*models.OrderLine{{ProductID: 1, Quantity: 2}},
+		Payments: []*models.Payment{{Amount: common.NewMoney(20), Method: common.CreditCard, User: user}},
+	})
+	assert.NoError(t, err, "expected the order to be stored")
+
+	_, err = service.RefundOrder(ctx, user.ID, order.ID, models.Refund{PaymentId: order.Payments[0].Id, Amount: common.NewMoney(2), Reason: common.Goodwill})
+	assert.NoError(t, err, "expected a partial refund")
+
+	order, err = service.AddPayment(ctx, user.ID, order.ID, models.Payment{Amount: common.NewMoney(2), Method: common.PayPal})
+	assert.NoError(t, err, "expected the refunded amount to be paid again")
+	assert.True(t, order.Balance().IsZero(), "expected the order to be settled")
+
+	_, err = service.AddPayment(ctx, user.ID, order.ID, models.Payment{Amount: common.NewMoney(0.01), Method: common.PayPal})
+	assert.ErrorIs(t, err, ErrOverpayment, "expected payments beyond the amount due net of refunds to be rejected")
+}
+
+func TestOrderService_OrderVersionsChangedBy(t *testing.T) {
+	log.InitLogger()
+	user := &models.User{ID: 3}
+	// like the order handlers, the context carries the authenticated user but no user id
+	ctx := context.WithValue(log.NewBackgroundContext(&zlog.Logger), constants.AuthenticatedUserKey, user)
+
+	productsService := mocks.NewProductsService(t)
+	productsService.On("GetProduct", mock.Anything, 1).Return(&models.Product{ID: 1, Price: common.NewMoney(10)}, nil)
+	inventoryStorage := file.NewInventoryStorage()
+	_, err := inventoryStorage.Save(ctx, dsmodels.Stock{ProductID: 1, OnHand: 5})
+	assert.NoError(t, err, "expected the stock to be set")
+
+	service := NewOrdersService(file.NewOrdersStorage(), NewPaymentsService(yugabyte.NewPaymentsStorage(utils.NewSequenceIDGenerator()), PaymentMethodsConfig{}, fake.NewPaymentGateway()), NewAuthorizationService(),
+		productsService, NewInventoryService(inventoryStorage, productsService), utils.NewSequenceIDGenerator(), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+	// POST /orders
+	order, err := service.StoreOrder(ctx, user.ID, models.Order{User: user, Lines: []*models.OrderLine{{ProductID: 1, Quantity: 1}}})
+	assert.NoError(t, err, "expected the order to be stored")
+
+	// PUT /orders/{id}
+	_, err = service.UpdateOrder(ctx, user.ID, models.Order{ID: order.ID, User: user, Lines: []*models.OrderLine{{ProductID: 1, Quantity: 2}}})
+	assert.NoError(t, err, "expected the order to be updated")
+
+	versions, err := service.GetOrderHistory(ctx, user.ID, order.ID)
+	assert.NoError(t, err, "expected the history to be returned")
+	assert.Len(t, versions, 2, "expected a version for the insert and the update")
+	assert.Equal(t, user.ID, versions[0].ChangedBy, "expected the insert to be attributed to the user")
+	assert.Equal(t, user.ID, versions[1].ChangedBy, "expected the update to be attributed to the user")
+
+	// DELETE /orders/{id}
+	assert.NoError(t, service.CancelOrder(ctx, user.ID, order.ID), "expected the order to be deleted")
+	history, err := service.GetOrderHistory(ctx, user.ID, order.ID)
+	assert.NoError(t, err, "expected the history of a deleted order to be kept")
+	assert.Equal(t, versions, history, "expected the history to be unchanged by the delete")
+}
+
+func TestOrderService_OrderVersions(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	changedAt := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+
+	dsVersions := []dsmodels.OrderVersion{
+		{Version: 1, ChangedAt: changedAt, ChangedBy: 1, Order: dsmodels.Order{ID: 5, UserId: 1, Status: common.Pending, Price: common.NewMoney(20)}},
+		{Version: 2, ChangedAt: changedAt.Add(time.Hour), ChangedBy: 1, Order: dsmodels.Order{ID: 5, UserId: 1, Status: common.Paid, Price: common.NewMoney(20), Payments: []int{1}}},
+		{Version: 3, ChangedAt: changedAt.Add(2 * time.Hour), ChangedBy: 1, Order: dsmodels.Order{ID: 5, UserId: 1, Status: common.Paid, Price: common.NewMoney(25), Payments: []int{1}}},
+	}
+
+	// newService creates a service with storage holding the versions of order 5 of user 1.
+	newService := func(t *testing.T) OrdersService {
+		storage := mocks.NewOrdersDatasource(t)
+		storage.On("GetOrderVersions", mock.Anything, 5).Return(dsVersions, nil)
+		return NewOrdersService(storage, mocks.NewPaymentsService(t), NewAuthorizationService(), mocks.NewProductsService(t), mocks.NewInventoryService(t), utils.NewSeededIDGenerator(1), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+	}
+
+	t.Run("history lists the changes of every version", func(t *testing.T) {
+		versions, err := newService(t).GetOrderHistory(ctx, 1, 5)
+
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, versions, 3, "expected all versions")
+		assert.Empty(t, versions[0].Changes, "expected no changes for the first version")
+		assert.Equal(t, []models.FieldChange{
+			{Field: "status", From: common.Pending, To: common.Paid},
+			{Field: "payment_ids", From: []int{}, To: []int{1}},
+		}, versions[1].Changes, "unexpected changes of the second version")
+		assert.Equal(t, []models.FieldChange{
+			{Field: "price", From: common.NewMoney(20.0), To: common.NewMoney(25.0)},
+		}, versions[2].Changes, "unexpected changes of the third version")
+	})
+
+	t.Run("version", func(t *testing.T) {
+		version, err := newService(t).GetOrderVersion(ctx, 1, 5, 2)
+
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, 2, version.Version, "unexpected version")
+		assert.Equal(t, common.Paid, version.Order.Status, "unexpected order of the version")
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		_, err := newService(t).GetOrderVersion(ctx, 1, 5, 4)
+
+		assert.ErrorIs(t, err, ErrOrderVersionNotFound, "expected the version not to be found")
+		assert.EqualError(t, err, "order version not found: 4", "unexpected error message")
+	})
+
+	t.Run("diff between any two versions", func(t *testing.T) {
+		changes, err := newService(t).DiffOrderVersions(ctx, 1, 5, 3, 1)
+
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, []models.FieldChange{
+			{Field: "status", From: common.Paid, To: common.Pending},
+			{Field: "price", From: common.NewMoney(25.0), To: common.NewMoney(20.0)},
+			{Field: "payment_ids", From: []int{1}, To: []int{}},
+		}, changes, "unexpected changes")
+	})
+
+	t.Run("order of another user", func(t *testing.T) {
+		_, err := newService(t).GetOrderHistory(ctx, 2, 5)
+
+		assert.ErrorIs(t, err, ErrNotAuthorized, "expected the history to be denied")
+	})
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/internal/models"
+	"os"
+)
+
+// ErrPaymentLimit is returned for a payment below the minimum or above the maximum amount of its method.
+var ErrPaymentLimit = common.NewDomainError(common.Unprocessable, "payment_limit", "payment amount outside the limits of its method")
+
+// PaymentMethodPolicy is the fee and the limits of a payment method.
+type PaymentMethodPolicy struct {
+	// FixedFee is charged for every payment, PercentFee in percent of the amount on top of it.
+	FixedFee   common.Money `json:"fixed_fee"`
+	PercentFee float64      `json:"percent_fee"`
+	MinAmount  common.Money `json:"min_amount"`
+	// MaxAmount is the largest amount of a payment, 0 does not limit the amount.
+	MaxAmount common.Money `json:"max_amount"`
+}
+
+// PaymentMethodsConfig holds the policy of every payment method, methods without a policy are free and unlimited.
+type PaymentMethodsConfig map[common.PaymentMethod]PaymentMethodPolicy
+
+// NewPaymentMethodsConfig reads the policies from FP_KATA_PAYMENT_METHODS, a JSON object by payment method,
+// for example {"CreditCard":{"fixed_fee":0.25,"percent_fee":1.4,"min_amount":1,"max_amount":5000}}.
+// Policies that cannot be parsed fail instead of leaving every method free.
+func NewPaymentMethodsConfig() (PaymentMethodsConfig, error) {
+	config := PaymentMethodsConfig{}
+	if value := os.Getenv("FP_KATA_PAYMENT_METHODS"); value != "" {
+		if err := json.Unmarshal([]byte(value), &config); err != nil {
+			return nil, fmt.Errorf("parsing FP_KATA_PAYMENT_METHODS: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// Fee is what the method charges for a payment of the amount, rounded half up to cents.
+func (p PaymentMethodPolicy) Fee(amount common.Money) common.Money {
+	return p.FixedFee.Add(amount.Scale(p.PercentFee).Div(100, common.HalfUp)).Round(common.HalfUp)
+}
+
+// checkPaymentMethod rejects a payment outside the limits of its method and sets the fee of the method.
+// Payments without an amount are neither limited nor charged.
+func (config PaymentMethodsConfig) checkPaymentMethod(payment *models.Payment) error {
+	if payment.Amount.Sign() <= 0 {
+		payment.Fee = common.Money{}
+		return nil
+	}
+	policy := config[payment.Method]
+	if payment.Amount.Compare(policy.MinAmount) < 0 {
+		return fmt.Errorf("%w: %s by %s, at least %s", ErrPaymentLimit, payment.Amount, payment.Method, policy.MinAmount)
+	}
+	if policy.MaxAmount.Sign() > 0 && payment.Amount.Compare(policy.MaxAmount) > 0 {
+		return fmt.Errorf("%w: %s by %s, at most %s", ErrPaymentLimit, payment.Amount, payment.Method, policy.MaxAmount)
+	}
+	payment.Fee = policy.Fee(payment.Amount)
+	return nil
+}
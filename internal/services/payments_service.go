@@ -2,31 +2,73 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"fp_kata/common"
 	"fp_kata/common/utils"
 	"fp_kata/internal/datasources"
 	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/internal/gateways"
 	"fp_kata/internal/models"
+	"time"
 )
 
 const compPaymentsService = "PaymentsService"
 
 type PaymentsService interface {
+	// StorePayment creates or updates a payment within the limits of its method, charging the fee of the method.
+	// New payments are authorized and captured with the payment gateway before they are stored.
 	StorePayment(ctx context.Context, payment models.Payment) (*models.Payment, error)
+	// ImportPayment stores a payment made before, like the payment of an imported order, within the limits of its method.
+	// It is stored as captured without processing it with the payment gateway.
+	ImportPayment(ctx context.Context, payment models.Payment) (*models.Payment, error)
 	GetPaymentsByOrder(ctx context.Context, orderId int) ([]*models.Payment, error)
 	GetPaymentByID(ctx context.Context, id int) (*models.Payment, error)
+	// DeletePayment deletes a payment, voiding or refunding it with the payment gateway first.
+	DeletePayment(ctx context.Context, id int) error
+	// GetUserPayment returns a payment of the user, the payments of other users are not found.
+	GetUserPayment(ctx context.Context, userId int, id int) (*models.Payment, error)
+	// GetUserPayments lists the payments of the user selected by the filter, ordered by id.
+	GetUserPayments(ctx context.Context, userId int, filter models.PaymentFilter) ([]*models.Payment, error)
+	// RefundPayment refunds the payment it references with the payment gateway and stores the refund,
+	// a refund without amount refunds all that is left.
+	RefundPayment(ctx context.Context, refund models.Refund) (*models.Refund, error)
 }
 
+// ErrPaymentNotFound is returned for a payment that does not exist or belongs to another user.
+var ErrPaymentNotFound = common.NewDomainError(common.NotFound, "payment_not_found", "payment not found")
+
+// ErrRefundExceedsPayment is returned when a refund is larger than what is left to refund of its payment.
+var ErrRefundExceedsPayment = common.NewDomainError(common.Unprocessable, "refund_exceeds_payment", "refund exceeds the refundable amount of the payment")
+
 type paymentsService struct {
 	storage datasources.PaymentsDatasource
+	methods PaymentMethodsConfig
+	gateway gateways.PaymentGateway
+	now     func() time.Time
 }
 
-func NewPaymentsService(storage datasources.PaymentsDatasource) PaymentsService {
-	return &paymentsService{storage: storage}
+func NewPaymentsService(storage datasources.PaymentsDatasource, methods PaymentMethodsConfig, gateway gateways.PaymentGateway) PaymentsService {
+	return &paymentsService{storage: storage, methods: methods, gateway: gateway, now: time.Now}
 }
 
 func (service *paymentsService) StorePayment(ctx context.Context, payment models.Payment) (*models.Payment, error) {
 	utils.LogAction(ctx, compPaymentsService, "StorePayment")
 
+	if err := service.methods.checkPaymentMethod(&payment); err != nil {
+		return nil, err
+	}
+
+	// Payments with a transaction were processed by the gateway before, payments without an amount are not processed at all
+	charged := payment.Id == 0 && payment.TransactionId == "" && payment.Amount.Sign() > 0
+	if charged {
+		if err := service.charge(ctx, &payment); err != nil {
+			return nil, err
+		}
+	} else if payment.Status == "" {
+		payment.Status = common.PaymentCaptured
+	}
+
 	createdDsPayment := dsmodels.Payment{}
 	var err error
 	if payment.Id == 0 {
@@ -35,12 +77,33 @@ func (service *paymentsService) StorePayment(ctx context.Context, payment models
 		createdDsPayment, err = service.storage.Update(ctx, *payment.ToDSModel())
 	}
 	if err != nil {
+		if charged {
+			return nil, errors.Join(err, service.release(ctx, &payment))
+		}
 		return nil, err
 	}
 	createdPayment := models.MapToPayment(createdDsPayment, payment.User, payment.Order)
 	return createdPayment, nil
 }
 
+func (service *paymentsService) ImportPayment(ctx context.Context, payment models.Payment) (*models.Payment, error) {
+	utils.LogAction(ctx, compPaymentsService, "ImportPayment")
+
+	if err := service.methods.checkPaymentMethod(&payment); err != nil {
+		return nil, err
+	}
+
+	// the money of imported payments was taken before, there is no transaction to charge or give back
+	payment.Id = 0
+	payment.TransactionId = ""
+	payment.Status = common.PaymentCaptured
+	dsPayment, err := service.storage.Create(ctx, *payment.ToDSModel())
+	if err != nil {
+		return nil, err
+	}
+	return models.MapToPayment(dsPayment, payment.User, payment.Order), nil
+}
+
 func (service *paymentsService) GetPaymentByID(ctx context.Context, id int) (*models.Payment, error) {
 	utils.LogAction(ctx, compPaymentsService, "GetPaymentByID")
 
@@ -65,3 +128,130 @@ func (service *paymentsService) GetPaymentsByOrder(ctx context.Context, orderId
 	}
 	return payments, nil
 }
+
+func (service *paymentsService) GetUserPayment(ctx context.Context, userId int, id int) (*models.Payment, error) {
+	utils.LogAction(ctx, compPaymentsService, "GetUserPayment")
+
+	if userId == 0 {
+		return nil, ErrUserRequired
+	}
+	payment, err := service.GetPaymentByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %d", ErrPaymentNotFound, id)
+	}
+	// payments are made by the owner of their order
+	if payment.User.ID != userId {
+		return nil, fmt.Errorf("%w: %d", ErrPaymentNotFound, id)
+	}
+	return payment, nil
+}
+
+func (service *paymentsService) GetUserPayments(ctx context.Context, userId int, filter models.PaymentFilter) ([]*models.Payment, error) {
+	utils.LogAction(ctx, compPaymentsService, "GetUserPayments")
+
+	if userId == 0 {
+		return nil, ErrUserRequired
+	}
+	dsPayments, err := service.storage.AllByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	payments := make([]*models.Payment, 0, len(dsPayments))
+	for _, dsPayment := range dsPayments {
+		payment := models.MapToPayment(dsPayment, &models.User{ID: dsPayment.UserId}, &models.Order{ID: dsPayment.OrderId})
+		if filter.Matches(payment) {
+			payments = append(payments, payment)
+		}
+	}
+	return payments, nil
+}
+
+// charge authorizes and captures the payment with the gateway. An authorization that cannot be captured is voided again.
+func (service *paymentsService) charge(ctx context.Context, payment *models.Payment) error {
+	transactionId, err := service.gateway.Authorize(ctx, payment.Amount, payment.Method)
+	if err != nil {
+		return err
+	}
+	if err := service.gateway.Capture(ctx, transactionId, payment.Amount); err != nil {
+		return errors.Join(err, service.gateway.Void(ctx, transactionId))
+	}
+	payment.TransactionId = transactionId
+	payment.Status = common.PaymentCaptured
+	return nil
+}
+
+// release gives the money of a payment back with the gateway: an authorization is voided,
+// what is left of a captured payment is refunded.
+func (service *paymentsService) release(ctx context.Context, payment *models.Payment) error {
+	if payment.TransactionId == "" {
+		return nil
+	}
+	switch payment.Status {
+	case common.PaymentAuthorized:
+		if err := service.gateway.Void(ctx, payment.TransactionId); err != nil {
+			return err
+		}
+		payment.Status = common.PaymentVoided
+	case common.PaymentCaptured:
+		if refundable := payment.RefundableAmount(); refundable.Sign() > 0 {
+			if err := service.gateway.Refund(ctx, payment.TransactionId, refundable); err != nil {
+				return err
+			}
+		}
+		payment.Status = common.PaymentRefunded
+	}
+	return nil
+}
+
+func (service *paymentsService) DeletePayment(ctx context.Context, id int) error {
+	utils.LogAction(ctx, compPaymentsService, "DeletePayment")
+
+	payment, err := service.GetPaymentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := service.release(ctx, payment); err != nil {
+		return err
+	}
+	return service.storage.Delete(ctx, id)
+}
+
+func (service *paymentsService) RefundPayment(ctx context.Context, refund models.Refund) (*models.Refund, error) {
+	utils.LogAction(ctx, compPaymentsService, "RefundPayment")
+
+	dsPayment, err := service.storage.Read(ctx, refund.PaymentId)
+	if err != nil {
+		return nil, err
+	}
+	payment := models.MapToPayment(dsPayment, &models.User{ID: dsPayment.UserId}, &models.Order{ID: dsPayment.OrderId})
+
+	refundable := payment.RefundableAmount()
+	if refund.Amount.IsZero() {
+		refund.Amount = refundable
+	}
+	if refund.Amount.Sign() <= 0 || refund.Amount.Compare(refundable) > 0 {
+		return nil, fmt.Errorf("%w: %s requested, %s refundable", ErrRefundExceedsPayment, refund.Amount, refundable)
+	}
+
+	// Refunds given back by the gateway cannot be taken back, so the payment is refunded before the refund is stored
+	if payment.TransactionId != "" {
+		if err := service.gateway.Refund(ctx, payment.TransactionId, refund.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	refund.Id = 0
+	refund.CreatedAt = service.now()
+	dsRefund, err := service.storage.CreateRefund(ctx, *refund.ToDSModel())
+	if err != nil {
+		return nil, err
+	}
+
+	if refund.Amount == refundable {
+		dsPayment.Status = common.PaymentRefunded
+		if _, err := service.storage.Update(ctx, dsPayment); err != nil {
+			return nil, err
+		}
+	}
+	return models.MapToRefund(dsRefund), nil
+}
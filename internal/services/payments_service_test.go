@@ -1,13 +1,18 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fp_kata/common"
 	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/internal/gateways"
+	"fp_kata/internal/gateways/fake"
 	"fp_kata/internal/models"
 	"fp_kata/mocks"
 	"fp_kata/pkg/log"
 	zlog "github.com/rs/zerolog/log"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -49,13 +54,13 @@ func TestGetPaymentsByOrder(t *testing.T) {
 				mockStorage.On("AllByOrderId", mock.Anything, 1).Return([]dsmodels.Payment{
 					{
 						Id:      101,
-						Amount:  10.50,
+						Amount:  common.NewMoney(10.50),
 						UserId:  1,
 						OrderId: 1,
 					},
 					{
 						Id:      102,
-						Amount:  20.00,
+						Amount:  common.NewMoney(20.00),
 						UserId:  2,
 						OrderId: 1,
 					},
@@ -67,9 +72,9 @@ func TestGetPaymentsByOrder(t *testing.T) {
 
 				assert.Len(t, result, 2, "Expected 2 payments but got a different number")
 				assert.Equal(t, 101, result[0].Id, "First payment ID mismatch")
-				assert.Equal(t, 10.50, result[0].Amount, "First payment amount mismatch")
+				assert.Equal(t, common.NewMoney(10.50), result[0].Amount, "First payment amount mismatch")
 				assert.Equal(t, 102, result[1].Id, "Second payment ID mismatch")
-				assert.Equal(t, 20.00, result[1].Amount, "Second payment amount mismatch")
+				assert.Equal(t, common.NewMoney(20.00), result[1].Amount, "Second payment amount mismatch")
 			},
 		},
 		{
@@ -105,7 +110,7 @@ func TestGetPaymentsByOrder(t *testing.T) {
 				tt.mockSetup(mockStorage)
 			}
 
-			service := NewPaymentsService(mockStorage)
+			service := NewPaymentsService(mockStorage, PaymentMethodsConfig{}, fake.NewPaymentGateway())
 			result, err := service.GetPaymentsByOrder(ctx, tt.orderID)
 
 			tt.validate(t, result, err)
@@ -130,23 +135,23 @@ func TestStorePayment(t *testing.T) {
 			name: "Successful Payment Creation",
 			payment: models.Payment{
 				Id:     0,
-				Amount: 100.50,
+				Amount: common.NewMoney(100.50),
 				User:   &models.User{ID: 1},
 				Order:  &models.Order{ID: 1},
 			},
 			mockSetup: func(mockStorage *mocks.PaymentsDatasource) {
 				mockStorage.On("Create", mock.Anything, mock.MatchedBy(func(p dsmodels.Payment) bool {
-					return p.Amount == 100.50 && p.UserId == 1 && p.OrderId == 1
+					return p.Amount == common.NewMoney(100.50) && p.UserId == 1 && p.OrderId == 1
 				})).Return(dsmodels.Payment{
 					Id:      1,
-					Amount:  100.50,
+					Amount:  common.NewMoney(100.50),
 					UserId:  1,
 					OrderId: 1,
 				}, nil)
 			},
 			validate: validateSuccess(&models.Payment{
 				Id:     1,
-				Amount: 100.50,
+				Amount: common.NewMoney(100.50),
 				User:   &models.User{ID: 1},
 				Order:  &models.Order{ID: 1},
 			}),
@@ -155,23 +160,23 @@ func TestStorePayment(t *testing.T) {
 			name: "Successful Payment Update",
 			payment: models.Payment{
 				Id:     1,
-				Amount: 200.75,
+				Amount: common.NewMoney(200.75),
 				User:   &models.User{ID: 2},
 				Order:  &models.Order{ID: 3},
 			},
 			mockSetup: func(mockStorage *mocks.PaymentsDatasource) {
 				mockStorage.On("Update", mock.Anything, mock.MatchedBy(func(p dsmodels.Payment) bool {
-					return p.Id == 1 && p.Amount == 200.75 && p.UserId == 2 && p.OrderId == 3
+					return p.Id == 1 && p.Amount == common.NewMoney(200.75) && p.UserId == 2 && p.OrderId == 3
 				})).Return(dsmodels.Payment{
 					Id:      1,
-					Amount:  200.75,
+					Amount:  common.NewMoney(200.75),
 					UserId:  2,
 					OrderId: 3,
 				}, nil)
 			},
 			validate: validateSuccess(&models.Payment{
 				Id:     1,
-				Amount: 200.75,
+				Amount: common.NewMoney(200.75),
 				User:   &models.User{ID: 2},
 				Order:  &models.Order{ID: 3},
 			}),
@@ -180,7 +185,7 @@ func TestStorePayment(t *testing.T) {
 			name: "Creation Fails",
 			payment: models.Payment{
 				Id:     0,
-				Amount: 300.00,
+				Amount: common.NewMoney(300.00),
 				User:   &models.User{ID: 3},
 				Order:  &models.Order{ID: 2},
 			},
@@ -193,7 +198,7 @@ func TestStorePayment(t *testing.T) {
 			name: "Update Fails",
 			payment: models.Payment{
 				Id:     5,
-				Amount: 150.00,
+				Amount: common.NewMoney(150.00),
 				User:   &models.User{ID: 4},
 				Order:  &models.Order{ID: 5},
 			},
@@ -211,7 +216,7 @@ func TestStorePayment(t *testing.T) {
 				tt.mockSetup(mockStorage)
 			}
 
-			service := NewPaymentsService(mockStorage)
+			service := NewPaymentsService(mockStorage, PaymentMethodsConfig{}, fake.NewPaymentGateway())
 			result, err := service.StorePayment(ctx, tt.payment)
 
 			tt.validate(t, result, err)
@@ -221,6 +226,26 @@ func TestStorePayment(t *testing.T) {
 	}
 }
 
+func TestImportPayment(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	mockStorage := mocks.NewPaymentsDatasource(t)
+	mockStorage.On("Create", mock.Anything, mock.MatchedBy(func(p dsmodels.Payment) bool {
+		return p.Amount == common.NewMoney(50) && p.Status == common.PaymentCaptured && p.TransactionId == ""
+	})).Return(dsmodels.Payment{Id: 1, Amount: common.NewMoney(50), UserId: 1, OrderId: 2, Status: common.PaymentCaptured}, nil)
+
+	// a declining gateway proves the imported payment is not charged
+	gateway := fake.NewPaymentGateway()
+	gateway.Script(fake.Authorize, fake.Decline)
+	service := NewPaymentsService(mockStorage, PaymentMethodsConfig{}, gateway)
+
+	payment, err := service.ImportPayment(ctx, models.Payment{Amount: common.NewMoney(50), User: &models.User{ID: 1}, Order: &models.Order{ID: 2}})
+	assert.NoError(t, err, "expected the payment to be imported")
+	assert.Equal(t, 1, payment.Id, "unexpected payment id")
+	assert.Equal(t, common.PaymentCaptured, payment.Status, "expected the imported payment to be captured")
+}
+
 func TestGetPaymentByID(t *testing.T) {
 	log.InitLogger()
 	ctx := log.NewBackgroundContext(&zlog.Logger)
@@ -237,14 +262,14 @@ func TestGetPaymentByID(t *testing.T) {
 			mockSetup: func(mockStorage *mocks.PaymentsDatasource) {
 				mockStorage.On("Read", mock.Anything, 1).Return(dsmodels.Payment{
 					Id:      1,
-					Amount:  100.50,
+					Amount:  common.NewMoney(100.50),
 					UserId:  1,
 					OrderId: 1,
 				}, nil)
 			},
 			validate: validateSuccess(&models.Payment{
 				Id:     1,
-				Amount: 100.50,
+				Amount: common.NewMoney(100.50),
 				User:   &models.User{ID: 1},
 				Order:  &models.Order{ID: 1},
 			}),
@@ -266,10 +291,390 @@ func TestGetPaymentByID(t *testing.T) {
 				tt.mockSetup(mockStorage)
 			}
 
-			service := NewPaymentsService(mockStorage)
+			service := NewPaymentsService(mockStorage, PaymentMethodsConfig{}, fake.NewPaymentGateway())
 			result, err := service.GetPaymentByID(ctx, tt.id)
 
 			tt.validate(t, result, err)
 		})
 	}
 }
+
+func TestDeletePayment(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	tests := []struct {
+		name      string
+		id        int
+		mockSetup func(*mocks.PaymentsDatasource)
+		validate  func(*testing.T, error)
+	}{
+		{
+			name: "Payment Deleted",
+			id:   1,
+			mockSetup: func(mockStorage *mocks.PaymentsDatasource) {
+				mockStorage.On("Read", mock.Anything, 1).Return(dsmodels.Payment{Id: 1, Amount: common.NewMoney(10), UserId: 1, OrderId: 1}, nil)
+				mockStorage.On("Delete", mock.Anything, 1).Return(nil)
+			},
+			validate: func(t *testing.T, err error) {
+				assert.NoError(t, err, "Expected no error but got one")
+			},
+		},
+		{
+			name: "Payment Not Found",
+			id:   2,
+			mockSetup: func(mockStorage *mocks.PaymentsDatasource) {
+				mockStorage.On("Read", mock.Anything, 2).Return(dsmodels.Payment{}, errors.New("payment with id 2 not found"))
+			},
+			validate: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "payment with id 2 not found", "Error message mismatch")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := mocks.NewPaymentsDatasource(t)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockStorage)
+			}
+
+			service := NewPaymentsService(mockStorage, PaymentMethodsConfig{}, fake.NewPaymentGateway())
+			err := service.DeletePayment(ctx, tt.id)
+
+			tt.validate(t, err)
+		})
+	}
+
+	t.Run("captured payment is refunded", func(t *testing.T) {
+		gateway := fake.NewPaymentGateway()
+		transactionId, _ := gateway.Authorize(ctx, common.NewMoney(20), common.PayPal)
+		_ = gateway.Capture(ctx, transactionId, common.NewMoney(20))
+
+		mockStorage := mocks.NewPaymentsDatasource(t)
+		mockStorage.On("Read", mock.Anything, 1).Return(dsmodels.Payment{Id: 1, Amount: common.NewMoney(20), Method: common.PayPal, UserId: 1, OrderId: 1,
+			Status: common.PaymentCaptured, TransactionId: transactionId, Refunds: []dsmodels.Refund{{Id: 2, PaymentId: 1, Amount: common.NewMoney(5)}}}, nil)
+		mockStorage.On("Delete", mock.Anything, 1).Return(nil)
+
+		err := NewPaymentsService(mockStorage, PaymentMethodsConfig{}, gateway).DeletePayment(ctx, 1)
+
+		assert.NoError(t, err, "Expected no error but got one")
+		transaction, _ := gateway.Transaction(transactionId)
+		assert.Equal(t, common.NewMoney(15.0), transaction.Refunded, "Expected what is left of the payment to be refunded")
+	})
+
+	t.Run("payment is kept when the gateway fails", func(t *testing.T) {
+		gateway := fake.NewPaymentGateway()
+		transactionId, _ := gateway.Authorize(ctx, common.NewMoney(20), common.PayPal)
+		_ = gateway.Capture(ctx, transactionId, common.NewMoney(20))
+		gateway.Script(fake.Refund, fake.Timeout)
+
+		mockStorage := mocks.NewPaymentsDatasource(t)
+		mockStorage.On("Read", mock.Anything, 1).Return(dsmodels.Payment{Id: 1, Amount: common.NewMoney(20), Method: common.PayPal, UserId: 1, OrderId: 1,
+			Status: common.PaymentCaptured, TransactionId: transactionId}, nil)
+
+		err := NewPaymentsService(mockStorage, PaymentMethodsConfig{}, gateway).DeletePayment(ctx, 1)
+
+		assert.ErrorIs(t, err, gateways.ErrGatewayTimeout, "Expected the timeout of the gateway")
+		mockStorage.AssertNotCalled(t, "Delete", mock.Anything, 1)
+	})
+}
+
+func TestRefundPayment(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	createdAt := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+	// payment 1 of 20 has 5 refunded already
+	storedPayment := dsmodels.Payment{Id: 1, Amount: common.NewMoney(20), Method: common.PayPal, UserId: 1, OrderId: 10,
+		Refunds: []dsmodels.Refund{{Id: 2, PaymentId: 1, Amount: common.NewMoney(5), Reason: common.DamagedGoods}}}
+
+	tests := []struct {
+		name      string
+		refund    models.Refund
+		mockSetup func(*mocks.PaymentsDatasource)
+		validate  func(*testing.T, *models.Refund, error)
+	}{
+		{
+			name:   "partial refund",
+			refund: models.Refund{PaymentId: 1, Amount: common.NewMoney(10), Reason: common.Goodwill},
+			mockSetup: func(mockStorage *mocks.PaymentsDatasource) {
+				mockStorage.On("CreateRefund", mock.Anything, dsmodels.Refund{PaymentId: 1, Amount: common.NewMoney(10), Reason: common.Goodwill, CreatedAt: createdAt}).
+					Return(dsmodels.Refund{Id: 3, PaymentId: 1, Amount: common.NewMoney(10), Reason: common.Goodwill, CreatedAt: createdAt}, nil)
+			},
+			validate: func(t *testing.T, refund *models.Refund, err error) {
+				assert.NoError(t, err, "Expected no error but got one")
+				assert.Equal(t, &models.Refund{Id: 3, PaymentId: 1, Amount: common.NewMoney(10), Reason: common.Goodwill, CreatedAt: createdAt}, refund, "Refund mismatch")
+			},
+		},
+		{
+			name:   "refund without amount refunds the rest",
+			refund: models.Refund{PaymentId: 1, Reason: common.CustomerRequest},
+			mockSetup: func(mockStorage *mocks.PaymentsDatasource) {
+				mockStorage.On("CreateRefund", mock.Anything, dsmodels.Refund{PaymentId: 1, Amount: common.NewMoney(15), Reason: common.CustomerRequest, CreatedAt: createdAt}).
+					Return(dsmodels.Refund{Id: 3, PaymentId: 1, Amount: common.NewMoney(15), Reason: common.CustomerRequest, CreatedAt: createdAt}, nil)
+				mockStorage.On("Update", mock.Anything, mock.MatchedBy(func(payment dsmodels.Payment) bool {
+					return payment.Id == 1 && payment.Status == common.PaymentRefunded
+				})).Return(dsmodels.Payment{Id: 1, Status: common.PaymentRefunded}, nil)
+			},
+			validate: func(t *testing.T, refund *models.Refund, err error) {
+				assert.NoError(t, err, "Expected no error but got one")
+				assert.Equal(t, common.NewMoney(15.0), refund.Amount, "Expected the rest of the payment to be refunded")
+			},
+		},
+		{
+			name:      "refund beyond the payment",
+			refund:    models.Refund{PaymentId: 1, Amount: common.NewMoney(15.01), Reason: common.Goodwill},
+			mockSetup: func(mockStorage *mocks.PaymentsDatasource) {},
+			validate: func(t *testing.T, refund *models.Refund, err error) {
+				assert.ErrorIs(t, err, ErrRefundExceedsPayment, "Expected the refund to be rejected")
+				assert.EqualError(t, err, "refund exceeds the refundable amount of the payment: 15.01 requested, 15.00 refundable", "Error message mismatch")
+				assert.Nil(t, refund, "Expected no refund")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := mocks.NewPaymentsDatasource(t)
+			mockStorage.On("Read", mock.Anything, 1).Return(storedPayment, nil)
+			tt.mockSetup(mockStorage)
+
+			service := &paymentsService{storage: mockStorage, now: func() time.Time { return createdAt }}
+			refund, err := service.RefundPayment(ctx, tt.refund)
+
+			tt.validate(t, refund, err)
+		})
+	}
+
+	t.Run("fully refunded payment", func(t *testing.T) {
+		mockStorage := mocks.NewPaymentsDatasource(t)
+		refunded := storedPayment
+		refunded.Refunds = append(refunded.Refunds, dsmodels.Refund{Id: 3, PaymentId: 1, Amount: common.NewMoney(15)})
+		mockStorage.On("Read", mock.Anything, 1).Return(refunded, nil)
+
+		_, err := NewPaymentsService(mockStorage, PaymentMethodsConfig{}, fake.NewPaymentGateway()).RefundPayment(ctx, models.Refund{PaymentId: 1, Reason: common.Goodwill})
+		assert.ErrorIs(t, err, ErrRefundExceedsPayment, "Expected nothing left to refund")
+	})
+
+	t.Run("payment is refunded with the gateway", func(t *testing.T) {
+		gateway := fake.NewPaymentGateway()
+		transactionId, _ := gateway.Authorize(ctx, common.NewMoney(20), common.PayPal)
+		_ = gateway.Capture(ctx, transactionId, common.NewMoney(20))
+		captured := storedPayment
+		captured.Status, captured.TransactionId = common.PaymentCaptured, transactionId
+
+		mockStorage := mocks.NewPaymentsDatasource(t)
+		mockStorage.On("Read", mock.Anything, 1).Return(captured, nil)
+		mockStorage.On("CreateRefund", mock.Anything, mock.Anything).Return(dsmodels.Refund{Id: 3, PaymentId: 1, Amount: common.NewMoney(10)}, nil)
+
+		_, err := NewPaymentsService(mockStorage, PaymentMethodsConfig{}, gateway).RefundPayment(ctx, models.Refund{PaymentId: 1, Amount: common.NewMoney(10), Reason: common.Goodwill})
+
+		assert.NoError(t, err, "Expected no error but got one")
+		transaction, _ := gateway.Transaction(transactionId)
+		assert.Equal(t, common.NewMoney(10.0), transaction.Refunded, "Expected the refund to be given back by the gateway")
+	})
+
+	t.Run("refund declined by the gateway is not stored", func(t *testing.T) {
+		gateway := fake.NewPaymentGateway()
+		transactionId, _ := gateway.Authorize(ctx, common.NewMoney(20), common.PayPal)
+		_ = gateway.Capture(ctx, transactionId, common.NewMoney(20))
+		gateway.Script(fake.Refund, fake.Decline)
+		captured := storedPayment
+		captured.Status, captured.TransactionId = common.PaymentCaptured, transactionId
+
+		mockStorage := mocks.NewPaymentsDatasource(t)
+		mockStorage.On("Read", mock.Anything, 1).Return(captured, nil)
+
+		refund, err := NewPaymentsService(mockStorage, PaymentMethodsConfig{}, gateway).RefundPayment(ctx, models.Refund{PaymentId: 1, Amount: common.NewMoney(10), Reason: common.Goodwill})
+
+		assert.ErrorIs(t, err, gateways.ErrPaymentDeclined, "Expected the refund to be declined")
+		assert.Nil(t, refund, "Expected no refund")
+	})
+}
+
+func TestGetUserPayment(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	tests := []struct {
+		name      string
+		userId    int
+		mockSetup func(*mocks.PaymentsDatasource)
+		validate  func(*testing.T, *models.Payment, error)
+	}{
+		{
+			name:   "Payment of the user",
+			userId: 1,
+			mockSetup: func(mockStorage *mocks.PaymentsDatasource) {
+				mockStorage.On("Read", mock.Anything, 2).Return(dsmodels.Payment{Id: 2, Amount: common.NewMoney(10), UserId: 1, OrderId: 7}, nil)
+			},
+			validate: validateSuccess(&models.Payment{Id: 2, Amount: common.NewMoney(10), User: &models.User{ID: 1}, Order: &models.Order{ID: 7}}),
+		},
+		{
+			name:   "Payment of another user",
+			userId: 3,
+			mockSetup: func(mockStorage *mocks.PaymentsDatasource) {
+				mockStorage.On("Read", mock.Anything, 2).Return(dsmodels.Payment{Id: 2, Amount: common.NewMoney(10), UserId: 1, OrderId: 7}, nil)
+			},
+			validate: validateError("payment not found: 2"),
+		},
+		{
+			name:   "Payment Not Found",
+			userId: 1,
+			mockSetup: func(mockStorage *mocks.PaymentsDatasource) {
+				mockStorage.On("Read", mock.Anything, 2).Return(dsmodels.Payment{}, errors.New("payment with id 2 not found"))
+			},
+			validate: validateError("payment not found: 2"),
+		},
+		{
+			name:      "User Required",
+			userId:    0,
+			mockSetup: func(mockStorage *mocks.PaymentsDatasource) {},
+			validate:  validateError(ErrUserRequired.Error()),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := mocks.NewPaymentsDatasource(t)
+			tt.mockSetup(mockStorage)
+
+			payment, err := NewPaymentsService(mockStorage, PaymentMethodsConfig{}, fake.NewPaymentGateway()).GetUserPayment(ctx, tt.userId, 2)
+
+			tt.validate(t, payment, err)
+		})
+	}
+}
+
+func TestGetUserPayments(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	february := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	dsPayments := []dsmodels.Payment{
+		{Id: 1, Amount: common.NewMoney(10), Method: common.PayPal, UserId: 1, OrderId: 7, PaidAt: february.Add(-time.Second)},
+		{Id: 2, Amount: common.NewMoney(20), Method: common.PayPal, UserId: 1, OrderId: 7, PaidAt: february},
+		{Id: 3, Amount: common.NewMoney(30), Method: common.CreditCard, UserId: 1, OrderId: 8, PaidAt: february.Add(time.Hour)},
+		{Id: 4, Amount: common.NewMoney(40), Method: common.PayPal, UserId: 1, OrderId: 8, PaidAt: march},
+	}
+
+	tests := []struct {
+		name        string
+		filter      models.PaymentFilter
+		expectedIDs []int
+	}{
+		{name: "all payments", filter: models.PaymentFilter{}, expectedIDs: []int{1, 2, 3, 4}},
+		{name: "payments of a method", filter: models.PaymentFilter{Method: common.CreditCard}, expectedIDs: []int{3}},
+		{name: "payments of a period", filter: models.PaymentFilter{From: february, To: march}, expectedIDs: []int{2, 3}},
+		{name: "payments of a method in a period", filter: models.PaymentFilter{Method: common.PayPal, From: february, To: march}, expectedIDs: []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := mocks.NewPaymentsDatasource(t)
+			mockStorage.On("AllByUserId", mock.Anything, 1).Return(dsPayments, nil)
+
+			payments, err := NewPaymentsService(mockStorage, PaymentMethodsConfig{}, fake.NewPaymentGateway()).GetUserPayments(ctx, 1, tt.filter)
+
+			assert.NoError(t, err, "Expected no error but got one")
+			ids := make([]int, len(payments))
+			for i, payment := range payments {
+				ids[i] = payment.Id
+			}
+			assert.Equal(t, tt.expectedIDs, ids, "Unexpected payments")
+		})
+	}
+
+	t.Run("User Required", func(t *testing.T) {
+		_, err := NewPaymentsService(mocks.NewPaymentsDatasource(t), PaymentMethodsConfig{}, fake.NewPaymentGateway()).GetUserPayments(ctx, 0, models.PaymentFilter{})
+		assert.ErrorIs(t, err, ErrUserRequired, "Error mismatch")
+	})
+}
+
+func TestStorePayment_PaymentMethods(t *testing.T) {
+
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	methods := PaymentMethodsConfig{
+		common.CreditCard: {FixedFee: common.NewMoney(0.25), PercentFee: 1.4, MinAmount: common.NewMoney(1), MaxAmount: common.NewMoney(5000)},
+	}
+
+	tests := []struct {
+		name        string
+		payment     models.Payment
+		expectedFee common.Money
+		expectedErr string
+	}{
+		{
+			name:        "fee of the method is charged",
+			payment:     models.Payment{Amount: common.NewMoney(100), Method: common.CreditCard},
+			expectedFee: common.NewMoney(1.65),
+		},
+		{
+			name:    "method without a policy is free",
+			payment: models.Payment{Amount: common.NewMoney(100), Method: common.PayPal},
+		},
+		{
+			name:    "negative adjustment is neither limited nor charged",
+			payment: models.Payment{Amount: common.NewMoney(-20), Method: common.CreditCard, Fee: common.NewMoney(3)},
+		},
+		{
+			name:        "amount below the minimum",
+			payment:     models.Payment{Amount: common.NewMoney(0.5), Method: common.CreditCard},
+			expectedErr: "payment amount outside the limits of its method: 0.50 by CreditCard, at least 1.00",
+		},
+		{
+			name:        "amount above the maximum",
+			payment:     models.Payment{Amount: common.NewMoney(5000.01), Method: common.CreditCard},
+			expectedErr: "payment amount outside the limits of its method: 5000.01 by CreditCard, at most 5000.00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := mocks.NewPaymentsDatasource(t)
+			if tt.expectedErr == "" {
+				mockStorage.On("Create", mock.Anything, mock.Anything).Return(
+					func(_ context.Context, payment dsmodels.Payment) (dsmodels.Payment, error) {
+						payment.Id = 1
+						return payment, nil
+					})
+			}
+			tt.payment.User = &models.User{ID: 1}
+			tt.payment.Order = &models.Order{ID: 1}
+
+			service := NewPaymentsService(mockStorage, methods, fake.NewPaymentGateway())
+			result, err := service.StorePayment(ctx, tt.payment)
+
+			if tt.expectedErr != "" {
+				assert.ErrorIs(t, err, ErrPaymentLimit)
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedFee, result.Fee)
+			assert.Equal(t, common.PaymentCaptured, result.Status)
+			assert.Equal(t, tt.payment.Amount.Sign() > 0, result.TransactionId != "", "expected only positive amounts to be charged")
+		})
+	}
+}
+
+func TestNewPaymentMethodsConfig(t *testing.T) {
+	t.Setenv("FP_KATA_PAYMENT_METHODS", `{"CreditCard":{"fixed_fee":0.25,"percent_fee":1.4,"min_amount":1,"max_amount":5000}}`)
+	config, err := NewPaymentMethodsConfig()
+	assert.NoError(t, err, "unexpected error reading the policies")
+	assert.Equal(t, PaymentMethodsConfig{
+		common.CreditCard: {FixedFee: common.NewMoney(0.25), PercentFee: 1.4, MinAmount: common.NewMoney(1), MaxAmount: common.NewMoney(5000)},
+	}, config, "expected the policies to be read from the environment")
+
+	t.Setenv("FP_KATA_PAYMENT_METHODS", "")
+	config, err = NewPaymentMethodsConfig()
+	assert.NoError(t, err, "unexpected error without policies")
+	assert.Equal(t, PaymentMethodsConfig{}, config, "expected no policies")
+
+	t.Setenv("FP_KATA_PAYMENT_METHODS", "free")
+	_, err = NewPaymentMethodsConfig()
+	assert.ErrorContains(t, err, "FP_KATA_PAYMENT_METHODS", "expected policies that cannot be parsed to fail")
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"fp_kata/common/utils"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/models"
+)
+
+const compProductsService = "ProductsService"
+
+type ProductsService interface {
+	CreateProduct(ctx context.Context, product models.Product) (*models.Product, error)
+	GetProduct(ctx context.Context, id int) (*models.Product, error)
+	UpdateProduct(ctx context.Context, product models.Product) (*models.Product, error)
+	DeleteProduct(ctx context.Context, id int) error
+	SearchProducts(ctx context.Context, text string) ([]*models.Product, error)
+}
+
+type productsService struct {
+	storage datasources.ProductsDatasource
+}
+
+func NewProductsService(storage datasources.ProductsDatasource) ProductsService {
+	return &productsService{storage: storage}
+}
+
+func (service *productsService) CreateProduct(ctx context.Context, product models.Product) (*models.Product, error) {
+	utils.LogAction(ctx, compProductsService, "CreateProduct")
+
+	product.ID = 0
+	dsProduct, err := service.storage.Create(ctx, product.ToDSModel())
+	if err != nil {
+		return nil, err
+	}
+	return models.MapToProduct(dsProduct), nil
+}
+
+func (service *productsService) GetProduct(ctx context.Context, id int) (*models.Product, error) {
+	utils.LogAction(ctx, compProductsService, "GetProduct")
+
+	dsProduct, err := service.storage.Read(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return models.MapToProduct(dsProduct), nil
+}
+
+func (service *productsService) UpdateProduct(ctx context.Context, product models.Product) (*models.Product, error) {
+	utils.LogAction(ctx, compProductsService, "UpdateProduct")
+
+	dsProduct, err := service.storage.Update(ctx, product.ToDSModel())
+	if err != nil {
+		return nil, err
+	}
+	return models.MapToProduct(dsProduct), nil
+}
+
+func (service *productsService) DeleteProduct(ctx context.Context, id int) error {
+	utils.LogAction(ctx, compProductsService, "DeleteProduct")
+
+	return service.storage.Delete(ctx, id)
+}
+
+func (service *productsService) SearchProducts(ctx context.Context, text string) ([]*models.Product, error) {
+	utils.LogAction(ctx, compProductsService, "SearchProducts")
+
+	dsProducts, err := service.storage.Search(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	products := make([]*models.Product, len(dsProducts))
+	for i, dsProduct := range dsProducts {
+		products[i] = models.MapToProduct(dsProduct)
+	}
+	return products, nil
+}
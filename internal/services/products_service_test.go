@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/internal/datasources"
+	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/internal/models"
+	"fp_kata/mocks"
+	"fp_kata/pkg/log"
+	zlog "github.com/rs/zerolog/log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProductsService_CreateProduct(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	tests := []struct {
+		name      string
+		product   models.Product
+		mockSetup func(*mocks.ProductsDatasource)
+		validate  func(*testing.T, *models.Product, error)
+	}{
+		{
+			name:    "product created",
+			product: models.Product{ID: 7, Name: "Apples", Price: common.NewMoney(2.5), WeightUnit: common.Kilogram},
+			mockSetup: func(mockStorage *mocks.ProductsDatasource) {
+				mockStorage.On("Create", mock.Anything, dsmodels.Product{Name: "Apples", Price: common.NewMoney(2.5), WeightUnit: common.Kilogram}).
+					Return(dsmodels.Product{ID: 1, Name: "Apples", Price: common.NewMoney(2.5), WeightUnit: common.Kilogram}, nil)
+			},
+			validate: func(t *testing.T, result *models.Product, err error) {
+				assert.NoError(t, err, "Expected no error but got one")
+				assert.Equal(t, &models.Product{ID: 1, Name: "Apples", Price: common.NewMoney(2.5), WeightUnit: common.Kilogram}, result, "Product mismatch")
+			},
+		},
+		{
+			name:    "storage error",
+			product: models.Product{Name: "Apples", Price: common.NewMoney(2.5)},
+			mockSetup: func(mockStorage *mocks.ProductsDatasource) {
+				mockStorage.On("Create", mock.Anything, mock.Anything).Return(dsmodels.Product{}, errors.New("write failed"))
+			},
+			validate: func(t *testing.T, result *models.Product, err error) {
+				assert.EqualError(t, err, "write failed", "Error message mismatch")
+				assert.Nil(t, result, "Expected result to be nil on error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := mocks.NewProductsDatasource(t)
+			tt.mockSetup(mockStorage)
+
+			service := NewProductsService(mockStorage)
+			result, err := service.CreateProduct(ctx, tt.product)
+
+			tt.validate(t, result, err)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProductsService_GetProduct(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	tests := []struct {
+		name      string
+		id        int
+		mockSetup func(*mocks.ProductsDatasource)
+		validate  func(*testing.T, *models.Product, error)
+	}{
+		{
+			name: "product found",
+			id:   1,
+			mockSetup: func(mockStorage *mocks.ProductsDatasource) {
+				mockStorage.On("Read", mock.Anything, 1).Return(dsmodels.Product{ID: 1, Name: "Bread", Price: common.NewMoney(3.2)}, nil)
+			},
+			validate: func(t *testing.T, result *models.Product, err error) {
+				assert.NoError(t, err, "Expected no error but got one")
+				assert.Equal(t, &models.Product{ID: 1, Name: "Bread", Price: common.NewMoney(3.2)}, result, "Product mismatch")
+			},
+		},
+		{
+			name: "product not found",
+			id:   2,
+			mockSetup: func(mockStorage *mocks.ProductsDatasource) {
+				mockStorage.On("Read", mock.Anything, 2).Return(dsmodels.Product{}, fmt.Errorf("%w: %d", datasources.ErrProductNotFound, 2))
+			},
+			validate: func(t *testing.T, result *models.Product, err error) {
+				assert.ErrorIs(t, err, datasources.ErrProductNotFound, "Expected a not found error")
+				assert.Nil(t, result, "Expected result to be nil on error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := mocks.NewProductsDatasource(t)
+			tt.mockSetup(mockStorage)
+
+			service := NewProductsService(mockStorage)
+			result, err := service.GetProduct(ctx, tt.id)
+
+			tt.validate(t, result, err)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProductsService_UpdateAndDeleteProduct(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	mockStorage := mocks.NewProductsDatasource(t)
+	mockStorage.On("Update", mock.Anything, dsmodels.Product{ID: 1, Name: "Bread", Price: common.NewMoney(3.5)}).
+		Return(dsmodels.Product{ID: 1, Name: "Bread", Price: common.NewMoney(3.5)}, nil)
+	mockStorage.On("Delete", mock.Anything, 1).Return(nil)
+	mockStorage.On("Delete", mock.Anything, 2).Return(fmt.Errorf("%w: %d", datasources.ErrProductNotFound, 2))
+
+	service := NewProductsService(mockStorage)
+
+	updated, err := service.UpdateProduct(ctx, models.Product{ID: 1, Name: "Bread", Price: common.NewMoney(3.5)})
+	assert.NoError(t, err, "Expected no error but got one")
+	assert.Equal(t, common.NewMoney(3.5), updated.Price, "Price mismatch")
+
+	assert.NoError(t, service.DeleteProduct(ctx, 1), "Expected no error deleting a product")
+	assert.ErrorIs(t, service.DeleteProduct(ctx, 2), datasources.ErrProductNotFound, "Expected a not found error")
+}
+
+func TestProductsService_SearchProducts(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+
+	tests := []struct {
+		name      string
+		text      string
+		mockSetup func(*mocks.ProductsDatasource)
+		validate  func(*testing.T, []*models.Product, error)
+	}{
+		{
+			name: "products found",
+			text: "apple",
+			mockSetup: func(mockStorage *mocks.ProductsDatasource) {
+				mockStorage.On("Search", mock.Anything, "apple").Return([]dsmodels.Product{
+					{ID: 1, Name: "Apples", Price: common.NewMoney(2.5)},
+					{ID: 3, Name: "Apple juice", Price: common.NewMoney(1.9)},
+				}, nil)
+			},
+			validate: func(t *testing.T, result []*models.Product, err error) {
+				assert.NoError(t, err, "Expected no error but got one")
+				assert.Len(t, result, 2, "Expected 2 products")
+				assert.Equal(t, "Apple juice", result[1].Name, "Second product name mismatch")
+			},
+		},
+		{
+			name: "storage error",
+			text: "",
+			mockSetup: func(mockStorage *mocks.ProductsDatasource) {
+				mockStorage.On("Search", mock.Anything, "").Return(nil, errors.New("read failed"))
+			},
+			validate: func(t *testing.T, result []*models.Product, err error) {
+				assert.EqualError(t, err, "read failed", "Error message mismatch")
+				assert.Nil(t, result, "Expected result to be nil on error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := mocks.NewProductsDatasource(t)
+			tt.mockSetup(mockStorage)
+
+			service := NewProductsService(mockStorage)
+			result, err := service.SearchProducts(ctx, tt.text)
+
+			tt.validate(t, result, err)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
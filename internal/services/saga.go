@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"errors"
+)
+
+// saga keeps an operation that spans several stores all-or-nothing: every completed step registers a
+// compensating action, and when a later step fails the completed steps are undone in reverse order.
+type saga struct {
+	compensations []func(ctx context.Context) error
+}
+
+// onRollback registers the action that undoes the step just completed.
+func (s *saga) onRollback(compensation func(ctx context.Context) error) {
+	s.compensations = append(s.compensations, compensation)
+}
+
+// rollback undoes the completed steps, latest first, and returns the cause of the failure.
+// All compensations are attempted even when one of them fails, their errors are joined to the cause.
+func (s *saga) rollback(ctx context.Context, cause error) error {
+	errs := []error{cause}
+	for i := len(s.compensations) - 1; i >= 0; i-- {
+		if err := s.compensations[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.compensations = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaga_Rollback(t *testing.T) {
+	tests := []struct {
+		name          string
+		failing       map[int]error
+		expectedOrder []int
+		expectedErr   string
+	}{
+		{
+			name:          "steps are undone in reverse order",
+			expectedOrder: []int{3, 2, 1},
+			expectedErr:   "step failed",
+		},
+		{
+			name:          "failing compensations do not stop the rollback",
+			failing:       map[int]error{2: errors.New("undo 2 failed"), 1: errors.New("undo 1 failed")},
+			expectedOrder: []int{3, 2, 1},
+			expectedErr:   "step failed\nundo 2 failed\nundo 1 failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var undone []int
+			placement := &saga{}
+			for step := 1; step <= 3; step++ {
+				placement.onRollback(func(ctx context.Context) error {
+					undone = append(undone, step)
+					return test.failing[step]
+				})
+			}
+
+			cause := errors.New("step failed")
+			err := placement.rollback(context.Background(), cause)
+
+			assert.ErrorIs(t, err, cause, "expected the cause to be returned")
+			assert.EqualError(t, err, test.expectedErr, "unexpected error message")
+			assert.Equal(t, test.expectedOrder, undone, "unexpected rollback order")
+
+			// a saga is rolled back only once
+			assert.ErrorIs(t, placement.rollback(context.Background(), cause), cause, "expected the cause to be returned")
+			assert.Len(t, undone, 3, "expected no step to be undone twice")
+		})
+	}
+}
@@ -2,14 +2,21 @@ package services
 
 import (
 	"context"
-	"errors"
+	"fp_kata/common"
 	"fp_kata/common/utils"
 	"fp_kata/internal/datasources"
 	"fp_kata/internal/models"
+	"fp_kata/pkg/fp"
 )
 
 const compUsersService = "UsersService"
 
+// ErrUserNotFound is returned when no user with the requested id exists.
+var ErrUserNotFound = common.NewDomainError(common.NotFound, "user_not_found", "no user found for id")
+
+// ErrUserStorageFull is returned when a user signs up but no further user can be stored.
+var ErrUserStorageFull = common.NewDomainError(common.Unavailable, "user_storage_full", "user storage is full")
+
 type UsersService interface {
 	GetUserByID(ctx context.Context, id int) (*models.User, error)
 	SignUp(ctx context.Context, user models.User) (*models.User, error)
@@ -30,27 +37,19 @@ func NewUsersService(storage datasources.UsersDatasource, authService AuthServic
 func (us *usersService) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	utils.LogAction(ctx, compUsersService, "GetUserByID")
 
-	dsUser, exists := us.storage.Read(ctx, id)
-	if !exists {
-		return nil, errors.New("no user found for id")
-	}
-	user := models.MapToUser(dsUser)
-	return user, nil
+	dsUser := fp.OptionOf(us.storage.Read(ctx, id)).OkOr(ErrUserNotFound)
+	return fp.Map(dsUser, models.MapToUser).Get()
 }
 
 func (us *usersService) SignUp(ctx context.Context, user models.User) (*models.User, error) {
 	utils.LogAction(ctx, compUsersService, "SignUp")
 
-	dsUser := user.ToDSModel()
-	createdDsUser, created := us.storage.Create(ctx, *dsUser)
-	if !created {
-		return nil, errors.New("user storage is full")
-	}
-	createdUser := models.MapToUser(createdDsUser)
-	_, err := us.authService.GenerateAuthToken(ctx, *createdUser)
-	if err != nil {
-		return nil, err
-	}
+	createdDsUser := fp.OptionOf(us.storage.Create(ctx, *user.ToDSModel())).OkOr(ErrUserStorageFull)
+	createdUser := fp.Map(createdDsUser, models.MapToUser)
 
-	return createdUser, nil
+	// every user is signed in right away
+	return createdUser.Check(func(createdUser *models.User) error {
+		_, err := us.authService.GenerateAuthToken(ctx, *createdUser)
+		return err
+	}).Get()
 }
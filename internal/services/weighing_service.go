@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/common/utils"
+	"fp_kata/internal/models"
+	"math"
+	"os"
+	"strconv"
+)
+
+const compWeighingService = "WeighingService"
+
+const (
+	// defaultWeighingTolerance accepts actual weights up to 10% above or below the estimate.
+	defaultWeighingTolerance = 0.1
+	// toleranceEpsilon absorbs floating point noise for weights right at the tolerance bound.
+	toleranceEpsilon = 1e-9
+)
+
+var (
+	ErrOrderNotWeighable    = common.NewDomainError(common.Conflict, "order_not_weighable", "order can no longer be weighed")
+	ErrInvalidWeighing      = common.NewDomainError(common.Unprocessable, "invalid_weighing", "invalid weighing")
+	ErrWeightOutOfTolerance = common.NewDomainError(common.Unprocessable, "weight_out_of_tolerance", "weight outside of tolerance")
+)
+
+type WeighingService interface {
+	WeighOrder(ctx context.Context, userId int, id int, weights []models.LineWeight) (*models.Order, error)
+}
+
+// WeighingConfig configures the post-weighing adjustment of orders.
+type WeighingConfig struct {
+	// Tolerance is the largest accepted deviation of an actual weight from its estimate, relative to the estimate.
+	Tolerance float64
+}
+
+// NewWeighingConfig reads the weighing tolerance from FP_KATA_WEIGHING_TOLERANCE, for example 0.05 for 5%.
+func NewWeighingConfig() WeighingConfig {
+	config := WeighingConfig{Tolerance: defaultWeighingTolerance}
+	if tolerance, err := strconv.ParseFloat(os.Getenv("FP_KATA_WEIGHING_TOLERANCE"), 64); err == nil && tolerance >= 0 {
+		config.Tolerance = tolerance
+	}
+	return config
+}
+
+type weighingService struct {
+	config        WeighingConfig
+	ordersService OrdersService
+}
+
+func NewWeighingService(config WeighingConfig, ordersService OrdersService) WeighingService {
+	return &weighingService{
+		config:        config,
+		ordersService: ordersService,
+	}
+}
+
+// WeighOrder records the actual weights of weighted order lines and recalculates the order price.
+// Settled orders are charged the difference to a higher price with the payment method of the last payment
+// of the order. Any amount paid beyond the new price is refunded with the payment gateway once the weighed
+// order is stored, partially paid orders keep their payments as long as they do not exceed the new price.
+func (service *weighingService) WeighOrder(ctx context.Context, userId int, id int, weights []models.LineWeight) (*models.Order, error) {
+	utils.LogAction(ctx, compWeighingService, "WeighOrder")
+
+	order, err := service.ordersService.GetOrder(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != common.Pending && order.Status != common.Paid {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotWeighable, order.Status)
+	}
+
+	wasSettled := order.Balance().Sign() <= 0
+	for _, weight := range weights {
+		if err := service.weighLine(order, weight); err != nil {
+			return nil, err
+		}
+	}
+	order.ApplyLines()
+
+	// settled orders are charged the higher price, any amount paid beyond the new price is refunded
+	adjustment := common.Money{}
+	if balance := order.Balance(); balance.Sign() < 0 || (balance.Sign() > 0 && wasSettled) {
+		adjustment = balance
+	}
+	if adjustment.Sign() > 0 && len(order.Payments) > 0 {
+		lastPayment := order.Payments[len(order.Payments)-1]
+		order.Payments = append(order.Payments, &models.Payment{
+			Amount: adjustment,
+			Method: lastPayment.Method,
+			User:   order.User,
+		})
+	}
+	var refunds []*models.Refund
+	if adjustment.Sign() < 0 {
+		if refunds, err = planRefunds(order, adjustment.Neg()); err != nil {
+			return nil, err
+		}
+	}
+
+	weighedOrder, err := service.ordersService.StoreOrder(ctx, userId, *order)
+	if err != nil {
+		return nil, err
+	}
+	if len(refunds) == 0 {
+		return weighedOrder, nil
+	}
+
+	// Refunds given back by the gateway cannot be taken back, so they are only made once the weighed order is stored
+	for _, refund := range refunds {
+		storedRefund, err := service.ordersService.RefundOrder(ctx, userId, order.ID, *refund)
+		if err != nil {
+			return nil, err
+		}
+		*refund = *storedRefund
+	}
+	for i, payment := range weighedOrder.Payments {
+		payment.Refunds = order.Payments[i].Refunds
+	}
+	return weighedOrder, nil
+}
+
+// planRefunds plans the refunds of the amount paid beyond the new price of the order against the payments that
+// captured it, the latest payments first, and adds them to the payments so the order is stored settled.
+// Only payments in the currency of the order are refunded, so the refunds settle the order to the cent.
+// All refunds are planned before the order is stored, so an overpayment that cannot be refunded stores nothing.
+func planRefunds(order *models.Order, overpaid common.Money) ([]*models.Refund, error) {
+	var refunds []*models.Refund
+	var payments []*models.Payment
+	remaining := overpaid
+	for i := len(order.Payments) - 1; i >= 0 && remaining.Sign() > 0; i-- {
+		payment := order.Payments[i]
+		refundable := payment.RefundableAmount()
+		if payment.Conversion != nil || refundable.Sign() <= 0 {
+			continue
+		}
+		amount := refundable
+		if amount.Compare(remaining) > 0 {
+			amount = remaining
+		}
+		refunds = append(refunds, &models.Refund{PaymentId: payment.Id, Amount: amount, Reason: common.WeightAdjustment})
+		payments = append(payments, payment)
+		remaining = remaining.Sub(amount)
+	}
+	if remaining.Sign() > 0 {
+		return nil, fmt.Errorf("%w: %s paid beyond the price cannot be refunded", ErrInvalidWeighing, remaining)
+	}
+
+	// refunds are stored with the refund, not with the payment, so the planned refunds are not stored with the order
+	for i, refund := range refunds {
+		payments[i].Refunds = append(payments[i].Refunds, refund)
+	}
+	return refunds, nil
+}
+
+func (service *weighingService) weighLine(order *models.Order, weight models.LineWeight) error {
+	if weight.Index < 0 || weight.Index >= len(order.Lines) {
+		return fmt.Errorf("%w: order has no line %d", ErrInvalidWeighing, weight.Index)
+	}
+	line := order.Lines[weight.Index]
+	if !line.IsWeighted() {
+		return fmt.Errorf("%w: line %d is not priced by weight", ErrInvalidWeighing, weight.Index)
+	}
+	if weight.Weight <= 0 {
+		return fmt.Errorf("%w: weight of line %d must be positive", ErrInvalidWeighing, weight.Index)
+	}
+
+	actualWeight := weight.Weight
+	if weight.Unit != "" {
+		actualWeight = weight.Unit.Convert(weight.Weight, line.WeightUnit)
+	}
+
+	deviation := math.Abs(actualWeight-line.EstimatedWeight) / line.EstimatedWeight
+	if deviation > service.config.Tolerance+toleranceEpsilon {
+		return fmt.Errorf("%w: line %d weighs %g %s, estimated %g %s",
+			ErrWeightOutOfTolerance, weight.Index, actualWeight, line.WeightUnit, line.EstimatedWeight, line.WeightUnit)
+	}
+
+	line.Weigh(actualWeight)
+	return nil
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fp_kata/common"
+	"fp_kata/common/constants"
+	"fp_kata/common/utils"
+	"fp_kata/internal/datasources/dsmodels"
+	"fp_kata/internal/datasources/file"
+	"fp_kata/internal/datasources/yugabyte"
+	"fp_kata/internal/gateways/fake"
+	"fp_kata/internal/models"
+	"fp_kata/mocks"
+	"fp_kata/pkg/log"
+	zlog "github.com/rs/zerolog/log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWeighingService_WeighOrder(t *testing.T) {
+	log.InitLogger()
+	ctx := log.NewBackgroundContext(&zlog.Logger)
+	user := &models.User{ID: 1}
+
+	// storedOrder is a paid order of a piece line and a weighted line estimated at 2 kg for 3.00 per kg
+	storedOrder := func(status common.OrderStatus, payments ...*models.Payment) *models.Order {
+		order := &models.Order{
+			ID:       42,
+			User:     user,
+			Status:   status,
+			Payments: payments,
+			Lines: []*models.OrderLine{
+				models.NewOrderLine(101, 2, common.NewMoney(1.5)),
+				models.NewWeightedOrderLine(102, 1, common.NewMoney(3), common.Kilogram, 2),
+			},
+		}
+		order.ApplyLines()
+		return order
+	}
+	paid := func() *models.Payment {
+		return &models.Payment{Id: 7, Amount: common.NewMoney(9), Method: common.PayPal, User: user}
+	}
+	refundReturningRefund := func(ordersService *mocks.OrdersService, refund models.Refund) {
+		ordersService.On("RefundOrder", mock.Anything, 1, 42, refund).Return(
+			func(ctx context.Context, userId int, id int, refund models.Refund) (*models.Refund, error) {
+				refund.Id = 3
+				return &refund, nil
+			}).Once()
+	}
+	storeReturningOrder := func(ordersService *mocks.OrdersService, matches func(order models.Order) bool) {
+		ordersService.On("StoreOrder", mock.Anything, 1, mock.MatchedBy(matches)).Return(
+			func(ctx context.Context, userId int, order models.Order) (*models.Order, error) {
+				return &order, nil
+			})
+	}
+
+	tests := []struct {
+		name       string
+		weights    []models.LineWeight
+		mockSetup  func(ordersService *mocks.OrdersService)
+		assertFunc func(t *testing.T, err error, order *models.Order)
+	}{
+		{
+			name:    "heavier than estimated charges the difference",
+			weights: []models.LineWeight{{Index: 1, Weight: 2.2}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Paid, paid()), nil)
+				storeReturningOrder(ordersService, func(order models.Order) bool {
+					return order.Price == common.NewMoney(9.6) && len(order.Payments) == 2
+				})
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.NoError(t, err, "expected no error")
+				assert.Equal(t, 2.2, order.Lines[1].ActualWeight, "expected the actual weight to be recorded")
+				assert.Equal(t, common.NewMoney(6.6), order.Lines[1].LineTotal, "expected the line total to follow the actual weight")
+				assert.Equal(t, &models.Payment{Amount: common.NewMoney(0.6), Method: common.PayPal, User: user}, order.Payments[1],
+					"expected the difference to be charged with the last payment method")
+			},
+		},
+		{
+			name:    "lighter than estimated refunds the difference",
+			weights: []models.LineWeight{{Index: 1, Weight: 1900, Unit: common.Gram}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Paid, paid()), nil)
+				refundReturningRefund(ordersService, models.Refund{PaymentId: 7, Amount: common.NewMoney(0.3), Reason: common.WeightAdjustment})
+				storeReturningOrder(ordersService, func(order models.Order) bool {
+					return order.Price == common.NewMoney(8.7) && len(order.Payments) == 1
+				})
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.NoError(t, err, "expected no error")
+				assert.InDelta(t, 1.9, order.Lines[1].ActualWeight, 1e-9, "expected the weight to be converted to the unit of the line")
+				assert.Equal(t, common.NewMoney(0.3), order.AmountRefunded(), "expected the difference to be refunded against the payment")
+				assert.Equal(t, common.Money{}, order.Balance(), "expected the order to be settled")
+			},
+		},
+		{
+			name:    "unpaid order only changes its price",
+			weights: []models.LineWeight{{Index: 1, Weight: 2.2}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Pending), nil)
+				storeReturningOrder(ordersService, func(order models.Order) bool {
+					return order.Price == common.NewMoney(9.6) && len(order.Payments) == 0
+				})
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.NoError(t, err, "expected no error")
+			},
+		},
+		{
+			name:    "partially paid order keeps its payments",
+			weights: []models.LineWeight{{Index: 1, Weight: 2.2}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Pending, &models.Payment{Id: 7, Amount: common.NewMoney(5), Method: common.PayPal, User: user}), nil)
+				storeReturningOrder(ordersService, func(order models.Order) bool {
+					return order.Price == common.NewMoney(9.6) && len(order.Payments) == 1
+				})
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.NoError(t, err, "expected no error")
+				assert.Equal(t, common.NewMoney(4.6), order.Balance(), "expected the higher price to stay outstanding")
+			},
+		},
+		{
+			name:    "partially paid order is refunded what exceeds the new price",
+			weights: []models.LineWeight{{Index: 1, Weight: 1.8}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Pending, &models.Payment{Id: 7, Amount: common.NewMoney(8.5), Method: common.PayPal, User: user}), nil)
+				refundReturningRefund(ordersService, models.Refund{PaymentId: 7, Amount: common.NewMoney(0.1), Reason: common.WeightAdjustment})
+				storeReturningOrder(ordersService, func(order models.Order) bool {
+					return order.Price == common.NewMoney(8.4) && len(order.Payments) == 1
+				})
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.NoError(t, err, "expected no error")
+				assert.Equal(t, common.NewMoney(0.1), order.Payments[0].Refunds[0].Amount, "expected the overpaid amount to be refunded")
+				assert.Equal(t, common.Money{}, order.Balance(), "expected the order to be settled")
+			},
+		},
+		{
+			name:    "overpayment is refunded from the latest payments first",
+			weights: []models.LineWeight{{Index: 1, Weight: 1.8}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Paid,
+					&models.Payment{Id: 7, Amount: common.NewMoney(8.9), Method: common.PayPal, User: user},
+					&models.Payment{Id: 8, Amount: common.NewMoney(0.1), Method: common.CreditCard, User: user},
+				), nil)
+				refundReturningRefund(ordersService, models.Refund{PaymentId: 8, Amount: common.NewMoney(0.1), Reason: common.WeightAdjustment})
+				refundReturningRefund(ordersService, models.Refund{PaymentId: 7, Amount: common.NewMoney(0.5), Reason: common.WeightAdjustment})
+				storeReturningOrder(ordersService, func(order models.Order) bool {
+					return order.Price == common.NewMoney(8.4)
+				})
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.NoError(t, err, "expected no error")
+				assert.Equal(t, common.Money{}, order.Balance(), "expected the order to be settled")
+			},
+		},
+		{
+			name:    "overpayment that cannot be refunded",
+			weights: []models.LineWeight{{Index: 1, Weight: 1.8}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Paid, &models.Payment{Id: 7, Amount: common.NewMoney(9), Method: common.PayPal, User: user,
+					Currency: common.USD, Conversion: &models.ExchangeRate{From: common.USD, To: common.EUR, Rate: 1}}), nil)
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.ErrorIs(t, err, ErrInvalidWeighing, "expected payments in other currencies not to be refunded")
+				assert.Nil(t, order, "expected no order")
+			},
+		},
+		{
+			name:    "failed refund",
+			weights: []models.LineWeight{{Index: 1, Weight: 1.8}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Paid, paid()), nil)
+				storeReturningOrder(ordersService, func(order models.Order) bool {
+					return order.Price == common.NewMoney(8.4)
+				})
+				ordersService.On("RefundOrder", mock.Anything, 1, 42, mock.Anything).Return(nil, errors.New("gateway error"))
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.EqualError(t, err, "gateway error", "expected the error of the refund")
+				assert.Nil(t, order, "expected no order")
+			},
+		},
+		{
+			name:    "failed store refunds nothing",
+			weights: []models.LineWeight{{Index: 1, Weight: 1.8}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Paid, paid()), nil)
+				// a refund would fail the test, the mock does not expect RefundOrder
+				ordersService.On("StoreOrder", mock.Anything, 1, mock.Anything).Return(nil, errors.New("database unavailable"))
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.EqualError(t, err, "database unavailable", "expected the error of the store")
+				assert.Nil(t, order, "expected no order")
+			},
+		},
+		{
+			name:    "weight at the tolerance bound is accepted",
+			weights: []models.LineWeight{{Index: 1, Weight: 1.8}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Pending), nil)
+				storeReturningOrder(ordersService, func(order models.Order) bool { return true })
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.NoError(t, err, "expected no error")
+				assert.Equal(t, common.NewMoney(8.4), order.Price, "expected the price to follow the actual weight")
+			},
+		},
+		{
+			name:    "weight outside of tolerance",
+			weights: []models.LineWeight{{Index: 1, Weight: 2.5}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Paid, paid()), nil)
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.ErrorIs(t, err, ErrWeightOutOfTolerance, "expected tolerance error")
+				assert.EqualError(t, err, "weight outside of tolerance: line 1 weighs 2.5 kg, estimated 2 kg", "unexpected error message")
+				assert.Nil(t, order, "expected no order")
+			},
+		},
+		{
+			name:    "line not priced by weight",
+			weights: []models.LineWeight{{Index: 0, Weight: 1}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Paid, paid()), nil)
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.EqualError(t, err, "invalid weighing: line 0 is not priced by weight", "unexpected error message")
+			},
+		},
+		{
+			name:    "unknown line",
+			weights: []models.LineWeight{{Index: 5, Weight: 1}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Paid, paid()), nil)
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.EqualError(t, err, "invalid weighing: order has no line 5", "unexpected error message")
+			},
+		},
+		{
+			name:    "fulfilled order can no longer be weighed",
+			weights: []models.LineWeight{{Index: 1, Weight: 2}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(storedOrder(common.Fulfilled, paid()), nil)
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.ErrorIs(t, err, ErrOrderNotWeighable, "expected not weighable error")
+			},
+		},
+		{
+			name:    "order not found",
+			weights: []models.LineWeight{{Index: 1, Weight: 2}},
+			mockSetup: func(ordersService *mocks.OrdersService) {
+				ordersService.On("GetOrder", mock.Anything, 1, 42).Return(nil, errors.New("order not found"))
+			},
+			assertFunc: func(t *testing.T, err error, order *models.Order) {
+				assert.EqualError(t, err, "order not found", "expected storage error")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ordersService := mocks.NewOrdersService(t)
+			test.mockSetup(ordersService)
+
+			service := NewWeighingService(WeighingConfig{Tolerance: 0.1}, ordersService)
+
+			testCtx := context.WithValue(ctx, constants.AuthenticatedUserIdKey, user.ID)
+			testCtx = context.WithValue(testCtx, constants.AuthenticatedUserKey, user)
+
+			order, err := service.WeighOrder(testCtx, user.ID, 42, test.weights)
+			test.assertFunc(t, err, order)
+		})
+	}
+}
+
+func TestWeighingService_RefundsStoredOrder(t *testing.T) {
+	log.InitLogger()
+	user := &models.User{ID: 1}
+	ctx := context.WithValue(log.NewBackgroundContext(&zlog.Logger), constants.AuthenticatedUserKey, user)
+
+	productsService := mocks.NewProductsService(t)
+	productsService.On("GetProduct", mock.Anything, 1).Return(&models.Product{ID: 1, Price: common.NewMoney(3), WeightUnit: common.Kilogram}, nil)
+	inventoryStorage := file.NewInventoryStorage()
+	_, err := inventoryStorage.Save(ctx, dsmodels.Stock{ProductID: 1, OnHand: 5})
+	assert.NoError(t, err, "expected the stock to be set")
+	paymentsStorage := yugabyte.NewPaymentsStorage(utils.NewSequenceIDGenerator())
+	ordersService := NewOrdersService(file.NewOrdersStorage(), NewPaymentsService(paymentsStorage, PaymentMethodsConfig{}, fake.NewPaymentGateway()), NewAuthorizationService(),
+		productsService, NewInventoryService(inventoryStorage, productsService), utils.NewSequenceIDGenerator(), NewOrderNumberGenerator(OrderNumberConfig{}), noExchangeRates())
+
+	// 2 kg estimated for 3.00 per kg are paid in full
+	order, err := ordersService.StoreOrder(ctx, user.ID, models.Order{
+		User:     user,
+		Lines:    []*models.OrderLine{{ProductID: 1, Quantity: 1, WeightUnit: common.Kilogram, EstimatedWeight: 2}},
+		Payments: []*models.Payment{{Amount: common.NewMoney(6), Method: common.PayPal, User: user}},
+	})
+	assert.NoError(t, err, "expected the order to be stored")
+
+	weighed, err := NewWeighingService(WeighingConfig{Tolerance: 0.1}, ordersService).WeighOrder(ctx, user.ID, order.ID, []models.LineWeight{{Index: 0, Weight: 1.9}})
+	assert.NoError(t, err, "expected the order to be weighed")
+	assert.Equal(t, common.NewMoney(0.3), weighed.AmountRefunded(), "expected the overpayment to be refunded")
+	assert.Equal(t, common.Money{}, weighed.Balance(), "expected the weighed order to be settled")
+
+	stored, err := ordersService.GetOrder(ctx, user.ID, order.ID)
+	assert.NoError(t, err, "expected the order to be read")
+	assert.Equal(t, common.NewMoney(5.7), stored.Price, "expected the weighed price to be stored")
+	assert.Equal(t, []*models.Refund{weighed.Payments[0].Refunds[0]}, stored.Payments[0].Refunds, "expected the refund to be stored once")
+	assert.Equal(t, common.Money{}, stored.Balance(), "expected the stored order to be settled")
+}
+
+func TestNewWeighingConfig(t *testing.T) {
+	t.Setenv("FP_KATA_WEIGHING_TOLERANCE", "0.05")
+	assert.Equal(t, WeighingConfig{Tolerance: 0.05}, NewWeighingConfig(), "expected the tolerance to be read from the environment")
+
+	t.Setenv("FP_KATA_WEIGHING_TOLERANCE", "")
+	assert.Equal(t, WeighingConfig{Tolerance: defaultWeighingTolerance}, NewWeighingConfig(), "expected the default tolerance")
+}
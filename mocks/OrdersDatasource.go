@@ -4,7 +4,7 @@ package mocks
 
 import (
 	context "context"
-
+	datasources "fp_kata/internal/datasources"
 	dsmodels "fp_kata/internal/datasources/dsmodels"
 
 	mock "github.com/stretchr/testify/mock"
@@ -81,25 +81,77 @@ func (_m *OrdersDatasource) GetOrder(ctx context.Context, orderID int) (*dsmodel
 	return r0, r1
 }
 
-// InsertOrder provides a mock function with given fields: ctx, order
-func (_m *OrdersDatasource) InsertOrder(ctx context.Context, order dsmodels.Order) (*dsmodels.Order, error) {
-	ret := _m.Called(ctx, order)
+// GetOrderVersions provides a mock function with given fields: ctx, orderID
+func (_m *OrdersDatasource) GetOrderVersions(ctx context.Context, orderID int) ([]dsmodels.OrderVersion, error) {
+	ret := _m.Called(ctx, orderID)
+
+	var r0 []dsmodels.OrderVersion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]dsmodels.OrderVersion, error)); ok {
+		return rf(ctx, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []dsmodels.OrderVersion); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dsmodels.OrderVersion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertOrder provides a mock function with given fields: ctx, order, changedBy
+func (_m *OrdersDatasource) InsertOrder(ctx context.Context, order dsmodels.Order, changedBy int) (*dsmodels.Order, error) {
+	ret := _m.Called(ctx, order, changedBy)
 
 	var r0 *dsmodels.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, dsmodels.Order) (*dsmodels.Order, error)); ok {
-		return rf(ctx, order)
+	if rf, ok := ret.Get(0).(func(context.Context, dsmodels.Order, int) (*dsmodels.Order, error)); ok {
+		return rf(ctx, order, changedBy)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, dsmodels.Order) *dsmodels.Order); ok {
-		r0 = rf(ctx, order)
+	if rf, ok := ret.Get(0).(func(context.Context, dsmodels.Order, int) *dsmodels.Order); ok {
+		r0 = rf(ctx, order, changedBy)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*dsmodels.Order)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, dsmodels.Order) error); ok {
-		r1 = rf(ctx, order)
+	if rf, ok := ret.Get(1).(func(context.Context, dsmodels.Order, int) error); ok {
+		r1 = rf(ctx, order, changedBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueryOrdersForUser provides a mock function with given fields: ctx, userID, query
+func (_m *OrdersDatasource) QueryOrdersForUser(ctx context.Context, userID int, query datasources.OrdersQuery) (*datasources.OrdersPage, error) {
+	ret := _m.Called(ctx, userID, query)
+
+	var r0 *datasources.OrdersPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, datasources.OrdersQuery) (*datasources.OrdersPage, error)); ok {
+		return rf(ctx, userID, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, datasources.OrdersQuery) *datasources.OrdersPage); ok {
+		r0 = rf(ctx, userID, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*datasources.OrdersPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, datasources.OrdersQuery) error); ok {
+		r1 = rf(ctx, userID, query)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -107,25 +159,25 @@ func (_m *OrdersDatasource) InsertOrder(ctx context.Context, order dsmodels.Orde
 	return r0, r1
 }
 
-// UpdateOrder provides a mock function with given fields: ctx, order
-func (_m *OrdersDatasource) UpdateOrder(ctx context.Context, order dsmodels.Order) (*dsmodels.Order, error) {
-	ret := _m.Called(ctx, order)
+// UpdateOrder provides a mock function with given fields: ctx, order, changedBy
+func (_m *OrdersDatasource) UpdateOrder(ctx context.Context, order dsmodels.Order, changedBy int) (*dsmodels.Order, error) {
+	ret := _m.Called(ctx, order, changedBy)
 
 	var r0 *dsmodels.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, dsmodels.Order) (*dsmodels.Order, error)); ok {
-		return rf(ctx, order)
+	if rf, ok := ret.Get(0).(func(context.Context, dsmodels.Order, int) (*dsmodels.Order, error)); ok {
+		return rf(ctx, order, changedBy)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, dsmodels.Order) *dsmodels.Order); ok {
-		r0 = rf(ctx, order)
+	if rf, ok := ret.Get(0).(func(context.Context, dsmodels.Order, int) *dsmodels.Order); ok {
+		r0 = rf(ctx, order, changedBy)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*dsmodels.Order)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, dsmodels.Order) error); ok {
-		r1 = rf(ctx, order)
+	if rf, ok := ret.Get(1).(func(context.Context, dsmodels.Order, int) error); ok {
+		r1 = rf(ctx, order, changedBy)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -4,6 +4,9 @@ package mocks
 
 import (
 	context "context"
+	common "fp_kata/common"
+	datasources "fp_kata/internal/datasources"
+	filters "fp_kata/internal/filters"
 	models "fp_kata/internal/models"
 
 	mock "github.com/stretchr/testify/mock"
@@ -14,6 +17,86 @@ type OrdersService struct {
 	mock.Mock
 }
 
+// AddPayment provides a mock function with given fields: ctx, userId, id, payment
+func (_m *OrdersService) AddPayment(ctx context.Context, userId int, id int, payment models.Payment) (*models.Order, error) {
+	ret := _m.Called(ctx, userId, id, payment)
+
+	var r0 *models.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, models.Payment) (*models.Order, error)); ok {
+		return rf(ctx, userId, id, payment)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, models.Payment) *models.Order); ok {
+		r0 = rf(ctx, userId, id, payment)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, models.Payment) error); ok {
+		r1 = rf(ctx, userId, id, payment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelOrder provides a mock function with given fields: ctx, userId, id
+func (_m *OrdersService) CancelOrder(ctx context.Context, userId int, id int) error {
+	ret := _m.Called(ctx, userId, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, userId, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DiffOrderVersions provides a mock function with given fields: ctx, userId, id, from, to
+func (_m *OrdersService) DiffOrderVersions(ctx context.Context, userId int, id int, from int, to int) ([]models.FieldChange, error) {
+	ret := _m.Called(ctx, userId, id, from, to)
+
+	var r0 []models.FieldChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, int) ([]models.FieldChange, error)); ok {
+		return rf(ctx, userId, id, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, int) []models.FieldChange); ok {
+		r0 = rf(ctx, userId, id, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.FieldChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int, int) error); ok {
+		r1 = rf(ctx, userId, id, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportOrders provides a mock function with given fields: ctx, userId, predicate, sorts, export
+func (_m *OrdersService) ExportOrders(ctx context.Context, userId int, predicate filters.Predicate, sorts []datasources.OrderSort, export func(*models.Order) error) error {
+	ret := _m.Called(ctx, userId, predicate, sorts, export)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, filters.Predicate, []datasources.OrderSort, func(*models.Order) error) error); ok {
+		r0 = rf(ctx, userId, predicate, sorts, export)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetOrder provides a mock function with given fields: ctx, userId, id
 func (_m *OrdersService) GetOrder(ctx context.Context, userId int, id int) (*models.Order, error) {
 	ret := _m.Called(ctx, userId, id)
@@ -40,6 +123,58 @@ func (_m *OrdersService) GetOrder(ctx context.Context, userId int, id int) (*mod
 	return r0, r1
 }
 
+// GetOrderHistory provides a mock function with given fields: ctx, userId, id
+func (_m *OrdersService) GetOrderHistory(ctx context.Context, userId int, id int) ([]*models.OrderVersion, error) {
+	ret := _m.Called(ctx, userId, id)
+
+	var r0 []*models.OrderVersion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]*models.OrderVersion, error)); ok {
+		return rf(ctx, userId, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []*models.OrderVersion); ok {
+		r0 = rf(ctx, userId, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.OrderVersion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userId, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderVersion provides a mock function with given fields: ctx, userId, id, version
+func (_m *OrdersService) GetOrderVersion(ctx context.Context, userId int, id int, version int) (*models.OrderVersion, error) {
+	ret := _m.Called(ctx, userId, id, version)
+
+	var r0 *models.OrderVersion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) (*models.OrderVersion, error)); ok {
+		return rf(ctx, userId, id, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) *models.OrderVersion); ok {
+		r0 = rf(ctx, userId, id, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.OrderVersion)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, userId, id, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetOrders provides a mock function with given fields: ctx, userId
 func (_m *OrdersService) GetOrders(ctx context.Context, userId int) ([]*models.Order, error) {
 	ret := _m.Called(ctx, userId)
@@ -66,6 +201,32 @@ func (_m *OrdersService) GetOrders(ctx context.Context, userId int) ([]*models.O
 	return r0, r1
 }
 
+// GetOrdersPage provides a mock function with given fields: ctx, userId, predicate, query
+func (_m *OrdersService) GetOrdersPage(ctx context.Context, userId int, predicate filters.Predicate, query datasources.OrdersQuery) (*models.OrdersPage, error) {
+	ret := _m.Called(ctx, userId, predicate, query)
+
+	var r0 *models.OrdersPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, filters.Predicate, datasources.OrdersQuery) (*models.OrdersPage, error)); ok {
+		return rf(ctx, userId, predicate, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, filters.Predicate, datasources.OrdersQuery) *models.OrdersPage); ok {
+		r0 = rf(ctx, userId, predicate, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.OrdersPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, filters.Predicate, datasources.OrdersQuery) error); ok {
+		r1 = rf(ctx, userId, predicate, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetOrdersWithFilter provides a mock function with given fields: ctx, userId, filter
 func (_m *OrdersService) GetOrdersWithFilter(ctx context.Context, userId int, filter func(*models.Order) bool) ([]*models.Order, error) {
 	ret := _m.Called(ctx, userId, filter)
@@ -92,6 +253,84 @@ func (_m *OrdersService) GetOrdersWithFilter(ctx context.Context, userId int, fi
 	return r0, r1
 }
 
+// GetRefunds provides a mock function with given fields: ctx, userId, id
+func (_m *OrdersService) GetRefunds(ctx context.Context, userId int, id int) ([]*models.Refund, error) {
+	ret := _m.Called(ctx, userId, id)
+
+	var r0 []*models.Refund
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]*models.Refund, error)); ok {
+		return rf(ctx, userId, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []*models.Refund); ok {
+		r0 = rf(ctx, userId, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Refund)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userId, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportOrders provides a mock function with given fields: ctx, userId, rows, options
+func (_m *OrdersService) ImportOrders(ctx context.Context, userId int, rows []*models.OrderImportRow, options models.OrderImportOptions) ([]*models.OrderImportResult, error) {
+	ret := _m.Called(ctx, userId, rows, options)
+
+	var r0 []*models.OrderImportResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, []*models.OrderImportRow, models.OrderImportOptions) ([]*models.OrderImportResult, error)); ok {
+		return rf(ctx, userId, rows, options)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, []*models.OrderImportRow, models.OrderImportOptions) []*models.OrderImportResult); ok {
+		r0 = rf(ctx, userId, rows, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.OrderImportResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, []*models.OrderImportRow, models.OrderImportOptions) error); ok {
+		r1 = rf(ctx, userId, rows, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundOrder provides a mock function with given fields: ctx, userId, id, refund
+func (_m *OrdersService) RefundOrder(ctx context.Context, userId int, id int, refund models.Refund) (*models.Refund, error) {
+	ret := _m.Called(ctx, userId, id, refund)
+
+	var r0 *models.Refund
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, models.Refund) (*models.Refund, error)); ok {
+		return rf(ctx, userId, id, refund)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, models.Refund) *models.Refund); ok {
+		r0 = rf(ctx, userId, id, refund)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Refund)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, models.Refund) error); ok {
+		r1 = rf(ctx, userId, id, refund)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // StoreOrder provides a mock function with given fields: ctx, userId, order
 func (_m *OrdersService) StoreOrder(ctx context.Context, userId int, order models.Order) (*models.Order, error) {
 	ret := _m.Called(ctx, userId, order)
@@ -118,6 +357,84 @@ func (_m *OrdersService) StoreOrder(ctx context.Context, userId int, order model
 	return r0, r1
 }
 
+// SummarizeOrders provides a mock function with given fields: ctx, userId, predicate, period
+func (_m *OrdersService) SummarizeOrders(ctx context.Context, userId int, predicate filters.Predicate, period models.SummaryPeriod) (*models.OrderSummary, error) {
+	ret := _m.Called(ctx, userId, predicate, period)
+
+	var r0 *models.OrderSummary
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, filters.Predicate, models.SummaryPeriod) (*models.OrderSummary, error)); ok {
+		return rf(ctx, userId, predicate, period)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, filters.Predicate, models.SummaryPeriod) *models.OrderSummary); ok {
+		r0 = rf(ctx, userId, predicate, period)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.OrderSummary)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, filters.Predicate, models.SummaryPeriod) error); ok {
+		r1 = rf(ctx, userId, predicate, period)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TransitionOrder provides a mock function with given fields: ctx, userId, id, status
+func (_m *OrdersService) TransitionOrder(ctx context.Context, userId int, id int, status common.OrderStatus) (*models.Order, error) {
+	ret := _m.Called(ctx, userId, id, status)
+
+	var r0 *models.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, common.OrderStatus) (*models.Order, error)); ok {
+		return rf(ctx, userId, id, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, common.OrderStatus) *models.Order); ok {
+		r0 = rf(ctx, userId, id, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, common.OrderStatus) error); ok {
+		r1 = rf(ctx, userId, id, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateOrder provides a mock function with given fields: ctx, userId, order
+func (_m *OrdersService) UpdateOrder(ctx context.Context, userId int, order models.Order) (*models.Order, error) {
+	ret := _m.Called(ctx, userId, order)
+
+	var r0 *models.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.Order) (*models.Order, error)); ok {
+		return rf(ctx, userId, order)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.Order) *models.Order); ok {
+		r0 = rf(ctx, userId, order)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.Order) error); ok {
+		r1 = rf(ctx, userId, order)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewOrdersService creates a new instance of OrdersService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewOrdersService(t interface {
@@ -4,7 +4,6 @@ package mocks
 
 import (
 	context "context"
-
 	dsmodels "fp_kata/internal/datasources/dsmodels"
 
 	mock "github.com/stretchr/testify/mock"
@@ -41,6 +40,32 @@ func (_m *PaymentsDatasource) AllByOrderId(ctx context.Context, paymentId int) (
 	return r0, r1
 }
 
+// AllByUserId provides a mock function with given fields: ctx, userId
+func (_m *PaymentsDatasource) AllByUserId(ctx context.Context, userId int) ([]dsmodels.Payment, error) {
+	ret := _m.Called(ctx, userId)
+
+	var r0 []dsmodels.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]dsmodels.Payment, error)); ok {
+		return rf(ctx, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []dsmodels.Payment); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dsmodels.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: ctx, payment
 func (_m *PaymentsDatasource) Create(ctx context.Context, payment dsmodels.Payment) (dsmodels.Payment, error) {
 	ret := _m.Called(ctx, payment)
@@ -65,6 +90,30 @@ func (_m *PaymentsDatasource) Create(ctx context.Context, payment dsmodels.Payme
 	return r0, r1
 }
 
+// CreateRefund provides a mock function with given fields: ctx, refund
+func (_m *PaymentsDatasource) CreateRefund(ctx context.Context, refund dsmodels.Refund) (dsmodels.Refund, error) {
+	ret := _m.Called(ctx, refund)
+
+	var r0 dsmodels.Refund
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, dsmodels.Refund) (dsmodels.Refund, error)); ok {
+		return rf(ctx, refund)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, dsmodels.Refund) dsmodels.Refund); ok {
+		r0 = rf(ctx, refund)
+	} else {
+		r0 = ret.Get(0).(dsmodels.Refund)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, dsmodels.Refund) error); ok {
+		r1 = rf(ctx, refund)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Delete provides a mock function with given fields: ctx, paymentId
 func (_m *PaymentsDatasource) Delete(ctx context.Context, paymentId int) error {
 	ret := _m.Called(ctx, paymentId)
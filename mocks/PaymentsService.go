@@ -14,6 +14,20 @@ type PaymentsService struct {
 	mock.Mock
 }
 
+// DeletePayment provides a mock function with given fields: ctx, id
+func (_m *PaymentsService) DeletePayment(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetPaymentByID provides a mock function with given fields: ctx, id
 func (_m *PaymentsService) GetPaymentByID(ctx context.Context, id int) (*models.Payment, error) {
 	ret := _m.Called(ctx, id)
@@ -66,6 +80,110 @@ func (_m *PaymentsService) GetPaymentsByOrder(ctx context.Context, orderId int)
 	return r0, r1
 }
 
+// GetUserPayment provides a mock function with given fields: ctx, userId, id
+func (_m *PaymentsService) GetUserPayment(ctx context.Context, userId int, id int) (*models.Payment, error) {
+	ret := _m.Called(ctx, userId, id)
+
+	var r0 *models.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (*models.Payment, error)); ok {
+		return rf(ctx, userId, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) *models.Payment); ok {
+		r0 = rf(ctx, userId, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userId, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserPayments provides a mock function with given fields: ctx, userId, filter
+func (_m *PaymentsService) GetUserPayments(ctx context.Context, userId int, filter models.PaymentFilter) ([]*models.Payment, error) {
+	ret := _m.Called(ctx, userId, filter)
+
+	var r0 []*models.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.PaymentFilter) ([]*models.Payment, error)); ok {
+		return rf(ctx, userId, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.PaymentFilter) []*models.Payment); ok {
+		r0 = rf(ctx, userId, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.PaymentFilter) error); ok {
+		r1 = rf(ctx, userId, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportPayment provides a mock function with given fields: ctx, payment
+func (_m *PaymentsService) ImportPayment(ctx context.Context, payment models.Payment) (*models.Payment, error) {
+	ret := _m.Called(ctx, payment)
+
+	var r0 *models.Payment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Payment) (*models.Payment, error)); ok {
+		return rf(ctx, payment)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Payment) *models.Payment); ok {
+		r0 = rf(ctx, payment)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Payment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.Payment) error); ok {
+		r1 = rf(ctx, payment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundPayment provides a mock function with given fields: ctx, refund
+func (_m *PaymentsService) RefundPayment(ctx context.Context, refund models.Refund) (*models.Refund, error) {
+	ret := _m.Called(ctx, refund)
+
+	var r0 *models.Refund
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Refund) (*models.Refund, error)); ok {
+		return rf(ctx, refund)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Refund) *models.Refund); ok {
+		r0 = rf(ctx, refund)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Refund)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.Refund) error); ok {
+		r1 = rf(ctx, refund)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // StorePayment provides a mock function with given fields: ctx, payment
 func (_m *PaymentsService) StorePayment(ctx context.Context, payment models.Payment) (*models.Payment, error) {
 	ret := _m.Called(ctx, payment)
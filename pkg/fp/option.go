@@ -0,0 +1,84 @@
+package fp
+
+// Option is a value that may be missing. The zero value is an Option without a value.
+type Option[T any] struct {
+	value T
+	ok    bool
+}
+
+// Some is the option of the value.
+func Some[T any](value T) Option[T] {
+	return Option[T]{value: value, ok: true}
+}
+
+// None is the option without a value.
+func None[T any]() Option[T] {
+	return Option[T]{}
+}
+
+// OptionOf is the option of a function returning a value and whether it exists, like OptionOf(storage.Read(ctx, id)).
+// The value is dropped when ok is false.
+func OptionOf[T any](value T, ok bool) Option[T] {
+	if !ok {
+		return None[T]()
+	}
+	return Some(value)
+}
+
+// Get returns the value of the option and whether it has one, the zero value of T when it has none.
+func (o Option[T]) Get() (T, bool) {
+	return o.value, o.ok
+}
+
+// IsSome reports whether the option has a value.
+func (o Option[T]) IsSome() bool {
+	return o.ok
+}
+
+// OrElse returns the value of the option and the fallback for an option without a value.
+func (o Option[T]) OrElse(fallback T) T {
+	if !o.ok {
+		return fallback
+	}
+	return o.value
+}
+
+// OkOr is the successful result of the value of the option, and the failed result of err when it has none.
+func (o Option[T]) OkOr(err error) Result[T] {
+	if !o.ok {
+		return Err[T](err)
+	}
+	return Ok(o.value)
+}
+
+// MapOption applies f to the value of the option, options without a value stay empty.
+func MapOption[T, U any](o Option[T], f func(T) U) Option[U] {
+	if !o.ok {
+		return None[U]()
+	}
+	return Some(f(o.value))
+}
+
+// FlatMapOption continues an option with a value with f, options without a value stay empty.
+func FlatMapOption[T, U any](o Option[T], f func(T) Option[U]) Option[U] {
+	if !o.ok {
+		return None[U]()
+	}
+	return f(o.value)
+}
+
+// FoldOption reduces the option to a single value, with onNone for an option without and onSome for an option with a value.
+func FoldOption[T, U any](o Option[T], onNone func() U, onSome func(T) U) U {
+	if !o.ok {
+		return onNone()
+	}
+	return onSome(o.value)
+}
+
+// ZipOption combines two options into an option of both values, it is empty when either option is.
+func ZipOption[T, U any](first Option[T], second Option[U]) Option[Pair[T, U]] {
+	if !first.ok || !second.ok {
+		return None[Pair[T, U]]()
+	}
+	return Some(Pair[T, U]{First: first.value, Second: second.value})
+}
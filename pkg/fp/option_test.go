@@ -0,0 +1,67 @@
+package fp
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOption_OptionOf(t *testing.T) {
+	prices := map[string]int{"apple": 3}
+	lookup := func(key string) (int, bool) {
+		value, ok := prices[key]
+		return value, ok
+	}
+
+	value, ok := OptionOf(lookup("apple")).Get()
+	assert.True(t, ok, "expected an option with a value")
+	assert.Equal(t, 3, value, "unexpected value")
+	assert.False(t, OptionOf(lookup("pear")).IsSome(), "expected an option without a value")
+	assert.Equal(t, None[int](), OptionOf(7, false), "expected the value to be dropped")
+}
+
+func TestOption_OrElse(t *testing.T) {
+	assert.Equal(t, 1, Some(1).OrElse(2), "expected the value of the option")
+	assert.Equal(t, 2, None[int]().OrElse(2), "expected the fallback of an empty option")
+}
+
+func TestOption_OkOr(t *testing.T) {
+	value, err := Some(1).OkOr(errTest).Get()
+	assert.NoError(t, err, "expected a successful result")
+	assert.Equal(t, 1, value, "unexpected value")
+
+	_, err = None[int]().OkOr(errTest).Get()
+	assert.ErrorIs(t, err, errTest, "expected an empty option to fail with the error")
+}
+
+func TestOption_Map(t *testing.T) {
+	assert.Equal(t, Some("42"), MapOption(Some(42), strconv.Itoa), "unexpected mapped option")
+	assert.Equal(t, None[string](), MapOption(None[int](), strconv.Itoa), "expected an empty option to stay empty")
+}
+
+func TestOption_FlatMap(t *testing.T) {
+	parse := func(s string) Option[int] {
+		value, err := strconv.Atoi(s)
+		return OptionOf(value, err == nil)
+	}
+
+	assert.Equal(t, Some(42), FlatMapOption(Some("42"), parse), "unexpected option")
+	assert.Equal(t, None[int](), FlatMapOption(Some("forty-two"), parse), "expected the empty option of the step")
+	assert.Equal(t, None[int](), FlatMapOption(None[string](), parse), "expected an empty option to stay empty")
+}
+
+func TestOption_Fold(t *testing.T) {
+	describe := func(o Option[int]) string {
+		return FoldOption(o, func() string { return "none" }, strconv.Itoa)
+	}
+
+	assert.Equal(t, "42", describe(Some(42)), "expected onSome to be applied")
+	assert.Equal(t, "none", describe(None[int]()), "expected onNone to be applied")
+}
+
+func TestOption_Zip(t *testing.T) {
+	assert.Equal(t, Some(Pair[int, string]{First: 1, Second: "one"}), ZipOption(Some(1), Some("one")), "unexpected zipped option")
+	assert.Equal(t, None[Pair[int, string]](), ZipOption(None[int](), Some("one")), "expected the zipped option to be empty")
+	assert.Equal(t, None[Pair[int, string]](), ZipOption(Some(1), None[string]()), "expected the zipped option to be empty")
+}
@@ -0,0 +1,115 @@
+// Package fp provides Result and Option, the types the services compose their steps with.
+// A chain of steps runs on the happy path until a step fails, the failure is passed on to the end
+// of the chain unchanged, so errors are handled once where the chain ends.
+package fp
+
+// Result is the outcome of a step, either a value or the error the step failed with.
+// The zero value is a successful result with the zero value of T.
+type Result[T any] struct {
+	value T
+	err   error
+}
+
+// Ok is the successful result of the value.
+func Ok[T any](value T) Result[T] {
+	return Result[T]{value: value}
+}
+
+// Err is the failed result of the error.
+func Err[T any](err error) Result[T] {
+	return Result[T]{err: err}
+}
+
+// Of is the result of a function returning a value and an error, like Of(strconv.Atoi(s)).
+// It fails when the error is not nil, the value is dropped then.
+func Of[T any](value T, err error) Result[T] {
+	if err != nil {
+		return Err[T](err)
+	}
+	return Ok(value)
+}
+
+// Get returns the value and the error of the result, the zero value of T for failed results.
+func (r Result[T]) Get() (T, error) {
+	if r.err != nil {
+		var zero T
+		return zero, r.err
+	}
+	return r.value, nil
+}
+
+// IsOk reports whether the result is successful.
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+// OrElse returns the value of a successful result and the fallback for a failed result.
+func (r Result[T]) OrElse(fallback T) T {
+	if r.err != nil {
+		return fallback
+	}
+	return r.value
+}
+
+// Check runs the check with the value of a successful result and fails with the error it returns.
+// Failed results are returned without running the check.
+func (r Result[T]) Check(check func(T) error) Result[T] {
+	if r.err != nil {
+		return r
+	}
+	if err := check(r.value); err != nil {
+		return Err[T](err)
+	}
+	return r
+}
+
+// MapErr replaces the error of a failed result with the error f returns for it.
+// Successful results are returned unchanged.
+func (r Result[T]) MapErr(f func(error) error) Result[T] {
+	if r.err == nil {
+		return r
+	}
+	return Err[T](f(r.err))
+}
+
+// Map applies f to the value of a successful result, failed results keep their error.
+func Map[T, U any](r Result[T], f func(T) U) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+	return Ok(f(r.value))
+}
+
+// FlatMap continues a successful result with the step f, failed results keep their error.
+func FlatMap[T, U any](r Result[T], f func(T) Result[U]) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+	return f(r.value)
+}
+
+// Fold reduces the result to a single value, with onErr for a failed and onOk for a successful result.
+func Fold[T, U any](r Result[T], onErr func(error) U, onOk func(T) U) U {
+	if r.err != nil {
+		return onErr(r.err)
+	}
+	return onOk(r.value)
+}
+
+// Pair holds two values, the values of zipped results and options.
+type Pair[T, U any] struct {
+	First  T
+	Second U
+}
+
+// Zip combines two successful results into a result of both values.
+// When either result failed, the zipped result fails with the error of the first failed one.
+func Zip[T, U any](first Result[T], second Result[U]) Result[Pair[T, U]] {
+	if first.err != nil {
+		return Err[Pair[T, U]](first.err)
+	}
+	if second.err != nil {
+		return Err[Pair[T, U]](second.err)
+	}
+	return Ok(Pair[T, U]{First: first.value, Second: second.value})
+}
@@ -0,0 +1,134 @@
+package fp
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTest = errors.New("test error")
+
+func TestResult_Of(t *testing.T) {
+	value, err := Of(strconv.Atoi("42")).Get()
+	assert.NoError(t, err, "expected a successful result")
+	assert.Equal(t, 42, value, "unexpected value")
+
+	value, err = Of(7, errTest).Get()
+	assert.ErrorIs(t, err, errTest, "expected the error of the function")
+	assert.Equal(t, 0, value, "expected the value of a failed result to be dropped")
+}
+
+func TestResult_OrElse(t *testing.T) {
+	assert.Equal(t, 1, Ok(1).OrElse(2), "expected the value of a successful result")
+	assert.Equal(t, 2, Err[int](errTest).OrElse(2), "expected the fallback of a failed result")
+}
+
+func TestResult_Check(t *testing.T) {
+	positive := func(value int) error {
+		if value <= 0 {
+			return errTest
+		}
+		return nil
+	}
+
+	assert.True(t, Ok(1).Check(positive).IsOk(), "expected a passed check to keep the result")
+	_, err := Ok(-1).Check(positive).Get()
+	assert.ErrorIs(t, err, errTest, "expected a failed check to fail the result")
+
+	otherErr := errors.New("other error")
+	_, err = Err[int](otherErr).Check(func(int) error {
+		t.Fatal("expected the check of a failed result not to run")
+		return nil
+	}).Get()
+	assert.ErrorIs(t, err, otherErr, "expected a failed result to keep its error")
+}
+
+func TestResult_MapErr(t *testing.T) {
+	wrap := func(err error) error { return errors.Join(errTest, err) }
+
+	assert.Equal(t, Ok(1), Ok(1).MapErr(wrap), "expected a successful result to be unchanged")
+	cause := errors.New("cause")
+	_, err := Err[int](cause).MapErr(wrap).Get()
+	assert.ErrorIs(t, err, errTest, "expected the replaced error")
+	assert.ErrorIs(t, err, cause, "expected the replaced error to wrap the cause")
+}
+
+func TestResult_Map(t *testing.T) {
+	value, err := Map(Ok(21), func(value int) string { return strconv.Itoa(value * 2) }).Get()
+	assert.NoError(t, err, "expected a successful result")
+	assert.Equal(t, "42", value, "unexpected mapped value")
+
+	_, err = Map(Err[int](errTest), strconv.Itoa).Get()
+	assert.ErrorIs(t, err, errTest, "expected a failed result to keep its error")
+}
+
+func TestResult_FlatMap(t *testing.T) {
+	parse := func(s string) Result[int] { return Of(strconv.Atoi(s)) }
+
+	value, err := FlatMap(Ok("42"), parse).Get()
+	assert.NoError(t, err, "expected a successful result")
+	assert.Equal(t, 42, value, "unexpected value")
+
+	_, err = FlatMap(Ok("forty-two"), parse).Get()
+	assert.Error(t, err, "expected the error of the step")
+
+	_, err = FlatMap(Err[string](errTest), parse).Get()
+	assert.ErrorIs(t, err, errTest, "expected a failed result to keep its error")
+}
+
+func TestResult_Fold(t *testing.T) {
+	describe := func(r Result[int]) string {
+		return Fold(r, func(err error) string { return "failed: " + err.Error() }, strconv.Itoa)
+	}
+
+	assert.Equal(t, "42", describe(Ok(42)), "expected onOk to be applied")
+	assert.Equal(t, "failed: test error", describe(Err[int](errTest)), "expected onErr to be applied")
+}
+
+func TestResult_Zip(t *testing.T) {
+	otherErr := errors.New("other error")
+
+	tests := []struct {
+		name          string
+		first         Result[int]
+		second        Result[string]
+		expected      Pair[int, string]
+		expectedError error
+	}{
+		{
+			name:     "both_successful",
+			first:    Ok(1),
+			second:   Ok("one"),
+			expected: Pair[int, string]{First: 1, Second: "one"},
+		},
+		{
+			name:          "first_failed",
+			first:         Err[int](errTest),
+			second:        Ok("one"),
+			expectedError: errTest,
+		},
+		{
+			name:          "second_failed",
+			first:         Ok(1),
+			second:        Err[string](errTest),
+			expectedError: errTest,
+		},
+		{
+			name:          "both_failed",
+			first:         Err[int](errTest),
+			second:        Err[string](otherErr),
+			expectedError: errTest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			zipped, err := Zip(tc.first, tc.second).Get()
+
+			assert.Equal(t, tc.expectedError, err, "unexpected error")
+			assert.Equal(t, tc.expected, zipped, "unexpected zipped values")
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package transports
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7396) to the target document and returns the patched document.
+// Objects are merged recursively, null values remove members and any other value replaces the target member.
+func ApplyMergePatch(target []byte, patch []byte) ([]byte, error) {
+	targetDoc, err := decodeDocument(target)
+	if err != nil {
+		return nil, err
+	}
+	patchDoc, err := decodeDocument(patch)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergePatch(targetDoc, patchDoc))
+}
+
+// MergePatchMembers returns the top level member names a merge patch touches.
+func MergePatchMembers(patch []byte) ([]string, error) {
+	var patchObject map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &patchObject); err != nil {
+		return nil, err
+	}
+	members := make([]string, 0, len(patchObject))
+	for member := range patchObject {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func decodeDocument(document []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	// keep numbers as written, so ids and amounts survive the round trip unchanged
+	decoder.UseNumber()
+
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+func mergePatch(target any, patch any) any {
+	patchObject, isObject := patch.(map[string]any)
+	if !isObject {
+		return patch
+	}
+
+	targetObject, isObject := target.(map[string]any)
+	if !isObject {
+		targetObject = make(map[string]any)
+	}
+
+	for member, value := range patchObject {
+		if value == nil {
+			delete(targetObject, member)
+			continue
+		}
+		targetObject[member] = mergePatch(targetObject[member], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,87 @@
+package transports
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		target        string
+		patch         string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "replace_member",
+			target:   `{"quantity":1,"price":10.5}`,
+			patch:    `{"quantity":3}`,
+			expected: `{"quantity":3,"price":10.5}`,
+		},
+		{
+			name:     "add_member",
+			target:   `{"quantity":1}`,
+			patch:    `{"has_weightables":true}`,
+			expected: `{"quantity":1,"has_weightables":true}`,
+		},
+		{
+			name:     "remove_member_with_null",
+			target:   `{"quantity":1,"price":10.5}`,
+			patch:    `{"price":null}`,
+			expected: `{"quantity":1}`,
+		},
+		{
+			name:     "merge_nested_objects",
+			target:   `{"a":{"b":1,"c":2}}`,
+			patch:    `{"a":{"c":null,"d":4}}`,
+			expected: `{"a":{"b":1,"d":4}}`,
+		},
+		{
+			name:     "arrays_are_replaced",
+			target:   `{"payments":[{"id":1,"payment_amount":5},{"id":2,"payment_amount":5}]}`,
+			patch:    `{"payments":[{"id":2,"payment_amount":10}]}`,
+			expected: `{"payments":[{"id":2,"payment_amount":10}]}`,
+		},
+		{
+			name:     "numbers_are_kept_as_written",
+			target:   `{"price":10.10}`,
+			patch:    `{}`,
+			expected: `{"price":10.10}`,
+		},
+		{
+			name:          "invalid_patch",
+			target:        `{"quantity":1}`,
+			patch:         `{"quantity":`,
+			expectedError: true,
+		},
+		{
+			name:          "invalid_target",
+			target:        `not json`,
+			patch:         `{}`,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ApplyMergePatch([]byte(tt.target), []byte(tt.patch))
+			if tt.expectedError {
+				assert.Error(t, err, "Expected an error for an invalid document")
+				return
+			}
+			assert.NoError(t, err, "Unexpected error applying the merge patch")
+			assert.JSONEq(t, tt.expected, string(actual), "Patched document differs")
+		})
+	}
+}
+
+func TestMergePatchMembers(t *testing.T) {
+	members, err := MergePatchMembers([]byte(`{"quantity":3,"user":null}`))
+	assert.NoError(t, err, "Unexpected error reading the patch members")
+	assert.ElementsMatch(t, []string{"quantity", "user"}, members, "Patch members differ")
+
+	_, err = MergePatchMembers([]byte(`[1,2]`))
+	assert.Error(t, err, "Expected an error for a patch that is not an object")
+}
@@ -1,47 +1,114 @@
 package transports
 
 import (
+	"fp_kata/common"
 	"fp_kata/internal/models"
 	"time"
 )
 
 type OrderResponse struct {
-	ID             int                `json:"id,omitempty"`
-	ProductID      int                `json:"product_id,omitempty"`
-	Quantity       int                `json:"quantity,omitempty"`
-	Price          float64            `json:"price,omitempty"`
-	OrderDate      time.Time          `json:"order_date,omitempty"`
-	Payments       []*PaymentResponse `json:"payments,omitempty"`
-	User           *UserResponse      `json:"user,omitempty"`
-	HasWeightables bool               `json:"has_weightables"`
+	ID             int                  `json:"id,omitempty"`
+	OrderNumber    string               `json:"order_number,omitempty"`
+	ProductID      int                  `json:"product_id,omitempty"`
+	Quantity       int                  `json:"quantity,omitempty"`
+	Price          *common.Money        `json:"price,omitempty"`
+	Currency       common.Currency      `json:"currency,omitempty"`
+	OrderDate      time.Time            `json:"order_date,omitempty"`
+	Payments       []*PaymentResponse   `json:"payments,omitempty"`
+	User           *UserResponse        `json:"user,omitempty"`
+	HasWeightables bool                 `json:"has_weightables"`
+	Status         common.OrderStatus   `json:"status,omitempty"`
+	Lines          []*OrderLineResponse `json:"lines,omitempty"`
+	AmountDue      common.Money         `json:"amount_due"`
+	AmountPaid     common.Money         `json:"amount_paid"`
+	AmountRefunded *common.Money        `json:"amount_refunded,omitempty"`
+	Balance        common.Money         `json:"balance"`
 }
 
+// OrderCreateRequest either lists the order lines or, for a single product, carries the product id,
+// quantity and total price of the order itself; such a request is stored as a one-line order.
+// Prices are optional, orders are priced from the product catalog when they are stored.
+// Orders without a currency are priced in the base currency of the shop.
 type OrderCreateRequest struct {
-	ProductID      int               `json:"product_id,omitempty" validate:"required" binding:"required"`
-	Quantity       int               `json:"quantity,omitempty" validate:"required" binding:"required"`
-	Price          float64           `json:"price,omitempty" validate:"required" binding:"required"`
-	OrderDate      time.Time         `json:"order_date,omitempty" validate:"required" binding:"required"`
-	Payments       []*PaymentRequest `json:"payments,omitempty" validate:"required" binding:"required"`
-	HasWeightables bool              `json:"has_weightables,omitempty" binding:"required"`
+	ProductID      int                 `json:"product_id,omitempty" validate:"required_without=Lines,excluded_with=Lines" binding:"required"`
+	Quantity       int                 `json:"quantity,omitempty" validate:"required_without=Lines,excluded_with=Lines" binding:"required"`
+	Price          common.Money        `json:"price" validate:"excluded_with=Lines"`
+	Currency       common.Currency     `json:"currency,omitempty" validate:"omitempty,iso4217"`
+	Lines          []*OrderLineRequest `json:"lines,omitempty" validate:"omitempty,dive,required"`
+	OrderDate      time.Time           `json:"order_date,omitempty" validate:"required" binding:"required"`
+	Payments       []*PaymentRequest   `json:"payments,omitempty" validate:"required,dive,required" binding:"required"`
+	HasWeightables bool                `json:"has_weightables,omitempty" binding:"required"`
 }
 
-func (orderRequest *OrderCreateRequest) ToOrder(user models.User) *models.Order {
+// ToOrder creates the order of the request, failing for payments with invalid details.
+func (orderRequest *OrderCreateRequest) ToOrder(user models.User) (*models.Order, error) {
 
 	payments := make([]*models.Payment, len(orderRequest.Payments))
 	for i, paymentReq := range orderRequest.Payments {
-		payment := paymentReq.ToPayment(user)
+		payment, err := paymentReq.ToPayment(user)
+		if err != nil {
+			return nil, err
+		}
 		payments[i] = payment
 	}
 
-	return &models.Order{
-		ProductID:      orderRequest.ProductID,
-		Quantity:       orderRequest.Quantity,
-		Price:          orderRequest.Price,
+	lines := make([]*models.OrderLine, len(orderRequest.Lines))
+	for i, lineReq := range orderRequest.Lines {
+		lines[i] = lineReq.ToOrderLine()
+	}
+	if len(lines) == 0 {
+		lines = []*models.OrderLine{singleProductLine(orderRequest.ProductID, orderRequest.Quantity, orderRequest.Price)}
+	}
+
+	order := &models.Order{
+		Currency:       orderRequest.Currency,
 		OrderDate:      orderRequest.OrderDate,
 		Payments:       payments,
 		User:           &user,
 		HasWeightables: orderRequest.HasWeightables,
+		Lines:          lines,
 	}
+	order.ApplyLines()
+	return order, nil
+}
+
+// singleProductLine creates the line of a single-product request, its price is the total of the line.
+func singleProductLine(productID int, quantity int, price common.Money) *models.OrderLine {
+	return &models.OrderLine{ProductID: productID, Quantity: quantity, UnitPrice: price.Div(quantity, common.HalfUp), LineTotal: price}
+}
+
+type OrderTransitionRequest struct {
+	Status common.OrderStatus `json:"status,omitempty" validate:"required,oneof=Pending Paid Fulfilled Delivered Cancelled Refunded" binding:"required"`
+}
+
+// MapToOrderCreateRequest creates an OrderCreateRequest from a models.Order.
+// It is the document a JSON Merge Patch of an existing order is applied to.
+func MapToOrderCreateRequest(order models.Order) *OrderCreateRequest {
+
+	payments := make([]*PaymentRequest, len(order.Payments))
+	for i, payment := range order.Payments {
+		payments[i] = MapToPaymentRequest(*payment)
+	}
+
+	orderRequest := &OrderCreateRequest{
+		Currency:       order.Currency,
+		OrderDate:      order.OrderDate,
+		Payments:       payments,
+		HasWeightables: order.HasWeightables,
+	}
+
+	// single-line orders without weights keep the single-product form, so existing patches of product, quantity or price still apply
+	if len(order.Lines) > 1 || (len(order.Lines) == 1 && order.Lines[0].IsWeighted()) {
+		orderRequest.Lines = make([]*OrderLineRequest, len(order.Lines))
+		for i, line := range order.Lines {
+			orderRequest.Lines[i] = MapToOrderLineRequest(*line)
+		}
+	} else {
+		orderRequest.ProductID = order.ProductID
+		orderRequest.Quantity = order.Quantity
+		orderRequest.Price = order.Price
+	}
+	return orderRequest
 }
 
 // MapToOrderResponse creates an OrderResponse from a models.Order.
@@ -55,14 +122,30 @@ func MapToOrderResponse(order models.Order) *OrderResponse {
 
 	return &OrderResponse{
 		ID:             order.ID,
+		OrderNumber:    order.Number,
 		ProductID:      order.ProductID,
 		Quantity:       order.Quantity,
-		Price:          order.Price,
+		Price:          optionalMoney(order.Price),
+		Currency:       order.Currency,
 		OrderDate:      order.OrderDate,
 		Payments:       convertPayments(order.Payments),
 		User:           user,
 		HasWeightables: order.HasWeightables,
+		Status:         order.Status,
+		Lines:          convertOrderLines(order.Lines),
+		AmountDue:      order.AmountDue(),
+		AmountPaid:     order.AmountPaid(),
+		AmountRefunded: optionalMoney(order.AmountRefunded()),
+		Balance:        order.Balance(),
+	}
+}
+
+// optionalMoney leaves an amount that is no money out of a response.
+func optionalMoney(amount common.Money) *common.Money {
+	if amount.IsZero() {
+		return nil
 	}
+	return &amount
 }
 
 // Helper function to convert a slice of models.Payment to []*PaymentResponse.
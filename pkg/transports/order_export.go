@@ -0,0 +1,242 @@
+package transports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/internal/models"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormat is a file format orders are exported in and imported from.
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+// ErrInvalidExport is returned for an unknown export format or column and for an import that cannot be read.
+var ErrInvalidExport = common.NewDomainError(common.Validation, "invalid_export", "invalid export")
+
+// exportColumn is a column of an order export. Orders are exported with one row per payment,
+// value is called with a nil payment for the single row of an order without payments.
+type exportColumn struct {
+	name  string
+	value func(order *models.Order, payment *models.Payment) any
+}
+
+// exportColumns are the columns of an order export in their default order.
+var exportColumns = []exportColumn{
+	{"id", func(order *models.Order, _ *models.Payment) any { return order.ID }},
+	{"order_number", func(order *models.Order, _ *models.Payment) any { return order.Number }},
+	{"order_date", func(order *models.Order, _ *models.Payment) any { return order.OrderDate }},
+	{"status", func(order *models.Order, _ *models.Payment) any { return string(order.Status) }},
+	{"product_id", func(order *models.Order, _ *models.Payment) any { return order.ProductID }},
+	{"quantity", func(order *models.Order, _ *models.Payment) any { return order.Quantity }},
+	{"price", func(order *models.Order, _ *models.Payment) any { return order.Price }},
+	{"has_weightables", func(order *models.Order, _ *models.Payment) any { return order.HasWeightables }},
+	{"line_count", func(order *models.Order, _ *models.Payment) any { return len(order.Lines) }},
+	{"amount_due", func(order *models.Order, _ *models.Payment) any { return order.AmountDue() }},
+	{"amount_paid", func(order *models.Order, _ *models.Payment) any { return order.AmountPaid() }},
+	{"balance", func(order *models.Order, _ *models.Payment) any { return order.Balance() }},
+	{"payment_id", paymentValue(func(payment *models.Payment) any { return payment.Id })},
+	{"payment_amount", paymentValue(func(payment *models.Payment) any { return payment.Amount })},
+	{"payment_method", paymentValue(func(payment *models.Payment) any { return string(payment.Method) })},
+	{"currency", func(order *models.Order, _ *models.Payment) any { return string(order.Currency) }},
+	{"payment_currency", paymentValue(func(payment *models.Payment) any { return string(payment.Currency) })},
+}
+
+// paymentValue leaves the payment columns of an order without payments empty.
+func paymentValue(value func(payment *models.Payment) any) func(order *models.Order, payment *models.Payment) any {
+	return func(_ *models.Order, payment *models.Payment) any {
+		if payment == nil {
+			return nil
+		}
+		return value(payment)
+	}
+}
+
+// ParseExportFormat parses the format of an export, csv when empty.
+func ParseExportFormat(format string) (ExportFormat, error) {
+	switch ExportFormat(format) {
+	case "", ExportCSV:
+		return ExportCSV, nil
+	case ExportNDJSON:
+		return ExportNDJSON, nil
+	}
+	return "", fmt.Errorf("%w: unknown format %q", ErrInvalidExport, format)
+}
+
+// ParseExportColumns parses a comma separated list of the columns to export, all columns when empty.
+func ParseExportColumns(expression string) ([]string, error) {
+	if expression == "" {
+		names := make([]string, len(exportColumns))
+		for i, column := range exportColumns {
+			names[i] = column.name
+		}
+		return names, nil
+	}
+
+	var names []string
+	for _, name := range strings.Split(expression, ",") {
+		name = strings.TrimSpace(name)
+		if _, err := findExportColumn(name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func findExportColumn(name string) (exportColumn, error) {
+	for _, column := range exportColumns {
+		if column.name == name {
+			return column, nil
+		}
+	}
+	return exportColumn{}, fmt.Errorf("%w: unknown column %q", ErrInvalidExport, name)
+}
+
+// OrderExportWriter writes the rows of exported orders, an order is written as one row per payment.
+type OrderExportWriter interface {
+	WriteOrder(order *models.Order) error
+	// Flush writes any buffered rows to the underlying writer.
+	Flush() error
+}
+
+// NewOrderExportWriter creates the writer of the format, exporting the named columns in the given order.
+func NewOrderExportWriter(w io.Writer, format ExportFormat, names []string) (OrderExportWriter, error) {
+	columns := make([]exportColumn, len(names))
+	for i, name := range names {
+		column, err := findExportColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		columns[i] = column
+	}
+
+	if format == ExportNDJSON {
+		return &ndjsonExportWriter{w: w, columns: columns}, nil
+	}
+	return &csvExportWriter{w: csv.NewWriter(w), columns: columns}, nil
+}
+
+// exportRows returns the rows of an order, one per payment or a single one without payment.
+func exportRows(order *models.Order, columns []exportColumn) [][]any {
+	payments := order.Payments
+	if len(payments) == 0 {
+		payments = []*models.Payment{nil}
+	}
+
+	rows := make([][]any, len(payments))
+	for i, payment := range payments {
+		rows[i] = make([]any, len(columns))
+		for j, column := range columns {
+			rows[i][j] = column.value(order, payment)
+		}
+	}
+	return rows
+}
+
+type csvExportWriter struct {
+	w             *csv.Writer
+	columns       []exportColumn
+	headerWritten bool
+}
+
+func (e *csvExportWriter) WriteOrder(order *models.Order) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	for _, row := range exportRows(order, e.columns) {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = formatCSVValue(value)
+		}
+		if err := e.w.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes the header row even when no order was exported.
+func (e *csvExportWriter) Flush() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExportWriter) writeHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.headerWritten = true
+
+	header := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		header[i] = column.name
+	}
+	return e.w.Write(header)
+}
+
+func formatCSVValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case common.Money:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+type ndjsonExportWriter struct {
+	w       io.Writer
+	columns []exportColumn
+}
+
+// WriteOrder writes every row as a JSON object with the members in the order of the columns.
+func (e *ndjsonExportWriter) WriteOrder(order *models.Order) error {
+	for _, row := range exportRows(order, e.columns) {
+		var line bytes.Buffer
+		line.WriteByte('{')
+		for i, value := range row {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			name, _ := json.Marshal(e.columns[i].name)
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			line.Write(name)
+			line.WriteByte(':')
+			line.Write(encoded)
+		}
+		line.WriteString("}\n")
+		if _, err := e.w.Write(line.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *ndjsonExportWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,129 @@
+package transports
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"fp_kata/common"
+	"fp_kata/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExportFormat(t *testing.T) {
+	tests := []struct {
+		name           string
+		format         string
+		expectedFormat ExportFormat
+		expectedErr    string
+	}{
+		{name: "csv by default", format: "", expectedFormat: ExportCSV},
+		{name: "csv", format: "csv", expectedFormat: ExportCSV},
+		{name: "ndjson", format: "ndjson", expectedFormat: ExportNDJSON},
+		{name: "unknown format", format: "xml", expectedErr: `invalid export: unknown format "xml"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			format, err := ParseExportFormat(test.format)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr, "unexpected error")
+				return
+			}
+			assert.NoError(t, err, "expected no error")
+			assert.Equal(t, test.expectedFormat, format, "unexpected format")
+		})
+	}
+}
+
+func TestParseExportColumns(t *testing.T) {
+	all, err := ParseExportColumns("")
+	assert.NoError(t, err, "expected no error")
+	assert.Len(t, all, len(exportColumns), "expected all columns by default")
+	assert.Equal(t, "id", all[0], "expected the columns in their default order")
+
+	selected, err := ParseExportColumns("payment_method, id")
+	assert.NoError(t, err, "expected no error")
+	assert.Equal(t, []string{"payment_method", "id"}, selected, "expected the selected columns in the given order")
+
+	_, err = ParseExportColumns("id,colour")
+	assert.ErrorIs(t, err, ErrInvalidExport, "expected an unknown column to be rejected")
+}
+
+func TestOrderExportWriter(t *testing.T) {
+	orderDate := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+	paid := &models.Order{
+		ID:        1,
+		Number:    "ORD-2025-000001",
+		OrderDate: orderDate,
+		Status:    common.Pending,
+		ProductID: 7,
+		Quantity:  2,
+		Price:     common.NewMoney(30),
+		Payments: []*models.Payment{
+			{Id: 10, Amount: common.NewMoney(10), Method: common.CreditCard},
+			{Id: 11, Amount: common.NewMoney(5.5), Method: common.PayPal},
+		},
+	}
+	unpaid := &models.Order{ID: 2, OrderDate: orderDate, Status: common.Pending, Price: common.NewMoney(12.5)}
+
+	tests := []struct {
+		name     string
+		format   ExportFormat
+		columns  string
+		orders   []*models.Order
+		expected string
+	}{
+		{
+			name:    "csv writes one row per payment",
+			format:  ExportCSV,
+			columns: "id,order_date,payment_id,payment_amount,payment_method,balance",
+			orders:  []*models.Order{paid, unpaid},
+			expected: "id,order_date,payment_id,payment_amount,payment_method,balance\n" +
+				"1,2025-02-10T12:00:00Z,10,10.00,CreditCard,14.50\n" +
+				"1,2025-02-10T12:00:00Z,11,5.50,PayPal,14.50\n" +
+				"2,2025-02-10T12:00:00Z,,,,12.50\n",
+		},
+		{
+			name:     "csv quotes values",
+			format:   ExportCSV,
+			columns:  "id,order_number",
+			orders:   []*models.Order{{ID: 3, Number: `A,"B"`}},
+			expected: "id,order_number\n3,\"A,\"\"B\"\"\"\n",
+		},
+		{
+			name:     "csv without orders writes the header",
+			format:   ExportCSV,
+			columns:  "id,status",
+			expected: "id,status\n",
+		},
+		{
+			name:    "ndjson writes one object per payment",
+			format:  ExportNDJSON,
+			columns: "id,payment_id,payment_method,has_weightables",
+			orders:  []*models.Order{paid, unpaid},
+			expected: `{"id":1,"payment_id":10,"payment_method":"CreditCard","has_weightables":false}` + "\n" +
+				`{"id":1,"payment_id":11,"payment_method":"PayPal","has_weightables":false}` + "\n" +
+				`{"id":2,"payment_id":null,"payment_method":null,"has_weightables":false}` + "\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			columns, err := ParseExportColumns(test.columns)
+			assert.NoError(t, err, "expected the columns to parse")
+
+			var out bytes.Buffer
+			writer, err := NewOrderExportWriter(&out, test.format, columns)
+			assert.NoError(t, err, "expected no error creating the writer")
+			for _, order := range test.orders {
+				assert.NoError(t, writer.WriteOrder(order), "expected no error writing an order")
+			}
+			assert.NoError(t, writer.Flush(), "expected no error flushing")
+
+			assert.Equal(t, test.expected, out.String(), "unexpected export")
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package transports
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fp_kata/common"
+	"fp_kata/common/utils"
+	"fp_kata/internal/models"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxImportLineLength is the longest line of an NDJSON import.
+const maxImportLineLength = 1 << 20
+
+// importRequest is an order being read from an import, the request of the rows starting at row.
+type importRequest struct {
+	row       int
+	reference string
+	request   *OrderCreateRequest
+	err       error
+}
+
+// ReadOrderImport reads the orders of an import. An NDJSON import has an OrderCreateRequest on every line.
+// A CSV import has a header row naming the columns of an export it reads, other columns are ignored;
+// consecutive rows with the same id are one order with a payment per row, orders with several lines need NDJSON.
+// Every order is validated like a created order, a row that cannot be read or is invalid is returned with its error.
+// The error is only set when the import as a whole cannot be read.
+func ReadOrderImport(r io.Reader, format ExportFormat, user models.User) ([]*models.OrderImportRow, error) {
+	var requests []*importRequest
+	var err error
+	if format == ExportNDJSON {
+		requests, err = readNDJSONImport(r)
+	} else {
+		requests, err = readCSVImport(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+
+	validate := utils.NewValidator()
+	rows := make([]*models.OrderImportRow, len(requests))
+	for i, request := range requests {
+		rows[i] = &models.OrderImportRow{Row: request.row, Err: request.err}
+		if rows[i].Err == nil {
+			if err := validate.Struct(request.request); err != nil {
+				rows[i].Err = fmt.Errorf("validation failed: %w", err)
+			}
+		}
+		if rows[i].Err == nil {
+			rows[i].Order, rows[i].Err = request.request.ToOrder(user)
+		}
+	}
+	return rows, nil
+}
+
+func readNDJSONImport(r io.Reader) ([]*importRequest, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineLength)
+
+	var requests []*importRequest
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		request := &importRequest{row: line, request: new(OrderCreateRequest)}
+		if err := json.Unmarshal(scanner.Bytes(), request.request); err != nil {
+			request.err = fmt.Errorf("invalid JSON: %w", err)
+		}
+		requests = append(requests, request)
+	}
+	return requests, scanner.Err()
+}
+
+func readCSVImport(r io.Reader) ([]*importRequest, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("missing header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	var requests []*importRequest
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return requests, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		row := csvImportRow{columns: columns, record: record}
+
+		// the further rows of an order only add its payments
+		reference := row.value("id")
+		var request *importRequest
+		if last := len(requests) - 1; reference != "" && last >= 0 && requests[last].reference == reference {
+			request = requests[last]
+		} else {
+			request = &importRequest{row: line, reference: reference, request: &OrderCreateRequest{}}
+			request.request.OrderDate = row.time("order_date")
+			request.request.ProductID = row.int("product_id")
+			request.request.Quantity = row.int("quantity")
+			request.request.Price = row.money("price")
+			request.request.Currency = common.Currency(row.value("currency"))
+			request.request.HasWeightables = row.bool("has_weightables")
+			requests = append(requests, request)
+		}
+
+		if row.value("payment_method") != "" || row.value("payment_amount") != "" {
+			request.request.Payments = append(request.request.Payments, &PaymentRequest{
+				PaymentAmount: row.money("payment_amount"),
+				PaymentMethod: common.PaymentMethod(row.value("payment_method")),
+				Currency:      common.Currency(row.value("payment_currency")),
+			})
+		}
+		if request.err == nil {
+			request.err = row.err
+		}
+	}
+}
+
+// csvImportRow reads the values of a CSV row, keeping the first value that cannot be parsed as its error.
+type csvImportRow struct {
+	columns map[string]int
+	record  []string
+	err     error
+}
+
+func (r *csvImportRow) value(column string) string {
+	if i, found := r.columns[column]; found {
+		return strings.TrimSpace(r.record[i])
+	}
+	return ""
+}
+
+func (r *csvImportRow) int(column string) int {
+	value := r.value(column)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.Atoi(value)
+	r.fail(column, value, err)
+	return parsed
+}
+
+func (r *csvImportRow) money(column string) common.Money {
+	value := r.value(column)
+	if value == "" {
+		return common.Money{}
+	}
+	parsed, err := common.ParseMoney(value)
+	r.fail(column, value, err)
+	return parsed
+}
+
+func (r *csvImportRow) bool(column string) bool {
+	value := r.value(column)
+	if value == "" {
+		return false
+	}
+	parsed, err := strconv.ParseBool(value)
+	r.fail(column, value, err)
+	return parsed
+}
+
+func (r *csvImportRow) time(column string) time.Time {
+	value := r.value(column)
+	if value == "" {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	r.fail(column, value, err)
+	return parsed
+}
+
+func (r *csvImportRow) fail(column string, value string, err error) {
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("invalid %s %q", column, value)
+	}
+}
+
+type OrderImportRowResponse struct {
+	Row     int                    `json:"row"`
+	Status  models.ImportRowStatus `json:"status"`
+	OrderID int                    `json:"order_id,omitempty"`
+	Reason  string                 `json:"reason,omitempty"`
+}
+
+type OrderImportResponse struct {
+	DryRun       bool                      `json:"dry_run"`
+	AllOrNothing bool                      `json:"all_or_nothing"`
+	Created      int                       `json:"created"`
+	Skipped      int                       `json:"skipped"`
+	Failed       int                       `json:"failed"`
+	Rows         []*OrderImportRowResponse `json:"rows"`
+}
+
+// MapToOrderImportResponse creates the report of an import, counting the rows of every status.
+func MapToOrderImportResponse(results []*models.OrderImportResult, options models.OrderImportOptions) *OrderImportResponse {
+	response := &OrderImportResponse{
+		DryRun:       options.DryRun,
+		AllOrNothing: options.AllOrNothing,
+		Rows:         make([]*OrderImportRowResponse, len(results)),
+	}
+	for i, result := range results {
+		response.Rows[i] = &OrderImportRowResponse{
+			Row:     result.Row,
+			Status:  result.Status,
+			OrderID: result.OrderID,
+			Reason:  result.Reason,
+		}
+		switch result.Status {
+		case models.ImportCreated:
+			response.Created++
+		case models.ImportSkipped:
+			response.Skipped++
+		case models.ImportFailed:
+			response.Failed++
+		}
+	}
+	return response
+}
@@ -0,0 +1,164 @@
+package transports
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"fp_kata/common"
+	"fp_kata/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOrderImport(t *testing.T) {
+	user := models.User{ID: 1}
+	orderDate := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+
+	// row describes an imported row by its number, error and the product, quantity and payments of its order.
+	type row struct {
+		row       int
+		err       string
+		productID int
+		quantity  int
+		payments  []common.PaymentMethod
+	}
+
+	tests := []struct {
+		name        string
+		format      ExportFormat
+		input       string
+		expected    []row
+		expectedErr string
+	}{
+		{
+			name:   "ndjson",
+			format: ExportNDJSON,
+			input: `{"product_id":1,"quantity":2,"order_date":"2025-02-10T12:00:00Z","payments":[{"payment_amount":5,"payment_method":"PayPal"}]}` + "\n" +
+				"\n" +
+				`{"product_id":1,` + "\n" +
+				`{"product_id":1,"quantity":2,"payments":[{"payment_amount":5,"payment_method":"PayPal"}]}` + "\n",
+			expected: []row{
+				{row: 1, productID: 1, quantity: 2, payments: []common.PaymentMethod{common.PayPal}},
+				{row: 3, err: "invalid JSON: unexpected end of JSON input"},
+				{row: 4, err: "validation failed: Key: 'OrderCreateRequest.OrderDate' Error:Field validation for 'OrderDate' failed on the 'required' tag"},
+			},
+		},
+		{
+			name:   "csv groups the payments of an order",
+			format: ExportCSV,
+			input: "id,order_date,product_id,quantity,price,status,payment_amount,payment_method\n" +
+				"7,2025-02-10T12:00:00Z,1,2,20,Paid,10,CreditCard\n" +
+				"7,2025-02-10T12:00:00Z,1,2,20,Paid,10,PayPal\n" +
+				"8,2025-02-10T12:00:00Z,2,many,20,Paid,10,PayPal\n" +
+				"9,2025-02-10T12:00:00Z,3,1,5,Pending,,\n",
+			expected: []row{
+				{row: 2, productID: 1, quantity: 2, payments: []common.PaymentMethod{common.CreditCard, common.PayPal}},
+				{row: 4, err: `invalid quantity "many"`},
+				{row: 5, err: "validation failed: Key: 'OrderCreateRequest.Payments' Error:Field validation for 'Payments' failed on the 'required' tag"},
+			},
+		},
+		{
+			name:   "csv rows without id are separate orders",
+			format: ExportCSV,
+			input: "order_date,product_id,quantity,payment_amount,payment_method\n" +
+				"2025-02-10T12:00:00Z,1,1,1,PayPal\n" +
+				"2025-02-10T12:00:00Z,1,1,1,PayPal\n",
+			expected: []row{
+				{row: 2, productID: 1, quantity: 1, payments: []common.PaymentMethod{common.PayPal}},
+				{row: 3, productID: 1, quantity: 1, payments: []common.PaymentMethod{common.PayPal}},
+			},
+		},
+		{
+			name:        "csv without header",
+			format:      ExportCSV,
+			input:       "",
+			expectedErr: "invalid export: missing header row",
+		},
+		{
+			name:        "malformed csv",
+			format:      ExportCSV,
+			input:       "id,order_date\n1,\"2025\n",
+			expectedErr: `invalid export: parse error on line 2, column 9: extraneous or missing " in quoted-field`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows, err := ReadOrderImport(strings.NewReader(test.input), test.format, user)
+
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr, "unexpected error")
+				return
+			}
+			assert.NoError(t, err, "expected no error")
+			assert.Len(t, rows, len(test.expected), "unexpected number of rows")
+			for i, expected := range test.expected {
+				actual := rows[i]
+				assert.Equal(t, expected.row, actual.Row, "unexpected row number")
+				if expected.err != "" {
+					assert.EqualError(t, actual.Err, expected.err, "unexpected row error")
+					assert.Nil(t, actual.Order, "expected no order for a failed row")
+					continue
+				}
+				assert.NoError(t, actual.Err, "expected no row error")
+				assert.Equal(t, orderDate, actual.Order.OrderDate, "unexpected order date")
+				assert.Equal(t, expected.productID, actual.Order.ProductID, "unexpected product")
+				assert.Equal(t, expected.quantity, actual.Order.Quantity, "unexpected quantity")
+				methods := make([]common.PaymentMethod, len(actual.Order.Payments))
+				for j, payment := range actual.Order.Payments {
+					methods[j] = payment.Method
+				}
+				assert.Equal(t, expected.payments, methods, "unexpected payments")
+			}
+		})
+	}
+}
+
+func TestReadOrderImport_ExportRoundTrip(t *testing.T) {
+	order := &models.Order{
+		ID:        3,
+		OrderDate: time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC),
+		Status:    common.Paid,
+		ProductID: 1,
+		Quantity:  2,
+		Price:     common.NewMoney(20),
+		Payments: []*models.Payment{
+			{Id: 1, Amount: common.NewMoney(15), Method: common.CreditCard},
+			{Id: 2, Amount: common.NewMoney(5), Method: common.PayPal},
+		},
+	}
+
+	var export bytes.Buffer
+	columns, _ := ParseExportColumns("")
+	writer, err := NewOrderExportWriter(&export, ExportCSV, columns)
+	assert.NoError(t, err, "expected no error creating the writer")
+	assert.NoError(t, writer.WriteOrder(order), "expected no error writing the order")
+	assert.NoError(t, writer.Flush(), "expected no error flushing")
+
+	rows, err := ReadOrderImport(&export, ExportCSV, models.User{ID: 1})
+	assert.NoError(t, err, "expected the export to be imported")
+	assert.Len(t, rows, 1, "expected the payments to be grouped into one order")
+	assert.NoError(t, rows[0].Err, "expected no row error")
+	assert.Equal(t, order.Price, rows[0].Order.Price, "unexpected price")
+	assert.Len(t, rows[0].Order.Payments, 2, "expected both payments")
+}
+
+func TestMapToOrderImportResponse(t *testing.T) {
+	response := MapToOrderImportResponse([]*models.OrderImportResult{
+		{Row: 1, Status: models.ImportCreated, OrderID: 5},
+		{Row: 2, Status: models.ImportFailed, Reason: "unknown product: 9"},
+		{Row: 3, Status: models.ImportCreated, OrderID: 6},
+	}, models.OrderImportOptions{})
+
+	assert.Equal(t, &OrderImportResponse{
+		Created: 2,
+		Failed:  1,
+		Rows: []*OrderImportRowResponse{
+			{Row: 1, Status: models.ImportCreated, OrderID: 5},
+			{Row: 2, Status: models.ImportFailed, Reason: "unknown product: 9"},
+			{Row: 3, Status: models.ImportCreated, OrderID: 6},
+		},
+	}, response, "unexpected import report")
+}
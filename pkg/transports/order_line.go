@@ -0,0 +1,95 @@
+package transports
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/models"
+)
+
+type OrderLineResponse struct {
+	ProductID       int               `json:"product_id"`
+	Quantity        int               `json:"quantity"`
+	UnitPrice       common.Money      `json:"unit_price"`
+	LineTotal       common.Money      `json:"line_total"`
+	WeightUnit      common.WeightUnit `json:"weight_unit,omitempty"`
+	EstimatedWeight float64           `json:"estimated_weight,omitempty"`
+	ActualWeight    float64           `json:"actual_weight,omitempty"`
+}
+
+func MapToOrderLineResponse(line models.OrderLine) *OrderLineResponse {
+	return &OrderLineResponse{
+		ProductID:       line.ProductID,
+		Quantity:        line.Quantity,
+		UnitPrice:       line.UnitPrice,
+		LineTotal:       line.LineTotal,
+		WeightUnit:      line.WeightUnit,
+		EstimatedWeight: line.EstimatedWeight,
+		ActualWeight:    line.ActualWeight,
+	}
+}
+
+// OrderLineRequest is a line of an order. Weightable products name a weight unit and an estimated weight,
+// their unit price is the price of one weight unit. The unit price is optional, lines are priced from the product catalog.
+// Weighed lines carry their actual weight, so updates of a weighed order keep the price of the weighing.
+type OrderLineRequest struct {
+	ProductID       int               `json:"product_id" validate:"required"`
+	Quantity        int               `json:"quantity" validate:"required,gt=0"`
+	UnitPrice       common.Money      `json:"unit_price" validate:"gte=0"`
+	WeightUnit      common.WeightUnit `json:"weight_unit,omitempty" validate:"omitempty,oneof=kg g lb"`
+	EstimatedWeight float64           `json:"estimated_weight,omitempty" validate:"required_with=WeightUnit,excluded_without=WeightUnit,gte=0"`
+	ActualWeight    float64           `json:"actual_weight,omitempty" validate:"excluded_without=WeightUnit,gte=0"`
+}
+
+func (l OrderLineRequest) ToOrderLine() *models.OrderLine {
+	if l.WeightUnit != "" {
+		line := models.NewWeightedOrderLine(l.ProductID, l.Quantity, l.UnitPrice, l.WeightUnit, l.EstimatedWeight)
+		if l.ActualWeight > 0 {
+			line.Weigh(l.ActualWeight)
+		}
+		return line
+	}
+	return models.NewOrderLine(l.ProductID, l.Quantity, l.UnitPrice)
+}
+
+// MapToOrderLineRequest creates an OrderLineRequest from a stored order line.
+func MapToOrderLineRequest(line models.OrderLine) *OrderLineRequest {
+	return &OrderLineRequest{
+		ProductID:       line.ProductID,
+		Quantity:        line.Quantity,
+		UnitPrice:       line.UnitPrice,
+		WeightUnit:      line.WeightUnit,
+		EstimatedWeight: line.EstimatedWeight,
+		ActualWeight:    line.ActualWeight,
+	}
+}
+
+// OrderWeighingRequest carries the actual weights of weighted order lines.
+type OrderWeighingRequest struct {
+	Lines []*LineWeightRequest `json:"lines" validate:"required,min=1,dive,required"`
+}
+
+type LineWeightRequest struct {
+	// Index is the position of the line in the order, starting at 0.
+	Index  int               `json:"index" validate:"min=0"`
+	Weight float64           `json:"weight" validate:"required,gt=0"`
+	Unit   common.WeightUnit `json:"unit,omitempty" validate:"omitempty,oneof=kg g lb"`
+}
+
+func (r OrderWeighingRequest) ToLineWeights() []models.LineWeight {
+	weights := make([]models.LineWeight, len(r.Lines))
+	for i, line := range r.Lines {
+		weights[i] = models.LineWeight{Index: line.Index, Weight: line.Weight, Unit: line.Unit}
+	}
+	return weights
+}
+
+// Helper function to convert a slice of models.OrderLine to []*OrderLineResponse.
+func convertOrderLines(lines []*models.OrderLine) []*OrderLineResponse {
+	if len(lines) == 0 {
+		return nil
+	}
+	lineResponses := make([]*OrderLineResponse, len(lines))
+	for i, line := range lines {
+		lineResponses[i] = MapToOrderLineResponse(*line)
+	}
+	return lineResponses
+}
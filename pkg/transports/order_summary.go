@@ -0,0 +1,77 @@
+package transports
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/models"
+)
+
+// OrderAggregateResponse is the spend of a group of orders.
+type OrderAggregateResponse struct {
+	TotalSpend    common.Money `json:"total_spend"`
+	OrderCount    int          `json:"order_count"`
+	AverageBasket common.Money `json:"average_basket"`
+}
+
+type PeriodAggregateResponse struct {
+	Period string `json:"period"`
+	OrderAggregateResponse
+}
+
+type PaymentMethodAggregateResponse struct {
+	PaymentMethod common.PaymentMethod `json:"payment_method"`
+	OrderAggregateResponse
+}
+
+type ProductAggregateResponse struct {
+	ProductID int `json:"product_id"`
+	OrderAggregateResponse
+}
+
+type OrderSummaryResponse struct {
+	Period models.SummaryPeriod `json:"period"`
+	// Currency is the currency of all amounts of the summary.
+	Currency common.Currency `json:"currency"`
+	OrderAggregateResponse
+	ByPeriod        []*PeriodAggregateResponse        `json:"by_period"`
+	ByPaymentMethod []*PaymentMethodAggregateResponse `json:"by_payment_method"`
+	ByProduct       []*ProductAggregateResponse       `json:"by_product"`
+}
+
+// MapToOrderSummaryResponse maps an order summary, its groups are empty lists rather than null without orders.
+func MapToOrderSummaryResponse(summary models.OrderSummary) *OrderSummaryResponse {
+	response := &OrderSummaryResponse{
+		Period:                 summary.Period,
+		Currency:               summary.Currency,
+		OrderAggregateResponse: mapToOrderAggregateResponse(summary.OrderAggregate),
+		ByPeriod:               make([]*PeriodAggregateResponse, len(summary.ByPeriod)),
+		ByPaymentMethod:        make([]*PaymentMethodAggregateResponse, len(summary.ByPaymentMethod)),
+		ByProduct:              make([]*ProductAggregateResponse, len(summary.ByProduct)),
+	}
+	for i, aggregate := range summary.ByPeriod {
+		response.ByPeriod[i] = &PeriodAggregateResponse{
+			Period:                 aggregate.Period,
+			OrderAggregateResponse: mapToOrderAggregateResponse(aggregate.OrderAggregate),
+		}
+	}
+	for i, aggregate := range summary.ByPaymentMethod {
+		response.ByPaymentMethod[i] = &PaymentMethodAggregateResponse{
+			PaymentMethod:          aggregate.Method,
+			OrderAggregateResponse: mapToOrderAggregateResponse(aggregate.OrderAggregate),
+		}
+	}
+	for i, aggregate := range summary.ByProduct {
+		response.ByProduct[i] = &ProductAggregateResponse{
+			ProductID:              aggregate.ProductID,
+			OrderAggregateResponse: mapToOrderAggregateResponse(aggregate.OrderAggregate),
+		}
+	}
+	return response
+}
+
+func mapToOrderAggregateResponse(aggregate models.OrderAggregate) OrderAggregateResponse {
+	return OrderAggregateResponse{
+		TotalSpend:    aggregate.TotalSpend,
+		OrderCount:    aggregate.OrderCount,
+		AverageBasket: aggregate.AverageBasket(),
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"fp_kata/common"
+	"fp_kata/common/utils"
 	"fp_kata/internal/models"
 
 	"github.com/stretchr/testify/assert"
@@ -22,11 +24,11 @@ func TestToOrder(t *testing.T) {
 			inputRequest: OrderCreateRequest{
 				ProductID: 101,
 				Quantity:  2,
-				Price:     100.50,
+				Price:     common.NewMoney(100.50),
 				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
 				Payments: []*PaymentRequest{
 					{
-						PaymentAmount: 100.50,
+						PaymentAmount: common.NewMoney(100.50),
 						PaymentMethod: "CreditCard",
 					},
 				},
@@ -40,12 +42,12 @@ func TestToOrder(t *testing.T) {
 			expected: &models.Order{
 				ProductID: 101,
 				Quantity:  2,
-				Price:     100.50,
+				Price:     common.NewMoney(100.50),
 				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
 				Payments: []*models.Payment{
 					{
 						Id:     0, // Set dynamically elsewhere
-						Amount: 100.50,
+						Amount: common.NewMoney(100.50),
 						Method: "CreditCard",
 						User: &models.User{
 							ID:       1,
@@ -60,15 +62,39 @@ func TestToOrder(t *testing.T) {
 					Email:    "test@example.com",
 				},
 				HasWeightables: true,
+				Lines:          []*models.OrderLine{{ProductID: 101, Quantity: 2, UnitPrice: common.NewMoney(50.25), LineTotal: common.NewMoney(100.50)}},
 			},
 			errorMessage: "Valid input should map correctly to a valid order",
 		},
+		{
+			name: "multiple_lines",
+			inputRequest: OrderCreateRequest{
+				Lines: []*OrderLineRequest{
+					{ProductID: 101, Quantity: 3, UnitPrice: common.NewMoney(1.10)},
+					{ProductID: 102, Quantity: 1, UnitPrice: common.NewMoney(20)},
+				},
+				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+			},
+			inputUser: models.User{ID: 1},
+			expected: &models.Order{
+				Quantity:  4,
+				Price:     common.NewMoney(23.30),
+				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+				Payments:  []*models.Payment{},
+				User:      &models.User{ID: 1},
+				Lines: []*models.OrderLine{
+					{ProductID: 101, Quantity: 3, UnitPrice: common.NewMoney(1.10), LineTotal: common.NewMoney(3.30)},
+					{ProductID: 102, Quantity: 1, UnitPrice: common.NewMoney(20), LineTotal: common.NewMoney(20)},
+				},
+			},
+			errorMessage: "Order lines should map to lines and the order total should be computed from them",
+		},
 		{
 			name: "no_payments",
 			inputRequest: OrderCreateRequest{
 				ProductID:      102,
 				Quantity:       1,
-				Price:          50.00,
+				Price:          common.NewMoney(50.00),
 				OrderDate:      time.Date(2025, 2, 10, 15, 0, 0, 0, time.UTC),
 				Payments:       nil,
 				HasWeightables: false,
@@ -81,7 +107,7 @@ func TestToOrder(t *testing.T) {
 			expected: &models.Order{
 				ProductID: 102,
 				Quantity:  1,
-				Price:     50.00,
+				Price:     common.NewMoney(50.00),
 				OrderDate: time.Date(2025, 2, 10, 15, 0, 0, 0, time.UTC),
 				Payments:  []*models.Payment{},
 				User: &models.User{
@@ -90,6 +116,7 @@ func TestToOrder(t *testing.T) {
 					Email:    "nopayments@example.com",
 				},
 				HasWeightables: false,
+				Lines:          []*models.OrderLine{{ProductID: 102, Quantity: 1, UnitPrice: common.NewMoney(50.00), LineTotal: common.NewMoney(50.00)}},
 			},
 			errorMessage: "Input without payments should set an empty payments slice",
 		},
@@ -98,7 +125,7 @@ func TestToOrder(t *testing.T) {
 			inputRequest: OrderCreateRequest{
 				ProductID:      0,
 				Quantity:       0,
-				Price:          0.0,
+				Price:          common.Money{},
 				OrderDate:      time.Time{},
 				Payments:       nil,
 				HasWeightables: false,
@@ -111,7 +138,7 @@ func TestToOrder(t *testing.T) {
 			expected: &models.Order{
 				ProductID: 0,
 				Quantity:  0,
-				Price:     0.0,
+				Price:     common.Money{},
 				OrderDate: time.Time{},
 				Payments:  []*models.Payment{},
 				User: &models.User{
@@ -120,6 +147,7 @@ func TestToOrder(t *testing.T) {
 					Email:    "",
 				},
 				HasWeightables: false,
+				Lines:          []*models.OrderLine{{}},
 			},
 			errorMessage: "Input with zero values should map correctly to order with defaults",
 		},
@@ -127,7 +155,8 @@ func TestToOrder(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			actual := tt.inputRequest.ToOrder(tt.inputUser)
+			actual, err := tt.inputRequest.ToOrder(tt.inputUser)
+			assert.NoError(t, err, tt.errorMessage)
 			assert.Equal(t, tt.expected, actual, tt.errorMessage)
 		})
 	}
@@ -144,14 +173,15 @@ func TestMapToOrderResponse(t *testing.T) {
 			name: "valid_order",
 			input: models.Order{
 				ID:        1,
+				Number:    "ORD-2025-000001",
 				ProductID: 101,
 				Quantity:  3,
-				Price:     200.50,
+				Price:     common.NewMoney(200.50),
 				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
 				Payments: []*models.Payment{
 					{
 						Id:     1,
-						Amount: 200.50,
+						Amount: common.NewMoney(200.50),
 						Method: "CreditCard",
 					},
 				},
@@ -161,17 +191,20 @@ func TestMapToOrderResponse(t *testing.T) {
 					Username: "johndoe",
 				},
 				HasWeightables: true,
+				Status:         "Paid",
+				Lines:          []*models.OrderLine{{ProductID: 101, Quantity: 3, UnitPrice: common.NewMoney(66.83), LineTotal: common.NewMoney(200.50)}},
 			},
 			expected: &OrderResponse{
-				ID:        1,
-				ProductID: 101,
-				Quantity:  3,
-				Price:     200.50,
-				OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+				ID:          1,
+				OrderNumber: "ORD-2025-000001",
+				ProductID:   101,
+				Quantity:    3,
+				Price:       optionalMoney(common.NewMoney(200.50)),
+				OrderDate:   time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
 				Payments: []*PaymentResponse{
 					{
 						Id:     1,
-						Amount: 200.50,
+						Amount: common.NewMoney(200.50),
 						Method: "CreditCard",
 					},
 				},
@@ -181,6 +214,10 @@ func TestMapToOrderResponse(t *testing.T) {
 					Email:    "john.doe@example.com",
 				},
 				HasWeightables: true,
+				Status:         "Paid",
+				Lines:          []*OrderLineResponse{{ProductID: 101, Quantity: 3, UnitPrice: common.NewMoney(66.83), LineTotal: common.NewMoney(200.50)}},
+				AmountDue:      common.NewMoney(200.50),
+				AmountPaid:     common.NewMoney(200.50),
 			},
 			errorMessage: "Expected correct mapping with all fields populated, but result differs",
 		},
@@ -190,12 +227,12 @@ func TestMapToOrderResponse(t *testing.T) {
 				ID:        2,
 				ProductID: 102,
 				Quantity:  1,
-				Price:     100.00,
+				Price:     common.NewMoney(100.00),
 				OrderDate: time.Date(2025, 2, 10, 15, 0, 0, 0, time.UTC),
 				Payments: []*models.Payment{
 					{
 						Id:     2,
-						Amount: 100.00,
+						Amount: common.NewMoney(100.00),
 						Method: "PayPal",
 					},
 				},
@@ -206,17 +243,19 @@ func TestMapToOrderResponse(t *testing.T) {
 				ID:        2,
 				ProductID: 102,
 				Quantity:  1,
-				Price:     100.00,
+				Price:     optionalMoney(common.NewMoney(100.00)),
 				OrderDate: time.Date(2025, 2, 10, 15, 0, 0, 0, time.UTC),
 				Payments: []*PaymentResponse{
 					{
 						Id:     2,
-						Amount: 100.00,
+						Amount: common.NewMoney(100.00),
 						Method: "PayPal",
 					},
 				},
 				User:           nil,
 				HasWeightables: false,
+				AmountDue:      common.NewMoney(100.00),
+				AmountPaid:     common.NewMoney(100.00),
 			},
 			errorMessage: "Expected correct mapping with a nil user, but result differs",
 		},
@@ -226,7 +265,7 @@ func TestMapToOrderResponse(t *testing.T) {
 				ID:             0,
 				ProductID:      0,
 				Quantity:       0,
-				Price:          0.0,
+				Price:          common.Money{},
 				OrderDate:      time.Time{},
 				Payments:       nil,
 				User:           nil,
@@ -236,7 +275,7 @@ func TestMapToOrderResponse(t *testing.T) {
 				ID:             0,
 				ProductID:      0,
 				Quantity:       0,
-				Price:          0.0,
+				Price:          optionalMoney(common.Money{}),
 				OrderDate:      time.Time{},
 				Payments:       []*PaymentResponse{},
 				User:           nil,
@@ -272,14 +311,14 @@ func TestConvertPayments(t *testing.T) {
 			input: []*models.Payment{
 				{
 					Id:     123,
-					Amount: 100.50,
+					Amount: common.NewMoney(100.50),
 					Method: "credit_card",
 				},
 			},
 			expected: []*PaymentResponse{
 				{
 					Id:     123,
-					Amount: 100.50,
+					Amount: common.NewMoney(100.50),
 					Method: "credit_card",
 				},
 			},
@@ -290,24 +329,24 @@ func TestConvertPayments(t *testing.T) {
 			input: []*models.Payment{
 				{
 					Id:     123,
-					Amount: 100.00,
+					Amount: common.NewMoney(100.00),
 					Method: "credit_card",
 				},
 				{
 					Id:     456,
-					Amount: 200.80,
+					Amount: common.NewMoney(200.80),
 					Method: "paypal",
 				},
 			},
 			expected: []*PaymentResponse{
 				{
 					Id:     123,
-					Amount: 100.00,
+					Amount: common.NewMoney(100.00),
 					Method: "credit_card",
 				},
 				{
 					Id:     456,
-					Amount: 200.80,
+					Amount: common.NewMoney(200.80),
 					Method: "paypal",
 				},
 			},
@@ -328,3 +367,107 @@ func TestConvertPayments(t *testing.T) {
 		})
 	}
 }
+
+func TestMapToOrderCreateRequest(t *testing.T) {
+	input := models.Order{
+		ID:        1,
+		ProductID: 101,
+		Quantity:  3,
+		Price:     common.NewMoney(200.50),
+		OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+		Payments: []*models.Payment{
+			{
+				Id:     7,
+				Amount: common.NewMoney(200.50),
+				Method: "CreditCard",
+			},
+		},
+		User:           &models.User{ID: 1},
+		HasWeightables: true,
+		Status:         "Paid",
+	}
+
+	expected := &OrderCreateRequest{
+		ProductID: 101,
+		Quantity:  3,
+		Price:     common.NewMoney(200.50),
+		OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+		Payments: []*PaymentRequest{
+			{
+				Id:            7,
+				PaymentAmount: common.NewMoney(200.50),
+				PaymentMethod: "CreditCard",
+			},
+		},
+		HasWeightables: true,
+	}
+
+	assert.Equal(t, expected, MapToOrderCreateRequest(input), "Expected the order to map to its create request")
+}
+
+func TestMapToOrderCreateRequest_MultipleLines(t *testing.T) {
+	input := models.Order{
+		ID:        1,
+		Quantity:  4,
+		Price:     common.NewMoney(23.30),
+		OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+		Lines: []*models.OrderLine{
+			{ProductID: 101, Quantity: 3, UnitPrice: common.NewMoney(1.10), LineTotal: common.NewMoney(3.30)},
+			{ProductID: 102, Quantity: 1, UnitPrice: common.NewMoney(20), LineTotal: common.NewMoney(20)},
+		},
+	}
+
+	expected := &OrderCreateRequest{
+		OrderDate: time.Date(2025, 1, 30, 10, 30, 0, 0, time.UTC),
+		Payments:  []*PaymentRequest{},
+		Lines: []*OrderLineRequest{
+			{ProductID: 101, Quantity: 3, UnitPrice: common.NewMoney(1.10)},
+			{ProductID: 102, Quantity: 1, UnitPrice: common.NewMoney(20)},
+		},
+	}
+
+	actual := MapToOrderCreateRequest(input)
+	assert.Equal(t, expected, actual, "Expected a multi-line order to map to a create request with lines")
+
+	roundTrip, err := actual.ToOrder(models.User{})
+	assert.NoError(t, err, "Expected the create request to be converted")
+	assert.Equal(t, input.Lines, roundTrip.Lines, "Expected the create request to round-trip the order lines")
+	assert.Equal(t, input.Price, roundTrip.Price, "Expected the create request to round-trip the order total")
+}
+
+func TestOrderLineRequest_Weighted(t *testing.T) {
+	validate := utils.NewValidator()
+
+	weighted := OrderLineRequest{ProductID: 1, Quantity: 1, UnitPrice: common.NewMoney(2.99), WeightUnit: common.Kilogram, EstimatedWeight: 1.5}
+	assert.NoError(t, validate.Struct(weighted), "Expected a weighted line to be valid")
+	assert.Equal(t, models.NewWeightedOrderLine(1, 1, common.NewMoney(2.99), common.Kilogram, 1.5), weighted.ToOrderLine(), "Expected a weighted order line")
+
+	missingWeight := OrderLineRequest{ProductID: 1, Quantity: 1, UnitPrice: common.NewMoney(2.99), WeightUnit: common.Kilogram}
+	assert.Error(t, validate.Struct(missingWeight), "Expected a weighted line without estimated weight to be invalid")
+
+	missingUnit := OrderLineRequest{ProductID: 1, Quantity: 1, UnitPrice: common.NewMoney(2.99), EstimatedWeight: 1.5}
+	assert.Error(t, validate.Struct(missingUnit), "Expected an estimated weight without unit to be invalid")
+
+	unknownUnit := OrderLineRequest{ProductID: 1, Quantity: 1, UnitPrice: common.NewMoney(2.99), WeightUnit: "oz", EstimatedWeight: 1.5}
+	assert.Error(t, validate.Struct(unknownUnit), "Expected an unknown weight unit to be invalid")
+
+	weighedWithoutUnit := OrderLineRequest{ProductID: 1, Quantity: 1, UnitPrice: common.NewMoney(2.99), ActualWeight: 1.4}
+	assert.Error(t, validate.Struct(weighedWithoutUnit), "Expected an actual weight without unit to be invalid")
+}
+
+func TestMapToOrderCreateRequest_WeighedLines(t *testing.T) {
+	weighed := models.NewWeightedOrderLine(102, 1, common.NewMoney(3), common.Kilogram, 2)
+	weighed.Weigh(1.9)
+	input := models.Order{Lines: []*models.OrderLine{weighed}}
+	input.ApplyLines()
+
+	actual := MapToOrderCreateRequest(input)
+	assert.Equal(t, []*OrderLineRequest{
+		{ProductID: 102, Quantity: 1, UnitPrice: common.NewMoney(3), WeightUnit: common.Kilogram, EstimatedWeight: 2, ActualWeight: 1.9},
+	}, actual.Lines, "Expected the actual weight to be kept")
+
+	roundTrip, err := actual.ToOrder(models.User{})
+	assert.NoError(t, err, "Expected the create request to be converted")
+	assert.Equal(t, input.Lines, roundTrip.Lines, "Expected the create request to round-trip the weighed line")
+	assert.Equal(t, common.NewMoney(5.7), roundTrip.Price, "Expected the price to follow the actual weight")
+}
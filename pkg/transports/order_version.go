@@ -0,0 +1,114 @@
+package transports
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/models"
+	"time"
+)
+
+// OrderSnapshotResponse is an order as it was stored by a version, its payments are listed by id.
+type OrderSnapshotResponse struct {
+	ID             int                  `json:"id"`
+	OrderNumber    string               `json:"order_number,omitempty"`
+	UserID         int                  `json:"user_id"`
+	Status         common.OrderStatus   `json:"status,omitempty"`
+	OrderDate      time.Time            `json:"order_date"`
+	ProductID      int                  `json:"product_id,omitempty"`
+	Quantity       int                  `json:"quantity,omitempty"`
+	Price          common.Money         `json:"price"`
+	HasWeightables bool                 `json:"has_weightables"`
+	Lines          []*OrderLineResponse `json:"lines"`
+	PaymentIDs     []int                `json:"payment_ids"`
+}
+
+type FieldChangeResponse struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type OrderVersionResponse struct {
+	Version   int                    `json:"version"`
+	ChangedAt time.Time              `json:"changed_at"`
+	ChangedBy int                    `json:"changed_by,omitempty"`
+	Order     *OrderSnapshotResponse `json:"order,omitempty"`
+	Changes   []*FieldChangeResponse `json:"changes"`
+}
+
+type OrderDiffResponse struct {
+	From    int                    `json:"from"`
+	To      int                    `json:"to"`
+	Changes []*FieldChangeResponse `json:"changes"`
+}
+
+// MapToOrderHistoryResponse lists the versions of an order with their changes, leaving out the orders themselves.
+func MapToOrderHistoryResponse(versions []*models.OrderVersion) []*OrderVersionResponse {
+	responses := make([]*OrderVersionResponse, len(versions))
+	for i, version := range versions {
+		responses[i] = &OrderVersionResponse{
+			Version:   version.Version,
+			ChangedAt: version.ChangedAt,
+			ChangedBy: version.ChangedBy,
+			Changes:   MapToFieldChangeResponses(version.Changes),
+		}
+	}
+	return responses
+}
+
+// MapToOrderVersionResponse creates the response of a version together with its order.
+func MapToOrderVersionResponse(version models.OrderVersion) *OrderVersionResponse {
+	order := version.Order
+	snapshot := &OrderSnapshotResponse{
+		ID:             order.ID,
+		OrderNumber:    order.Number,
+		Status:         order.Status,
+		OrderDate:      order.OrderDate,
+		ProductID:      order.ProductID,
+		Quantity:       order.Quantity,
+		Price:          order.Price,
+		HasWeightables: order.HasWeightables,
+		Lines:          make([]*OrderLineResponse, len(order.Lines)),
+		PaymentIDs:     make([]int, len(order.Payments)),
+	}
+	if order.User != nil {
+		snapshot.UserID = order.User.ID
+	}
+	for i, line := range order.Lines {
+		snapshot.Lines[i] = MapToOrderLineResponse(*line)
+	}
+	for i, payment := range order.Payments {
+		snapshot.PaymentIDs[i] = payment.Id
+	}
+
+	return &OrderVersionResponse{
+		Version:   version.Version,
+		ChangedAt: version.ChangedAt,
+		ChangedBy: version.ChangedBy,
+		Order:     snapshot,
+		Changes:   MapToFieldChangeResponses(version.Changes),
+	}
+}
+
+// MapToFieldChangeResponses maps the changes of an order, lines are shown like the lines of an order response.
+func MapToFieldChangeResponses(changes []models.FieldChange) []*FieldChangeResponse {
+	responses := make([]*FieldChangeResponse, len(changes))
+	for i, change := range changes {
+		responses[i] = &FieldChangeResponse{
+			Field: change.Field,
+			From:  mapFieldValue(change.From),
+			To:    mapFieldValue(change.To),
+		}
+	}
+	return responses
+}
+
+func mapFieldValue(value any) any {
+	if lines, isLines := value.([]models.OrderLine); isLines {
+		responses := make([]*OrderLineResponse, len(lines))
+		for i, line := range lines {
+			responses[i] = MapToOrderLineResponse(line)
+		}
+		return responses
+	}
+	return value
+}
@@ -1,33 +1,197 @@
 package transports
 
 import (
+	"fmt"
 	"fp_kata/common"
 	"fp_kata/internal/models"
+	"time"
 )
 
 type PaymentResponse struct {
-	Id     int                  `json:"id"`
-	Amount float64              `json:"amount"`
-	Method common.PaymentMethod `json:"method"`
+	Id             int                  `json:"id"`
+	Amount         common.Money         `json:"amount"`
+	Currency       common.Currency      `json:"currency,omitempty"`
+	Conversion     *ConversionResponse  `json:"conversion,omitempty"`
+	Method         common.PaymentMethod `json:"method"`
+	Status         common.PaymentStatus `json:"status,omitempty"`
+	OrderID        int                  `json:"order_id,omitempty"`
+	PaidAt         *time.Time           `json:"paid_at,omitempty"`
+	Fee            *common.Money        `json:"fee,omitempty"`
+	AmountRefunded *common.Money        `json:"amount_refunded,omitempty"`
+	PaymentDetailsResponse
+}
+
+// ConversionResponse shows the amount of a payment converted to the currency of its order.
+type ConversionResponse struct {
+	Currency      common.Currency `json:"currency"`
+	Rate          float64         `json:"rate"`
+	Amount        common.Money    `json:"amount"`
+	EffectiveFrom time.Time       `json:"effective_from"`
+}
+
+// PaymentDetailsResponse shows the details of the method of a payment, at most one of them is set.
+type PaymentDetailsResponse struct {
+	Card        *CardResponse        `json:"card,omitempty"`
+	BankAccount *BankAccountResponse `json:"bank_account,omitempty"`
+	PayPal      *PayPalResponse      `json:"paypal,omitempty"`
+}
+
+type CardResponse struct {
+	Brand models.CardBrand `json:"brand"`
+	Last4 string           `json:"last4"`
+}
+
+// BankAccountResponse shows the IBAN of a bank transfer masked.
+type BankAccountResponse struct {
+	IBAN string `json:"iban"`
+}
+
+type PayPalResponse struct {
+	Email string `json:"email"`
 }
 
 func MapToPaymentResponse(payment models.Payment) *PaymentResponse {
-	return &PaymentResponse{
-		Id:     payment.Id,
-		Amount: payment.Amount,
-		Method: payment.Method,
+	response := &PaymentResponse{
+		Id:             payment.Id,
+		Amount:         payment.Amount,
+		Currency:       payment.Currency,
+		Method:         payment.Method,
+		Status:         payment.Status,
+		Fee:            optionalMoney(payment.Fee),
+		AmountRefunded: optionalMoney(payment.AmountRefunded()),
 	}
+	if payment.Order != nil {
+		response.OrderID = payment.Order.ID
+	}
+	if !payment.PaidAt.IsZero() {
+		response.PaidAt = &payment.PaidAt
+	}
+	if payment.Conversion != nil {
+		response.Conversion = &ConversionResponse{
+			Currency:      payment.Conversion.To,
+			Rate:          payment.Conversion.Rate,
+			Amount:        payment.OrderAmount(),
+			EffectiveFrom: payment.Conversion.EffectiveFrom,
+		}
+	}
+	if payment.Details != nil {
+		response.PaymentDetailsResponse = models.MatchPaymentDetails(payment.Details,
+			func(card models.Card) PaymentDetailsResponse {
+				return PaymentDetailsResponse{Card: &CardResponse{Brand: card.Brand, Last4: card.Last4}}
+			},
+			func(bankTransfer models.BankTransfer) PaymentDetailsResponse {
+				return PaymentDetailsResponse{BankAccount: &BankAccountResponse{IBAN: bankTransfer.MaskedIBAN()}}
+			},
+			func(payPal models.PayPal) PaymentDetailsResponse {
+				return PaymentDetailsResponse{PayPal: &PayPalResponse{Email: payPal.Email}}
+			})
+	}
+	return response
 }
 
+func MapToPaymentResponses(payments []*models.Payment) []*PaymentResponse {
+	responses := make([]*PaymentResponse, len(payments))
+	for i, payment := range payments {
+		responses[i] = MapToPaymentResponse(*payment)
+	}
+	return responses
+}
+
+// PaymentRequest is a payment of an amount, in the currency of the order when no currency is given.
 type PaymentRequest struct {
-	PaymentAmount float64              `json:"payment_amount"`
-	PaymentMethod common.PaymentMethod `json:"payment_method"`
+	Id            int                  `json:"id,omitempty"`
+	PaymentAmount common.Money         `json:"payment_amount" validate:"gt=0"`
+	PaymentMethod common.PaymentMethod `json:"payment_method" validate:"required,oneof=CreditCard DebitCard PayPal BankTransfer"`
+	Currency      common.Currency      `json:"currency,omitempty" validate:"omitempty,iso4217"`
+	// Card, BankAccount and PayPal are the details of the method of the payment, at most one of them is given.
+	Card        *CardRequest        `json:"card,omitempty"`
+	BankAccount *BankAccountRequest `json:"bank_account,omitempty"`
+	PayPal      *PayPalRequest      `json:"paypal,omitempty"`
+}
+
+type CardRequest struct {
+	Number string `json:"number" validate:"required"`
 }
 
-func (p PaymentRequest) ToPayment(user models.User) *models.Payment {
+type BankAccountRequest struct {
+	IBAN string `json:"iban" validate:"required"`
+}
+
+type PayPalRequest struct {
+	Email string `json:"email" validate:"required"`
+}
+
+// ToPayment creates the payment of the request, failing for an unknown method or details that are invalid
+// or belong to another method.
+func (p PaymentRequest) ToPayment(user models.User) (*models.Payment, error) {
+	details, err := p.toPaymentDetails()
+	if err != nil {
+		return nil, err
+	}
 	return &models.Payment{
-		Amount: p.PaymentAmount,
-		Method: p.PaymentMethod,
-		User:   &user,
+		Id:       p.Id,
+		Amount:   p.PaymentAmount,
+		Currency: p.Currency,
+		Method:   p.PaymentMethod,
+		User:     &user,
+		Details:  details,
+	}, nil
+}
+
+// toPaymentDetails validates the details given for the method of the payment, nil when none are given.
+func (p PaymentRequest) toPaymentDetails() (models.PaymentDetails, error) {
+	given := 0
+	for _, isGiven := range []bool{p.Card != nil, p.BankAccount != nil, p.PayPal != nil} {
+		if isGiven {
+			given++
+		}
+	}
+	if given > 1 {
+		return nil, fmt.Errorf("%w: details of more than one payment method", models.ErrInvalidPaymentDetails)
+	}
+	mismatch := fmt.Errorf("%w: details do not belong to %s", models.ErrInvalidPaymentDetails, p.PaymentMethod)
+
+	switch p.PaymentMethod {
+	case common.CreditCard, common.DebitCard:
+		if p.Card == nil {
+			return nil, noDetailsOr(given, mismatch)
+		}
+		return toDetails(models.NewCard(p.Card.Number, p.PaymentMethod == common.DebitCard))
+	case common.BankTransfer:
+		if p.BankAccount == nil {
+			return nil, noDetailsOr(given, mismatch)
+		}
+		return toDetails(models.NewBankTransfer(p.BankAccount.IBAN))
+	case common.PayPal:
+		if p.PayPal == nil {
+			return nil, noDetailsOr(given, mismatch)
+		}
+		return toDetails(models.NewPayPal(p.PayPal.Email))
+	}
+	return nil, fmt.Errorf("%w: unknown payment method %q", models.ErrInvalidPaymentDetails, p.PaymentMethod)
+}
+
+// noDetailsOr accepts a payment without details, but not one with the details of another method.
+func noDetailsOr(given int, mismatch error) error {
+	if given == 0 {
+		return nil
+	}
+	return mismatch
+}
+
+func toDetails[T models.PaymentDetails](details T, err error) (models.PaymentDetails, error) {
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// MapToPaymentRequest creates a PaymentRequest referencing the stored payment.
+func MapToPaymentRequest(payment models.Payment) *PaymentRequest {
+	return &PaymentRequest{
+		Id:            payment.Id,
+		PaymentAmount: payment.Amount,
+		Currency:      payment.Currency,
+		PaymentMethod: payment.Method,
 	}
 }
@@ -1,9 +1,11 @@
 package transports
 
 import (
+	"encoding/json"
 	"fp_kata/common"
 	"fp_kata/internal/models"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -18,14 +20,14 @@ func TestMapToPaymentResponse(t *testing.T) {
 			name: "valid payment with user and order",
 			input: models.Payment{
 				Id:     1,
-				Amount: 100.50,
+				Amount: common.NewMoney(100.50),
 				Method: common.CreditCard,
 				User:   &models.User{ID: 10},
 				Order:  &models.Order{ID: 20},
 			},
 			expect: &PaymentResponse{
 				Id:     1,
-				Amount: 100.50,
+				Amount: common.NewMoney(100.50),
 				Method: common.CreditCard,
 			},
 		},
@@ -33,14 +35,14 @@ func TestMapToPaymentResponse(t *testing.T) {
 			name: "valid payment without user or order",
 			input: models.Payment{
 				Id:     2,
-				Amount: 50.25,
+				Amount: common.NewMoney(50.25),
 				Method: common.PayPal,
 				User:   nil,
 				Order:  nil,
 			},
 			expect: &PaymentResponse{
 				Id:     2,
-				Amount: 50.25,
+				Amount: common.NewMoney(50.25),
 				Method: common.PayPal,
 			},
 		},
@@ -48,12 +50,12 @@ func TestMapToPaymentResponse(t *testing.T) {
 			name: "valid payment with zero amount",
 			input: models.Payment{
 				Id:     3,
-				Amount: 0,
+				Amount: common.Money{},
 				Method: common.BankTransfer,
 			},
 			expect: &PaymentResponse{
 				Id:     3,
-				Amount: 0,
+				Amount: common.Money{},
 				Method: common.BankTransfer,
 			},
 		},
@@ -81,12 +83,12 @@ func TestToPayment(t *testing.T) {
 		{
 			name: "valid payment request",
 			request: PaymentRequest{
-				PaymentAmount: 100.50,
+				PaymentAmount: common.NewMoney(100.50),
 				PaymentMethod: common.CreditCard,
 			},
 			user: models.User{ID: 1},
 			expected: &models.Payment{
-				Amount: 100.50,
+				Amount: common.NewMoney(100.50),
 				Method: common.CreditCard,
 				User:   &models.User{ID: 1},
 			},
@@ -95,12 +97,12 @@ func TestToPayment(t *testing.T) {
 		{
 			name: "default values in request",
 			request: PaymentRequest{
-				PaymentAmount: 0,
+				PaymentAmount: common.Money{},
 				PaymentMethod: common.PayPal,
 			},
 			user: models.User{},
 			expected: &models.Payment{
-				Amount: 0,
+				Amount: common.Money{},
 				Method: common.PayPal,
 				User:   &models.User{},
 			},
@@ -109,12 +111,12 @@ func TestToPayment(t *testing.T) {
 		{
 			name: "zero payment amount",
 			request: PaymentRequest{
-				PaymentAmount: 0,
+				PaymentAmount: common.Money{},
 				PaymentMethod: common.DebitCard,
 			},
 			user: models.User{ID: 2},
 			expected: &models.Payment{
-				Amount: 0,
+				Amount: common.Money{},
 				Method: common.DebitCard,
 				User:   &models.User{ID: 2},
 			},
@@ -123,12 +125,12 @@ func TestToPayment(t *testing.T) {
 		{
 			name: "large payment amount",
 			request: PaymentRequest{
-				PaymentAmount: 1e9,
+				PaymentAmount: common.NewMoney(1e9),
 				PaymentMethod: common.BankTransfer,
 			},
 			user: models.User{ID: 3},
 			expected: &models.Payment{
-				Amount: 1e9,
+				Amount: common.NewMoney(1e9),
 				Method: common.BankTransfer,
 				User:   &models.User{ID: 3},
 			},
@@ -137,12 +139,12 @@ func TestToPayment(t *testing.T) {
 		{
 			name: "empty user in request",
 			request: PaymentRequest{
-				PaymentAmount: 51.25,
+				PaymentAmount: common.NewMoney(51.25),
 				PaymentMethod: common.PayPal,
 			},
 			user: models.User{},
 			expected: &models.Payment{
-				Amount: 51.25,
+				Amount: common.NewMoney(51.25),
 				Method: common.PayPal,
 				User:   &models.User{},
 			},
@@ -152,7 +154,8 @@ func TestToPayment(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			result := test.request.ToPayment(test.user)
+			result, err := test.request.ToPayment(test.user)
+			assert.NoError(t, err, test.assertMessage)
 
 			assert.Equal(t, test.expected.Amount, result.Amount, test.assertMessage+": Amount mismatch")
 			assert.Equal(t, test.expected.Method, result.Method, test.assertMessage+": Method mismatch")
@@ -160,3 +163,129 @@ func TestToPayment(t *testing.T) {
 		})
 	}
 }
+
+func TestToPaymentDetails(t *testing.T) {
+	tests := []struct {
+		name        string
+		request     PaymentRequest
+		expected    models.PaymentDetails
+		expectedErr string
+	}{
+		{
+			name:     "credit card",
+			request:  PaymentRequest{PaymentAmount: common.NewMoney(10), PaymentMethod: common.CreditCard, Card: &CardRequest{Number: "4111 1111 1111 1111"}},
+			expected: models.Card{Brand: models.Visa, Last4: "1111"},
+		},
+		{
+			name:     "debit card",
+			request:  PaymentRequest{PaymentAmount: common.NewMoney(10), PaymentMethod: common.DebitCard, Card: &CardRequest{Number: "5555-5555-5555-4444"}},
+			expected: models.Card{Debit: true, Brand: models.Mastercard, Last4: "4444"},
+		},
+		{
+			name:     "bank account",
+			request:  PaymentRequest{PaymentAmount: common.NewMoney(10), PaymentMethod: common.BankTransfer, BankAccount: &BankAccountRequest{IBAN: "de89 3704 0044 0532 0130 00"}},
+			expected: models.BankTransfer{IBAN: "DE89370400440532013000"},
+		},
+		{
+			name:     "paypal",
+			request:  PaymentRequest{PaymentAmount: common.NewMoney(10), PaymentMethod: common.PayPal, PayPal: &PayPalRequest{Email: "jane@example.com"}},
+			expected: models.PayPal{Email: "jane@example.com"},
+		},
+		{
+			name:    "no details",
+			request: PaymentRequest{PaymentAmount: common.NewMoney(10), PaymentMethod: common.PayPal},
+		},
+		{
+			name:        "details of another method",
+			request:     PaymentRequest{PaymentAmount: common.NewMoney(10), PaymentMethod: common.PayPal, Card: &CardRequest{Number: "4111111111111111"}},
+			expectedErr: "invalid payment details: details do not belong to PayPal",
+		},
+		{
+			name: "details of more than one method",
+			request: PaymentRequest{PaymentAmount: common.NewMoney(10), PaymentMethod: common.CreditCard,
+				Card: &CardRequest{Number: "4111111111111111"}, PayPal: &PayPalRequest{Email: "jane@example.com"}},
+			expectedErr: "invalid payment details: details of more than one payment method",
+		},
+		{
+			name:        "invalid card number",
+			request:     PaymentRequest{PaymentAmount: common.NewMoney(10), PaymentMethod: common.CreditCard, Card: &CardRequest{Number: "4111111111111112"}},
+			expectedErr: "invalid payment details: card number fails the Luhn check",
+		},
+		{
+			name:        "unknown method",
+			request:     PaymentRequest{PaymentAmount: common.NewMoney(10), PaymentMethod: "Cash"},
+			expectedErr: `invalid payment details: unknown payment method "Cash"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := test.request.ToPayment(models.User{ID: 1})
+
+			if test.expectedErr != "" {
+				assert.ErrorIs(t, err, models.ErrInvalidPaymentDetails)
+				assert.EqualError(t, err, test.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, result.Details)
+		})
+	}
+}
+
+func TestMapToPaymentResponse_Details(t *testing.T) {
+	tests := []struct {
+		name     string
+		details  models.PaymentDetails
+		expected string
+	}{
+		{
+			name:     "card",
+			details:  models.Card{Brand: models.Visa, Last4: "1111"},
+			expected: `{"id":1,"amount":"10.00","method":"CreditCard","fee":"0.50","card":{"brand":"Visa","last4":"1111"}}`,
+		},
+		{
+			name:     "bank account with masked IBAN",
+			details:  models.BankTransfer{IBAN: "DE89370400440532013000"},
+			expected: `{"id":1,"amount":"10.00","method":"CreditCard","fee":"0.50","bank_account":{"iban":"DE89**************3000"}}`,
+		},
+		{
+			name:     "paypal",
+			details:  models.PayPal{Email: "jane@example.com"},
+			expected: `{"id":1,"amount":"10.00","method":"CreditCard","fee":"0.50","paypal":{"email":"jane@example.com"}}`,
+		},
+		{
+			name:     "no details",
+			expected: `{"id":1,"amount":"10.00","method":"CreditCard","fee":"0.50"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payment := models.Payment{Id: 1, Amount: common.NewMoney(10), Method: common.CreditCard, Fee: common.NewMoney(0.5), Details: test.details}
+
+			result, err := json.Marshal(MapToPaymentResponse(payment))
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, test.expected, string(result))
+		})
+	}
+}
+
+func TestMapToPaymentResponse_Conversion(t *testing.T) {
+	effectiveFrom := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	payment := models.Payment{
+		Id:         1,
+		Amount:     common.NewMoney(10),
+		Currency:   common.USD,
+		Method:     common.PayPal,
+		Conversion: &models.ExchangeRate{From: common.USD, To: common.EUR, Rate: 0.9234, EffectiveFrom: effectiveFrom},
+	}
+
+	result, err := json.Marshal(MapToPaymentResponse(payment))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"amount":"10.00","currency":"USD","method":"PayPal",`+
+		`"conversion":{"currency":"EUR","rate":0.9234,"amount":"9.23","effective_from":"2025-01-01T00:00:00Z"}}`, string(result))
+}
@@ -0,0 +1,18 @@
+package transports
+
+// ProblemContentType is the media type of problem responses.
+const ProblemContentType = "application/problem+json"
+
+// ProblemResponse describes an error as problem details (RFC 7807). Code is a stable, machine-readable
+// code of the error, like "order_not_found"; Detail is meant for people and may change.
+// Position and Token are extension members locating the error in a filter expression.
+type ProblemResponse struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	Position int    `json:"position,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
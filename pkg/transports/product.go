@@ -0,0 +1,42 @@
+package transports
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/models"
+)
+
+type ProductResponse struct {
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Price       common.Money      `json:"price"`
+	WeightUnit  common.WeightUnit `json:"weight_unit,omitempty"`
+}
+
+// ProductRequest creates or replaces a catalog product. Products sold by weight name a weight unit,
+// their price is the price of one weight unit.
+type ProductRequest struct {
+	Name        string            `json:"name" validate:"required"`
+	Description string            `json:"description,omitempty"`
+	Price       common.Money      `json:"price" validate:"required,gt=0"`
+	WeightUnit  common.WeightUnit `json:"weight_unit,omitempty" validate:"omitempty,oneof=kg g lb"`
+}
+
+func (r ProductRequest) ToProduct() *models.Product {
+	return &models.Product{
+		Name:        r.Name,
+		Description: r.Description,
+		Price:       r.Price,
+		WeightUnit:  r.WeightUnit,
+	}
+}
+
+func MapToProductResponse(product models.Product) *ProductResponse {
+	return &ProductResponse{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		WeightUnit:  product.WeightUnit,
+	}
+}
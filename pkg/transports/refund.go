@@ -0,0 +1,48 @@
+package transports
+
+import (
+	"fp_kata/common"
+	"fp_kata/internal/models"
+	"time"
+)
+
+// RefundRequest refunds a payment of an order, without amount all that is left of the payment is refunded.
+type RefundRequest struct {
+	PaymentID int                 `json:"payment_id" validate:"required"`
+	Amount    common.Money        `json:"amount" validate:"gte=0"`
+	Reason    common.RefundReason `json:"reason" validate:"required,oneof=CustomerRequest DamagedGoods NotDelivered DuplicatePayment Goodwill"`
+}
+
+func (r RefundRequest) ToRefund() *models.Refund {
+	return &models.Refund{
+		PaymentId: r.PaymentID,
+		Amount:    r.Amount,
+		Reason:    r.Reason,
+	}
+}
+
+type RefundResponse struct {
+	Id        int                 `json:"id"`
+	PaymentID int                 `json:"payment_id"`
+	Amount    common.Money        `json:"amount"`
+	Reason    common.RefundReason `json:"reason"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+func MapToRefundResponse(refund models.Refund) *RefundResponse {
+	return &RefundResponse{
+		Id:        refund.Id,
+		PaymentID: refund.PaymentId,
+		Amount:    refund.Amount,
+		Reason:    refund.Reason,
+		CreatedAt: refund.CreatedAt,
+	}
+}
+
+func MapToRefundResponses(refunds []*models.Refund) []*RefundResponse {
+	responses := make([]*RefundResponse, len(refunds))
+	for i, refund := range refunds {
+		responses[i] = MapToRefundResponse(*refund)
+	}
+	return responses
+}
@@ -0,0 +1,49 @@
+package transports
+
+import "fp_kata/internal/models"
+
+type StockResponse struct {
+	ProductID    int `json:"product_id"`
+	OnHand       int `json:"on_hand"`
+	Reserved     int `json:"reserved"`
+	Available    int `json:"available"`
+	ReorderLevel int `json:"reorder_level"`
+}
+
+func MapToStockResponse(stock models.Stock) *StockResponse {
+	return &StockResponse{
+		ProductID:    stock.ProductID,
+		OnHand:       stock.OnHand,
+		Reserved:     stock.Reserved,
+		Available:    stock.Available(),
+		ReorderLevel: stock.ReorderLevel,
+	}
+}
+
+// StockRequest sets the stock of a product.
+type StockRequest struct {
+	OnHand       int `json:"on_hand" validate:"gte=0"`
+	ReorderLevel int `json:"reorder_level" validate:"gte=0"`
+}
+
+func (r StockRequest) ToStock(productId int) *models.Stock {
+	return &models.Stock{
+		ProductID:    productId,
+		OnHand:       r.OnHand,
+		ReorderLevel: r.ReorderLevel,
+	}
+}
+
+// StockAdjustmentRequest adds stock to a product, a negative delta removes stock.
+type StockAdjustmentRequest struct {
+	Delta int `json:"delta" validate:"required"`
+}
+
+// Helper function to convert a slice of models.Stock to []*StockResponse.
+func ConvertStocks(stocks []*models.Stock) []*StockResponse {
+	stockResponses := make([]*StockResponse, len(stocks))
+	for i, stock := range stocks {
+		stockResponses[i] = MapToStockResponse(*stock)
+	}
+	return stockResponses
+}